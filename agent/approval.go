@@ -0,0 +1,253 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/yashrahurikar23/goagents/core"
+)
+
+// ApprovalPolicy decides from its arguments whether a call of a tool needs a
+// human's approval before it runs. The arguments have been validated and
+// converted to the tool's schema, so numbers are float64 even when the LLM
+// sent them as text.
+type ApprovalPolicy func(args map[string]interface{}) bool
+
+// ApprovalAlways requires approval for every call of the tool.
+func ApprovalAlways(map[string]interface{}) bool { return true }
+
+// ApprovalNever runs every call of the tool without approval.
+func ApprovalNever(map[string]interface{}) bool { return false }
+
+// ApprovalWhenArg requires approval when the argument has one of the values,
+// compared case-insensitively:
+//
+//	agent.WithApprovalFor("file_operations", agent.ApprovalWhenArg("operation", "write", "append", "delete"))
+//	agent.WithApprovalFor("http", agent.ApprovalWhenArg("method", "POST", "PUT", "PATCH", "DELETE"))
+func ApprovalWhenArg(arg string, values ...string) ApprovalPolicy {
+	return func(args map[string]interface{}) bool {
+		value, ok := args[arg].(string)
+		if !ok {
+			return false
+		}
+		for _, v := range values {
+			if strings.EqualFold(value, v) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// ApprovalRequest is a tool call waiting for approval.
+type ApprovalRequest struct {
+	// ToolCall is the pending call: its ID, tool name and arguments.
+	ToolCall core.ToolCall
+
+	// RunID and SessionID identify the run the call belongs to.
+	RunID     string
+	SessionID string
+}
+
+// ApprovalDecision is the answer to an ApprovalRequest.
+type ApprovalDecision struct {
+	// Approved lets the call run.
+	Approved bool
+
+	// Reason explains a rejection to the LLM.
+	Reason string
+
+	// Args, when set on an approved call, replace the call's arguments.
+	Args map[string]interface{}
+}
+
+// Approver decides on the tool calls that need approval. Approve may block
+// until a human has decided; the run waits for it. Calls of one turn are
+// reviewed concurrently, so implementations must be safe for concurrent use.
+//
+// An error rejects the call with the error as the reason.
+type Approver interface {
+	Approve(ctx context.Context, req ApprovalRequest) (ApprovalDecision, error)
+}
+
+// ApproverFunc adapts a function to an Approver.
+type ApproverFunc func(ctx context.Context, req ApprovalRequest) (ApprovalDecision, error)
+
+// Approve calls f.
+func (f ApproverFunc) Approve(ctx context.Context, req ApprovalRequest) (ApprovalDecision, error) {
+	return f(ctx, req)
+}
+
+// ChannelApprover hands approval requests to the caller over a channel, for
+// example to show them in a UI:
+//
+//	approver := agent.NewChannelApprover()
+//	go func() {
+//		for pending := range approver.Requests() {
+//			if askUser(pending.ToolCall) {
+//				pending.Approve()
+//			} else {
+//				pending.Reject("the user declined")
+//			}
+//		}
+//	}()
+type ChannelApprover struct {
+	requests chan *PendingApproval
+}
+
+// NewChannelApprover creates a ChannelApprover. The channel is unbuffered:
+// a run waits until its request is received and then until it is resolved.
+func NewChannelApprover() *ChannelApprover {
+	return &ChannelApprover{requests: make(chan *PendingApproval)}
+}
+
+// Requests returns the channel pending approvals are sent on. Each one must
+// be resolved, or the run waits until its context ends.
+func (c *ChannelApprover) Requests() <-chan *PendingApproval {
+	return c.requests
+}
+
+// Approve sends the request on the channel and waits for its decision.
+func (c *ChannelApprover) Approve(ctx context.Context, req ApprovalRequest) (ApprovalDecision, error) {
+	pending := &PendingApproval{ApprovalRequest: req, decision: make(chan ApprovalDecision, 1)}
+
+	select {
+	case c.requests <- pending:
+	case <-ctx.Done():
+		return ApprovalDecision{}, ctx.Err()
+	}
+
+	select {
+	case decision := <-pending.decision:
+		return decision, nil
+	case <-ctx.Done():
+		return ApprovalDecision{}, ctx.Err()
+	}
+}
+
+// PendingApproval is an approval request received from a ChannelApprover.
+// Only the first decision counts.
+type PendingApproval struct {
+	ApprovalRequest
+
+	decision chan ApprovalDecision
+	once     sync.Once
+}
+
+// Resolve answers the request.
+func (p *PendingApproval) Resolve(decision ApprovalDecision) {
+	p.once.Do(func() { p.decision <- decision })
+}
+
+// Approve lets the call run as it is.
+func (p *PendingApproval) Approve() {
+	p.Resolve(ApprovalDecision{Approved: true})
+}
+
+// ApproveWithArgs lets the call run with edited arguments.
+func (p *PendingApproval) ApproveWithArgs(args map[string]interface{}) {
+	p.Resolve(ApprovalDecision{Approved: true, Args: args})
+}
+
+// Reject stops the call; the reason is reported to the LLM.
+func (p *PendingApproval) Reject(reason string) {
+	p.Resolve(ApprovalDecision{Reason: reason})
+}
+
+// approvals is the approval configuration of an agent.
+type approvals struct {
+	approver Approver
+	policies map[string]ApprovalPolicy
+}
+
+// setPolicy sets the policy of the named tool.
+func (a *approvals) setPolicy(name string, policy ApprovalPolicy) {
+	if a.policies == nil {
+		a.policies = make(map[string]ApprovalPolicy)
+	}
+	a.policies[name] = policy
+}
+
+// review asks for approval of the call if its tool's policy requires it,
+// emitting an approval_required event first when emit is non-nil. A rejected
+// call is returned with its Error and Result set; an approved one may have
+// new arguments.
+//
+// The policy and the approver see the arguments validated and converted to
+// the tool's schema, as the tool would get them, so that "500" from a ReAct
+// action is judged as the number 500. Invalid arguments fail the call before
+// anyone is asked, and arguments edited by the approver are validated again.
+func (a *approvals) review(ctx context.Context, call *core.ToolCall, tools map[string]core.Tool, emit func(core.StreamEvent)) {
+	policy, ok := a.policies[call.Name]
+	if !ok || policy == nil {
+		return
+	}
+
+	// An unknown tool fails when it is executed
+	tool, ok := tools[call.Name]
+	if !ok {
+		return
+	}
+	args, err := core.ValidateToolArgs(tool.Schema(), call.Args)
+	if err != nil {
+		failToolArgs(call, err)
+		return
+	}
+	call.Args = args
+
+	if !policy(call.Args) {
+		return
+	}
+
+	if emit != nil {
+		emit(core.NewStreamEventWithData(core.EventTypeApprovalRequired, call.Name, map[string]interface{}{
+			"tool_id":   call.ID,
+			"arguments": call.Args,
+		}))
+	}
+
+	decision, err := a.decide(ctx, *call)
+	switch {
+	case err != nil:
+		call.Error = &core.ErrToolCallRejected{ToolName: call.Name, Err: err}
+	case !decision.Approved:
+		reason := decision.Reason
+		if reason == "" {
+			reason = "not approved"
+		}
+		call.Error = &core.ErrToolCallRejected{ToolName: call.Name, Err: errors.New(reason)}
+	case decision.Args != nil:
+		args, err := core.ValidateToolArgs(tool.Schema(), decision.Args)
+		if err != nil {
+			call.Error = &core.ErrToolCallRejected{ToolName: call.Name, Err: fmt.Errorf("approved arguments are invalid: %w", err)}
+			break
+		}
+		call.Args = args
+	}
+	if call.Error != nil {
+		call.Result = fmt.Sprintf("Error: %v", call.Error)
+	}
+}
+
+// decide asks the approver about a call.
+func (a *approvals) decide(ctx context.Context, call core.ToolCall) (ApprovalDecision, error) {
+	if a.approver == nil {
+		return ApprovalDecision{}, errors.New("approval required but no approver is configured")
+	}
+
+	// The approver gets its own copy of the arguments to edit
+	args := make(map[string]interface{}, len(call.Args))
+	for k, v := range call.Args {
+		args[k] = v
+	}
+	call.Args = args
+
+	return a.approver.Approve(ctx, ApprovalRequest{
+		ToolCall:  call,
+		RunID:     core.RunIDFromContext(ctx),
+		SessionID: core.SessionIDFromContext(ctx),
+	})
+}
@@ -0,0 +1,405 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/yashrahurikar23/goagents/core"
+	"github.com/yashrahurikar23/goagents/tests/mocks"
+)
+
+// TestApprovalWhenArg tests the argument value policy.
+func TestApprovalWhenArg(t *testing.T) {
+	policy := ApprovalWhenArg("method", "POST", "DELETE")
+
+	tests := []struct {
+		args map[string]interface{}
+		want bool
+	}{
+		{map[string]interface{}{"method": "POST"}, true},
+		{map[string]interface{}{"method": "delete"}, true},
+		{map[string]interface{}{"method": "GET"}, false},
+		{map[string]interface{}{"method": 1}, false},
+		{map[string]interface{}{}, false},
+	}
+	for _, tt := range tests {
+		if got := policy(tt.args); got != tt.want {
+			t.Errorf("policy(%v) = %v, want %v", tt.args, got, tt.want)
+		}
+	}
+}
+
+// deleteFileLLM asks to delete a file, then answers with the tool result.
+func deleteFileLLM() *mocks.MockLLM {
+	llm := mocks.NewMockLLM()
+	llm.WithSequentialChatResponses([]*core.Response{
+		{ToolCalls: []core.ToolCall{{ID: "call_1", Name: "file_operations", Args: map[string]interface{}{"operation": "delete", "path": "a.txt"}}}},
+		{Content: "Done"},
+	}, nil)
+	return llm
+}
+
+// lastToolResult returns the content of the last tool message sent to the LLM.
+func lastToolResult(llm *mocks.MockLLM) string {
+	calls := llm.GetChatCalls()
+	messages := calls[len(calls)-1].Messages
+	for i := len(messages) - 1; i >= 0; i-- {
+		if messages[i].Role == "tool" {
+			return messages[i].Content
+		}
+	}
+	return ""
+}
+
+// TestFunctionAgent_Approval tests the approver's decisions.
+func TestFunctionAgent_Approval(t *testing.T) {
+	tests := []struct {
+		name       string
+		decision   ApprovalDecision
+		err        error
+		wantCalls  int
+		wantPath   string
+		wantResult string
+	}{
+		{"approve", ApprovalDecision{Approved: true}, nil, 1, "a.txt", "deleted"},
+		{"edit", ApprovalDecision{Approved: true, Args: map[string]interface{}{"operation": "delete", "path": "b.txt"}}, nil, 1, "b.txt", "deleted"},
+		{"reject", ApprovalDecision{Reason: "keep the file"}, nil, 0, "", "rejected: keep the file"},
+		{"reject without reason", ApprovalDecision{}, nil, 0, "", "rejected: not approved"},
+		{"approver error", ApprovalDecision{}, errors.New("approval service down"), 0, "", "rejected: approval service down"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			llm := deleteFileLLM()
+			tool := mocks.NewMockTool("file_operations", "Manages files").WithExecuteResult("deleted")
+
+			var requests []ApprovalRequest
+			approver := ApproverFunc(func(ctx context.Context, req ApprovalRequest) (ApprovalDecision, error) {
+				requests = append(requests, req)
+				return tt.decision, tt.err
+			})
+
+			agent := NewFunctionAgent(llm,
+				WithApprover(approver),
+				WithApprovalFor("file_operations", ApprovalWhenArg("operation", "write", "delete")),
+			)
+			agent.AddTool(tool)
+
+			if _, err := agent.Run(context.Background(), "Delete a.txt"); err != nil {
+				t.Fatalf("Run() error = %v", err)
+			}
+
+			if len(requests) != 1 || requests[0].ToolCall.Args["path"] != "a.txt" || requests[0].RunID == "" {
+				t.Fatalf("approval requests = %+v, want one for a.txt with the run ID", requests)
+			}
+			if tool.CallCount() != tt.wantCalls {
+				t.Fatalf("tool ran %d times, want %d", tool.CallCount(), tt.wantCalls)
+			}
+			if tt.wantCalls > 0 {
+				if got := tool.GetCalls()[0].Args["path"]; got != tt.wantPath {
+					t.Errorf("tool called with path = %v, want %s", got, tt.wantPath)
+				}
+			}
+			if got := lastToolResult(llm); !strings.Contains(got, tt.wantResult) {
+				t.Errorf("tool result = %q, want it to contain %q", got, tt.wantResult)
+			}
+		})
+	}
+}
+
+// TestFunctionAgent_Approval_Policy tests that only calls matching the policy
+// need approval, and that they are rejected without an approver.
+func TestFunctionAgent_Approval_Policy(t *testing.T) {
+	llm := mocks.NewMockLLM()
+	llm.WithSequentialChatResponses([]*core.Response{
+		{ToolCalls: []core.ToolCall{
+			{ID: "call_1", Name: "file_operations", Args: map[string]interface{}{"operation": "read", "path": "a.txt"}},
+			{ID: "call_2", Name: "file_operations", Args: map[string]interface{}{"operation": "delete", "path": "a.txt"}},
+			{ID: "call_3", Name: "calculator"},
+		}},
+		{Content: "Done"},
+	}, nil)
+	files := mocks.NewMockTool("file_operations", "Manages files").WithExecuteResult("ok")
+	calculator := mocks.NewMockTool("calculator", "Calculates").WithExecuteResult("4")
+
+	agent := NewFunctionAgent(llm,
+		WithApprovalFor("file_operations", ApprovalWhenArg("operation", "delete")),
+		WithApprovalFor("calculator", ApprovalNever),
+	)
+	agent.AddTool(files)
+	agent.AddTool(calculator)
+
+	if _, err := agent.Run(context.Background(), "Read and delete a.txt"); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	if files.CallCount() != 1 || files.GetCalls()[0].Args["operation"] != "read" {
+		t.Errorf("file tool calls = %+v, want only the read", files.GetCalls())
+	}
+	if calculator.CallCount() != 1 {
+		t.Errorf("calculator ran %d times, want 1", calculator.CallCount())
+	}
+
+	messages := llm.GetChatCalls()[1].Messages
+	if got := messages[len(messages)-2].Content; !strings.Contains(got, "no approver is configured") {
+		t.Errorf("delete result = %q, want the rejection", got)
+	}
+}
+
+// TestFunctionAgent_Approval_RunStream tests that streaming runs pause on an
+// approval_required event until the call is approved.
+func TestFunctionAgent_Approval_RunStream(t *testing.T) {
+	llm := deleteFileLLM()
+	tool := mocks.NewMockTool("file_operations", "Manages files").WithExecuteResult("deleted")
+	approver := NewChannelApprover()
+
+	agent := NewFunctionAgent(llm,
+		WithApprover(approver),
+		WithApprovalFor("file_operations", ApprovalAlways),
+	)
+	agent.AddTool(tool)
+
+	stream, err := agent.RunStream(context.Background(), "Delete a.txt")
+	if err != nil {
+		t.Fatalf("RunStream() error = %v", err)
+	}
+
+	var types []string
+	for event := range stream {
+		types = append(types, event.Type)
+		if event.Type != core.EventTypeApprovalRequired {
+			continue
+		}
+
+		if event.Content != "file_operations" || event.Data["tool_id"] != "call_1" {
+			t.Errorf("approval_required event = %+v, want the pending call", event)
+		}
+		if tool.CallCount() != 0 {
+			t.Error("tool ran before it was approved")
+		}
+
+		pending := <-approver.Requests()
+		if pending.ToolCall.ID != "call_1" {
+			t.Errorf("pending call = %+v, want call_1", pending.ToolCall)
+		}
+		pending.ApproveWithArgs(map[string]interface{}{"operation": "delete", "path": "b.txt"})
+	}
+
+	want := "approval_required tool_start tool_end token complete"
+	if got := strings.Join(types, " "); got != want {
+		t.Errorf("events = %q, want %q", got, want)
+	}
+	if tool.CallCount() != 1 || tool.GetCalls()[0].Args["path"] != "b.txt" {
+		t.Errorf("tool calls = %+v, want one with the edited path", tool.GetCalls())
+	}
+}
+
+// TestChannelApprover_Context tests that a run stops waiting when its
+// context ends.
+func TestChannelApprover_Context(t *testing.T) {
+	approver := NewChannelApprover()
+	req := ApprovalRequest{ToolCall: core.ToolCall{Name: "http"}}
+
+	// Nobody receives the request
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := approver.Approve(ctx, req); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Approve() error = %v, want deadline exceeded", err)
+	}
+
+	// The request is received but never resolved
+	ctx, cancel = context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	go func() { <-approver.Requests() }()
+	if _, err := approver.Approve(ctx, req); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Approve() error = %v, want deadline exceeded", err)
+	}
+
+	// Only the first decision counts
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		pending := <-approver.Requests()
+		pending.Reject("no")
+		pending.Approve()
+	}()
+	decision, err := approver.Approve(context.Background(), req)
+	wg.Wait()
+	if err != nil || decision.Approved || decision.Reason != "no" {
+		t.Errorf("Approve() = %+v, %v, want the rejection", decision, err)
+	}
+}
+
+// TestConversationalAgent_Approval tests approval with the conversational agent.
+func TestConversationalAgent_Approval(t *testing.T) {
+	llm := deleteFileLLM()
+	tool := mocks.NewMockTool("file_operations", "Manages files").WithExecuteResult("deleted")
+	approver := ApproverFunc(func(ctx context.Context, req ApprovalRequest) (ApprovalDecision, error) {
+		return ApprovalDecision{Reason: "not today"}, nil
+	})
+
+	agent := NewConversationalAgent(llm,
+		ConvWithApprover(approver),
+		ConvWithApprovalFor("file_operations", ApprovalAlways),
+	)
+	agent.AddTool(tool)
+
+	if _, err := agent.Run(context.Background(), "Delete a.txt"); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if tool.CallCount() != 0 {
+		t.Errorf("rejected tool ran %d times", tool.CallCount())
+	}
+	if got := lastToolResult(llm); !strings.Contains(got, "rejected: not today") {
+		t.Errorf("tool result = %q, want the rejection", got)
+	}
+}
+
+// TestReActAgent_Approval tests that approved actions run with edited
+// arguments and rejected ones are observed.
+func TestReActAgent_Approval(t *testing.T) {
+	llm := mocks.NewMockLLM()
+	llm.WithSequentialChatResponses([]*core.Response{
+		{Content: "Thought: Fetch it\nAction: http(method=GET, url=https://example.com)"},
+		{Content: "Thought: Post it\nAction: http(method=POST, url=https://example.com)"},
+		{Content: "Thought: Done\nFinal Answer: done"},
+	}, nil)
+	tool := mocks.NewMockTool("http", "Makes HTTP requests").WithExecuteResult("200 OK")
+
+	var requests []ApprovalRequest
+	approver := ApproverFunc(func(ctx context.Context, req ApprovalRequest) (ApprovalDecision, error) {
+		requests = append(requests, req)
+		return ApprovalDecision{Reason: "no writes"}, nil
+	})
+
+	agent := NewReActAgent(llm,
+		ReActWithApprover(approver),
+		ReActWithApprovalFor("http", ApprovalWhenArg("method", "POST", "DELETE")),
+	)
+	agent.AddTool(tool)
+
+	stream, err := agent.RunStream(context.Background(), "Fetch and post")
+	if err != nil {
+		t.Fatalf("RunStream() error = %v", err)
+	}
+	var approvalEvents int
+	var complete core.StreamEvent
+	for event := range stream {
+		switch event.Type {
+		case core.EventTypeApprovalRequired:
+			approvalEvents++
+		case core.EventTypeComplete:
+			complete = event
+		}
+	}
+
+	if approvalEvents != 1 || len(requests) != 1 || requests[0].ToolCall.ID != "step_2" {
+		t.Errorf("%d approval events and requests %+v, want one for step_2", approvalEvents, requests)
+	}
+	if tool.CallCount() != 1 {
+		t.Errorf("tool ran %d times, want only the GET", tool.CallCount())
+	}
+	trace := complete.Data["trace"].([]ReActStep)
+	if !strings.Contains(trace[1].Observation, "rejected: no writes") {
+		t.Errorf("observation = %q, want the rejection", trace[1].Observation)
+	}
+}
+
+// refundTool returns a tool taking a numeric amount.
+func refundTool() *mocks.MockTool {
+	return mocks.NewMockTool("refund", "Refunds an order").WithExecuteResult("refunded").WithSchema(&core.ToolSchema{
+		Name:        "refund",
+		Description: "Refunds an order",
+		Parameters:  []core.Parameter{{Name: "amount", Type: "number", Required: true}},
+	})
+}
+
+// approvalOver requires approval for refunds over limit.
+func approvalOver(limit float64) ApprovalPolicy {
+	return func(args map[string]interface{}) bool {
+		amount, _ := args["amount"].(float64)
+		return amount > limit
+	}
+}
+
+// TestReActAgent_Approval_ConvertedArgs tests that the policy and approver
+// see the arguments converted to the tool's schema, and that edited
+// arguments are converted too.
+func TestReActAgent_Approval_ConvertedArgs(t *testing.T) {
+	llm := mocks.NewMockLLM()
+	llm.WithSequentialChatResponses([]*core.Response{
+		{Content: "Thought: Refund it\nAction: refund(amount=500)"},
+		{Content: "Thought: Done\nFinal Answer: refunded"},
+	}, nil)
+	tool := refundTool()
+
+	var requests []ApprovalRequest
+	approver := ApproverFunc(func(ctx context.Context, req ApprovalRequest) (ApprovalDecision, error) {
+		requests = append(requests, req)
+		return ApprovalDecision{Approved: true, Args: map[string]interface{}{"amount": "50"}}, nil
+	})
+
+	agent := NewReActAgent(llm, ReActWithApprover(approver), ReActWithApprovalFor("refund", approvalOver(100)))
+	agent.AddTool(tool)
+
+	if _, err := agent.Run(context.Background(), "Refund 500"); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	if len(requests) != 1 || requests[0].ToolCall.Args["amount"] != 500.0 {
+		t.Fatalf("approval requests = %+v, want one with amount 500 as a number", requests)
+	}
+	if tool.CallCount() != 1 || tool.GetCalls()[0].Args["amount"] != 50.0 {
+		t.Errorf("tool calls = %+v, want the edited amount as a number", tool.GetCalls())
+	}
+}
+
+// TestFunctionAgent_Approval_InvalidArgs tests that invalid arguments fail
+// before approval is asked, and that invalid edits reject the call.
+func TestFunctionAgent_Approval_InvalidArgs(t *testing.T) {
+	tests := []struct {
+		name         string
+		args         map[string]interface{}
+		edit         map[string]interface{}
+		wantRequests int
+		wantResult   string
+	}{
+		{"invalid call", map[string]interface{}{"amount": "lots"}, nil, 0, "invalid_arguments"},
+		{"invalid edit", map[string]interface{}{"amount": 500.0}, map[string]interface{}{"amount": "lots"}, 1, "approved arguments are invalid"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			llm := mocks.NewMockLLM()
+			llm.WithSequentialChatResponses([]*core.Response{
+				{ToolCalls: []core.ToolCall{{ID: "call_1", Name: "refund", Args: tt.args}}},
+				{Content: "Done"},
+			}, nil)
+			tool := refundTool()
+
+			requests := 0
+			approver := ApproverFunc(func(ctx context.Context, req ApprovalRequest) (ApprovalDecision, error) {
+				requests++
+				return ApprovalDecision{Approved: true, Args: tt.edit}, nil
+			})
+
+			agent := NewFunctionAgent(llm, WithApprover(approver), WithApprovalFor("refund", approvalOver(100)))
+			agent.AddTool(tool)
+
+			if _, err := agent.Run(context.Background(), "Refund"); err != nil {
+				t.Fatalf("Run() error = %v", err)
+			}
+			if requests != tt.wantRequests || tool.CallCount() != 0 {
+				t.Errorf("%d approval requests and %d tool calls, want %d and 0", requests, tool.CallCount(), tt.wantRequests)
+			}
+			if got := lastToolResult(llm); !strings.Contains(got, tt.wantResult) {
+				t.Errorf("tool result = %q, want it to contain %q", got, tt.wantResult)
+			}
+		})
+	}
+}
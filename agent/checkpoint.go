@@ -0,0 +1,225 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/yashrahurikar23/goagents/core"
+	"github.com/yashrahurikar23/goagents/memory"
+)
+
+// Agent kinds recorded in checkpoints.
+const (
+	checkpointFunction       = "function"
+	checkpointReAct          = "react"
+	checkpointConversational = "conversational"
+)
+
+// checkpointVersion is the version of the checkpoint encoding.
+const checkpointVersion = 1
+
+// Checkpoint is the state of an agent run between two of its steps.
+//
+// With a checkpoint store (WithCheckpointStore, ReActWithCheckpointStore,
+// ConvWithCheckpointStore) an agent saves a checkpoint after every LLM call and every tool call turn, and
+// deletes it when the run completes. A run that failed, was cancelled or
+// stopped with its process can then be continued from its last checkpoint
+// with Resume or ResumeStream, by any process with access to the store.
+type Checkpoint struct {
+	// RunID and SessionID identify the run and its conversation.
+	RunID     string
+	SessionID string
+
+	// Agent is the kind of agent the run belongs to: "function", "react" or
+	// "conversational".
+	Agent string
+
+	// Input is the user input the run started with.
+	Input string
+
+	// Iteration is the number of LLM calls made so far.
+	Iteration int
+
+	// Messages is the conversation of a FunctionAgent run, the history sent to
+	// the LLM by a ConversationalAgent run (after its memory strategy), or the
+	// steps and observations of a ReActAgent run.
+	Messages []core.Message
+
+	// PendingToolCalls are the tool calls requested by the last message that
+	// have not run yet, for example because they wait for approval. They run
+	// first when the run is resumed.
+	PendingToolCalls []core.ToolCall
+
+	// Trace is the reasoning trace of a ReActAgent run.
+	Trace []ReActStep
+
+	// Usage is the token usage of the run so far.
+	Usage *core.Usage
+
+	// UpdatedAt is when the checkpoint was taken.
+	UpdatedAt time.Time
+
+	// loaded is the number of Messages that came before the run (see session)
+	loaded int
+}
+
+// checkpointRecord is the stored form of a Checkpoint. Pending tool calls are
+// stored as the tool calls of the last message.
+type checkpointRecord struct {
+	Version   int             `json:"version"`
+	RunID     string          `json:"run_id"`
+	SessionID string          `json:"session_id,omitempty"`
+	Agent     string          `json:"agent"`
+	Input     string          `json:"input"`
+	Iteration int             `json:"iteration"`
+	Messages  json.RawMessage `json:"messages"`
+	Pending   bool            `json:"pending,omitempty"`
+	Trace     []stepRecord    `json:"trace,omitempty"`
+	Usage     *core.Usage     `json:"usage,omitempty"`
+	UpdatedAt time.Time       `json:"updated_at"`
+	Loaded    int             `json:"loaded,omitempty"`
+}
+
+type stepRecord struct {
+	Iteration   int                    `json:"iteration"`
+	Thought     string                 `json:"thought,omitempty"`
+	Action      string                 `json:"action,omitempty"`
+	ActionInput map[string]interface{} `json:"action_input,omitempty"`
+	Observation string                 `json:"observation,omitempty"`
+}
+
+// MarshalCheckpoint encodes a checkpoint as JSON.
+func MarshalCheckpoint(cp *Checkpoint) ([]byte, error) {
+	messages, err := memory.MarshalMessages(cp.Messages)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode checkpoint: %w", err)
+	}
+
+	rec := checkpointRecord{
+		Version:   checkpointVersion,
+		RunID:     cp.RunID,
+		SessionID: cp.SessionID,
+		Agent:     cp.Agent,
+		Input:     cp.Input,
+		Iteration: cp.Iteration,
+		Messages:  messages,
+		Pending:   len(cp.PendingToolCalls) > 0,
+		Usage:     cp.Usage,
+		UpdatedAt: cp.UpdatedAt,
+		Loaded:    cp.loaded,
+	}
+	for _, step := range cp.Trace {
+		rec.Trace = append(rec.Trace, stepRecord(step))
+	}
+
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode checkpoint: %w", err)
+	}
+	return data, nil
+}
+
+// UnmarshalCheckpoint decodes a checkpoint encoded with MarshalCheckpoint.
+func UnmarshalCheckpoint(data []byte) (*Checkpoint, error) {
+	var rec checkpointRecord
+	if err := json.Unmarshal(data, &rec); err != nil {
+		return nil, fmt.Errorf("failed to decode checkpoint: %w", err)
+	}
+	if rec.Version != checkpointVersion {
+		return nil, fmt.Errorf("unsupported checkpoint version %d", rec.Version)
+	}
+
+	messages, err := memory.UnmarshalMessages(rec.Messages)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode checkpoint: %w", err)
+	}
+
+	cp := &Checkpoint{
+		RunID:     rec.RunID,
+		SessionID: rec.SessionID,
+		Agent:     rec.Agent,
+		Input:     rec.Input,
+		Iteration: rec.Iteration,
+		Messages:  messages,
+		Usage:     rec.Usage,
+		UpdatedAt: rec.UpdatedAt,
+		loaded:    rec.Loaded,
+	}
+	if rec.Pending && len(messages) > 0 {
+		cp.PendingToolCalls = messages[len(messages)-1].ToolCalls
+	}
+	for _, step := range rec.Trace {
+		cp.Trace = append(cp.Trace, ReActStep(step))
+	}
+	return cp, nil
+}
+
+// LoadCheckpoint returns the checkpoint of a run from store, for example to
+// show the tool calls it waits on. It fails when the run has no checkpoint.
+func LoadCheckpoint(ctx context.Context, store memory.CheckpointStore, runID string) (*Checkpoint, error) {
+	data, err := store.Load(ctx, runID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load checkpoint: %w", err)
+	}
+	if data == nil {
+		return nil, fmt.Errorf("no checkpoint found for run %s", runID)
+	}
+	return UnmarshalCheckpoint(data)
+}
+
+// saveCheckpoint stamps and saves a checkpoint. Without a store it does
+// nothing.
+func saveCheckpoint(ctx context.Context, store memory.CheckpointStore, cp *Checkpoint) error {
+	if store == nil {
+		return nil
+	}
+
+	cp.UpdatedAt = time.Now()
+	data, err := MarshalCheckpoint(cp)
+	if err != nil {
+		return err
+	}
+	if err := store.Save(ctx, cp.RunID, data); err != nil {
+		return fmt.Errorf("failed to save checkpoint: %w", err)
+	}
+	return nil
+}
+
+// deleteCheckpoint removes the checkpoint of a completed run. The run has
+// already succeeded, so a failure is reported in the response metadata
+// rather than as an error.
+func deleteCheckpoint(ctx context.Context, store memory.CheckpointStore, runID string, meta map[string]interface{}) {
+	if store == nil {
+		return
+	}
+	if err := store.Delete(ctx, runID); err != nil {
+		meta["checkpoint_error"] = err.Error()
+	}
+}
+
+// resumeContext loads the checkpoint a run is resumed from and returns a
+// context carrying the run's ID and session.
+func resumeContext(ctx context.Context, store memory.CheckpointStore, runID, agent string) (context.Context, *Checkpoint, error) {
+	if store == nil {
+		return nil, nil, &core.ErrInvalidArgument{
+			Argument: "checkpointStore",
+			Reason:   "resuming a run requires a checkpoint store",
+		}
+	}
+
+	cp, err := LoadCheckpoint(ctx, store, runID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if cp.Agent != agent {
+		return nil, nil, fmt.Errorf("checkpoint of run %s belongs to a %s agent, not a %s agent", runID, cp.Agent, agent)
+	}
+
+	ctx = core.ContextWithRunID(ctx, cp.RunID)
+	if cp.SessionID != "" {
+		ctx = core.ContextWithSessionID(ctx, cp.SessionID)
+	}
+	return ctx, cp, nil
+}
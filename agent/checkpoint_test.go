@@ -0,0 +1,426 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yashrahurikar23/goagents/core"
+	"github.com/yashrahurikar23/goagents/memory"
+	"github.com/yashrahurikar23/goagents/tests/mocks"
+)
+
+// TestMarshalCheckpoint tests that checkpoints survive encoding.
+func TestMarshalCheckpoint(t *testing.T) {
+	pending := []core.ToolCall{{ID: "call_1", Name: "http", Args: map[string]interface{}{"method": "POST"}}}
+	cp := &Checkpoint{
+		RunID:     "run-1",
+		SessionID: "user-1",
+		Agent:     checkpointFunction,
+		Input:     "Post it",
+		Iteration: 2,
+		Messages: []core.Message{
+			core.UserMessage("Post it"),
+			{Role: "assistant", Content: "Posting", ToolCalls: pending, Meta: map[string]interface{}{}},
+		},
+		PendingToolCalls: pending,
+		Trace:            []ReActStep{{Iteration: 1, Thought: "Post it", Action: "http", ActionInput: map[string]interface{}{"method": "POST"}, Observation: "ok"}},
+		Usage:            core.NewUsage(10, 5),
+		UpdatedAt:        time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		loaded:           3,
+	}
+
+	data, err := MarshalCheckpoint(cp)
+	if err != nil {
+		t.Fatalf("MarshalCheckpoint() error = %v", err)
+	}
+	got, err := UnmarshalCheckpoint(data)
+	if err != nil {
+		t.Fatalf("UnmarshalCheckpoint() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, cp) {
+		t.Errorf("UnmarshalCheckpoint() = %+v, want %+v", got, cp)
+	}
+
+	if _, err := UnmarshalCheckpoint([]byte(`{"version":99}`)); err == nil {
+		t.Error("UnmarshalCheckpoint() of an unknown version succeeded")
+	}
+}
+
+// pauseApprover cancels the run when asked for approval, as a service does
+// when it stops waiting for a human.
+func pauseApprover(cancel context.CancelFunc) Approver {
+	return ApproverFunc(func(ctx context.Context, req ApprovalRequest) (ApprovalDecision, error) {
+		cancel()
+		return ApprovalDecision{}, ctx.Err()
+	})
+}
+
+// approveAll approves every call.
+var approveAll = ApproverFunc(func(ctx context.Context, req ApprovalRequest) (ApprovalDecision, error) {
+	return ApprovalDecision{Approved: true}, nil
+})
+
+// TestFunctionAgent_ResumeAfterFailure tests that a run that failed after a
+// tool turn is resumed by another agent without running the tool again.
+func TestFunctionAgent_ResumeAfterFailure(t *testing.T) {
+	checkpoints := memory.NewInMemoryCheckpointStore()
+	sessions := memory.NewInMemoryStore()
+	ctx := core.ContextWithSessionID(core.ContextWithRunID(context.Background(), "run-1"), "user-1")
+
+	llm := mocks.NewMockLLM()
+	llm.WithSequentialChatResponses([]*core.Response{
+		{ToolCalls: []core.ToolCall{{ID: "call_1", Name: "calculator", Args: map[string]interface{}{"a": 2}}}, Usage: core.NewUsage(10, 5)},
+	}, nil)
+	tool := mocks.NewMockTool("calculator", "Calculates").WithExecuteResult("4")
+
+	agent := NewFunctionAgent(llm, WithStore(sessions), WithCheckpointStore(checkpoints))
+	agent.AddTool(tool)
+	if _, err := agent.Run(ctx, "What is 2+2?"); err == nil {
+		t.Fatal("Run() succeeded, want the second LLM call to fail")
+	}
+
+	cp, err := LoadCheckpoint(context.Background(), checkpoints, "run-1")
+	if err != nil {
+		t.Fatalf("LoadCheckpoint() error = %v", err)
+	}
+	if cp.Iteration != 1 || len(cp.PendingToolCalls) != 0 || cp.SessionID != "user-1" || cp.Usage.TotalTokens != 15 {
+		t.Errorf("checkpoint = %+v, want one iteration and no pending calls", cp)
+	}
+	if last := cp.Messages[len(cp.Messages)-1]; last.Role != "tool" || last.Content != "4" {
+		t.Errorf("last checkpoint message = %+v, want the tool result", last)
+	}
+
+	// Another process picks the run up
+	llm2 := mocks.NewMockLLM()
+	llm2.WithSequentialChatResponses([]*core.Response{{Content: "4", Usage: core.NewUsage(20, 1)}}, nil)
+	tool2 := mocks.NewMockTool("calculator", "Calculates").WithExecuteResult("4")
+	resumed := NewFunctionAgent(llm2, WithStore(sessions), WithCheckpointStore(checkpoints))
+	resumed.AddTool(tool2)
+
+	resp, err := resumed.Resume(context.Background(), "run-1")
+	if err != nil {
+		t.Fatalf("Resume() error = %v", err)
+	}
+	if resp.Content != "4" || resp.Meta["run_id"] != "run-1" || resp.Meta["iterations"] != 2 || resp.Usage.TotalTokens != 36 {
+		t.Errorf("Resume() = %+v, want the answer with the whole run's usage", resp)
+	}
+	if tool2.CallCount() != 0 {
+		t.Error("resumed run executed the finished tool call again")
+	}
+	if got := llm2.GetChatCalls()[0].Messages; len(got) != 4 || got[3].Content != "4" {
+		t.Errorf("resumed LLM call messages = %+v, want the conversation so far", got)
+	}
+
+	stored, _ := sessions.Load(context.Background(), "user-1")
+	if len(stored) != 4 || stored[0].Content != "What is 2+2?" || stored[3].Content != "4" {
+		t.Errorf("session = %+v, want the whole turn", stored)
+	}
+	if data, _ := checkpoints.Load(context.Background(), "run-1"); data != nil {
+		t.Error("checkpoint was kept after the run completed")
+	}
+}
+
+// TestFunctionAgent_ResumePendingApproval tests pausing a run while a tool
+// call waits for approval and resuming it once approved.
+func TestFunctionAgent_ResumePendingApproval(t *testing.T) {
+	checkpoints := memory.NewInMemoryCheckpointStore()
+	ctx, cancel := context.WithCancel(core.ContextWithRunID(context.Background(), "run-1"))
+	defer cancel()
+
+	tool := mocks.NewMockTool("file_operations", "Manages files").WithExecuteResult("deleted")
+	agent := NewFunctionAgent(deleteFileLLM(),
+		WithCheckpointStore(checkpoints),
+		WithApprover(pauseApprover(cancel)),
+		WithApprovalFor("file_operations", ApprovalAlways),
+	)
+	agent.AddTool(tool)
+
+	if _, err := agent.Run(ctx, "Delete a.txt"); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Run() error = %v, want the run to be cancelled", err)
+	}
+	if tool.CallCount() != 0 {
+		t.Fatal("tool ran without approval")
+	}
+
+	cp, err := LoadCheckpoint(context.Background(), checkpoints, "run-1")
+	if err != nil {
+		t.Fatalf("LoadCheckpoint() error = %v", err)
+	}
+	if len(cp.PendingToolCalls) != 1 || cp.PendingToolCalls[0].Args["path"] != "a.txt" {
+		t.Fatalf("pending calls = %+v, want the delete", cp.PendingToolCalls)
+	}
+
+	// Approved later, by a new agent with the same configuration
+	llm := mocks.NewMockLLM()
+	llm.WithChatResponse("Done", nil)
+	resumed := NewFunctionAgent(llm,
+		WithCheckpointStore(checkpoints),
+		WithApprover(approveAll),
+		WithApprovalFor("file_operations", ApprovalAlways),
+	)
+	resumed.AddTool(tool)
+
+	stream, err := resumed.ResumeStream(context.Background(), "run-1")
+	if err != nil {
+		t.Fatalf("ResumeStream() error = %v", err)
+	}
+	var types []string
+	for event := range stream {
+		types = append(types, event.Type)
+	}
+
+	want := "approval_required tool_start tool_end token complete"
+	if got := strings.Join(types, " "); got != want {
+		t.Errorf("events = %q, want %q", got, want)
+	}
+	if tool.CallCount() != 1 {
+		t.Errorf("tool ran %d times, want 1", tool.CallCount())
+	}
+	if got := lastToolResult(llm); got != "deleted" {
+		t.Errorf("tool result sent to the LLM = %q, want deleted", got)
+	}
+	if messages := resumed.GetMessages(); len(messages) != 5 || messages[0].Role != "system" || messages[4].Content != "Done" {
+		t.Errorf("history = %+v, want the resumed turn", messages)
+	}
+}
+
+// TestReActAgent_ResumePendingApproval tests pausing a ReAct run on an
+// action waiting for approval and resuming it.
+func TestReActAgent_ResumePendingApproval(t *testing.T) {
+	checkpoints := memory.NewInMemoryCheckpointStore()
+	ctx, cancel := context.WithCancel(core.ContextWithRunID(context.Background(), "run-1"))
+	defer cancel()
+
+	llm := mocks.NewMockLLM()
+	llm.WithSequentialChatResponses([]*core.Response{
+		{Content: "Thought: Fetch it\nAction: http(method=GET, url=https://example.com)"},
+		{Content: "Thought: Post it\nAction: http(method=POST, url=https://example.com)"},
+	}, nil)
+	tool := mocks.NewMockTool("http", "Makes HTTP requests").WithExecuteResult("200 OK")
+	policy := ReActWithApprovalFor("http", ApprovalWhenArg("method", "POST"))
+
+	agent := NewReActAgent(llm, ReActWithCheckpointStore(checkpoints), ReActWithApprover(pauseApprover(cancel)), policy)
+	agent.AddTool(tool)
+
+	if _, err := agent.Run(ctx, "Fetch and post"); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Run() error = %v, want the run to be cancelled", err)
+	}
+
+	cp, err := LoadCheckpoint(context.Background(), checkpoints, "run-1")
+	if err != nil {
+		t.Fatalf("LoadCheckpoint() error = %v", err)
+	}
+	if cp.Iteration != 2 || len(cp.Trace) != 1 || len(cp.PendingToolCalls) != 1 || cp.PendingToolCalls[0].ID != "step_2" {
+		t.Fatalf("checkpoint = %+v, want one step done and step_2 pending", cp)
+	}
+
+	resumedLLM := mocks.NewMockLLM()
+	resumedLLM.WithChatResponse("Thought: Done\nFinal Answer: posted", nil)
+	resumed := NewReActAgent(resumedLLM, ReActWithCheckpointStore(checkpoints), ReActWithApprover(approveAll), policy)
+	resumed.AddTool(tool)
+
+	resp, err := resumed.Resume(context.Background(), "run-1")
+	if err != nil {
+		t.Fatalf("Resume() error = %v", err)
+	}
+	if resp.Content != "posted" || resp.Meta["iterations"] != 3 {
+		t.Errorf("Resume() = %+v, want the answer after 3 iterations", resp)
+	}
+	if tool.CallCount() != 2 || tool.GetCalls()[1].Args["method"] != "POST" {
+		t.Errorf("tool calls = %+v, want the GET and then the approved POST", tool.GetCalls())
+	}
+
+	trace := resp.Meta["trace"].([]ReActStep)
+	if len(trace) != 3 || trace[1].Action != "http" || trace[1].Observation != "200 OK" {
+		t.Errorf("trace = %+v, want all three steps", trace)
+	}
+	messages := resumedLLM.GetChatCalls()[0].Messages
+	if got := messages[len(messages)-1].Content; got != "Observation: 200 OK" {
+		t.Errorf("last message sent = %q, want the POST's observation", got)
+	}
+	if data, _ := checkpoints.Load(context.Background(), "run-1"); data != nil {
+		t.Error("checkpoint was kept after the run completed")
+	}
+}
+
+// TestConversationalAgent_ResumeAfterFailure tests resuming a run whose
+// history the memory strategy trimmed: the LLM is sent the trimmed history
+// and the store still receives the whole turn.
+func TestConversationalAgent_ResumeAfterFailure(t *testing.T) {
+	checkpoints := memory.NewInMemoryCheckpointStore()
+	sessions := memory.NewInMemoryStore()
+	ctx := core.ContextWithSessionID(core.ContextWithRunID(context.Background(), "run-1"), "user-1")
+	sessions.Append(ctx, "user-1",
+		core.UserMessage("Q1"), core.AssistantMessage("A1"),
+		core.UserMessage("Q2"), core.AssistantMessage("A2"),
+		core.UserMessage("Q3"), core.AssistantMessage("A3"),
+	)
+
+	llm := mocks.NewMockLLM()
+	llm.WithSequentialChatResponses([]*core.Response{
+		{ToolCalls: []core.ToolCall{{ID: "call_1", Name: "calculator", Args: map[string]interface{}{"a": 2}}}, Usage: core.NewUsage(10, 5)},
+	}, nil)
+	tool := mocks.NewMockTool("calculator", "Calculates").WithExecuteResult("4")
+
+	opts := []ConversationalAgentOption{ConvWithStore(sessions), ConvWithMaxMessages(4), ConvWithCheckpointStore(checkpoints)}
+	agent := NewConversationalAgent(llm, opts...)
+	agent.AddTool(tool)
+	if _, err := agent.Run(ctx, "What is 2+2?"); err == nil {
+		t.Fatal("Run() succeeded, want the second LLM call to fail")
+	}
+
+	cp, err := LoadCheckpoint(context.Background(), checkpoints, "run-1")
+	if err != nil {
+		t.Fatalf("LoadCheckpoint() error = %v", err)
+	}
+	if cp.Agent != checkpointConversational || cp.Iteration != 1 || len(cp.PendingToolCalls) != 0 || cp.SessionID != "user-1" {
+		t.Errorf("checkpoint = %+v, want one iteration and no pending calls", cp)
+	}
+	if len(cp.Messages) != 6 || cp.Messages[1].Content != "Q3" || cp.Messages[5].Content != "4" {
+		t.Errorf("checkpoint messages = %+v, want the window and the tool turn", cp.Messages)
+	}
+
+	// Another process picks the run up
+	llm2 := mocks.NewMockLLM()
+	llm2.WithSequentialChatResponses([]*core.Response{{Content: "4", Usage: core.NewUsage(20, 1)}}, nil)
+	tool2 := mocks.NewMockTool("calculator", "Calculates").WithExecuteResult("4")
+	resumed := NewConversationalAgent(llm2, opts...)
+	resumed.AddTool(tool2)
+
+	resp, err := resumed.Resume(context.Background(), "run-1")
+	if err != nil {
+		t.Fatalf("Resume() error = %v", err)
+	}
+	if resp.Content != "4" || resp.Meta["run_id"] != "run-1" || resp.Meta["iterations"] != 2 || resp.Usage.TotalTokens != 36 {
+		t.Errorf("Resume() = %+v, want the answer with the whole run's usage", resp)
+	}
+	if tool2.CallCount() != 0 {
+		t.Error("resumed run executed the finished tool call again")
+	}
+	if got := llm2.GetChatCalls()[0].Messages; len(got) != 6 || got[1].Content != "Q3" || got[5].Content != "4" {
+		t.Errorf("resumed LLM call messages = %+v, want the window so far", got)
+	}
+
+	stored, _ := sessions.Load(context.Background(), "user-1")
+	if len(stored) != 10 || stored[5].Content != "A3" || stored[6].Content != "What is 2+2?" || stored[9].Content != "4" {
+		t.Errorf("session = %+v, want the earlier turns and the whole new turn", stored)
+	}
+	if data, _ := checkpoints.Load(context.Background(), "run-1"); data != nil {
+		t.Error("checkpoint was kept after the run completed")
+	}
+}
+
+// TestConversationalAgent_ResumePendingApproval tests pausing a
+// conversational run while a tool call waits for approval and resuming it
+// with ResumeStream.
+func TestConversationalAgent_ResumePendingApproval(t *testing.T) {
+	checkpoints := memory.NewInMemoryCheckpointStore()
+	ctx, cancel := context.WithCancel(core.ContextWithRunID(context.Background(), "run-1"))
+	defer cancel()
+
+	tool := mocks.NewMockTool("file_operations", "Manages files").WithExecuteResult("deleted")
+	agent := NewConversationalAgent(deleteFileLLM(),
+		ConvWithCheckpointStore(checkpoints),
+		ConvWithApprover(pauseApprover(cancel)),
+		ConvWithApprovalFor("file_operations", ApprovalAlways),
+	)
+	agent.AddTool(tool)
+
+	if _, err := agent.Run(ctx, "Delete a.txt"); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Run() error = %v, want the run to be cancelled", err)
+	}
+	if tool.CallCount() != 0 {
+		t.Fatal("tool ran without approval")
+	}
+
+	cp, err := LoadCheckpoint(context.Background(), checkpoints, "run-1")
+	if err != nil {
+		t.Fatalf("LoadCheckpoint() error = %v", err)
+	}
+	if len(cp.PendingToolCalls) != 1 || cp.PendingToolCalls[0].Args["path"] != "a.txt" {
+		t.Fatalf("pending calls = %+v, want the delete", cp.PendingToolCalls)
+	}
+
+	llm := mocks.NewMockLLM()
+	llm.WithChatResponse("Done", nil)
+	resumed := NewConversationalAgent(llm,
+		ConvWithCheckpointStore(checkpoints),
+		ConvWithApprover(approveAll),
+		ConvWithApprovalFor("file_operations", ApprovalAlways),
+	)
+	resumed.AddTool(tool)
+
+	stream, err := resumed.ResumeStream(context.Background(), "run-1")
+	if err != nil {
+		t.Fatalf("ResumeStream() error = %v", err)
+	}
+	var types []string
+	var complete core.StreamEvent
+	for event := range stream {
+		types = append(types, event.Type)
+		if event.Type == core.EventTypeComplete {
+			complete = event
+		}
+	}
+
+	want := "approval_required tool_start tool_end token complete"
+	if got := strings.Join(types, " "); got != want {
+		t.Errorf("events = %q, want %q", got, want)
+	}
+	if complete.Data["iterations"] != 2 {
+		t.Errorf("complete data = %+v, want 2 iterations", complete.Data)
+	}
+	if tool.CallCount() != 1 {
+		t.Errorf("tool ran %d times, want 1", tool.CallCount())
+	}
+	if got := lastToolResult(llm); got != "deleted" {
+		t.Errorf("tool result sent to the LLM = %q, want deleted", got)
+	}
+	if messages := resumed.GetMessages(); len(messages) != 5 || messages[0].Role != "system" || messages[1].Content != "Delete a.txt" || messages[4].Content != "Done" {
+		t.Errorf("history = %+v, want the resumed turn", messages)
+	}
+	if data, _ := checkpoints.Load(context.Background(), "run-1"); data != nil {
+		t.Error("checkpoint was kept after the run completed")
+	}
+}
+
+// TestResume_Errors tests resuming without a usable checkpoint.
+func TestResume_Errors(t *testing.T) {
+	checkpoints := memory.NewInMemoryCheckpointStore()
+	data, _ := MarshalCheckpoint(&Checkpoint{RunID: "react-run", Agent: checkpointReAct})
+	checkpoints.Save(context.Background(), "react-run", data)
+
+	tests := []struct {
+		name   string
+		resume func() error
+		want   string
+	}{
+		{"no store", func() error {
+			_, err := NewFunctionAgent(mocks.NewMockLLM()).Resume(context.Background(), "run-1")
+			return err
+		}, "checkpoint store"},
+		{"unknown run", func() error {
+			_, err := NewReActAgent(mocks.NewMockLLM(), ReActWithCheckpointStore(checkpoints)).Resume(context.Background(), "run-1")
+			return err
+		}, "no checkpoint found for run run-1"},
+		{"other agent", func() error {
+			_, err := NewFunctionAgent(mocks.NewMockLLM(), WithCheckpointStore(checkpoints)).ResumeStream(context.Background(), "react-run")
+			return err
+		}, "belongs to a react agent"},
+		{"conversational agent", func() error {
+			_, err := NewConversationalAgent(mocks.NewMockLLM(), ConvWithCheckpointStore(checkpoints)).Resume(context.Background(), "react-run")
+			return err
+		}, "not a conversational agent"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.resume(); err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("error = %v, want it to mention %q", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,325 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/yashrahurikar23/goagents/core"
+	"github.com/yashrahurikar23/goagents/memory"
+	"github.com/yashrahurikar23/goagents/tests/mocks"
+)
+
+// These tests share one agent between goroutines; run them with -race.
+
+const concurrentRuns = 20
+
+// lastUserMessage returns the content of the last user message.
+func lastUserMessage(messages []core.Message) string {
+	for i := len(messages) - 1; i >= 0; i-- {
+		if messages[i].Role == "user" {
+			return messages[i].Content
+		}
+	}
+	return ""
+}
+
+// echoToolLLM calls the echo tool with the user's question, then answers with
+// the tool's result.
+func echoToolLLM() *mocks.MockLLM {
+	llm := mocks.NewMockLLM()
+	llm.ChatFunc = func(ctx context.Context, messages []core.Message) (*core.Response, error) {
+		last := messages[len(messages)-1]
+		if last.Role == "tool" {
+			return &core.Response{Content: "answer: " + last.Content}, nil
+		}
+		return &core.Response{ToolCalls: []core.ToolCall{{
+			ID:   "call_1",
+			Name: "echo",
+			Args: map[string]interface{}{"text": last.Content},
+		}}}, nil
+	}
+	return llm
+}
+
+// echoTool returns its text argument.
+func echoTool() *mocks.MockTool {
+	tool := mocks.NewMockTool("echo", "Echoes text")
+	tool.ExecuteFunc = func(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+		return args["text"], nil
+	}
+	return tool
+}
+
+// runConcurrently calls run for 0..concurrentRuns-1 in parallel.
+func runConcurrently(t *testing.T, run func(i int)) {
+	t.Helper()
+
+	var wg sync.WaitGroup
+	for i := 0; i < concurrentRuns; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			run(i)
+		}(i)
+	}
+	wg.Wait()
+}
+
+// TestFunctionAgent_ConcurrentRuns tests that runs sharing an agent, each in
+// its own session, only see their own conversation.
+func TestFunctionAgent_ConcurrentRuns(t *testing.T) {
+	store := memory.NewInMemoryStore()
+	agent := NewFunctionAgent(echoToolLLM(), WithStore(store))
+	agent.AddTool(echoTool())
+
+	runConcurrently(t, func(i int) {
+		ctx := core.ContextWithSessionID(context.Background(), fmt.Sprintf("user-%d", i))
+		question := fmt.Sprintf("question %d", i)
+
+		resp, err := agent.Run(ctx, question)
+		if err != nil {
+			t.Errorf("Run(%d) error = %v", i, err)
+			return
+		}
+		if resp.Content != "answer: "+question {
+			t.Errorf("Run(%d) = %q, want the answer to its own question", i, resp.Content)
+		}
+
+		stored, _ := store.Load(ctx, fmt.Sprintf("user-%d", i))
+		if len(stored) != 4 || stored[0].Content != question {
+			t.Errorf("session %d = %+v, want its own 4 messages", i, stored)
+		}
+	})
+}
+
+// TestFunctionAgent_ConcurrentRunStream tests concurrent streaming runs.
+func TestFunctionAgent_ConcurrentRunStream(t *testing.T) {
+	agent := NewFunctionAgent(echoToolLLM())
+	agent.AddTool(echoTool())
+
+	runConcurrently(t, func(i int) {
+		question := fmt.Sprintf("question %d", i)
+		events, err := agent.RunStream(context.Background(), question)
+		if err != nil {
+			t.Errorf("RunStream(%d) error = %v", i, err)
+			return
+		}
+
+		var complete string
+		for event := range events {
+			if event.Type == core.EventTypeError {
+				t.Errorf("RunStream(%d) error event: %v", i, event.Error)
+			}
+			if event.Type == core.EventTypeComplete {
+				complete = event.Content
+			}
+		}
+		if complete != "answer: "+question {
+			t.Errorf("RunStream(%d) complete = %q, want the answer to its own question", i, complete)
+		}
+	})
+
+	// Every turn is kept whole: system, then user, tool call, result, answer
+	messages := agent.GetMessages()
+	if len(messages) != 1+4*concurrentRuns {
+		t.Fatalf("history has %d messages, want %d", len(messages), 1+4*concurrentRuns)
+	}
+	for i := 1; i < len(messages); i += 4 {
+		if want := "answer: " + messages[i].Content; messages[i+3].Content != want {
+			t.Errorf("turn at %d ends with %q, want %q", i, messages[i+3].Content, want)
+		}
+	}
+}
+
+// TestFunctionAgent_AddToolDuringRuns tests registering tools while runs are
+// in progress.
+func TestFunctionAgent_AddToolDuringRuns(t *testing.T) {
+	agent := NewFunctionAgent(echoToolLLM())
+	agent.AddTool(echoTool())
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < concurrentRuns; i++ {
+			if err := agent.AddTool(mocks.NewMockTool(fmt.Sprintf("tool_%d", i), "A tool")); err != nil {
+				t.Errorf("AddTool(%d) error = %v", i, err)
+			}
+		}
+	}()
+
+	runConcurrently(t, func(i int) {
+		if _, err := agent.Run(context.Background(), fmt.Sprintf("question %d", i)); err != nil {
+			t.Errorf("Run(%d) error = %v", i, err)
+		}
+	})
+	wg.Wait()
+
+	if got := len(agent.tools.snapshot()); got != concurrentRuns+1 {
+		t.Errorf("%d tools registered, want %d", got, concurrentRuns+1)
+	}
+}
+
+// TestConversationalAgent_ConcurrentRuns tests that concurrent runs without a
+// store each add their whole turn to the shared history.
+func TestConversationalAgent_ConcurrentRuns(t *testing.T) {
+	llm := mocks.NewMockLLM()
+	llm.ChatFunc = func(ctx context.Context, messages []core.Message) (*core.Response, error) {
+		return &core.Response{Content: "re: " + lastUserMessage(messages)}, nil
+	}
+	agent := NewConversationalAgent(llm, ConvWithMemoryStrategy(MemoryStrategyAll))
+
+	runConcurrently(t, func(i int) {
+		question := fmt.Sprintf("question %d", i)
+		resp, err := agent.Run(context.Background(), question)
+		if err != nil {
+			t.Errorf("Run(%d) error = %v", i, err)
+			return
+		}
+		if resp.Content != "re: "+question {
+			t.Errorf("Run(%d) = %q, want the reply to its own question", i, resp.Content)
+		}
+	})
+
+	messages := agent.GetMessages()
+	if len(messages) != 1+2*concurrentRuns {
+		t.Fatalf("history has %d messages, want %d", len(messages), 1+2*concurrentRuns)
+	}
+	for i := 1; i < len(messages); i += 2 {
+		if messages[i+1].Content != "re: "+messages[i].Content {
+			t.Errorf("messages %d-%d = %q, %q, want a question and its reply", i, i+1, messages[i].Content, messages[i+1].Content)
+		}
+	}
+}
+
+// barrierHooks holds every LLM call until the number of calls it was given
+// with Add have started, so that concurrent runs all load the history before
+// any of them commits.
+type barrierHooks struct {
+	BaseHooks
+	sync.WaitGroup
+}
+
+func (h *barrierHooks) BeforeLLMCall(ctx context.Context, call *LLMCall) error {
+	h.Done()
+	h.Wait()
+	return nil
+}
+
+// TestConversationalAgent_ConcurrentRuns_Window tests that concurrent runs
+// whose window trims the history keep each other's turns: each run drops only
+// the old messages it trimmed.
+func TestConversationalAgent_ConcurrentRuns_Window(t *testing.T) {
+	llm := mocks.NewMockLLM()
+	llm.ChatFunc = func(ctx context.Context, messages []core.Message) (*core.Response, error) {
+		return &core.Response{Content: "re: " + lastUserMessage(messages)}, nil
+	}
+	loaded := &barrierHooks{}
+	loaded.Add(concurrentRuns)
+	agent := NewConversationalAgent(llm, ConvWithMaxMessages(4), ConvWithHooks(loaded))
+	agent.history.messages = append(agent.history.messages,
+		core.UserMessage("Q1"), core.AssistantMessage("A1"),
+		core.UserMessage("Q2"), core.AssistantMessage("A2"),
+		core.UserMessage("Q3"), core.AssistantMessage("A3"),
+	)
+
+	runConcurrently(t, func(i int) {
+		if _, err := agent.Run(context.Background(), fmt.Sprintf("question %d", i)); err != nil {
+			t.Errorf("Run(%d) error = %v", i, err)
+		}
+	})
+
+	// The window dropped the turns before Q3, and nothing else
+	messages := agent.GetMessages()
+	if len(messages) != 3+2*concurrentRuns {
+		t.Fatalf("history has %d messages, want the system prompt, Q3, A3 and %d turns", len(messages), concurrentRuns)
+	}
+	if messages[0].Role != "system" || messages[1].Content != "Q3" || messages[2].Content != "A3" {
+		t.Errorf("history starts with %+v, want the system prompt and the last seeded turn", messages[:3])
+	}
+
+	questions := make(map[string]bool)
+	for i := 3; i < len(messages); i += 2 {
+		if messages[i+1].Content != "re: "+messages[i].Content {
+			t.Errorf("messages %d-%d = %q, %q, want a question and its reply", i, i+1, messages[i].Content, messages[i+1].Content)
+		}
+		questions[messages[i].Content] = true
+	}
+	for i := 0; i < concurrentRuns; i++ {
+		if question := fmt.Sprintf("question %d", i); !questions[question] {
+			t.Errorf("history lost the turn of %q", question)
+		}
+	}
+}
+
+// TestConversationalAgent_ConcurrentSessions tests concurrent runs with tools
+// and a memory strategy, each in its own stored session.
+func TestConversationalAgent_ConcurrentSessions(t *testing.T) {
+	store := memory.NewInMemoryStore()
+	agent := NewConversationalAgent(echoToolLLM(),
+		ConvWithStore(store),
+		ConvWithMaxMessages(4),
+	)
+	agent.AddTool(echoTool())
+
+	runConcurrently(t, func(i int) {
+		ctx := core.ContextWithSessionID(context.Background(), fmt.Sprintf("user-%d", i))
+		for turn := 0; turn < 3; turn++ {
+			question := fmt.Sprintf("question %d.%d", i, turn)
+			resp, err := agent.Run(ctx, question)
+			if err != nil {
+				t.Errorf("Run(%d) error = %v", i, err)
+				return
+			}
+			if resp.Content != "answer: "+question {
+				t.Errorf("Run(%d) = %q, want the answer to its own question", i, resp.Content)
+			}
+		}
+
+		stored, _ := store.Load(ctx, fmt.Sprintf("user-%d", i))
+		for _, msg := range stored {
+			if msg.Role == "user" && !strings.HasPrefix(msg.Content, fmt.Sprintf("question %d.", i)) {
+				t.Errorf("session %d holds %q from another session", i, msg.Content)
+			}
+		}
+	})
+}
+
+// TestReActAgent_ConcurrentRuns tests that each run gets its own trace.
+func TestReActAgent_ConcurrentRuns(t *testing.T) {
+	llm := mocks.NewMockLLM()
+	llm.ChatFunc = func(ctx context.Context, messages []core.Message) (*core.Response, error) {
+		last := messages[len(messages)-1].Content
+		if strings.HasPrefix(last, "Observation: ") {
+			return &core.Response{Content: "Thought: Done\nFinal Answer: " + strings.TrimPrefix(last, "Observation: ")}, nil
+		}
+		question := strings.TrimPrefix(strings.SplitN(last, "\n", 2)[0], "Question: ")
+		return &core.Response{Content: fmt.Sprintf("Thought: Echo it\nAction: echo(text=%q)", question)}, nil
+	}
+	agent := NewReActAgent(llm)
+	agent.AddTool(echoTool())
+
+	runConcurrently(t, func(i int) {
+		question := fmt.Sprintf("question %d", i)
+		resp, err := agent.Run(context.Background(), question)
+		if err != nil {
+			t.Errorf("Run(%d) error = %v", i, err)
+			return
+		}
+		if resp.Content != question {
+			t.Errorf("Run(%d) = %q, want %q", i, resp.Content, question)
+		}
+
+		trace, _ := resp.Meta["trace"].([]ReActStep)
+		if len(trace) != 2 || trace[0].Observation != question {
+			t.Errorf("Run(%d) trace = %+v, want its own two steps", i, trace)
+		}
+	})
+
+	if got := agent.GetTrace(); len(got) != 2 {
+		t.Errorf("GetTrace() = %+v, want the last run's two steps", got)
+	}
+}
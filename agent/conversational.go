@@ -2,9 +2,13 @@ package agent
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"sync"
+	"time"
 
 	"github.com/yashrahurikar23/goagents/core"
+	"github.com/yashrahurikar23/goagents/memory"
 )
 
 // ConversationalAgent maintains conversation history and provides
@@ -15,11 +19,30 @@ import (
 // - Maintain context over long conversations
 // - Manage token limits through memory strategies
 // - Support streaming responses
+// - Call tools registered with AddTool
 //
 // Memory Strategies:
 // - Windowing: Keep last N messages
 // - Summarization: Compress old messages into summaries
 // - Selective: Keep important messages (system, tool results, etc.)
+// - Token budget: Keep the most recent turns that fit the model's context window
+//
+// Tools are offered to the LLM through native tool calling when it implements
+// core.ToolCallingLLM, and through a JSON reply format described in the prompt
+// otherwise. The memory strategies never separate an assistant tool-call
+// message from the tool results that follow it.
+//
+// With ConvWithStore, the history is kept in a memory.Store instead of only in
+// the agent: each run loads the session named by core.ContextWithSessionID (or
+// ConvWithSessionID) and saves the completed turn, so conversations survive
+// restarts and one agent can serve many sessions.
+//
+// With ConvWithLongTermMemory, facts from each exchange are remembered in a
+// vector store, and the ones relevant to a new message are recalled into a
+// system message for that turn, even from sessions long gone from the history.
+//
+// With ConvWithCheckpointStore, a run that calls tools is checkpointed after
+// every step and can be continued with Resume if it fails or stops.
 //
 // Example usage:
 //
@@ -33,13 +56,57 @@ import (
 //	// Second turn (remembers Alice)
 //	resp2, _ := agent.Run(ctx, "What's my name?")
 //	// "Your name is Alice."
+//
+// A ConversationalAgent is safe for concurrent use. Each run works on its own
+// copy of the conversation and adds its turn to the agent's history when it
+// completes; give each conversation its own session (ConvWithStore) to keep
+// them apart.
 type ConversationalAgent struct {
 	llm             core.LLM
-	messages        []core.Message
+	history         *history
+	mu              sync.RWMutex // guards systemPrompt
 	systemPrompt    string
 	memoryStrategy  MemoryStrategy
 	maxMessages     int
 	summarizationLM core.LLM // Optional separate LLM for summarization
+	costTracker     *core.CostTracker
+
+	// Token budget strategy
+	tokenizer         core.Tokenizer
+	contextWindow     int
+	responseReserve   int
+	summarizeOverflow bool
+
+	tools            *toolSet
+	maxIter          int
+	maxParallelTools int
+	toolTimeout      time.Duration
+	toolTimeouts     map[string]time.Duration
+
+	// Persistent history
+	store     memory.Store
+	sessionID string
+
+	// Long-term memory
+	longTerm *memory.LongTermMemory
+
+	hooks     hookChain
+	approvals approvals
+
+	checkpoints memory.CheckpointStore
+}
+
+// convRun is the state of one ConversationalAgent run.
+type convRun struct {
+	session   *session
+	messages  []core.Message
+	added     []core.Message // messages of this turn, in order
+	rewrite   *rewrite       // set when a memory strategy rewrote the history
+	tools     map[string]core.Tool
+	recalled  []memory.ScoredRecord // memories recalled for the turn
+	input     string
+	iteration int             // LLM calls made
+	pending   []core.ToolCall // tool calls of the last message that have not run
 }
 
 // MemoryStrategy defines how conversation history is managed.
@@ -57,6 +124,25 @@ const (
 
 	// MemoryStrategyAll keeps all messages (no limit).
 	MemoryStrategyAll MemoryStrategy = "all"
+
+	// MemoryStrategyTokenBudget keeps the most recent turns that fit in the
+	// model's context window, after reserving room for the response and the
+	// tool definitions. Older turns are dropped, or summarized with
+	// ConvWithSummarizeOverflow.
+	MemoryStrategyTokenBudget MemoryStrategy = "token_budget"
+)
+
+const (
+	// DefaultContextWindow is the context window assumed by the token budget
+	// strategy when it is not set and the model's is unknown.
+	DefaultContextWindow = 8192
+
+	// DefaultResponseReserve is the number of tokens the token budget
+	// strategy leaves free for the response.
+	DefaultResponseReserve = 1024
+
+	// maxSummaryTokens caps the room the token budget strategy reserves for a summary.
+	maxSummaryTokens = 1024
 )
 
 // ConversationalAgentOption configures a ConversationalAgent.
@@ -90,14 +176,173 @@ func ConvWithSummarizationLLM(llm core.LLM) ConversationalAgentOption {
 	}
 }
 
+// ConvWithCostTracker records the token usage and cost of every chat call.
+// Calls are attributed to the run and to the session set on the context
+// with core.ContextWithSessionID.
+func ConvWithCostTracker(tracker *core.CostTracker) ConversationalAgentOption {
+	return func(a *ConversationalAgent) {
+		a.costTracker = tracker
+	}
+}
+
+// ConvWithTokenizer sets the tokenizer the token budget strategy counts with
+// (default core.ApproxTokenizer). Use a core.BPETokenizer for exact counts
+// with OpenAI models.
+func ConvWithTokenizer(tokenizer core.Tokenizer) ConversationalAgentOption {
+	return func(a *ConversationalAgent) {
+		a.tokenizer = tokenizer
+	}
+}
+
+// ConvWithContextWindow sets the context window size in tokens for the token
+// budget strategy. By default it is looked up in core.DefaultContextWindows
+// for LLMs that report their model with a Model() method, falling back to
+// DefaultContextWindow.
+func ConvWithContextWindow(tokens int) ConversationalAgentOption {
+	return func(a *ConversationalAgent) {
+		a.contextWindow = tokens
+	}
+}
+
+// ConvWithResponseReserve sets how many tokens of the context window the
+// token budget strategy leaves free for the response (default DefaultResponseReserve).
+func ConvWithResponseReserve(tokens int) ConversationalAgentOption {
+	return func(a *ConversationalAgent) {
+		a.responseReserve = tokens
+	}
+}
+
+// ConvWithSummarizeOverflow makes the token budget strategy replace the turns
+// it drops with a summary, written by the summarization LLM (or the main LLM).
+func ConvWithSummarizeOverflow(enabled bool) ConversationalAgentOption {
+	return func(a *ConversationalAgent) {
+		a.summarizeOverflow = enabled
+	}
+}
+
+// ConvWithMaxIterations sets the maximum number of LLM calls per run when
+// the LLM keeps calling tools.
+func ConvWithMaxIterations(max int) ConversationalAgentOption {
+	return func(a *ConversationalAgent) {
+		a.maxIter = max
+	}
+}
+
+// ConvWithMaxParallelTools limits how many tool calls of one LLM turn run at
+// the same time. 0 (the default) runs all of them concurrently.
+func ConvWithMaxParallelTools(n int) ConversationalAgentOption {
+	return func(a *ConversationalAgent) {
+		a.maxParallelTools = n
+	}
+}
+
+// ConvWithToolTimeout sets how long a tool call may run before it is
+// abandoned and reported to the LLM as timed out. 0 (the default) means no timeout.
+func ConvWithToolTimeout(timeout time.Duration) ConversationalAgentOption {
+	return func(a *ConversationalAgent) {
+		a.toolTimeout = timeout
+	}
+}
+
+// ConvWithToolTimeoutFor overrides the tool timeout for the named tool.
+func ConvWithToolTimeoutFor(name string, timeout time.Duration) ConversationalAgentOption {
+	return func(a *ConversationalAgent) {
+		if a.toolTimeouts == nil {
+			a.toolTimeouts = make(map[string]time.Duration)
+		}
+		a.toolTimeouts[name] = timeout
+	}
+}
+
+// ConvWithStore keeps the conversation history in store. Every run loads the
+// session's history, replacing the agent's, and appends the messages of the
+// turn once it completes. The store keeps the full transcript: the memory
+// strategy only trims or summarizes the copy sent to the LLM, again on every
+// run, so summarizing strategies make a summary call on each turn once the
+// session outgrows the limit (see ConvWithSummarizationLLM).
+//
+// The session is taken from the context (core.ContextWithSessionID), else the
+// one set with ConvWithSessionID; running without either is an error. The
+// system prompt is not stored.
+func ConvWithStore(store memory.Store) ConversationalAgentOption {
+	return func(a *ConversationalAgent) {
+		a.store = store
+	}
+}
+
+// ConvWithSessionID sets the session used when the context carries none.
+func ConvWithSessionID(id string) ConversationalAgentOption {
+	return func(a *ConversationalAgent) {
+		a.sessionID = id
+	}
+}
+
+// ConvWithLongTermMemory recalls the memories relevant to each user message
+// into a system message sent with that turn (it is not kept in the history),
+// and remembers the facts of each completed exchange. Facts are extracted
+// with the agent's LLM unless memory.WithExtractionLLM is set.
+//
+// Failing to recall fails the run. Failing to remember does not: the reply
+// is returned with the error under "memory_error" in its Meta (or the
+// complete event's data).
+func ConvWithLongTermMemory(ltm *memory.LongTermMemory) ConversationalAgentOption {
+	return func(a *ConversationalAgent) {
+		a.longTerm = ltm
+	}
+}
+
+// ConvWithHooks adds hooks that are called as the agent runs. Hooks added by
+// several options form one chain, in order; see ChainHooks.
+//
+// The LLM calls of the memory strategies and long-term memory (summaries and
+// fact extraction) do not go through the hooks. The usage of fact extraction
+// is counted in the run's usage and reported to the cost tracker.
+func ConvWithHooks(hooks ...Hooks) ConversationalAgentOption {
+	return func(a *ConversationalAgent) {
+		a.hooks = a.hooks.with(hooks...)
+	}
+}
+
+// ConvWithApprover sets who approves the tool calls that need approval; see
+// ConvWithApprovalFor.
+func ConvWithApprover(approver Approver) ConversationalAgentOption {
+	return func(a *ConversationalAgent) {
+		a.approvals.approver = approver
+	}
+}
+
+// ConvWithApprovalFor sets when calls of the named tool need approval before
+// they run. A rejected call is reported to the LLM with the reason. Calls
+// that need approval are rejected when no approver is set.
+func ConvWithApprovalFor(name string, policy ApprovalPolicy) ConversationalAgentOption {
+	return func(a *ConversationalAgent) {
+		a.approvals.setPolicy(name, policy)
+	}
+}
+
+// ConvWithCheckpointStore saves a checkpoint of every run to store after each
+// step, so a run that stopped can be continued with Resume. The checkpoint is
+// kept under the run's ID (set it with core.ContextWithRunID to know it in
+// advance) and deleted once the run completes. A run that answers in a single
+// LLM call has no step to resume and is never checkpointed.
+func ConvWithCheckpointStore(store memory.CheckpointStore) ConversationalAgentOption {
+	return func(a *ConversationalAgent) {
+		a.checkpoints = store
+	}
+}
+
 // NewConversationalAgent creates a conversational agent with memory management.
 func NewConversationalAgent(llm core.LLM, opts ...ConversationalAgentOption) *ConversationalAgent {
 	agent := &ConversationalAgent{
 		llm:            llm,
-		messages:       make([]core.Message, 0),
+		history:        &history{},
 		systemPrompt:   "You are a helpful assistant.",
 		memoryStrategy: MemoryStrategyWindow,
 		maxMessages:    20, // Default: keep last 20 messages (10 turns)
+		tools:          newToolSet(),
+		maxIter:        5,
+
+		responseReserve: DefaultResponseReserve,
 	}
 
 	for _, opt := range opts {
@@ -105,123 +350,506 @@ func NewConversationalAgent(llm core.LLM, opts ...ConversationalAgentOption) *Co
 	}
 
 	// Initialize with system prompt
-	if agent.systemPrompt != "" {
-		agent.messages = append(agent.messages, core.SystemMessage(agent.systemPrompt))
-	}
+	agent.history.reset(agent.systemPrompt)
 
 	return agent
 }
 
 // Run processes a user message and returns a response.
+//
+// When tools are registered, the tool calls the LLM requests are executed and
+// their results sent back until it answers without calling tools (or the
+// maximum number of iterations is reached). The calls and results are kept in
+// the conversation history. The response's Usage is the total across all LLM
+// calls of the run.
 func (a *ConversationalAgent) Run(ctx context.Context, input string) (*core.Response, error) {
-	// Add user message
-	a.messages = append(a.messages, core.UserMessage(input))
+	ctx = sessionContext(ctx, a.sessionID)
+	ctx, usage := startRun(ctx, a.costTracker)
 
-	// Apply memory management before calling LLM
-	if err := a.applyMemoryStrategy(ctx); err != nil {
-		return nil, fmt.Errorf("memory management failed: %w", err)
-	}
-
-	// Call LLM with conversation history
-	response, err := a.llm.Chat(ctx, a.messages)
+	r, err := a.begin(ctx, input)
 	if err != nil {
-		return nil, fmt.Errorf("LLM call failed: %w", err)
+		return a.hooks.finish(ctx, nil, err)
 	}
 
-	// Add assistant response to history
-	a.messages = append(a.messages, core.AssistantMessage(response.Content))
-
-	return response, nil
+	resp, err := a.run(ctx, r, usage, nil)
+	return a.hooks.finish(ctx, resp, err)
 }
 
 // RunStream processes a user message and streams the response in real-time.
 // Emits events as tokens arrive from the LLM.
 //
+// When tools are registered, the LLM turns are not streamed, since the tool
+// calls must be known before they run: tool_start and tool_end events are
+// emitted as the tools run, and the final reply arrives as a single token event.
+//
 // Events emitted:
 // - "token": Each token as it's generated
-// - "complete": When generation finishes successfully
+// - "approval_required": When a tool call waits for approval (see ConvWithApprovalFor)
+// - "tool_start": As each tool call starts
+// - "tool_end": As each tool call finishes, with its result, error and duration
+// - "complete": When generation finishes successfully (with the run's total "usage")
 // - "error": If an error occurs
 func (a *ConversationalAgent) RunStream(ctx context.Context, input string) (<-chan core.StreamEvent, error) {
-	// Check if LLM supports streaming
+	// Check if LLM supports streaming (not needed for the tool loop)
 	streamingLLM, ok := a.llm.(core.StreamingLLM)
-	if !ok {
+	if !ok && len(a.tools.snapshot()) == 0 {
 		return nil, fmt.Errorf("LLM does not support streaming")
 	}
 
-	// Add user message
-	a.messages = append(a.messages, core.UserMessage(input))
+	ctx = sessionContext(ctx, a.sessionID)
+	ctx, usage := startRun(ctx, a.costTracker)
 
-	// Apply memory management before calling LLM
-	if err := a.applyMemoryStrategy(ctx); err != nil {
-		return nil, fmt.Errorf("memory management failed: %w", err)
+	r, err := a.begin(ctx, input)
+	if err != nil {
+		a.hooks.OnError(ctx, err)
+		return nil, err
+	}
+
+	if len(r.tools) > 0 {
+		streamingLLM = nil
+	}
+	return a.stream(ctx, r, usage, streamingLLM), nil
+}
+
+// Resume continues a run from its last checkpoint (see
+// ConvWithCheckpointStore): tool calls that were pending run first, then the
+// run goes on as Run would. The run keeps its ID and session, and the
+// response's Usage includes the calls made before the checkpoint. Tools are
+// the ones registered now, and long-term memories are recalled again.
+func (a *ConversationalAgent) Resume(ctx context.Context, runID string) (*core.Response, error) {
+	ctx, cp, err := resumeContext(ctx, a.checkpoints, runID, checkpointConversational)
+	if err != nil {
+		return nil, err
+	}
+	ctx, usage := startRun(ctx, a.costTracker)
+	usage.restore(cp.Usage)
+
+	r, err := a.restore(ctx, cp)
+	if err != nil {
+		return a.hooks.finish(ctx, nil, err)
+	}
+
+	resp, err := a.run(ctx, r, usage, nil)
+	return a.hooks.finish(ctx, resp, err)
+}
+
+// ResumeStream is the streaming form of Resume; it emits the events of
+// RunStream with tools.
+func (a *ConversationalAgent) ResumeStream(ctx context.Context, runID string) (<-chan core.StreamEvent, error) {
+	ctx, cp, err := resumeContext(ctx, a.checkpoints, runID, checkpointConversational)
+	if err != nil {
+		return nil, err
 	}
+	ctx, usage := startRun(ctx, a.costTracker)
+	usage.restore(cp.Usage)
 
+	r, err := a.restore(ctx, cp)
+	if err != nil {
+		a.hooks.OnError(ctx, err)
+		return nil, err
+	}
+	return a.stream(ctx, r, usage, nil), nil
+}
+
+// stream runs a run in a goroutine, sending its events on the returned
+// channel. The reply is streamed from streamingLLM when it is non-nil;
+// otherwise the tool loop runs.
+func (a *ConversationalAgent) stream(ctx context.Context, r *convRun, usage *runUsage, streamingLLM core.StreamingLLM) <-chan core.StreamEvent {
 	// Create event channel
 	eventChan := make(chan core.StreamEvent, 10)
 
+	send := func(event core.StreamEvent) bool {
+		a.hooks.observe(ctx, event)
+		select {
+		case eventChan <- event:
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}
+
 	// Start streaming in goroutine
 	go func() {
 		defer close(eventChan)
 
-		// Get streaming response
-		chunkChan, err := streamingLLM.ChatStream(ctx, a.messages)
-		if err != nil {
-			select {
-			case eventChan <- core.NewErrorEvent(fmt.Errorf("stream initialization failed: %w", err)):
-			case <-ctx.Done():
+		if streamingLLM == nil {
+			if _, err := a.run(ctx, r, usage, send); err != nil {
+				send(core.NewErrorEvent(err))
 			}
 			return
 		}
+		a.streamReply(ctx, r, usage, streamingLLM, send)
+	}()
 
-		var fullContent string
+	return eventChan
+}
 
-		// Process chunks and emit token events
-		for chunk := range chunkChan {
-			// Check for errors in chunk
-			if chunk.Error != nil {
-				select {
-				case eventChan <- core.NewErrorEvent(chunk.Error):
-				case <-ctx.Done():
-				}
+// streamReply streams the reply of a run without tools, sending its events
+// with send, which reports false once the consumer has gone away.
+func (a *ConversationalAgent) streamReply(ctx context.Context, r *convRun, usage *runUsage, streamingLLM core.StreamingLLM, send func(core.StreamEvent) bool) {
+	call := newLLMCall(r.llmMessages(), nil, 1)
+	if err := a.hooks.BeforeLLMCall(ctx, call); err != nil {
+		send(core.NewErrorEvent(fmt.Errorf("LLM call failed: %w", err)))
+		return
+	}
+
+	// Get streaming response
+	chunkChan, err := streamingLLM.ChatStream(ctx, call.Messages)
+	if err != nil {
+		send(core.NewErrorEvent(fmt.Errorf("stream initialization failed: %w", err)))
+		return
+	}
+
+	var fullContent string
+	var last core.StreamChunk
+
+	// Process chunks and emit token events
+	for chunk := range chunkChan {
+		// Check for errors in chunk
+		if chunk.Error != nil {
+			send(core.NewErrorEvent(chunk.Error))
+			return
+		}
+
+		// Emit token event for each delta
+		if chunk.Delta != "" {
+			tokenEvent := core.NewStreamEventWithData(
+				core.EventTypeToken,
+				chunk.Delta,
+				map[string]interface{}{
+					"index": chunk.Index,
+				},
+			)
+			if !send(tokenEvent) {
 				return
 			}
+		}
+
+		fullContent = chunk.Content
+		last = chunk
+
+		// Check if stream finished
+		if chunk.FinishReason != "" {
+			usage.addChunk(chunk)
+			break
+		}
+	}
+
+	// The hooks see the streamed reply as one response
+	response := &core.Response{Content: fullContent, Usage: last.Usage, Meta: last.Metadata}
+	if err := a.hooks.AfterLLMCall(ctx, call, response); err != nil {
+		send(core.NewErrorEvent(fmt.Errorf("LLM call failed: %w", err)))
+		return
+	}
+	fullContent = response.Content
+
+	// Add assistant response to history
+	r.add(core.AssistantMessage(fullContent))
+	if err := r.session.save(ctx, r.messages, r.added, r.rewrite); err != nil {
+		send(core.NewErrorEvent(err))
+		return
+	}
+
+	// Emit complete event, with the usage of remembering the exchange
+	data := make(map[string]interface{})
+	a.remember(ctx, r, usage, r.input, fullContent, data)
+	for k, v := range usage.eventData() {
+		data[k] = v
+	}
+	send(core.NewStreamEventWithData(core.EventTypeComplete, fullContent, data))
+}
+
+// run is the tool loop shared by Run, RunStream with tools and the resume
+// methods. Each iteration runs the pending tool calls, if any, or else calls
+// the LLM; a checkpoint is saved after each. When emit is non-nil it receives
+// the tool events and, once the LLM answers, the token and complete events.
+func (a *ConversationalAgent) run(ctx context.Context, r *convRun, usage *runUsage, emit func(core.StreamEvent) bool) (*core.Response, error) {
+	for {
+		if len(r.pending) > 0 {
+			var notify func(core.StreamEvent)
+			if emit != nil {
+				notify = func(event core.StreamEvent) { emit(event) }
+			}
+			toolResults, err := a.executeToolCalls(ctx, r, r.pending, notify)
+			if err != nil {
+				return nil, fmt.Errorf("tool execution failed: %w", err)
+			}
 
-			// Emit token event for each delta
-			if chunk.Delta != "" {
+			// Replace the pending call message with the calls and their results
+			r.replaceLast(toolTurnMessages(r.messages[len(r.messages)-1].Content, toolResults)...)
+			r.pending = nil
+			if err := a.checkpoint(ctx, r, usage); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		if r.iteration >= a.maxIter {
+			return nil, fmt.Errorf("max iterations (%d) reached without final answer", a.maxIter)
+		}
+
+		// Tool results may have outgrown the token budget
+		if r.iteration > 0 {
+			if err := a.refitTokenBudget(ctx, r); err != nil {
+				return nil, fmt.Errorf("memory management failed: %w", err)
+			}
+		}
+
+		// Call LLM with conversation history
+		response, err := a.chat(ctx, r, r.iteration+1)
+		if err != nil {
+			return nil, fmt.Errorf("LLM call failed: %w", err)
+		}
+		usage.addResponse(response)
+		r.iteration++
+
+		if len(response.ToolCalls) > 0 {
+			r.add(core.Message{
+				Role:      "assistant",
+				Content:   response.Content,
+				ToolCalls: response.ToolCalls,
+			})
+			r.pending = response.ToolCalls
+			if err := a.checkpoint(ctx, r, usage); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		// Add assistant response to history
+		r.add(core.AssistantMessage(response.Content))
+		if err := r.session.save(ctx, r.messages, r.added, r.rewrite); err != nil {
+			return nil, err
+		}
+
+		meta := make(map[string]interface{}, len(response.Meta)+2)
+		for k, v := range response.Meta {
+			meta[k] = v
+		}
+		meta["iterations"] = r.iteration
+		meta["run_id"] = usage.runID()
+		a.remember(ctx, r, usage, r.input, response.Content, meta)
+		deleteCheckpoint(ctx, a.checkpoints, usage.runID(), meta)
+
+		if emit != nil {
+			if response.Content != "" {
 				tokenEvent := core.NewStreamEventWithData(
 					core.EventTypeToken,
-					chunk.Delta,
+					response.Content,
 					map[string]interface{}{
-						"index": chunk.Index,
+						"index": 0,
 					},
 				)
-				select {
-				case eventChan <- tokenEvent:
-				case <-ctx.Done():
-					return
+				if !emit(tokenEvent) {
+					return nil, ctx.Err()
 				}
 			}
 
-			fullContent = chunk.Content
-
-			// Check if stream finished
-			if chunk.FinishReason != "" {
-				break
+			data := map[string]interface{}{"iterations": r.iteration}
+			for _, key := range []string{"memories_recalled", "memory_error", "checkpoint_error"} {
+				if v, ok := meta[key]; ok {
+					data[key] = v
+				}
 			}
+			for k, v := range usage.eventData() {
+				data[k] = v
+			}
+			emit(core.NewStreamEventWithData(core.EventTypeComplete, response.Content, data))
 		}
 
-		// Add assistant response to history
-		a.messages = append(a.messages, core.AssistantMessage(fullContent))
+		final := *response
+		final.Usage = usage.total
+		final.Meta = meta
+		return &final, nil
+	}
+}
 
-		// Emit complete event
-		completeEvent := core.NewStreamEvent(core.EventTypeComplete, fullContent)
-		select {
-		case eventChan <- completeEvent:
-		case <-ctx.Done():
+// checkpoint saves the state of a run to the checkpoint store, if any. The
+// messages are the history as sent to the LLM; the turn's own messages are
+// the last of them.
+func (a *ConversationalAgent) checkpoint(ctx context.Context, r *convRun, usage *runUsage) error {
+	if a.checkpoints == nil {
+		return nil
+	}
+	return saveCheckpoint(ctx, a.checkpoints, &Checkpoint{
+		RunID:            usage.runID(),
+		SessionID:        core.SessionIDFromContext(ctx),
+		Agent:            checkpointConversational,
+		Input:            r.input,
+		Iteration:        r.iteration,
+		Messages:         r.messages,
+		PendingToolCalls: r.pending,
+		Usage:            usage.total,
+		loaded:           len(r.messages) - len(r.added),
+	})
+}
+
+// chat sends the conversation to the LLM, offering it the registered tools,
+// through the LLM call hooks.
+func (a *ConversationalAgent) chat(ctx context.Context, r *convRun, iteration int) (*core.Response, error) {
+	call := newLLMCall(r.llmMessages(), sortedTools(r.tools), iteration)
+	return a.hooks.callLLM(ctx, call, func(call *LLMCall) (*core.Response, error) {
+		if len(call.Tools) == 0 {
+			return a.llm.Chat(ctx, call.Messages)
 		}
-	}()
 
-	return eventChan, nil
+		if llm, ok := a.llm.(core.ToolCallingLLM); ok {
+			return llm.ChatWithTools(ctx, call.Messages, call.Tools)
+		}
+		return a.chatWithPromptedTools(ctx, call.Messages, call.Tools, len(r.messages))
+	})
+}
+
+// executeToolCalls executes the tool calls requested by the LLM concurrently
+// and returns the results in call order. See toolExecutor.execute.
+func (a *ConversationalAgent) executeToolCalls(ctx context.Context, r *convRun, toolCalls []core.ToolCall, emit func(core.StreamEvent)) ([]core.ToolCall, error) {
+	executor := &toolExecutor{
+		tools:       r.tools,
+		maxParallel: a.maxParallelTools,
+		timeout:     a.toolTimeout,
+		timeouts:    a.toolTimeouts,
+		hooks:       a.hooks,
+		approvals:   &a.approvals,
+	}
+	return executor.execute(ctx, toolCalls, emit)
+}
+
+// begin starts a run: it loads the conversation, adds the user message,
+// recalls long-term memories and applies the memory strategy.
+func (a *ConversationalAgent) begin(ctx context.Context, input string) (*convRun, error) {
+	sess, messages, err := openSession(ctx, a.store, a.history, a.GetSystemPrompt())
+	if err != nil {
+		return nil, err
+	}
+
+	r := &convRun{
+		session:  sess,
+		messages: messages,
+		tools:    a.tools.snapshot(),
+		input:    input,
+	}
+	r.add(core.UserMessage(input))
+
+	if err := a.recall(ctx, r); err != nil {
+		return nil, err
+	}
+
+	// Apply memory management before calling LLM
+	if err := a.applyMemoryStrategy(ctx, r); err != nil {
+		return nil, fmt.Errorf("memory management failed: %w", err)
+	}
+	return r, nil
+}
+
+// restore rebuilds a run from its checkpoint. The memories recalled for the
+// turn are not checkpointed, so they are recalled again.
+func (a *ConversationalAgent) restore(ctx context.Context, cp *Checkpoint) (*convRun, error) {
+	loaded := cp.loaded
+	if loaded < 0 || loaded > len(cp.Messages) {
+		loaded = 0
+	}
+
+	r := &convRun{
+		session:   resumeSession(a.store, a.history, a.GetSystemPrompt(), cp.SessionID, loaded),
+		messages:  cp.Messages,
+		added:     append([]core.Message(nil), cp.Messages[loaded:]...),
+		tools:     a.tools.snapshot(),
+		input:     cp.Input,
+		iteration: cp.Iteration,
+		pending:   cp.PendingToolCalls,
+	}
+	if err := a.recall(ctx, r); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// recall recalls the long-term memories relevant to the run's input.
+func (a *ConversationalAgent) recall(ctx context.Context, r *convRun) error {
+	if a.longTerm == nil {
+		return nil
+	}
+
+	memories, err := a.longTerm.Recall(ctx, r.input)
+	if err != nil {
+		return fmt.Errorf("long-term memory recall failed: %w", err)
+	}
+	r.recalled = memories
+	return nil
+}
+
+// remember stores the facts of a completed exchange in long-term memory and
+// reports the number of recalled memories, and any error, in meta. The usage
+// of the fact extraction call is added to the run's.
+func (a *ConversationalAgent) remember(ctx context.Context, r *convRun, usage *runUsage, input, reply string, meta map[string]interface{}) {
+	if a.longTerm == nil {
+		return
+	}
+
+	meta["memories_recalled"] = len(r.recalled)
+	resp, err := a.longTerm.RememberExchange(ctx, a.llm, input, reply)
+	if resp != nil {
+		usage.addResponse(resp)
+	}
+	if err != nil {
+		meta["memory_error"] = err.Error()
+	}
+}
+
+// recalledMessage returns the system message carrying the recalled memories.
+func (r *convRun) recalledMessage() (core.Message, bool) {
+	if len(r.recalled) == 0 {
+		return core.Message{}, false
+	}
+	return core.SystemMessage(memory.FormatMemories(r.recalled)), true
+}
+
+// llmMessages returns the messages to send to the LLM: the history, with the
+// recalled memories after the system prompt.
+func (r *convRun) llmMessages() []core.Message {
+	recalled, ok := r.recalledMessage()
+	if !ok {
+		return r.messages
+	}
+
+	head := 0
+	if len(r.messages) > 0 && r.messages[0].Role == "system" {
+		head = 1
+	}
+	messages := make([]core.Message, 0, len(r.messages)+1)
+	messages = append(messages, r.messages[:head]...)
+	messages = append(messages, recalled)
+	return append(messages, r.messages[head:]...)
+}
+
+// add appends messages of the turn to the history.
+func (r *convRun) add(messages ...core.Message) {
+	r.messages = append(r.messages, messages...)
+	r.added = append(r.added, messages...)
+}
+
+// replaceLast replaces the last message of the turn.
+func (r *convRun) replaceLast(messages ...core.Message) {
+	r.messages = append(r.messages[:len(r.messages)-1], messages...)
+	r.added = append(r.added[:len(r.added)-1], messages...)
+}
+
+// setHistory replaces the messages before index from of the history with
+// head, as a memory strategy trims or summarizes it.
+func (r *convRun) setHistory(head []core.Message, from int) {
+	if r.rewrite == nil {
+		r.rewrite = &rewrite{}
+	}
+	if from <= r.rewrite.head {
+		r.rewrite.head += len(head) - from
+	} else {
+		r.rewrite.from += from - r.rewrite.head
+		r.rewrite.head = len(head)
+	}
+
+	messages := make([]core.Message, 0, len(head)+len(r.messages)-from)
+	messages = append(messages, head...)
+	r.messages = append(messages, r.messages[from:]...)
 }
 
 // Chat is an alias for Run to match conversational patterns.
@@ -229,46 +857,48 @@ func (a *ConversationalAgent) Chat(ctx context.Context, message string) (*core.R
 	return a.Run(ctx, message)
 }
 
-// Reset clears the conversation history.
+// Reset clears the agent's conversation history. Sessions in a store are
+// not affected; replace one with no messages to delete it.
 func (a *ConversationalAgent) Reset() error {
-	a.messages = make([]core.Message, 0)
-	if a.systemPrompt != "" {
-		a.messages = append(a.messages, core.SystemMessage(a.systemPrompt))
-	}
+	a.history.reset(a.GetSystemPrompt())
 	return nil
 }
 
-// GetMessages returns the conversation history.
+// GetMessages returns a copy of the agent's conversation history. With a
+// store, load the session from the store instead.
 func (a *ConversationalAgent) GetMessages() []core.Message {
-	return a.messages
+	return a.history.get()
 }
 
 // GetMessageCount returns the number of messages in history.
 func (a *ConversationalAgent) GetMessageCount() int {
-	return len(a.messages)
+	return len(a.history.get())
 }
 
-// AddTool is required by the Agent interface but not implemented.
-// ConversationalAgent focuses on conversation, not tool execution.
+// AddTool registers a tool that the LLM can call during the conversation.
+// Runs already in progress keep the tools they started with.
 func (a *ConversationalAgent) AddTool(tool core.Tool) error {
-	return fmt.Errorf("ConversationalAgent does not support tools")
+	return a.tools.add(tool)
 }
 
 // applyMemoryStrategy manages conversation history based on strategy.
-func (a *ConversationalAgent) applyMemoryStrategy(ctx context.Context) error {
+func (a *ConversationalAgent) applyMemoryStrategy(ctx context.Context, r *convRun) error {
 	switch a.memoryStrategy {
 	case MemoryStrategyAll:
 		// Keep everything, no management needed
 		return nil
 
 	case MemoryStrategyWindow:
-		return a.applyWindowStrategy()
+		return a.applyWindowStrategy(r)
 
 	case MemoryStrategySummarize:
-		return a.applySummarizeStrategy(ctx)
+		return a.applySummarizeStrategy(ctx, r)
 
 	case MemoryStrategySelective:
-		return a.applySelectiveStrategy(ctx)
+		return a.applySelectiveStrategy(ctx, r)
+
+	case MemoryStrategyTokenBudget:
+		return a.applyTokenBudgetStrategy(ctx, r)
 
 	default:
 		return fmt.Errorf("unknown memory strategy: %s", a.memoryStrategy)
@@ -276,41 +906,43 @@ func (a *ConversationalAgent) applyMemoryStrategy(ctx context.Context) error {
 }
 
 // applyWindowStrategy keeps only the last N messages.
-func (a *ConversationalAgent) applyWindowStrategy() error {
-	if len(a.messages) <= a.maxMessages {
+func (a *ConversationalAgent) applyWindowStrategy(r *convRun) error {
+	if len(r.messages) <= a.maxMessages {
 		return nil
 	}
 
 	// Always keep system prompt (first message)
-	systemMsg := a.messages[0]
+	systemMsg := r.messages[0]
 
 	// Keep last N messages
-	startIdx := len(a.messages) - a.maxMessages + 1 // +1 for system message
+	startIdx := len(r.messages) - a.maxMessages + 1 // +1 for system message
 	if startIdx < 1 {
 		startIdx = 1
 	}
+	startIdx = groupBoundary(r.messages, startIdx)
 
-	a.messages = append([]core.Message{systemMsg}, a.messages[startIdx:]...)
+	r.setHistory([]core.Message{systemMsg}, startIdx)
 	return nil
 }
 
 // applySummarizeStrategy summarizes old messages and keeps recent ones.
-func (a *ConversationalAgent) applySummarizeStrategy(ctx context.Context) error {
+func (a *ConversationalAgent) applySummarizeStrategy(ctx context.Context, r *convRun) error {
 	// If we're under the limit, no need to summarize
-	if len(a.messages) <= a.maxMessages {
+	if len(r.messages) <= a.maxMessages {
 		return nil
 	}
 
 	// Keep system message and recent messages
-	systemMsg := a.messages[0]
+	systemMsg := r.messages[0]
 
 	// Messages to summarize (middle portion)
-	summarizeEndIdx := len(a.messages) - (a.maxMessages / 2)
+	summarizeEndIdx := len(r.messages) - (a.maxMessages / 2)
 	if summarizeEndIdx <= 1 {
 		return nil // Nothing to summarize
 	}
+	summarizeEndIdx = groupBoundary(r.messages, summarizeEndIdx)
 
-	messagesToSummarize := a.messages[1:summarizeEndIdx]
+	messagesToSummarize := r.messages[1:summarizeEndIdx]
 
 	// Use summarization LLM or main LLM
 	summarizerLLM := a.summarizationLM
@@ -329,7 +961,7 @@ func (a *ConversationalAgent) applySummarizeStrategy(ctx context.Context) error
 	summary, err := summarizerLLM.Complete(ctx, summaryPrompt)
 	if err != nil {
 		// If summarization fails, fall back to window strategy
-		return a.applyWindowStrategy()
+		return a.applyWindowStrategy(r)
 	}
 
 	// Rebuild messages: system + summary + recent messages
@@ -338,31 +970,28 @@ func (a *ConversationalAgent) applySummarizeStrategy(ctx context.Context) error
 		Content: fmt.Sprintf("Previous conversation summary: %s", summary),
 	}
 
-	a.messages = append(
-		[]core.Message{systemMsg, summaryMsg},
-		a.messages[summarizeEndIdx:]...,
-	)
+	r.setHistory([]core.Message{systemMsg, summaryMsg}, summarizeEndIdx)
 
 	return nil
 }
 
 // applySelectiveStrategy keeps important messages and summarizes less important ones.
-func (a *ConversationalAgent) applySelectiveStrategy(ctx context.Context) error {
+func (a *ConversationalAgent) applySelectiveStrategy(ctx context.Context, r *convRun) error {
 	// If we're under the limit, no need to manage
-	if len(a.messages) <= a.maxMessages {
+	if len(r.messages) <= a.maxMessages {
 		return nil
 	}
 
 	// Keep system messages, recent messages, and messages with metadata
-	systemMsg := a.messages[0]
+	systemMsg := r.messages[0]
 
 	// Identify important messages
 	importantMsgs := make([]core.Message, 0)
 	regularMsgs := make([]core.Message, 0)
 
-	recentStartIdx := len(a.messages) - (a.maxMessages / 2)
+	recentStartIdx := groupBoundary(r.messages, len(r.messages)-(a.maxMessages/2))
 
-	for i, msg := range a.messages[1:] { // Skip system message
+	for i, msg := range r.messages[1:] { // Skip system message
 		actualIdx := i + 1
 
 		// Keep recent messages
@@ -371,8 +1000,8 @@ func (a *ConversationalAgent) applySelectiveStrategy(ctx context.Context) error
 			continue
 		}
 
-		// Keep messages with metadata (tool calls, etc.)
-		if len(msg.Meta) > 0 || msg.Role == "tool" {
+		// Keep tool calls with their results, and messages with metadata
+		if len(msg.ToolCalls) > 0 || len(msg.Meta) > 0 || msg.Role == "tool" {
 			importantMsgs = append(importantMsgs, msg)
 		} else {
 			regularMsgs = append(regularMsgs, msg)
@@ -387,7 +1016,8 @@ func (a *ConversationalAgent) applySelectiveStrategy(ctx context.Context) error
 			summarizerLLM = a.llm
 		}
 
-		conversationText := a.formatMessagesForSummarization(regularMsgs[:len(regularMsgs)/2])
+		split := groupBoundary(regularMsgs, len(regularMsgs)/2)
+		conversationText := a.formatMessagesForSummarization(regularMsgs[:split])
 		summaryPrompt := fmt.Sprintf(
 			"Summarize the following conversation concisely:\n\n%s\n\nSummary:",
 			conversationText,
@@ -395,7 +1025,7 @@ func (a *ConversationalAgent) applySelectiveStrategy(ctx context.Context) error
 
 		summary, err := summarizerLLM.Complete(ctx, summaryPrompt)
 		if err != nil {
-			return a.applyWindowStrategy()
+			return a.applyWindowStrategy(r)
 		}
 
 		summaryMsg := core.Message{
@@ -403,36 +1033,196 @@ func (a *ConversationalAgent) applySelectiveStrategy(ctx context.Context) error
 			Content: fmt.Sprintf("Conversation summary: %s", summary),
 		}
 
-		a.messages = append(
-			[]core.Message{systemMsg, summaryMsg},
-			append(importantMsgs, regularMsgs[len(regularMsgs)/2:]...)...,
-		)
+		// The recent messages are kept in place; what comes before them
+		// becomes the head
+		recentStart := recentStartIdx
+		if recentStart < 1 {
+			recentStart = 1
+		}
+		older := len(regularMsgs) - (len(r.messages) - recentStart)
+		head := append([]core.Message{systemMsg, summaryMsg}, importantMsgs...)
+		if split <= older {
+			r.setHistory(append(head, regularMsgs[split:older]...), recentStart)
+		} else {
+			r.setHistory(head, recentStart+split-older)
+		}
+	}
+
+	return nil
+}
+
+// refitTokenBudget re-applies the token budget strategy between the LLM calls
+// of a run. The other strategies only run before the first call, since they
+// could drop the messages of the current turn.
+func (a *ConversationalAgent) refitTokenBudget(ctx context.Context, r *convRun) error {
+	if a.memoryStrategy != MemoryStrategyTokenBudget {
+		return nil
+	}
+	return a.applyTokenBudgetStrategy(ctx, r)
+}
+
+// applyTokenBudgetStrategy drops the oldest turns until the history fits the
+// token budget. A turn is a user message and the messages that follow it, so
+// tool calls stay with their results; the current turn is always kept.
+func (a *ConversationalAgent) applyTokenBudgetStrategy(ctx context.Context, r *convRun) error {
+	tokenizer := a.tokenCounter()
+	budget, err := a.tokenBudget(tokenizer, r)
+	if err != nil {
+		return err
+	}
+	if core.CountMessageTokens(tokenizer, r.messages) <= budget {
+		return nil
+	}
+
+	// Always keep the system prompt
+	head := 0
+	if len(r.messages) > 0 && r.messages[0].Role == "system" {
+		head = 1
+	}
+
+	// Leave room for the summary of the dropped turns
+	summaryTokens := 0
+	if a.summarizeOverflow {
+		summaryTokens = budget / 8
+		if summaryTokens > maxSummaryTokens {
+			summaryTokens = maxSummaryTokens
+		}
+	}
+
+	// Keep the newest turns that fit
+	used := core.CountMessageTokens(tokenizer, r.messages[:head])
+	start := len(r.messages)
+	for start > head {
+		turnStart := start - 1
+		for turnStart > head && r.messages[turnStart].Role != "user" {
+			turnStart--
+		}
+
+		cost := 0
+		for _, msg := range r.messages[turnStart:start] {
+			cost += core.MessageTokens(tokenizer, msg)
+		}
+		if start < len(r.messages) && used+cost > budget-summaryTokens {
+			break
+		}
+		used += cost
+		start = turnStart
+	}
+	if start == head {
+		return nil // Only the current turn is left
 	}
 
+	messages := append([]core.Message{}, r.messages[:head]...)
+	if a.summarizeOverflow {
+		if summary, ok := a.summarizeTurns(ctx, r.messages[head:start], summaryTokens); ok &&
+			used+core.MessageTokens(tokenizer, summary) <= budget {
+			messages = append(messages, summary)
+		}
+	}
+	r.setHistory(messages, start)
 	return nil
 }
 
+// summarizeTurns summarizes dropped turns into a system message of about
+// maxTokens tokens. It reports false if summarization fails.
+func (a *ConversationalAgent) summarizeTurns(ctx context.Context, messages []core.Message, maxTokens int) (core.Message, bool) {
+	summarizerLLM := a.summarizationLM
+	if summarizerLLM == nil {
+		summarizerLLM = a.llm
+	}
+
+	summaryPrompt := fmt.Sprintf(
+		"Summarize the following conversation concisely, preserving key facts and context:\n\n%s\n\nSummary:",
+		a.formatMessagesForSummarization(messages),
+	)
+	summary, err := summarizerLLM.Complete(ctx, summaryPrompt, core.WithMaxTokens(maxTokens))
+	if err != nil {
+		return core.Message{}, false
+	}
+
+	return core.Message{
+		Role:    "system",
+		Content: fmt.Sprintf("Previous conversation summary: %s", summary),
+	}, true
+}
+
+// tokenBudget returns the number of tokens the history may use: the context
+// window minus the response reserve, the tool definitions and the recalled
+// long-term memories.
+func (a *ConversationalAgent) tokenBudget(tokenizer core.Tokenizer, r *convRun) (int, error) {
+	window := a.contextWindow
+	if window <= 0 {
+		window = DefaultContextWindow
+		if named, ok := a.llm.(interface{ Model() string }); ok {
+			if size, ok := core.DefaultContextWindows().Lookup(named.Model()); ok {
+				window = size
+			}
+		}
+	}
+
+	tools := core.ToolTokens(tokenizer, sortedTools(r.tools))
+	recalledTokens := 0
+	if recalled, ok := r.recalledMessage(); ok {
+		recalledTokens = core.MessageTokens(tokenizer, recalled)
+	}
+	budget := window - a.responseReserve - tools - recalledTokens
+	if budget <= 0 {
+		return 0, &core.ErrInvalidArgument{
+			Argument: "context window",
+			Reason: fmt.Sprintf("%d tokens leave no room for history after the response reserve (%d), tools (%d) and recalled memories (%d)",
+				window, a.responseReserve, tools, recalledTokens),
+		}
+	}
+	return budget, nil
+}
+
+// tokenCounter returns the tokenizer of the token budget strategy.
+func (a *ConversationalAgent) tokenCounter() core.Tokenizer {
+	if a.tokenizer == nil {
+		return core.ApproxTokenizer{}
+	}
+	return a.tokenizer
+}
+
+// groupBoundary returns the first index at or after i that does not hold a
+// tool result. Cutting the history there never separates tool results from
+// the assistant message that requested them.
+func groupBoundary(messages []core.Message, i int) int {
+	for i < len(messages) && messages[i].Role == "tool" {
+		i++
+	}
+	return i
+}
+
 // formatMessagesForSummarization formats messages into text for summarization.
 func (a *ConversationalAgent) formatMessagesForSummarization(messages []core.Message) string {
 	var text string
 	for _, msg := range messages {
 		text += fmt.Sprintf("%s: %s\n", msg.Role, msg.Content)
+		for _, tc := range msg.ToolCalls {
+			args, _ := json.Marshal(tc.Args)
+			text += fmt.Sprintf("%s: [called %s with %s]\n", msg.Role, tc.Name, args)
+		}
 	}
 	return text
 }
 
 // SetSystemPrompt updates the system prompt and resets conversation.
 func (a *ConversationalAgent) SetSystemPrompt(prompt string) {
+	a.mu.Lock()
 	a.systemPrompt = prompt
+	a.mu.Unlock()
 	a.Reset()
 }
 
 // GetSystemPrompt returns the current system prompt.
 func (a *ConversationalAgent) GetSystemPrompt() string {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
 	return a.systemPrompt
 }
 
 // ExportConversation exports the conversation history as a formatted string.
 func (a *ConversationalAgent) ExportConversation() string {
-	return a.formatMessagesForSummarization(a.messages)
+	return a.formatMessagesForSummarization(a.history.get())
 }
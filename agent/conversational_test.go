@@ -2,10 +2,13 @@ package agent
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"strings"
 	"testing"
 
 	"github.com/yashrahurikar23/goagents/core"
+	"github.com/yashrahurikar23/goagents/memory"
 	"github.com/yashrahurikar23/goagents/tests/mocks"
 )
 
@@ -31,8 +34,8 @@ func TestConversationalAgent_NewAgent(t *testing.T) {
 	}
 
 	// Should have system message
-	if len(agent.messages) != 1 {
-		t.Errorf("len(messages) = %d, want 1 (system message)", len(agent.messages))
+	if len(agent.history.messages) != 1 {
+		t.Errorf("len(messages) = %d, want 1 (system message)", len(agent.history.messages))
 	}
 }
 
@@ -170,7 +173,7 @@ func TestConversationalAgent_Reset(t *testing.T) {
 	agent.Run(ctx, "Test 1")
 	agent.Run(ctx, "Test 2")
 
-	if len(agent.messages) <= 1 {
+	if len(agent.history.messages) <= 1 {
 		t.Fatal("agent should have multiple messages")
 	}
 
@@ -181,11 +184,11 @@ func TestConversationalAgent_Reset(t *testing.T) {
 	}
 
 	// Should only have system message
-	if len(agent.messages) != 1 {
-		t.Errorf("len(messages) after reset = %d, want 1", len(agent.messages))
+	if len(agent.history.messages) != 1 {
+		t.Errorf("len(messages) after reset = %d, want 1", len(agent.history.messages))
 	}
 
-	if agent.messages[0].Role != "system" {
+	if agent.history.messages[0].Role != "system" {
 		t.Error("should have system message after reset")
 	}
 }
@@ -293,11 +296,11 @@ func TestConversationalAgent_SetSystemPrompt(t *testing.T) {
 	}
 
 	// Should reset conversation
-	if len(agent.messages) != 1 {
-		t.Errorf("len(messages) = %d, want 1 (should reset)", len(agent.messages))
+	if len(agent.history.messages) != 1 {
+		t.Errorf("len(messages) = %d, want 1 (should reset)", len(agent.history.messages))
 	}
 
-	if agent.messages[0].Content != newPrompt {
+	if agent.history.messages[0].Content != newPrompt {
 		t.Error("system message should have new prompt")
 	}
 }
@@ -324,21 +327,22 @@ func TestConversationalAgent_ExportConversation(t *testing.T) {
 	}
 }
 
-// TestConversationalAgent_AddTool tests that tools are not supported.
+// TestConversationalAgent_AddTool tests tool registration.
 func TestConversationalAgent_AddTool(t *testing.T) {
 	llm := mocks.NewMockLLM()
 	agent := NewConversationalAgent(llm)
 
 	tool := mocks.NewMockTool("test", "test tool")
-	err := agent.AddTool(tool)
+	if err := agent.AddTool(tool); err != nil {
+		t.Fatalf("AddTool() error = %v", err)
+	}
 
-	if err == nil {
-		t.Fatal("AddTool() expected error, got nil")
+	if err := agent.AddTool(tool); err == nil {
+		t.Error("AddTool() expected error for duplicate tool, got nil")
 	}
 
-	expectedMsg := "ConversationalAgent does not support tools"
-	if err.Error() != expectedMsg {
-		t.Errorf("error message = %q, want %q", err.Error(), expectedMsg)
+	if err := agent.AddTool(nil); err == nil {
+		t.Error("AddTool() expected error for nil tool, got nil")
 	}
 }
 
@@ -394,31 +398,726 @@ func TestConversationalAgent_ApplyWindowStrategy(t *testing.T) {
 
 	// Manually add many messages
 	for i := 0; i < 10; i++ {
-		agent.messages = append(agent.messages,
+		agent.history.messages = append(agent.history.messages,
 			core.UserMessage("User message"),
 			core.AssistantMessage("Assistant message"),
 		)
 	}
 
-	initialCount := len(agent.messages)
+	initialCount := len(agent.history.messages)
 	if initialCount <= 5 {
 		t.Fatalf("test setup failed: need more than 5 messages, got %d", initialCount)
 	}
 
 	// Apply windowing
-	err := agent.applyWindowStrategy()
+	r := &convRun{messages: agent.GetMessages()}
+	err := agent.applyWindowStrategy(r)
 	if err != nil {
 		t.Fatalf("applyWindowStrategy() error = %v", err)
 	}
+	agent.history.messages = r.messages
 
 	// Should be reduced
-	afterCount := len(agent.messages)
+	afterCount := len(agent.history.messages)
 	if afterCount > 6 { // system + 5
 		t.Errorf("after windowing: count = %d, want <= 6", afterCount)
 	}
 
 	// System message should be preserved
-	if agent.messages[0].Role != "system" {
+	if agent.history.messages[0].Role != "system" {
 		t.Error("system message should be first after windowing")
 	}
 }
+
+// assertToolPairs checks that every tool result directly follows, possibly
+// after other results, the assistant message that requested it.
+func assertToolPairs(t *testing.T, messages []core.Message) {
+	t.Helper()
+
+	pending := map[string]bool{}
+	for i, msg := range messages {
+		switch {
+		case msg.Role == "tool":
+			if !pending[msg.ToolCallID] {
+				t.Errorf("messages[%d]: tool result %s has no preceding tool call", i, msg.ToolCallID)
+			}
+			delete(pending, msg.ToolCallID)
+		case len(pending) > 0:
+			t.Errorf("messages[%d]: tool calls %v have no results", i, pending)
+			pending = map[string]bool{}
+		}
+		for _, tc := range msg.ToolCalls {
+			pending[tc.ID] = true
+		}
+	}
+	if len(pending) > 0 {
+		t.Errorf("tool calls %v have no results", pending)
+	}
+}
+
+// applyStrategy applies the memory strategy to the agent's history, as a
+// run does before calling the LLM, and keeps the result.
+func applyStrategy(agent *ConversationalAgent) error {
+	r := &convRun{messages: agent.GetMessages(), tools: agent.tools.snapshot()}
+	if err := agent.applyMemoryStrategy(context.Background(), r); err != nil {
+		return err
+	}
+	agent.history.messages = r.messages
+	return nil
+}
+
+// toolTurnHistory builds a conversation of n turns, each with a tool call.
+func toolTurnHistory(agent *ConversationalAgent, n int) {
+	for i := 0; i < n; i++ {
+		id := fmt.Sprintf("call_%d", i)
+		agent.history.messages = append(agent.history.messages, core.UserMessage("What is 2+2?"))
+		agent.history.messages = append(agent.history.messages, toolTurnMessages("", []core.ToolCall{
+			{ID: id + "a", Name: "calculator", Args: map[string]interface{}{"a": 2}, Result: "4"},
+			{ID: id + "b", Name: "calculator", Args: map[string]interface{}{"b": 2}, Result: "4"},
+		})...)
+		agent.history.messages = append(agent.history.messages, core.AssistantMessage("4"))
+	}
+	agent.history.messages = append(agent.history.messages, core.UserMessage("Thanks"))
+}
+
+// TestConversationalAgent_Run_WithTools tests the function-calling loop.
+func TestConversationalAgent_Run_WithTools(t *testing.T) {
+	llm := mocks.NewMockLLM()
+	llm.WithSequentialChatResponses([]*core.Response{
+		{
+			ToolCalls: []core.ToolCall{{ID: "call_1", Name: "calculator", Args: map[string]interface{}{"operation": "multiply", "a": "25", "b": 4}}},
+			Usage:     core.NewUsage(10, 5),
+		},
+		{Content: "25 * 4 = 100", Usage: core.NewUsage(20, 5)},
+	}, nil)
+
+	calc := mocks.NewMockTool("calculator", "Performs arithmetic").
+		WithSchema(calculatorSchema()).
+		WithExecuteResult(100)
+
+	agent := NewConversationalAgent(llm)
+	if err := agent.AddTool(calc); err != nil {
+		t.Fatalf("AddTool() error = %v", err)
+	}
+
+	response, err := agent.Run(context.Background(), "What is 25 * 4?")
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	if response.Content != "25 * 4 = 100" {
+		t.Errorf("response.Content = %q", response.Content)
+	}
+	if response.Usage == nil || response.Usage.TotalTokens != 40 {
+		t.Errorf("response.Usage = %+v, want 40 total tokens", response.Usage)
+	}
+	if response.Meta["iterations"] != 2 {
+		t.Errorf("iterations = %v, want 2", response.Meta["iterations"])
+	}
+
+	calls := calc.GetCalls()
+	if len(calls) != 1 || calls[0].Args["a"] != 25.0 {
+		t.Fatalf("tool calls = %+v, want one call with coerced args", calls)
+	}
+
+	chatCalls := llm.GetChatCalls()
+	if len(chatCalls) != 2 || len(chatCalls[0].Tools) != 1 {
+		t.Fatalf("chat calls = %d, want 2 with the tool offered", len(chatCalls))
+	}
+
+	// system, user, assistant tool call, tool result, assistant answer
+	messages := agent.GetMessages()
+	if len(messages) != 5 {
+		t.Fatalf("len(messages) = %d, want 5", len(messages))
+	}
+	if messages[3].Role != "tool" || messages[3].Content != "100" || messages[3].ToolCallID != "call_1" {
+		t.Errorf("tool result message = %+v", messages[3])
+	}
+	assertToolPairs(t, messages)
+}
+
+// TestConversationalAgent_Run_MaxIterations tests that a tool loop ends.
+func TestConversationalAgent_Run_MaxIterations(t *testing.T) {
+	llm := mocks.NewMockLLM()
+	llm.WithChatResponse("", []core.ToolCall{{ID: "call_1", Name: "lookup"}})
+
+	agent := NewConversationalAgent(llm, ConvWithMaxIterations(3))
+	agent.AddTool(mocks.NewMockTool("lookup", "Looks things up"))
+
+	if _, err := agent.Run(context.Background(), "Look it up"); err == nil {
+		t.Fatal("Run() expected max iterations error, got nil")
+	}
+	if llm.ChatCallCount() != 3 {
+		t.Errorf("ChatCallCount() = %d, want 3", llm.ChatCallCount())
+	}
+}
+
+// plainLLM hides the native tool calling of the wrapped LLM.
+type plainLLM struct {
+	core.LLM
+}
+
+// TestConversationalAgent_Run_PromptedTools tests tool calling with an LLM
+// without native tool calling.
+func TestConversationalAgent_Run_PromptedTools(t *testing.T) {
+	mock := mocks.NewMockLLM()
+	mock.WithSequentialChatResponses([]*core.Response{
+		{Content: "```json\n{\"tool_calls\": [{\"name\": \"lookup\", \"arguments\": {\"key\": \"x\"}}]}\n```"},
+		{Content: "The value is 42."},
+	}, nil)
+
+	lookup := mocks.NewMockTool("lookup", "Looks up a key").WithExecuteResult("42")
+
+	agent := NewConversationalAgent(plainLLM{mock})
+	agent.AddTool(lookup)
+
+	response, err := agent.Run(context.Background(), "What is x?")
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if response.Content != "The value is 42." {
+		t.Errorf("response.Content = %q", response.Content)
+	}
+
+	if calls := lookup.GetCalls(); len(calls) != 1 || calls[0].Args["key"] != "x" {
+		t.Fatalf("tool calls = %+v", calls)
+	}
+
+	chatCalls := mock.GetChatCalls()
+	if len(chatCalls) != 2 {
+		t.Fatalf("chat calls = %d, want 2", len(chatCalls))
+	}
+	first := chatCalls[0].Messages
+	if !strings.Contains(first[0].Content, "lookup: Looks up a key") || !strings.Contains(first[0].Content, "tool_calls") {
+		t.Errorf("system prompt does not describe the tools: %q", first[0].Content)
+	}
+
+	// The tool turn is sent back as text
+	second := chatCalls[1].Messages
+	for _, msg := range second {
+		if msg.Role == "tool" || len(msg.ToolCalls) > 0 {
+			t.Errorf("message %+v should have been rewritten as text", msg)
+		}
+	}
+	if last := second[len(second)-1]; last.Role != "user" || !strings.Contains(last.Content, "42") {
+		t.Errorf("last message = %+v, want the tool result", last)
+	}
+
+	assertToolPairs(t, agent.GetMessages())
+}
+
+// TestConversationalAgent_RunStream_WithTools tests tool events while streaming.
+func TestConversationalAgent_RunStream_WithTools(t *testing.T) {
+	llm := mocks.NewMockLLM()
+	llm.WithSequentialChatResponses([]*core.Response{
+		{ToolCalls: []core.ToolCall{{ID: "call_1", Name: "lookup", Args: map[string]interface{}{"key": "x"}}}},
+		{Content: "It is 42."},
+	}, nil)
+
+	agent := NewConversationalAgent(llm)
+	agent.AddTool(mocks.NewMockTool("lookup", "Looks up a key").WithExecuteResult("42"))
+
+	stream, err := agent.RunStream(context.Background(), "What is x?")
+	if err != nil {
+		t.Fatalf("RunStream() error = %v", err)
+	}
+
+	var types []string
+	var final string
+	for event := range stream {
+		if event.Type == core.EventTypeError {
+			t.Fatalf("error event: %v", event.Error)
+		}
+		types = append(types, event.Type)
+		if event.Type == core.EventTypeComplete {
+			final = event.Content
+		}
+	}
+
+	want := []string{core.EventTypeToolStart, core.EventTypeToolEnd, core.EventTypeToken, core.EventTypeComplete}
+	if strings.Join(types, ",") != strings.Join(want, ",") {
+		t.Errorf("events = %v, want %v", types, want)
+	}
+	if final != "It is 42." {
+		t.Errorf("final = %q", final)
+	}
+	assertToolPairs(t, agent.GetMessages())
+}
+
+// TestConversationalAgent_MemoryStrategies_KeepToolPairs tests that trimming
+// never separates tool calls from their results.
+func TestConversationalAgent_MemoryStrategies_KeepToolPairs(t *testing.T) {
+	strategies := []MemoryStrategy{MemoryStrategyWindow, MemoryStrategySummarize, MemoryStrategySelective}
+
+	for _, strategy := range strategies {
+		for max := 3; max <= 12; max++ {
+			t.Run(fmt.Sprintf("%s/%d", strategy, max), func(t *testing.T) {
+				llm := mocks.NewMockLLM()
+				llm.WithCompleteResponse("Summary")
+
+				agent := NewConversationalAgent(llm,
+					ConvWithMemoryStrategy(strategy),
+					ConvWithMaxMessages(max),
+				)
+				toolTurnHistory(agent, 4)
+
+				if err := applyStrategy(agent); err != nil {
+					t.Fatalf("applyMemoryStrategy() error = %v", err)
+				}
+
+				messages := agent.GetMessages()
+				if messages[0].Role != "system" {
+					t.Error("system message should be preserved")
+				}
+				if last := messages[len(messages)-1]; last.Content != "Thanks" {
+					t.Errorf("last message = %+v, want the latest user message", last)
+				}
+				assertToolPairs(t, messages)
+			})
+		}
+	}
+}
+
+// namedLLM reports a model name, like the provider clients.
+type namedLLM struct {
+	*mocks.MockLLM
+	model string
+}
+
+func (l namedLLM) Model() string {
+	return l.model
+}
+
+// charTokenizer counts one token per character, which keeps budgets readable.
+var charTokenizer = core.ApproxTokenizer{CharsPerToken: 1}
+
+// TestConversationalAgent_TokenBudgetStrategy tests that the oldest turns
+// are dropped to fit the context window.
+func TestConversationalAgent_TokenBudgetStrategy(t *testing.T) {
+	llm := mocks.NewMockLLM()
+	llm.WithChatResponse("ok", nil)
+
+	agent := NewConversationalAgent(llm,
+		ConvWithSystemPrompt("Be brief."),
+		ConvWithMemoryStrategy(MemoryStrategyTokenBudget),
+		ConvWithTokenizer(charTokenizer),
+		ConvWithContextWindow(400),
+		ConvWithResponseReserve(100),
+	)
+
+	// A pasted log overflows the budget on its own
+	if _, err := agent.Run(context.Background(), "Here is my log: "+strings.Repeat("x", 250)); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		if _, err := agent.Run(context.Background(), fmt.Sprintf("Question %d", i)); err != nil {
+			t.Fatalf("Run() error = %v", err)
+		}
+	}
+
+	sent := llm.GetChatCalls()[1].Messages
+	if len(sent) != 2 || sent[1].Content != "Question 0" {
+		t.Fatalf("second call messages = %+v, want the log turn dropped", sent)
+	}
+
+	for i, call := range llm.GetChatCalls() {
+		if i > 0 && core.CountMessageTokens(charTokenizer, call.Messages) > 300 {
+			t.Errorf("call %d: %d tokens, want <= 300", i, core.CountMessageTokens(charTokenizer, call.Messages))
+		}
+	}
+
+	// Short turns all fit
+	if agent.GetMessageCount() != 7 {
+		t.Errorf("GetMessageCount() = %d, want system and 3 turns", agent.GetMessageCount())
+	}
+	if agent.GetMessages()[0].Content != "Be brief." {
+		t.Error("system message should be preserved")
+	}
+}
+
+// TestConversationalAgent_TokenBudgetStrategy_KeepsToolPairs tests that turns
+// are dropped whole, with their tool calls and results.
+func TestConversationalAgent_TokenBudgetStrategy_KeepsToolPairs(t *testing.T) {
+	for window := 250; window <= 700; window += 50 {
+		agent := NewConversationalAgent(mocks.NewMockLLM(),
+			ConvWithMemoryStrategy(MemoryStrategyTokenBudget),
+			ConvWithTokenizer(charTokenizer),
+			ConvWithContextWindow(window),
+			ConvWithResponseReserve(0),
+		)
+		toolTurnHistory(agent, 4)
+
+		if err := applyStrategy(agent); err != nil {
+			t.Fatalf("window %d: applyMemoryStrategy() error = %v", window, err)
+		}
+
+		messages := agent.GetMessages()
+		if messages[1].Role != "user" {
+			t.Errorf("window %d: history starts with %+v, want a user message", window, messages[1])
+		}
+		if got := core.CountMessageTokens(charTokenizer, messages); got > window {
+			t.Errorf("window %d: %d tokens kept", window, got)
+		}
+		assertToolPairs(t, messages)
+	}
+}
+
+// TestConversationalAgent_TokenBudgetStrategy_Summarize tests that dropped
+// turns are replaced by a summary.
+func TestConversationalAgent_TokenBudgetStrategy_Summarize(t *testing.T) {
+	llm := mocks.NewMockLLM()
+	llm.WithCompleteResponse("The user asked about 2+2.")
+
+	agent := NewConversationalAgent(llm,
+		ConvWithMemoryStrategy(MemoryStrategyTokenBudget),
+		ConvWithTokenizer(charTokenizer),
+		ConvWithContextWindow(600),
+		ConvWithResponseReserve(200),
+		ConvWithSummarizeOverflow(true),
+	)
+	toolTurnHistory(agent, 4)
+
+	if err := applyStrategy(agent); err != nil {
+		t.Fatalf("applyMemoryStrategy() error = %v", err)
+	}
+
+	messages := agent.GetMessages()
+	if messages[1].Role != "system" || !strings.Contains(messages[1].Content, "The user asked about 2+2.") {
+		t.Fatalf("messages[1] = %+v, want the summary", messages[1])
+	}
+	if got := core.CountMessageTokens(charTokenizer, messages); got > 400 {
+		t.Errorf("%d tokens kept, want <= 400", got)
+	}
+	assertToolPairs(t, messages)
+
+	calls := llm.GetCompleteCalls()
+	if len(calls) != 1 || !strings.Contains(calls[0].Prompt, "[called calculator") {
+		t.Fatalf("summarization calls = %+v", calls)
+	}
+	if maxTokens := calls[0].Options.MaxTokens; maxTokens == nil || *maxTokens != 50 {
+		t.Errorf("summary MaxTokens = %v, want 50", maxTokens)
+	}
+}
+
+// TestConversationalAgent_TokenBudgetStrategy_ToolResults tests that the
+// budget is re-applied when tool results grow the history during a run.
+func TestConversationalAgent_TokenBudgetStrategy_ToolResults(t *testing.T) {
+	llm := mocks.NewMockLLM()
+	llm.WithSequentialChatResponses([]*core.Response{
+		{Content: "Hi!"},
+		{ToolCalls: []core.ToolCall{{ID: "call_1", Name: "fetch_log"}}},
+		{Content: "The log is full of x."},
+	}, nil)
+
+	agent := NewConversationalAgent(llm,
+		ConvWithMemoryStrategy(MemoryStrategyTokenBudget),
+		ConvWithTokenizer(charTokenizer),
+		ConvWithContextWindow(500),
+		ConvWithResponseReserve(0),
+	)
+	agent.AddTool(mocks.NewMockTool("fetch_log", "Fetches the log").WithExecuteResult(strings.Repeat("x", 300)))
+
+	agent.Run(context.Background(), "Hello")
+	if _, err := agent.Run(context.Background(), "Show me the log"); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	calls := llm.GetChatCalls()
+	if got := calls[1].Messages[1].Content; got != "Hello" {
+		t.Errorf("before the tool call: messages[1] = %q, want the first turn", got)
+	}
+	if got := calls[2].Messages[1].Content; got != "Show me the log" {
+		t.Errorf("after the tool call: messages[1] = %q, want the first turn dropped", got)
+	}
+	assertToolPairs(t, agent.GetMessages())
+}
+
+// TestConversationalAgent_TokenBudget tests the context window lookup.
+func TestConversationalAgent_TokenBudget(t *testing.T) {
+	tool := mocks.NewMockTool("lookup", "Looks up a key")
+	toolTokens := core.ToolTokens(charTokenizer, []core.Tool{tool})
+
+	tests := []struct {
+		name string
+		llm  core.LLM
+		opts []ConversationalAgentOption
+		want int
+	}{
+		{"known model", namedLLM{mocks.NewMockLLM(), "gpt-4-0613"}, nil, 8192 - DefaultResponseReserve - toolTokens},
+		{"unknown model", namedLLM{mocks.NewMockLLM(), "my-model"}, nil, DefaultContextWindow - DefaultResponseReserve - toolTokens},
+		{"explicit window", namedLLM{mocks.NewMockLLM(), "gpt-4o"}, []ConversationalAgentOption{ConvWithContextWindow(4000), ConvWithResponseReserve(500)}, 3500 - toolTokens},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			agent := NewConversationalAgent(tt.llm, tt.opts...)
+			agent.AddTool(tool)
+
+			got, err := agent.tokenBudget(charTokenizer, &convRun{tools: agent.tools.snapshot()})
+			if err != nil {
+				t.Fatalf("tokenBudget() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("tokenBudget() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+
+	agent := NewConversationalAgent(mocks.NewMockLLM(),
+		ConvWithMemoryStrategy(MemoryStrategyTokenBudget),
+		ConvWithContextWindow(1000),
+		ConvWithResponseReserve(1000),
+	)
+	if _, err := agent.Run(context.Background(), "Hi"); err == nil {
+		t.Error("Run() expected error when the reserve fills the window, got nil")
+	}
+}
+
+// TestConversationalAgent_Store tests that a conversation survives a new
+// agent instance when both use the same store.
+func TestConversationalAgent_Store(t *testing.T) {
+	ctx := context.Background()
+	store := memory.NewInMemoryStore()
+
+	llm := mocks.NewMockLLM().WithChatResponse("Hello Alice!", nil)
+	first := NewConversationalAgent(llm, ConvWithStore(store), ConvWithSessionID("alice"))
+	if _, err := first.Run(ctx, "Hi, I'm Alice"); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	// The system prompt is not stored
+	stored, _ := store.Load(ctx, "alice")
+	if len(stored) != 2 || stored[0].Content != "Hi, I'm Alice" || stored[1].Content != "Hello Alice!" {
+		t.Fatalf("stored = %+v, want the user and assistant messages", stored)
+	}
+
+	// A new agent, as after a restart, continues the conversation
+	llm2 := mocks.NewMockLLM().WithChatResponse("Your name is Alice.", nil)
+	second := NewConversationalAgent(llm2, ConvWithStore(store), ConvWithSessionID("alice"))
+	if _, err := second.Run(ctx, "What's my name?"); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	sent := llm2.GetChatCalls()[0].Messages
+	if len(sent) != 4 || sent[0].Role != "system" || sent[1].Content != "Hi, I'm Alice" || sent[3].Content != "What's my name?" {
+		t.Errorf("messages sent = %+v, want system, stored turn and new question", sent)
+	}
+
+	stored, _ = store.Load(ctx, "alice")
+	if len(stored) != 4 {
+		t.Errorf("len(stored) = %d, want 4", len(stored))
+	}
+}
+
+// TestConversationalAgent_Store_Sessions tests that one agent serves many
+// sessions selected through the context.
+func TestConversationalAgent_Store_Sessions(t *testing.T) {
+	store := memory.NewInMemoryStore()
+	llm := mocks.NewMockLLM()
+	llm.ChatFunc = func(ctx context.Context, messages []core.Message) (*core.Response, error) {
+		return &core.Response{Content: fmt.Sprintf("%d messages", len(messages))}, nil
+	}
+	agent := NewConversationalAgent(llm, ConvWithStore(store))
+
+	alice := core.ContextWithSessionID(context.Background(), "alice")
+	bob := core.ContextWithSessionID(context.Background(), "bob")
+
+	agent.Run(alice, "Hi")
+	agent.Run(bob, "Hi")
+	resp, err := agent.Run(alice, "Again")
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	// system + alice's first turn + new question
+	if resp.Content != "4 messages" {
+		t.Errorf("resp.Content = %q, want %q", resp.Content, "4 messages")
+	}
+	if msgs, _ := store.Load(context.Background(), "bob"); len(msgs) != 2 {
+		t.Errorf("len(bob) = %d, want 2", len(msgs))
+	}
+
+	// A store without a session ID is an error
+	var invalid *core.ErrInvalidArgument
+	if _, err := agent.Run(context.Background(), "Hi"); !errors.As(err, &invalid) {
+		t.Errorf("Run() without session error = %v, want ErrInvalidArgument", err)
+	}
+}
+
+// TestConversationalAgent_Store_MemoryStrategy tests that the store keeps the
+// full transcript while a memory strategy trims what the LLM is sent.
+func TestConversationalAgent_Store_MemoryStrategy(t *testing.T) {
+	ctx := core.ContextWithSessionID(context.Background(), "s1")
+	store := memory.NewInMemoryStore()
+	for i := 0; i < 5; i++ {
+		store.Append(ctx, "s1", core.UserMessage(fmt.Sprintf("Q%d", i)), core.AssistantMessage(fmt.Sprintf("A%d", i)))
+	}
+
+	llm := mocks.NewMockLLM().WithChatResponse("A5", nil)
+	agent := NewConversationalAgent(llm,
+		ConvWithStore(store),
+		ConvWithMemoryStrategy(MemoryStrategyWindow),
+		ConvWithMaxMessages(5),
+	)
+	if _, err := agent.Run(ctx, "Q5"); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	sent := llm.GetChatCalls()[0].Messages
+	want := []string{"You are a helpful assistant.", "A3", "Q4", "A4", "Q5"}
+	if len(sent) != len(want) {
+		t.Fatalf("sent = %+v, want %v", sent, want)
+	}
+	for i, msg := range sent[1:] {
+		if msg.Content != want[i+1] {
+			t.Errorf("sent[%d] = %q, want %q", i+1, msg.Content, want[i+1])
+		}
+	}
+
+	stored, _ := store.Load(ctx, "s1")
+	if len(stored) != 12 || stored[0].Content != "Q0" || stored[11].Content != "A5" {
+		t.Fatalf("stored = %+v, want the full transcript Q0..A5", stored)
+	}
+
+	// Later runs append again
+	agent.Run(ctx, "Q6")
+	if stored, _ = store.Load(ctx, "s1"); len(stored) != 14 {
+		t.Errorf("len(stored) = %d, want 14", len(stored))
+	}
+	if sent := llm.GetChatCalls()[1].Messages; len(sent) != 5 || sent[4].Content != "Q6" {
+		t.Errorf("second run sent %+v, want the trimmed window ending in Q6", sent)
+	}
+}
+
+// TestConversationalAgent_Store_RunStream tests that streamed turns are saved.
+func TestConversationalAgent_Store_RunStream(t *testing.T) {
+	ctx := context.Background()
+	store := memory.NewInMemoryStore()
+	llm := mocks.NewMockLLM().WithSequentialChatResponses([]*core.Response{
+		{ToolCalls: []core.ToolCall{{ID: "call_1", Name: "lookup"}}},
+		{Content: "It is 42."},
+	}, nil)
+
+	agent := NewConversationalAgent(llm, ConvWithStore(store), ConvWithSessionID("s1"))
+	agent.AddTool(mocks.NewMockTool("lookup", "Looks up a key").WithExecuteResult("42"))
+
+	stream, err := agent.RunStream(ctx, "What is x?")
+	if err != nil {
+		t.Fatalf("RunStream() error = %v", err)
+	}
+	for event := range stream {
+		if event.Type == core.EventTypeError {
+			t.Fatalf("error event: %v", event.Error)
+		}
+	}
+
+	stored, _ := store.Load(ctx, "s1")
+	if len(stored) != 4 {
+		t.Fatalf("len(stored) = %d, want 4 (user, tool call, tool result, answer)", len(stored))
+	}
+	assertToolPairs(t, stored)
+}
+
+// topicEmbedder embeds a text as the counts of a few topic words.
+type topicEmbedder struct{}
+
+func (topicEmbedder) Embed(ctx context.Context, texts []string) ([][]float64, error) {
+	topics := []string{"name", "tea", "paris"}
+	vectors := make([][]float64, len(texts))
+	for i, text := range texts {
+		vectors[i] = make([]float64, len(topics))
+		for j, topic := range topics {
+			vectors[i][j] = float64(strings.Count(strings.ToLower(text), topic))
+		}
+	}
+	return vectors, nil
+}
+
+// singleUser scopes all long-term memories to one namespace.
+var singleUser = memory.WithScope(func(ctx context.Context) string { return "user" })
+
+// TestConversationalAgent_LongTermMemory tests that facts remembered in one
+// session are recalled in another, without entering the history.
+func TestConversationalAgent_LongTermMemory(t *testing.T) {
+	ctx := context.Background()
+	ltm, err := memory.NewLongTermMemory(memory.NewInMemoryVectorStore(), topicEmbedder{}, singleUser, memory.WithMinScore(0.5))
+	if err != nil {
+		t.Fatalf("NewLongTermMemory() error = %v", err)
+	}
+
+	// The agent replies, then extracts the facts of the exchange
+	llm := mocks.NewMockLLM().WithSequentialChatResponses([]*core.Response{
+		{Content: "Nice to meet you, Alice!", Usage: core.NewUsage(20, 8)},
+		{Content: "- The user's name is Alice", Usage: core.NewUsage(50, 10)},
+	}, nil)
+	tracker := core.NewCostTracker(nil)
+	first := NewConversationalAgent(llm, ConvWithLongTermMemory(ltm), ConvWithCostTracker(tracker))
+	resp, err := first.Run(ctx, "Hi, my name is Alice")
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if resp.Meta["memories_recalled"] != 0 || resp.Meta["memory_error"] != nil {
+		t.Errorf("Meta = %v, want no memories recalled and no error", resp.Meta)
+	}
+	if resp.Usage.TotalTokens != 88 || tracker.Total().Calls != 2 {
+		t.Errorf("usage = %+v, tracked calls = %d, want the reply and the extraction", resp.Usage, tracker.Total().Calls)
+	}
+
+	// A new conversation recalls the name
+	llm2 := mocks.NewMockLLM().WithSequentialChatResponses([]*core.Response{
+		{Content: "Your name is Alice."}, {Content: "NONE"},
+		{Content: "Try green tea."}, {Content: "NONE"},
+	}, nil)
+	second := NewConversationalAgent(llm2, ConvWithLongTermMemory(ltm))
+	resp, err = second.Run(ctx, "What is my name?")
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if resp.Meta["memories_recalled"] != 1 {
+		t.Errorf("memories_recalled = %v, want 1", resp.Meta["memories_recalled"])
+	}
+
+	sent := llm2.GetChatCalls()[0].Messages
+	if len(sent) != 3 || sent[1].Role != "system" || !strings.Contains(sent[1].Content, "The user's name is Alice") {
+		t.Fatalf("messages sent = %+v, want system prompt, memories, question", sent)
+	}
+	if sent[2].Content != "What is my name?" {
+		t.Errorf("last message sent = %q, want the question", sent[2].Content)
+	}
+
+	// Memories are not kept in the history
+	for _, msg := range second.GetMessages() {
+		if strings.Contains(msg.Content, "Relevant memories") {
+			t.Errorf("history contains recalled memories: %+v", msg)
+		}
+	}
+
+	// Unrelated messages recall nothing
+	resp, _ = second.Run(ctx, "Recommend some tea")
+	if resp.Meta["memories_recalled"] != 0 {
+		t.Errorf("memories_recalled = %v, want 0", resp.Meta["memories_recalled"])
+	}
+}
+
+// TestConversationalAgent_LongTermMemory_RememberError tests that failing to
+// remember does not fail the run.
+func TestConversationalAgent_LongTermMemory_RememberError(t *testing.T) {
+	ltm, _ := memory.NewLongTermMemory(memory.NewInMemoryVectorStore(), topicEmbedder{}, singleUser)
+
+	llm := mocks.NewMockLLM().WithSequentialChatResponses(
+		[]*core.Response{{Content: "Hello!"}, nil},
+		[]error{nil, fmt.Errorf("rate limited")},
+	)
+
+	agent := NewConversationalAgent(llm, ConvWithLongTermMemory(ltm))
+	resp, err := agent.Run(context.Background(), "Hi")
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if resp.Content != "Hello!" {
+		t.Errorf("resp.Content = %q, want %q", resp.Content, "Hello!")
+	}
+	if msg, _ := resp.Meta["memory_error"].(string); !strings.Contains(msg, "rate limited") {
+		t.Errorf("memory_error = %v, want the extraction error", resp.Meta["memory_error"])
+	}
+}
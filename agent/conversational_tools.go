@@ -0,0 +1,125 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/yashrahurikar23/goagents/core"
+)
+
+// promptedToolCalls is the reply an LLM without native tool calling gives to
+// call tools.
+type promptedToolCalls struct {
+	ToolCalls []promptedToolCall `json:"tool_calls"`
+}
+
+type promptedToolCall struct {
+	Name      string                 `json:"name"`
+	Arguments map[string]interface{} `json:"arguments"`
+}
+
+// chatWithPromptedTools offers tools to an LLM that does not implement
+// core.ToolCallingLLM. The tools are described in the system prompt and the
+// model is asked to reply with a JSON object to call them. Tool calls found in
+// the reply are returned in Response.ToolCalls, as native tool calling would.
+// historyLen, the length of the history, makes call IDs unique within the
+// conversation.
+func (a *ConversationalAgent) chatWithPromptedTools(ctx context.Context, messages []core.Message, tools []core.Tool, historyLen int) (*core.Response, error) {
+	resp, err := a.llm.Chat(ctx, promptedToolMessages(messages, tools))
+	if err != nil {
+		return nil, err
+	}
+
+	calls := parsePromptedToolCalls(resp.Content, fmt.Sprintf("call_%d", historyLen))
+	if len(calls) == 0 {
+		return resp, nil
+	}
+
+	out := *resp
+	out.Content = ""
+	out.ToolCalls = calls
+	return &out, nil
+}
+
+// promptedToolMessages adds the tool instructions to the system prompt and
+// rewrites tool-call and tool-result messages as plain text, since the LLM
+// cannot read them natively.
+func promptedToolMessages(messages []core.Message, tools []core.Tool) []core.Message {
+	instructions := promptedToolInstructions(tools)
+
+	out := make([]core.Message, 0, len(messages)+1)
+	if len(messages) == 0 || messages[0].Role != "system" {
+		out = append(out, core.SystemMessage(instructions))
+	}
+
+	for i, msg := range messages {
+		switch {
+		case i == 0 && msg.Role == "system":
+			msg.Content = msg.Content + "\n\n" + instructions
+			out = append(out, msg)
+		case msg.Role == "tool":
+			out = append(out, core.UserMessage(fmt.Sprintf("Result of %s (%s): %s", msg.Name, msg.ToolCallID, msg.Content)))
+		case msg.Role == "assistant" && len(msg.ToolCalls) > 0:
+			out = append(out, core.AssistantMessage(formatPromptedToolCalls(msg.ToolCalls)))
+		default:
+			out = append(out, msg)
+		}
+	}
+	return out
+}
+
+// promptedToolInstructions describes the tools and the reply format for calling them.
+func promptedToolInstructions(tools []core.Tool) string {
+	var sb strings.Builder
+	sb.WriteString("You can call the following tools:\n\n")
+	for _, tool := range tools {
+		sb.WriteString(fmt.Sprintf("- %s: %s\n", tool.Name(), tool.Description()))
+		if schema := tool.Schema(); schema != nil {
+			if data, err := json.Marshal(schema.JSONSchema()); err == nil {
+				sb.WriteString(fmt.Sprintf("  Arguments (JSON Schema): %s\n", data))
+			}
+		}
+	}
+	sb.WriteString("\nTo call one or more tools, reply with only a JSON object of the form:\n")
+	sb.WriteString(`{"tool_calls": [{"name": "<tool name>", "arguments": {<arguments>}}]}`)
+	sb.WriteString("\nThe results will be sent back to you. Otherwise, reply to the user normally.")
+	return sb.String()
+}
+
+// formatPromptedToolCalls renders tool calls in the reply format the LLM was asked to use.
+func formatPromptedToolCalls(toolCalls []core.ToolCall) string {
+	reply := promptedToolCalls{ToolCalls: make([]promptedToolCall, len(toolCalls))}
+	for i, tc := range toolCalls {
+		reply.ToolCalls[i] = promptedToolCall{Name: tc.Name, Arguments: tc.Args}
+	}
+	data, _ := json.Marshal(reply)
+	return string(data)
+}
+
+// parsePromptedToolCalls extracts the tool calls from a reply, or returns nil
+// if the reply is not a tool-call object. Calls are given IDs idPrefix_0, idPrefix_1, ...
+func parsePromptedToolCalls(content, idPrefix string) []core.ToolCall {
+	if !strings.Contains(content, "tool_calls") {
+		return nil
+	}
+
+	var reply promptedToolCalls
+	if err := json.Unmarshal([]byte(core.ExtractJSON(content)), &reply); err != nil {
+		return nil
+	}
+
+	calls := make([]core.ToolCall, 0, len(reply.ToolCalls))
+	for i, tc := range reply.ToolCalls {
+		if tc.Name == "" {
+			return nil
+		}
+		calls = append(calls, core.ToolCall{
+			ID:   fmt.Sprintf("%s_%d", idPrefix, i),
+			Name: tc.Name,
+			Args: tc.Arguments,
+		})
+	}
+	return calls
+}
@@ -5,21 +5,23 @@
 //   - FunctionAgent: Uses LLM function calling for tool execution
 //   - ReActAgent: Implements reasoning and acting pattern
 //   - ConversationalAgent: Maintains conversation history and context
+//   - PlanExecuteAgent: Plans a task as steps and executes them with another agent
 //   - Multi-agent coordinators for complex workflows
 package agent
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
+	"time"
 
 	"github.com/yashrahurikar23/goagents/core"
-	"github.com/yashrahurikar23/goagents/llm/openai"
+	"github.com/yashrahurikar23/goagents/memory"
 )
 
-// FunctionAgent uses OpenAI's function calling capability to execute tools.
-// It automatically converts tool calls into function executions and handles
-// multi-turn conversations with the LLM.
+// FunctionAgent uses native LLM function calling to execute tools.
+// It works with any LLM implementing core.ToolCallingLLM (OpenAI, Anthropic,
+// Gemini, Ollama, ...), automatically executes the tool calls the model requests
+// and handles multi-turn conversations with the LLM.
 //
 // Example usage:
 //
@@ -30,12 +32,40 @@ import (
 //	agent.AddTool(calc)
 //
 //	response, err := agent.Run(ctx, "What is 25 * 4?")
+//
+// A FunctionAgent is safe for concurrent use. Each run works on its own copy
+// of the conversation and adds its messages to the agent's history when it
+// completes; give each conversation its own session (WithStore) to keep them
+// apart.
 type FunctionAgent struct {
-	llm          core.LLM
-	tools        map[string]core.Tool
-	messages     []core.Message
+	llm          core.ToolCallingLLM
+	tools        *toolSet
+	history      *history
 	systemPrompt string
 	maxIter      int
+	costTracker  *core.CostTracker
+
+	maxParallelTools int
+	toolTimeout      time.Duration
+	toolTimeouts     map[string]time.Duration
+
+	store     memory.Store
+	sessionID string
+
+	hooks     hookChain
+	approvals approvals
+
+	checkpoints memory.CheckpointStore
+}
+
+// functionRun is the state of one FunctionAgent run.
+type functionRun struct {
+	session   *session
+	messages  []core.Message
+	tools     map[string]core.Tool
+	input     string
+	iteration int             // LLM calls made
+	pending   []core.ToolCall // tool calls of the last message that have not run
 }
 
 // FunctionAgentOption configures a FunctionAgent.
@@ -56,12 +86,100 @@ func WithMaxIterations(max int) FunctionAgentOption {
 	}
 }
 
+// WithCostTracker records the token usage and cost of every LLM call the agent
+// makes. Calls are attributed to the run and to the session set on the context
+// with core.ContextWithSessionID.
+func WithCostTracker(tracker *core.CostTracker) FunctionAgentOption {
+	return func(a *FunctionAgent) {
+		a.costTracker = tracker
+	}
+}
+
+// WithMaxParallelTools limits how many tool calls of one LLM turn run at the
+// same time. 0 (the default) runs all of them concurrently; 1 runs them one
+// after another.
+func WithMaxParallelTools(n int) FunctionAgentOption {
+	return func(a *FunctionAgent) {
+		a.maxParallelTools = n
+	}
+}
+
+// WithToolTimeout sets how long a tool call may run before it is abandoned
+// and reported to the LLM as timed out. 0 (the default) means no timeout.
+func WithToolTimeout(timeout time.Duration) FunctionAgentOption {
+	return func(a *FunctionAgent) {
+		a.toolTimeout = timeout
+	}
+}
+
+// WithToolTimeoutFor overrides the tool timeout for the named tool.
+func WithToolTimeoutFor(name string, timeout time.Duration) FunctionAgentOption {
+	return func(a *FunctionAgent) {
+		if a.toolTimeouts == nil {
+			a.toolTimeouts = make(map[string]time.Duration)
+		}
+		a.toolTimeouts[name] = timeout
+	}
+}
+
+// WithStore keeps the conversation history in store: every run loads the
+// session's history and saves the messages of the run once it completes.
+// The session is taken from the context (core.ContextWithSessionID), else
+// the one set with WithSessionID. The system prompt is not stored.
+func WithStore(store memory.Store) FunctionAgentOption {
+	return func(a *FunctionAgent) {
+		a.store = store
+	}
+}
+
+// WithSessionID sets the session used when the context carries none.
+func WithSessionID(id string) FunctionAgentOption {
+	return func(a *FunctionAgent) {
+		a.sessionID = id
+	}
+}
+
+// WithHooks adds hooks that are called as the agent runs. Hooks added by
+// several options form one chain, in order; see ChainHooks.
+func WithHooks(hooks ...Hooks) FunctionAgentOption {
+	return func(a *FunctionAgent) {
+		a.hooks = a.hooks.with(hooks...)
+	}
+}
+
+// WithApprover sets who approves the tool calls that need approval; see
+// WithApprovalFor.
+func WithApprover(approver Approver) FunctionAgentOption {
+	return func(a *FunctionAgent) {
+		a.approvals.approver = approver
+	}
+}
+
+// WithApprovalFor sets when calls of the named tool need approval before
+// they run. A rejected call is reported to the LLM with the reason. Calls
+// that need approval are rejected when no approver is set.
+func WithApprovalFor(name string, policy ApprovalPolicy) FunctionAgentOption {
+	return func(a *FunctionAgent) {
+		a.approvals.setPolicy(name, policy)
+	}
+}
+
+// WithCheckpointStore saves a checkpoint of every run to store after each
+// step, so a run that stopped can be continued with Resume. The checkpoint is
+// kept under the run's ID (set it with core.ContextWithRunID to know it in
+// advance) and deleted once the run completes.
+func WithCheckpointStore(store memory.CheckpointStore) FunctionAgentOption {
+	return func(a *FunctionAgent) {
+		a.checkpoints = store
+	}
+}
+
 // NewFunctionAgent creates a new function calling agent with the given LLM.
-func NewFunctionAgent(llm core.LLM, opts ...FunctionAgentOption) *FunctionAgent {
+func NewFunctionAgent(llm core.ToolCallingLLM, opts ...FunctionAgentOption) *FunctionAgent {
 	agent := &FunctionAgent{
 		llm:          llm,
-		tools:        make(map[string]core.Tool),
-		messages:     make([]core.Message, 0),
+		tools:        newToolSet(),
+		history:      &history{},
 		systemPrompt: "You are a helpful assistant with access to tools. Use them when needed to accomplish tasks.",
 		maxIter:      5, // Default max iterations
 	}
@@ -75,27 +193,22 @@ func NewFunctionAgent(llm core.LLM, opts ...FunctionAgentOption) *FunctionAgent
 
 // AddTool registers a tool that the agent can use.
 func (a *FunctionAgent) AddTool(tool core.Tool) error {
-	if tool == nil {
-		return &core.ErrInvalidArgument{
-			Argument: "tool",
-			Reason:   "cannot be nil",
-		}
-	}
-
-	name := tool.Name()
-	if name == "" {
-		return &core.ErrInvalidArgument{
-			Argument: "tool.Name()",
-			Reason:   "cannot be empty",
-		}
-	}
+	return a.tools.add(tool)
+}
 
-	if _, exists := a.tools[name]; exists {
-		return fmt.Errorf("tool %s already registered", name)
+// begin starts a run: it loads the conversation and adds the user message.
+func (a *FunctionAgent) begin(ctx context.Context, input string) (*functionRun, error) {
+	sess, messages, err := openSession(ctx, a.store, a.history, a.systemPrompt)
+	if err != nil {
+		return nil, err
 	}
 
-	a.tools[name] = tool
-	return nil
+	return &functionRun{
+		session:  sess,
+		messages: append(messages, core.UserMessage(input)),
+		tools:    a.tools.snapshot(),
+		input:    input,
+	}, nil
 }
 
 // Run executes the agent with the given input and returns a response.
@@ -104,110 +217,19 @@ func (a *FunctionAgent) AddTool(tool core.Tool) error {
 //  2. If LLM requests tool calls, execute them
 //  3. Send tool results back to LLM
 //  4. Repeat until LLM provides final answer (or max iterations reached)
+//
+// The response's Usage is the total across all LLM calls of the run.
 func (a *FunctionAgent) Run(ctx context.Context, input string) (*core.Response, error) {
-	// Initialize messages if this is the first run
-	if len(a.messages) == 0 && a.systemPrompt != "" {
-		a.messages = append(a.messages, core.SystemMessage(a.systemPrompt))
-	}
-
-	// Add user message
-	a.messages = append(a.messages, core.UserMessage(input))
+	ctx = sessionContext(ctx, a.sessionID)
+	ctx, usage := startRun(ctx, a.costTracker)
 
-	// Check if LLM supports OpenAI interface (for function calling)
-	openaiClient, ok := a.llm.(*openai.Client)
-	if !ok {
-		return nil, fmt.Errorf("FunctionAgent requires an LLM that supports function calling (OpenAI)")
+	run, err := a.begin(ctx, input)
+	if err != nil {
+		return a.hooks.finish(ctx, nil, err)
 	}
 
-	// Convert tools to OpenAI function format
-	functions := a.convertToolsToFunctions()
-
-	// Main execution loop
-	for iter := 0; iter < a.maxIter; iter++ {
-		// Create chat completion request with functions
-		req := openai.ChatCompletionRequest{
-			Model:    "gpt-3.5-turbo", // Default model
-			Messages: a.convertToOpenAIMessages(a.messages),
-		}
-
-		// Add functions if we have tools
-		if len(functions) > 0 {
-			req.Tools = functions
-			req.ToolChoice = "auto"
-		}
-
-		// Call LLM
-		resp, err := openaiClient.CreateChatCompletion(ctx, req)
-		if err != nil {
-			return nil, fmt.Errorf("LLM call failed: %w", err)
-		}
-
-		// Extract the response message
-		if len(resp.Choices) == 0 {
-			return nil, fmt.Errorf("no response from LLM")
-		}
-
-		choice := resp.Choices[0]
-
-		// Convert content to string (handle interface{})
-		contentStr := ""
-		if choice.Message.Content != nil {
-			contentStr = fmt.Sprintf("%v", choice.Message.Content)
-		}
-
-		// Add assistant's message to history
-		assistantMsg := core.Message{
-			Role:    "assistant",
-			Content: contentStr,
-		}
-
-		// Check if there are tool calls
-		if len(choice.Message.ToolCalls) > 0 {
-			// Execute tool calls
-			toolResults, err := a.executeToolCalls(ctx, choice.Message.ToolCalls)
-			if err != nil {
-				return nil, fmt.Errorf("tool execution failed: %w", err)
-			}
-
-			// Add tool calls to assistant message
-			assistantMsg.ToolCalls = toolResults
-
-			// Add assistant message with tool calls
-			a.messages = append(a.messages, assistantMsg)
-
-			// Add tool results as separate messages
-			for _, result := range toolResults {
-				toolMsg := core.Message{
-					Role:       "tool",
-					Content:    fmt.Sprintf("%v", result.Result),
-					Name:       result.Name,
-					ToolCallID: result.ID,
-				}
-				a.messages = append(a.messages, toolMsg)
-			}
-
-			// Continue loop to send tool results back to LLM
-			continue
-		}
-
-		// No tool calls - this is the final response
-		a.messages = append(a.messages, assistantMsg)
-
-		// Return response
-		return &core.Response{
-			Content: fmt.Sprintf("%v", choice.Message.Content),
-			Meta: map[string]interface{}{
-				"model":             resp.Model,
-				"finish":            choice.FinishReason,
-				"iterations":        iter + 1,
-				"prompt_tokens":     resp.Usage.PromptTokens,
-				"completion_tokens": resp.Usage.CompletionTokens,
-				"total_tokens":      resp.Usage.TotalTokens,
-			},
-		}, nil
-	}
-
-	return nil, fmt.Errorf("max iterations (%d) reached without final answer", a.maxIter)
+	resp, err := a.run(ctx, run, usage, nil)
+	return a.hooks.finish(ctx, resp, err)
 }
 
 // RunStream executes the agent with streaming and emits events in real-time.
@@ -215,351 +237,248 @@ func (a *FunctionAgent) Run(ctx context.Context, input string) (*core.Response,
 //
 // Events emitted:
 // - "token": Each token as it's generated from the LLM
-// - "tool_start": When a tool is about to be executed
-// - "tool_end": When a tool finishes executing
-// - "complete": When generation finishes successfully
+// - "approval_required": When a tool call waits for approval (see WithApprovalFor)
+// - "tool_start": As each tool call starts (calls of one turn may run concurrently)
+// - "tool_end": As each tool call finishes, with its result, error and duration
+// - "complete": When generation finishes successfully (with the run's total "usage")
 // - "error": If an error occurs
 func (a *FunctionAgent) RunStream(ctx context.Context, input string) (<-chan core.StreamEvent, error) {
-	// Initialize messages if this is the first run
-	if len(a.messages) == 0 && a.systemPrompt != "" {
-		a.messages = append(a.messages, core.SystemMessage(a.systemPrompt))
+	ctx = sessionContext(ctx, a.sessionID)
+	ctx, usage := startRun(ctx, a.costTracker)
+
+	run, err := a.begin(ctx, input)
+	if err != nil {
+		a.hooks.OnError(ctx, err)
+		return nil, err
 	}
 
-	// Add user message
-	a.messages = append(a.messages, core.UserMessage(input))
+	return a.stream(ctx, run, usage), nil
+}
+
+// Resume continues a run from its last checkpoint (see WithCheckpointStore):
+// tool calls that were pending run first, then the run goes on as Run would.
+// The run keeps its ID and session, and the response's Usage includes the
+// calls made before the checkpoint. Tools are the ones registered now.
+func (a *FunctionAgent) Resume(ctx context.Context, runID string) (*core.Response, error) {
+	ctx, cp, err := resumeContext(ctx, a.checkpoints, runID, checkpointFunction)
+	if err != nil {
+		return nil, err
+	}
+	ctx, usage := startRun(ctx, a.costTracker)
+	usage.restore(cp.Usage)
 
-	// Check if LLM supports OpenAI function calling
-	openaiClient, ok := a.llm.(*openai.Client)
-	if !ok {
-		return nil, fmt.Errorf("FunctionAgent requires an LLM that supports function calling (OpenAI)")
+	resp, err := a.run(ctx, a.restore(cp), usage, nil)
+	return a.hooks.finish(ctx, resp, err)
+}
+
+// ResumeStream is the streaming form of Resume; it emits the events of
+// RunStream.
+func (a *FunctionAgent) ResumeStream(ctx context.Context, runID string) (<-chan core.StreamEvent, error) {
+	ctx, cp, err := resumeContext(ctx, a.checkpoints, runID, checkpointFunction)
+	if err != nil {
+		return nil, err
 	}
+	ctx, usage := startRun(ctx, a.costTracker)
+	usage.restore(cp.Usage)
+
+	return a.stream(ctx, a.restore(cp), usage), nil
+}
 
+// stream runs the tool loop in a goroutine, sending its events on the
+// returned channel.
+func (a *FunctionAgent) stream(ctx context.Context, run *functionRun, usage *runUsage) <-chan core.StreamEvent {
 	// Create event channel
 	eventChan := make(chan core.StreamEvent, 10)
 
-	// Convert tools to OpenAI function format
-	functions := a.convertToolsToFunctions()
+	send := func(event core.StreamEvent) bool {
+		a.hooks.observe(ctx, event)
+		select {
+		case eventChan <- event:
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}
 
 	// Start execution in goroutine
 	go func() {
 		defer close(eventChan)
 
-		// Main execution loop
-		for iter := 0; iter < a.maxIter; iter++ {
-			// For OpenAI function calling, we need to use the direct API
-			// because streaming with function calls requires special handling
+		if _, err := a.run(ctx, run, usage, send); err != nil {
+			send(core.NewErrorEvent(err))
+		}
+	}()
 
-			// Create chat completion request with functions
-			req := openai.ChatCompletionRequest{
-				Model:    "gpt-3.5-turbo",
-				Messages: a.convertToOpenAIMessages(a.messages),
-			}
+	return eventChan
+}
 
-			// Add functions if we have tools
-			if len(functions) > 0 {
-				req.Tools = functions
-				req.ToolChoice = "auto"
-			}
+// run is the tool loop shared by Run, RunStream and the resume methods. Each
+// iteration runs the pending tool calls, if any, or else calls the LLM; a
+// checkpoint is saved after each. When emit is non-nil it receives the tool
+// events and, once the LLM answers, the token and complete events.
+func (a *FunctionAgent) run(ctx context.Context, run *functionRun, usage *runUsage, emit func(core.StreamEvent) bool) (*core.Response, error) {
+	tools := sortedTools(run.tools)
 
-			// Call LLM (non-streaming for function calling decisions)
-			resp, err := openaiClient.CreateChatCompletion(ctx, req)
+	// Main execution loop
+	for {
+		if len(run.pending) > 0 {
+			// Execute the tool calls the LLM requested, emitting
+			// tool_start/tool_end as each one runs
+			var notify func(core.StreamEvent)
+			if emit != nil {
+				notify = func(event core.StreamEvent) { emit(event) }
+			}
+			toolResults, err := a.executeToolCalls(ctx, run.tools, run.pending, notify)
 			if err != nil {
-				select {
-				case eventChan <- core.NewErrorEvent(fmt.Errorf("LLM call failed: %w", err)):
-				case <-ctx.Done():
-				}
-				return
+				return nil, fmt.Errorf("tool execution failed: %w", err)
 			}
 
-			// Extract the response message
-			if len(resp.Choices) == 0 {
-				select {
-				case eventChan <- core.NewErrorEvent(fmt.Errorf("no response from LLM")):
-				case <-ctx.Done():
-				}
-				return
+			// Replace the pending call message with the calls and their results
+			last := len(run.messages) - 1
+			run.messages = append(run.messages[:last], toolTurnMessages(run.messages[last].Content, toolResults)...)
+			run.pending = nil
+			if err := a.checkpoint(ctx, run, usage); err != nil {
+				return nil, err
 			}
 
-			choice := resp.Choices[0]
-
-			// Convert content to string
-			contentStr := ""
-			if choice.Message.Content != nil {
-				contentStr = fmt.Sprintf("%v", choice.Message.Content)
-			}
+			// Continue loop to send tool results back to LLM
+			continue
+		}
 
-			// Check if there are tool calls
-			if len(choice.Message.ToolCalls) > 0 {
-				// Emit tool_start events for each tool
-				for _, tc := range choice.Message.ToolCalls {
-					toolStartEvent := core.NewStreamEventWithData(
-						core.EventTypeToolStart,
-						tc.Function.Name,
-						map[string]interface{}{
-							"tool_id":   tc.ID,
-							"arguments": tc.Function.Arguments,
-						},
-					)
-					select {
-					case eventChan <- toolStartEvent:
-					case <-ctx.Done():
-						return
-					}
-				}
+		if run.iteration >= a.maxIter {
+			return nil, fmt.Errorf("max iterations (%d) reached without final answer", a.maxIter)
+		}
 
-				// Execute tool calls
-				toolResults, err := a.executeToolCalls(ctx, choice.Message.ToolCalls)
-				if err != nil {
-					select {
-					case eventChan <- core.NewErrorEvent(fmt.Errorf("tool execution failed: %w", err)):
-					case <-ctx.Done():
-					}
-					return
-				}
+		// Call LLM with the available tools
+		resp, err := a.chat(ctx, run, tools, run.iteration+1)
+		if err != nil {
+			return nil, fmt.Errorf("LLM call failed: %w", err)
+		}
+		usage.addResponse(resp)
+		run.iteration++
 
-				// Emit tool_end events
-				for _, result := range toolResults {
-					toolEndEvent := core.NewStreamEventWithData(
-						core.EventTypeToolEnd,
-						result.Name,
-						map[string]interface{}{
-							"tool_id": result.ID,
-							"result":  result.Result,
-						},
-					)
-					select {
-					case eventChan <- toolEndEvent:
-					case <-ctx.Done():
-						return
-					}
-				}
+		// Check if there are tool calls
+		if len(resp.ToolCalls) > 0 {
+			run.messages = append(run.messages, core.Message{
+				Role:      "assistant",
+				Content:   resp.Content,
+				ToolCalls: resp.ToolCalls,
+			})
+			run.pending = resp.ToolCalls
+			if err := a.checkpoint(ctx, run, usage); err != nil {
+				return nil, err
+			}
+			continue
+		}
 
-				// Add assistant message with tool calls
-				assistantMsg := core.Message{
-					Role:      "assistant",
-					Content:   contentStr,
-					ToolCalls: toolResults,
-				}
-				a.messages = append(a.messages, assistantMsg)
-
-				// Add tool results as separate messages
-				for _, result := range toolResults {
-					toolMsg := core.Message{
-						Role:       "tool",
-						Content:    fmt.Sprintf("%v", result.Result),
-						Name:       result.Name,
-						ToolCallID: result.ID,
-					}
-					a.messages = append(a.messages, toolMsg)
-				}
+		// No tool calls - this is the final response
+		run.messages = append(run.messages, core.AssistantMessage(resp.Content))
+		if err := run.session.save(ctx, run.messages, run.messages[run.session.loaded:], nil); err != nil {
+			return nil, err
+		}
 
-				// Continue loop to send tool results back to LLM
-				continue
-			}
+		meta := make(map[string]interface{}, len(resp.Meta)+2)
+		for k, v := range resp.Meta {
+			meta[k] = v
+		}
+		meta["iterations"] = run.iteration
+		meta["run_id"] = usage.runID()
+		deleteCheckpoint(ctx, a.checkpoints, usage.runID(), meta)
 
-			// No tool calls - stream the final response
+		if emit != nil {
 			// Since we already have the content, emit it as tokens
-			if contentStr != "" {
-				// Try to stream the final response
-				a.messages = append(a.messages, core.AssistantMessage(contentStr))
-
-				// Emit tokens (simulate streaming by emitting the full content)
+			// (simulate streaming by emitting the full content)
+			if resp.Content != "" {
 				tokenEvent := core.NewStreamEventWithData(
 					core.EventTypeToken,
-					contentStr,
+					resp.Content,
 					map[string]interface{}{
 						"index": 0,
 					},
 				)
-				select {
-				case eventChan <- tokenEvent:
-				case <-ctx.Done():
-					return
+				if !emit(tokenEvent) {
+					return nil, ctx.Err()
 				}
 			}
 
 			// Emit complete event
-			completeEvent := core.NewStreamEvent(core.EventTypeComplete, contentStr)
-			select {
-			case eventChan <- completeEvent:
-			case <-ctx.Done():
-			}
-			return
-		}
-
-		// Max iterations reached
-		select {
-		case eventChan <- core.NewErrorEvent(fmt.Errorf("max iterations (%d) reached without final answer", a.maxIter)):
-		case <-ctx.Done():
-		}
-	}()
-
-	return eventChan, nil
-}
-
-// Reset clears the conversation history.
-func (a *FunctionAgent) Reset() error {
-	a.messages = make([]core.Message, 0)
-	if a.systemPrompt != "" {
-		a.messages = append(a.messages, core.SystemMessage(a.systemPrompt))
-	}
-	return nil
-}
-
-// GetMessages returns the current conversation history.
-func (a *FunctionAgent) GetMessages() []core.Message {
-	return a.messages
-}
-
-// executeToolCalls executes the tool calls requested by the LLM.
-func (a *FunctionAgent) executeToolCalls(ctx context.Context, toolCalls []openai.ToolCall) ([]core.ToolCall, error) {
-	results := make([]core.ToolCall, 0, len(toolCalls))
-
-	for _, tc := range toolCalls {
-		// Find the tool
-		tool, exists := a.tools[tc.Function.Name]
-		if !exists {
-			// Tool not found - return error result
-			results = append(results, core.ToolCall{
-				ID:     tc.ID,
-				Name:   tc.Function.Name,
-				Result: fmt.Sprintf("Error: tool '%s' not found", tc.Function.Name),
-			})
-			continue
-		}
-
-		// Parse arguments
-		var args map[string]interface{}
-		if tc.Function.Arguments != "" {
-			if err := json.Unmarshal([]byte(tc.Function.Arguments), &args); err != nil {
-				results = append(results, core.ToolCall{
-					ID:     tc.ID,
-					Name:   tc.Function.Name,
-					Result: fmt.Sprintf("Error: invalid arguments: %v", err),
-				})
-				continue
+			data := usage.eventData()
+			if errText, ok := meta["checkpoint_error"]; ok {
+				data["checkpoint_error"] = errText
 			}
-		} else {
-			args = make(map[string]interface{})
-		}
-
-		// Execute tool
-		result, err := tool.Execute(ctx, args)
-		if err != nil {
-			results = append(results, core.ToolCall{
-				ID:     tc.ID,
-				Name:   tc.Function.Name,
-				Result: fmt.Sprintf("Error: %v", err),
-			})
-			continue
+			emit(core.NewStreamEventWithData(core.EventTypeComplete, resp.Content, data))
 		}
 
-		// Format result as string
-		resultStr := fmt.Sprintf("%v", result)
-
-		results = append(results, core.ToolCall{
-			ID:     tc.ID,
-			Name:   tc.Function.Name,
-			Args:   args,
-			Result: resultStr,
-		})
+		return &core.Response{
+			Content: resp.Content,
+			Usage:   usage.total,
+			Meta:    meta,
+		}, nil
 	}
-
-	return results, nil
 }
 
-// convertToolsToFunctions converts core.Tool to OpenAI function format.
-func (a *FunctionAgent) convertToolsToFunctions() []openai.Tool {
-	if len(a.tools) == 0 {
+// checkpoint saves the state of a run to the checkpoint store, if any.
+func (a *FunctionAgent) checkpoint(ctx context.Context, run *functionRun, usage *runUsage) error {
+	if a.checkpoints == nil {
 		return nil
 	}
+	return saveCheckpoint(ctx, a.checkpoints, &Checkpoint{
+		RunID:            usage.runID(),
+		SessionID:        core.SessionIDFromContext(ctx),
+		Agent:            checkpointFunction,
+		Input:            run.input,
+		Iteration:        run.iteration,
+		Messages:         run.messages,
+		PendingToolCalls: run.pending,
+		Usage:            usage.total,
+		loaded:           run.session.loaded,
+	})
+}
 
-	functions := make([]openai.Tool, 0, len(a.tools))
-	for _, tool := range a.tools {
-		schema := tool.Schema()
-		if schema == nil {
-			continue
-		}
-
-		// Build parameters
-		params := map[string]interface{}{
-			"type":       "object",
-			"properties": make(map[string]interface{}),
-		}
-
-		required := make([]string, 0)
-
-		// Add each parameter
-		for _, param := range schema.Parameters {
-			paramDef := map[string]interface{}{
-				"type":        param.Type,
-				"description": param.Description,
-			}
-
-			// Add enum if present
-			if len(param.Enum) > 0 {
-				paramDef["enum"] = param.Enum
-			}
-
-			params["properties"].(map[string]interface{})[param.Name] = paramDef
-
-			if param.Required {
-				required = append(required, param.Name)
-			}
-		}
-
-		if len(required) > 0 {
-			params["required"] = required
-		}
-
-		functions = append(functions, openai.Tool{
-			Type: "function",
-			Function: &openai.Function{
-				Name:        tool.Name(),
-				Description: tool.Description(),
-				Parameters:  params,
-			},
-		})
+// restore rebuilds a run from its checkpoint.
+func (a *FunctionAgent) restore(cp *Checkpoint) *functionRun {
+	return &functionRun{
+		session:   resumeSession(a.store, a.history, a.systemPrompt, cp.SessionID, cp.loaded),
+		messages:  cp.Messages,
+		tools:     a.tools.snapshot(),
+		input:     cp.Input,
+		iteration: cp.Iteration,
+		pending:   cp.PendingToolCalls,
 	}
-
-	return functions
 }
 
-// convertToOpenAIMessages converts core.Message to openai.ChatMessage.
-func (a *FunctionAgent) convertToOpenAIMessages(messages []core.Message) []openai.ChatMessage {
-	result := make([]openai.ChatMessage, 0, len(messages))
-
-	for _, msg := range messages {
-		openaiMsg := openai.ChatMessage{
-			Role:    msg.Role,
-			Content: msg.Content,
-			Name:    msg.Name,
-		}
+// Reset clears the agent's conversation history. Sessions in a store are
+// not affected.
+func (a *FunctionAgent) Reset() error {
+	a.history.reset(a.systemPrompt)
+	return nil
+}
 
-		// Add tool call ID for tool messages
-		if msg.Role == "tool" {
-			openaiMsg.ToolCallID = msg.ToolCallID
-		}
+// GetMessages returns a copy of the agent's conversation history. With a
+// store, load the session from the store instead.
+func (a *FunctionAgent) GetMessages() []core.Message {
+	return a.history.get()
+}
 
-		// Convert tool calls if present
-		if len(msg.ToolCalls) > 0 {
-			openaiMsg.ToolCalls = make([]openai.ToolCall, len(msg.ToolCalls))
-			for i, tc := range msg.ToolCalls {
-				// Marshal args back to JSON string
-				argsJSON, _ := json.Marshal(tc.Args)
-				openaiMsg.ToolCalls[i] = openai.ToolCall{
-					ID:   tc.ID,
-					Type: "function",
-					Function: &openai.FunctionCall{
-						Name:      tc.Name,
-						Arguments: string(argsJSON),
-					},
-				}
-			}
-		}
+// chat sends the conversation to the LLM with the tools, through the LLM
+// call hooks.
+func (a *FunctionAgent) chat(ctx context.Context, run *functionRun, tools []core.Tool, iteration int) (*core.Response, error) {
+	call := newLLMCall(run.messages, tools, iteration)
+	return a.hooks.callLLM(ctx, call, func(call *LLMCall) (*core.Response, error) {
+		return a.llm.ChatWithTools(ctx, call.Messages, call.Tools)
+	})
+}
 
-		result = append(result, openaiMsg)
+// executeToolCalls executes the tool calls requested by the LLM concurrently
+// and returns the results in call order. See toolExecutor.execute.
+func (a *FunctionAgent) executeToolCalls(ctx context.Context, tools map[string]core.Tool, toolCalls []core.ToolCall, emit func(core.StreamEvent)) ([]core.ToolCall, error) {
+	executor := &toolExecutor{
+		tools:       tools,
+		maxParallel: a.maxParallelTools,
+		timeout:     a.toolTimeout,
+		timeouts:    a.toolTimeouts,
+		hooks:       a.hooks,
+		approvals:   &a.approvals,
 	}
-
-	return result
+	return executor.execute(ctx, toolCalls, emit)
 }
@@ -3,9 +3,14 @@ package agent
 import (
 	"context"
 	"errors"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/yashrahurikar23/goagents/core"
+	"github.com/yashrahurikar23/goagents/memory"
 	"github.com/yashrahurikar23/goagents/tests/mocks"
 )
 
@@ -68,11 +73,11 @@ func TestFunctionAgent_AddTool(t *testing.T) {
 		t.Fatalf("AddTool() error = %v", err)
 	}
 
-	if len(agent.tools) != 1 {
-		t.Errorf("len(agent.tools) = %d, want 1", len(agent.tools))
+	if len(agent.tools.snapshot()) != 1 {
+		t.Errorf("len(agent.tools) = %d, want 1", len(agent.tools.snapshot()))
 	}
 
-	if _, exists := agent.tools["calculator"]; !exists {
+	if _, exists := agent.tools.snapshot()["calculator"]; !exists {
 		t.Error("tool 'calculator' not found in agent.tools")
 	}
 }
@@ -136,11 +141,11 @@ func TestFunctionAgent_Reset(t *testing.T) {
 	agent := NewFunctionAgent(llm)
 
 	// Add some messages
-	agent.messages = append(agent.messages, core.UserMessage("Hello"))
-	agent.messages = append(agent.messages, core.AssistantMessage("Hi there"))
+	agent.history.messages = append(agent.history.messages, core.UserMessage("Hello"))
+	agent.history.messages = append(agent.history.messages, core.AssistantMessage("Hi there"))
 
-	if len(agent.messages) != 2 {
-		t.Fatalf("len(messages) = %d, want 2", len(agent.messages))
+	if len(agent.history.messages) != 2 {
+		t.Fatalf("len(messages) = %d, want 2", len(agent.history.messages))
 	}
 
 	err := agent.Reset()
@@ -149,12 +154,12 @@ func TestFunctionAgent_Reset(t *testing.T) {
 	}
 
 	// Should have system prompt
-	if len(agent.messages) != 1 {
-		t.Errorf("len(messages) after reset = %d, want 1 (system prompt)", len(agent.messages))
+	if len(agent.history.messages) != 1 {
+		t.Errorf("len(messages) after reset = %d, want 1 (system prompt)", len(agent.history.messages))
 	}
 
-	if agent.messages[0].Role != "system" {
-		t.Errorf("first message role = %q, want \"system\"", agent.messages[0].Role)
+	if agent.history.messages[0].Role != "system" {
+		t.Errorf("first message role = %q, want \"system\"", agent.history.messages[0].Role)
 	}
 }
 
@@ -164,8 +169,8 @@ func TestFunctionAgent_GetMessages(t *testing.T) {
 	agent := NewFunctionAgent(llm)
 
 	// Add some messages
-	agent.messages = append(agent.messages, core.UserMessage("Test 1"))
-	agent.messages = append(agent.messages, core.AssistantMessage("Response 1"))
+	agent.history.messages = append(agent.history.messages, core.UserMessage("Test 1"))
+	agent.history.messages = append(agent.history.messages, core.AssistantMessage("Response 1"))
 
 	messages := agent.GetMessages()
 
@@ -178,92 +183,491 @@ func TestFunctionAgent_GetMessages(t *testing.T) {
 	}
 }
 
-// TestFunctionAgent_Run_RequiresOpenAI tests that Run() requires OpenAI client.
-func TestFunctionAgent_Run_RequiresOpenAI(t *testing.T) {
-	// Use generic mock LLM (not OpenAI)
-	llm := mocks.NewMockLLM()
+// TestFunctionAgent_Run_NoToolCalls tests a run where the LLM answers directly.
+func TestFunctionAgent_Run_NoToolCalls(t *testing.T) {
+	llm := mocks.NewMockLLM().WithChatResponse("Hello there", nil)
 	agent := NewFunctionAgent(llm)
 
 	ctx := context.Background()
-	_, err := agent.Run(ctx, "Hello")
+	resp, err := agent.Run(ctx, "Hello")
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
 
-	if err == nil {
-		t.Fatal("Run() expected error for non-OpenAI LLM, got nil")
+	if resp.Content != "Hello there" {
+		t.Errorf("resp.Content = %q, want %q", resp.Content, "Hello there")
 	}
 
-	expectedMsg := "FunctionAgent requires an LLM that supports function calling (OpenAI)"
-	if err.Error() != expectedMsg {
-		t.Errorf("error message = %q, want %q", err.Error(), expectedMsg)
+	if resp.Meta["iterations"] != 1 {
+		t.Errorf("iterations = %v, want 1", resp.Meta["iterations"])
 	}
 }
 
-// TestFunctionAgent_ConvertToolsToFunctions tests basic tool to function conversion.
-func TestFunctionAgent_ConvertToolsToFunctions(t *testing.T) {
-	llm := mocks.NewMockLLM()
-	agent := NewFunctionAgent(llm)
+// TestFunctionAgent_Run_WithToolCalls tests the full tool calling loop
+// against a provider-agnostic LLM.
+func TestFunctionAgent_Run_WithToolCalls(t *testing.T) {
+	llm := mocks.NewMockLLM().WithSequentialChatResponses(
+		[]*core.Response{
+			{
+				ToolCalls: []core.ToolCall{
+					{ID: "call_1", Name: "calculator", Args: map[string]interface{}{"a": 25.0, "b": 4.0}},
+				},
+			},
+			{Content: "The answer is 100"},
+		},
+		nil,
+	)
 
-	// Add a simple tool
 	tool := mocks.NewMockTool("calculator", "Performs arithmetic")
+	tool.ExecuteFunc = func(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+		return 100, nil
+	}
+
+	agent := NewFunctionAgent(llm)
 	agent.AddTool(tool)
 
-	functions := agent.convertToolsToFunctions()
+	resp, err := agent.Run(context.Background(), "What is 25 * 4?")
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
 
-	if len(functions) != 1 {
-		t.Fatalf("len(functions) = %d, want 1", len(functions))
+	if resp.Content != "The answer is 100" {
+		t.Errorf("resp.Content = %q, want %q", resp.Content, "The answer is 100")
 	}
 
-	fn := functions[0]
-	if fn.Type != "function" {
-		t.Errorf("function.Type = %q, want \"function\"", fn.Type)
+	if tool.CallCount() != 1 {
+		t.Errorf("tool call count = %d, want 1", tool.CallCount())
 	}
 
-	if fn.Function == nil {
-		t.Fatal("function.Function is nil")
+	// Tools should be advertised to the LLM on every call
+	calls := llm.GetChatCalls()
+	if len(calls) != 2 {
+		t.Fatalf("len(chat calls) = %d, want 2", len(calls))
+	}
+	if len(calls[0].Tools) != 1 || calls[0].Tools[0].Name() != "calculator" {
+		t.Errorf("tools passed to LLM = %v, want [calculator]", calls[0].Tools)
 	}
 
-	if fn.Function.Name != "calculator" {
-		t.Errorf("function.Name = %q, want \"calculator\"", fn.Function.Name)
+	// History: system, user, assistant(tool calls), tool, assistant
+	messages := agent.GetMessages()
+	if len(messages) != 5 {
+		t.Fatalf("len(messages) = %d, want 5", len(messages))
+	}
+
+	if len(messages[2].ToolCalls) != 1 || messages[2].ToolCalls[0].ID != "call_1" {
+		t.Errorf("assistant tool calls = %v, want call_1", messages[2].ToolCalls)
 	}
 
-	if fn.Function.Description != "Performs arithmetic" {
-		t.Errorf("function.Description = %q, want \"Performs arithmetic\"", fn.Function.Description)
+	if messages[3].Role != "tool" || messages[3].ToolCallID != "call_1" || messages[3].Content != "100" {
+		t.Errorf("tool message = %+v, want tool result for call_1", messages[3])
 	}
 }
 
-// TestFunctionAgent_ConvertToOpenAIMessages tests message conversion.
-func TestFunctionAgent_ConvertToOpenAIMessages(t *testing.T) {
-	llm := mocks.NewMockLLM()
+// TestFunctionAgent_Run_UnknownTool tests that unknown tools are reported back to the LLM.
+func TestFunctionAgent_Run_UnknownTool(t *testing.T) {
+	llm := mocks.NewMockLLM().WithSequentialChatResponses(
+		[]*core.Response{
+			{ToolCalls: []core.ToolCall{{ID: "call_1", Name: "missing"}}},
+			{Content: "Sorry"},
+		},
+		nil,
+	)
+
 	agent := NewFunctionAgent(llm)
 
-	messages := []core.Message{
-		core.SystemMessage("System prompt"),
-		core.UserMessage("Hello"),
-		core.AssistantMessage("Hi"),
-		{
-			Role:       "tool",
-			Content:    "42",
-			Name:       "calculator",
-			ToolCallID: "call_123",
+	if _, err := agent.Run(context.Background(), "Do something"); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	messages := agent.GetMessages()
+	toolMsg := messages[3]
+	if toolMsg.Role != "tool" || toolMsg.Content != "Error: tool 'missing' not found" {
+		t.Errorf("tool message = %+v, want not found error", toolMsg)
+	}
+}
+
+// calculatorSchema declares two required numbers.
+func calculatorSchema() *core.ToolSchema {
+	return &core.ToolSchema{
+		Name: "calculator",
+		Parameters: []core.Parameter{
+			{Name: "a", Type: "number", Required: true},
+			{Name: "b", Type: "number", Required: true},
 		},
 	}
+}
+
+// TestFunctionAgent_Run_InvalidToolArgs tests that invalid arguments are fed
+// back to the LLM without executing the tool, and valid ones are coerced.
+func TestFunctionAgent_Run_InvalidToolArgs(t *testing.T) {
+	llm := mocks.NewMockLLM().WithSequentialChatResponses(
+		[]*core.Response{
+			{ToolCalls: []core.ToolCall{{ID: "call_1", Name: "calculator", Args: map[string]interface{}{"a": "ten"}}}},
+			{ToolCalls: []core.ToolCall{{ID: "call_2", Name: "calculator", Args: map[string]interface{}{"a": "10", "b": 4.0}}}},
+			{Content: "14"},
+		},
+		nil,
+	)
+
+	tool := mocks.NewMockTool("calculator", "Adds numbers").WithSchema(calculatorSchema()).WithExecuteResult(14)
+
+	agent := NewFunctionAgent(llm)
+	agent.AddTool(tool)
+
+	if _, err := agent.Run(context.Background(), "What is 10 + 4?"); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	calls := tool.GetCalls()
+	if len(calls) != 1 {
+		t.Fatalf("tool call count = %d, want 1", len(calls))
+	}
+	if calls[0].Args["a"] != 10.0 {
+		t.Errorf("tool args = %v, want a coerced to 10", calls[0].Args)
+	}
+
+	// History: system, user, assistant, tool (invalid), assistant, tool, assistant
+	feedback := agent.GetMessages()[3]
+	for _, want := range []string{`"invalid_arguments"`, `"argument":"a"`, `"argument":"b"`} {
+		if !strings.Contains(feedback.Content, want) {
+			t.Errorf("tool message = %q, want it to contain %s", feedback.Content, want)
+		}
+	}
+}
+
+// TestFunctionAgent_Run_MaxIterations tests the iteration limit.
+func TestFunctionAgent_Run_MaxIterations(t *testing.T) {
+	llm := mocks.NewMockLLM().WithChatResponse("", []core.ToolCall{{ID: "call_1", Name: "calculator"}})
+
+	agent := NewFunctionAgent(llm, WithMaxIterations(2))
+	agent.AddTool(mocks.NewMockTool("calculator", "Performs arithmetic"))
+
+	_, err := agent.Run(context.Background(), "Loop forever")
+	if err == nil {
+		t.Fatal("Run() expected max iterations error, got nil")
+	}
+
+	if llm.ChatCallCount() != 2 {
+		t.Errorf("ChatCallCount() = %d, want 2", llm.ChatCallCount())
+	}
+}
+
+// TestFunctionAgent_Run_LLMError tests LLM failure propagation.
+func TestFunctionAgent_Run_LLMError(t *testing.T) {
+	llm := mocks.NewMockLLM().WithChatError(errors.New("API error"))
+	agent := NewFunctionAgent(llm)
+
+	_, err := agent.Run(context.Background(), "Hello")
+	if err == nil {
+		t.Fatal("Run() expected error, got nil")
+	}
+}
 
-	openaiMsgs := agent.convertToOpenAIMessages(messages)
+// TestFunctionAgent_RunStream_WithToolCalls tests streaming tool events.
+func TestFunctionAgent_RunStream_WithToolCalls(t *testing.T) {
+	llm := mocks.NewMockLLM().WithSequentialChatResponses(
+		[]*core.Response{
+			{ToolCalls: []core.ToolCall{{ID: "call_1", Name: "calculator", Args: map[string]interface{}{}}}},
+			{Content: "Done"},
+		},
+		nil,
+	)
+
+	agent := NewFunctionAgent(llm)
+	agent.AddTool(mocks.NewMockTool("calculator", "Performs arithmetic"))
+
+	stream, err := agent.RunStream(context.Background(), "Calculate")
+	if err != nil {
+		t.Fatalf("RunStream() error = %v", err)
+	}
 
-	if len(openaiMsgs) != 4 {
-		t.Fatalf("len(openaiMsgs) = %d, want 4", len(openaiMsgs))
+	var types []string
+	for event := range stream {
+		types = append(types, event.Type)
 	}
 
-	// Check system message
-	if openaiMsgs[0].Role != "system" {
-		t.Errorf("openaiMsgs[0].Role = %q, want \"system\"", openaiMsgs[0].Role)
+	want := []string{core.EventTypeToolStart, core.EventTypeToolEnd, core.EventTypeToken, core.EventTypeComplete}
+	if len(types) != len(want) {
+		t.Fatalf("event types = %v, want %v", types, want)
+	}
+	for i := range want {
+		if types[i] != want[i] {
+			t.Errorf("event[%d] = %q, want %q", i, types[i], want[i])
+		}
 	}
+}
 
-	// Check tool message
-	if openaiMsgs[3].Role != "tool" {
-		t.Errorf("openaiMsgs[3].Role = %q, want \"tool\"", openaiMsgs[3].Role)
+// TestFunctionAgent_Run_Usage tests usage totals and cost tracking across iterations.
+func TestFunctionAgent_Run_Usage(t *testing.T) {
+	meta := map[string]interface{}{"model": "gpt-4o-mini"}
+	llm := mocks.NewMockLLM().WithSequentialChatResponses(
+		[]*core.Response{
+			{
+				ToolCalls: []core.ToolCall{{ID: "call_1", Name: "calculator", Args: map[string]interface{}{}}},
+				Usage:     core.NewUsage(100, 10),
+				Meta:      meta,
+			},
+			{Content: "Done", Usage: core.NewUsage(150, 20), Meta: meta},
+		},
+		nil,
+	)
+
+	tracker := core.NewCostTracker(nil)
+	agent := NewFunctionAgent(llm, WithCostTracker(tracker))
+	agent.AddTool(mocks.NewMockTool("calculator", "Performs arithmetic"))
+
+	ctx := core.ContextWithSessionID(context.Background(), "tenant-1")
+	resp, err := agent.Run(ctx, "Calculate")
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
 	}
 
-	if openaiMsgs[3].ToolCallID != "call_123" {
-		t.Errorf("openaiMsgs[3].ToolCallID = %q, want \"call_123\"", openaiMsgs[3].ToolCallID)
+	if resp.Usage == nil || resp.Usage.PromptTokens != 250 || resp.Usage.CompletionTokens != 30 || resp.Usage.TotalTokens != 280 {
+		t.Errorf("resp.Usage = %+v, want 250/30/280", resp.Usage)
+	}
+
+	runID, _ := resp.Meta["run_id"].(string)
+	if runID == "" {
+		t.Fatal("resp.Meta[run_id] is empty")
+	}
+
+	run := tracker.Run(runID)
+	if run.Calls != 2 || run.Usage.TotalTokens != 280 || run.Cost <= 0 {
+		t.Errorf("tracker.Run() = %+v, want 2 priced calls", run)
+	}
+
+	if session := tracker.Session("tenant-1"); session != run {
+		t.Errorf("tracker.Session() = %+v, want %+v", session, run)
+	}
+}
+
+// parallelToolCalls returns an LLM that requests the given tools in one turn and then answers.
+func parallelToolCalls(names ...string) *mocks.MockLLM {
+	calls := make([]core.ToolCall, len(names))
+	for i, name := range names {
+		calls[i] = core.ToolCall{ID: "call_" + name, Name: name, Args: map[string]interface{}{}}
+	}
+	return mocks.NewMockLLM().WithSequentialChatResponses(
+		[]*core.Response{{ToolCalls: calls}, {Content: "Done"}},
+		nil,
+	)
+}
+
+// TestFunctionAgent_Run_ParallelTools tests that tool calls run concurrently
+// and that results keep the order of the calls.
+func TestFunctionAgent_Run_ParallelTools(t *testing.T) {
+	names := []string{"a", "b", "c"}
+	llm := parallelToolCalls(names...)
+	agent := NewFunctionAgent(llm)
+
+	// Every tool waits until all of them have started, which only completes
+	// if they run at the same time. Later tools finish first.
+	var started sync.WaitGroup
+	started.Add(len(names))
+	for i, name := range names {
+		delay := time.Duration(len(names)-i) * 5 * time.Millisecond
+		result := "result_" + name
+		tool := mocks.NewMockTool(name, "Looks something up")
+		tool.ExecuteFunc = func(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+			started.Done()
+			started.Wait()
+			time.Sleep(delay)
+			return result, nil
+		}
+		agent.AddTool(tool)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		if _, err := agent.Run(context.Background(), "Look up everything"); err != nil {
+			t.Errorf("Run() error = %v", err)
+		}
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("tool calls did not run concurrently")
+	}
+
+	// History: system, user, assistant(tool calls), tool a, tool b, tool c, assistant
+	messages := agent.GetMessages()
+	if len(messages) != 7 {
+		t.Fatalf("len(messages) = %d, want 7", len(messages))
+	}
+	for i, name := range names {
+		msg := messages[3+i]
+		if msg.ToolCallID != "call_"+name || msg.Content != "result_"+name {
+			t.Errorf("tool message %d = %+v, want result for call_%s", i, msg, name)
+		}
+	}
+}
+
+// TestFunctionAgent_Run_MaxParallelTools tests the concurrency limit.
+func TestFunctionAgent_Run_MaxParallelTools(t *testing.T) {
+	names := []string{"a", "b", "c", "d"}
+	agent := NewFunctionAgent(parallelToolCalls(names...), WithMaxParallelTools(2))
+
+	var running, peak int32
+	for _, name := range names {
+		tool := mocks.NewMockTool(name, "Looks something up")
+		tool.ExecuteFunc = func(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+			n := atomic.AddInt32(&running, 1)
+			for {
+				p := atomic.LoadInt32(&peak)
+				if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+					break
+				}
+			}
+			time.Sleep(10 * time.Millisecond)
+			atomic.AddInt32(&running, -1)
+			return "ok", nil
+		}
+		agent.AddTool(tool)
+	}
+
+	if _, err := agent.Run(context.Background(), "Look up everything"); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	if peak := atomic.LoadInt32(&peak); peak > 2 {
+		t.Errorf("peak concurrency = %d, want at most 2", peak)
+	}
+}
+
+// TestFunctionAgent_Run_ToolTimeout tests per-tool timeouts.
+func TestFunctionAgent_Run_ToolTimeout(t *testing.T) {
+	agent := NewFunctionAgent(
+		parallelToolCalls("slow", "fast"),
+		WithToolTimeout(time.Second),
+		WithToolTimeoutFor("slow", 20*time.Millisecond),
+	)
+
+	release := make(chan struct{})
+	defer close(release)
+
+	slow := mocks.NewMockTool("slow", "Never returns in time")
+	slow.ExecuteFunc = func(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+		<-release // Ignores ctx on purpose
+		return "late", nil
+	}
+	agent.AddTool(slow)
+	agent.AddTool(mocks.NewMockTool("fast", "Returns at once").WithExecuteResult("fast result"))
+
+	if _, err := agent.Run(context.Background(), "Go"); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	messages := agent.GetMessages()
+	if got := messages[3].Content; !strings.Contains(got, "timed out") {
+		t.Errorf("slow tool result = %q, want timeout error", got)
+	}
+	if got := messages[4].Content; got != "fast result" {
+		t.Errorf("fast tool result = %q, want %q", got, "fast result")
+	}
+
+	var timeoutErr *core.ErrTimeout
+	if !errors.As(messages[2].ToolCalls[0].Error, &timeoutErr) {
+		t.Errorf("slow tool call error = %v, want *core.ErrTimeout", messages[2].ToolCalls[0].Error)
+	}
+}
+
+// TestFunctionAgent_RunStream_ParallelToolEvents tests that tool_start events
+// are emitted as calls start, before any of them has finished.
+func TestFunctionAgent_RunStream_ParallelToolEvents(t *testing.T) {
+	agent := NewFunctionAgent(parallelToolCalls("a", "b"))
+
+	var started sync.WaitGroup
+	started.Add(2)
+	for _, name := range []string{"a", "b"} {
+		tool := mocks.NewMockTool(name, "Looks something up")
+		tool.ExecuteFunc = func(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+			started.Done()
+			started.Wait()
+			return nil, errors.New("lookup failed")
+		}
+		agent.AddTool(tool)
+	}
+
+	stream, err := agent.RunStream(context.Background(), "Go")
+	if err != nil {
+		t.Fatalf("RunStream() error = %v", err)
+	}
+
+	var types []string
+	for event := range stream {
+		types = append(types, event.Type)
+		if event.Type == core.EventTypeToolEnd {
+			if event.Data["error"] == nil || event.Data["duration"] == nil {
+				t.Errorf("tool_end data = %v, want error and duration", event.Data)
+			}
+		}
+	}
+
+	want := []string{
+		core.EventTypeToolStart, core.EventTypeToolStart,
+		core.EventTypeToolEnd, core.EventTypeToolEnd,
+		core.EventTypeToken, core.EventTypeComplete,
+	}
+	if strings.Join(types, ",") != strings.Join(want, ",") {
+		t.Errorf("event types = %v, want %v", types, want)
+	}
+}
+
+// TestFunctionAgent_Run_ToolCancellation tests that cancelling the run stops tool execution.
+func TestFunctionAgent_Run_ToolCancellation(t *testing.T) {
+	agent := NewFunctionAgent(parallelToolCalls("wait"))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	tool := mocks.NewMockTool("wait", "Waits for cancellation")
+	tool.ExecuteFunc = func(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+		cancel()
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	agent.AddTool(tool)
+
+	_, err := agent.Run(ctx, "Go")
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Run() error = %v, want context.Canceled", err)
+	}
+}
+
+// TestFunctionAgent_Store tests that tool turns are saved and reloaded by a
+// new agent instance.
+func TestFunctionAgent_Store(t *testing.T) {
+	ctx := core.ContextWithSessionID(context.Background(), "s1")
+	store := memory.NewInMemoryStore()
+
+	llm := mocks.NewMockLLM().WithSequentialChatResponses(
+		[]*core.Response{
+			{ToolCalls: []core.ToolCall{{ID: "call_1", Name: "calculator", Args: map[string]interface{}{"a": 25.0}}}},
+			{Content: "The answer is 100"},
+		},
+		nil,
+	)
+	agent := NewFunctionAgent(llm, WithStore(store))
+	agent.AddTool(mocks.NewMockTool("calculator", "Performs arithmetic").WithExecuteResult(100))
+
+	if _, err := agent.Run(ctx, "What is 25 * 4?"); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	stored, _ := store.Load(ctx, "s1")
+	if len(stored) != 4 || stored[1].ToolCalls[0].ID != "call_1" || stored[2].ToolCallID != "call_1" {
+		t.Fatalf("stored = %+v, want user, tool call, tool result, answer", stored)
+	}
+
+	llm2 := mocks.NewMockLLM().WithChatResponse("You asked about 25 * 4", nil)
+	restarted := NewFunctionAgent(llm2, WithStore(store))
+	if _, err := restarted.Run(ctx, "What did I ask?"); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if sent := llm2.GetChatCalls()[0].Messages; len(sent) != 6 {
+		t.Errorf("len(messages sent) = %d, want 6 (system, stored run, question)", len(sent))
+	}
+	if stored, _ = store.Load(ctx, "s1"); len(stored) != 6 {
+		t.Errorf("len(stored) = %d, want 6", len(stored))
 	}
 }
@@ -0,0 +1,195 @@
+package agent
+
+import (
+	"context"
+
+	"github.com/yashrahurikar23/goagents/core"
+)
+
+// Hooks is called by agents at defined points of a run, to observe it or to
+// intercept its LLM and tool calls. Embed BaseHooks to implement only the
+// methods you need, and combine several with ChainHooks.
+//
+// Hooks of one agent are shared by all its runs, and the tool calls of a turn
+// run concurrently, so implementations must be safe for concurrent use. The
+// run's ID is on the context (core.RunIDFromContext).
+type Hooks interface {
+	// BeforeLLMCall is called before each LLM call and may rewrite
+	// call.Messages; the rewrite applies to this call only, not to the
+	// history. Returning an error fails the run.
+	BeforeLLMCall(ctx context.Context, call *LLMCall) error
+
+	// AfterLLMCall is called with each LLM response, which it may modify.
+	// When the response was streamed its tokens have already been emitted.
+	// Returning an error fails the run.
+	AfterLLMCall(ctx context.Context, call *LLMCall, resp *core.Response) error
+
+	// BeforeToolCall is called before each tool call and may modify
+	// call.Args. Returning an error vetoes the call: the tool does not run
+	// and the LLM is told the call was rejected (core.ErrToolCallRejected).
+	BeforeToolCall(ctx context.Context, call *core.ToolCall) error
+
+	// AfterToolCall is called once a tool call has finished, failed or been
+	// vetoed, and may rewrite call.Result (what the LLM sees) and call.Error.
+	AfterToolCall(ctx context.Context, call *core.ToolCall)
+
+	// OnError is called with the error a run fails with.
+	OnError(ctx context.Context, err error)
+
+	// OnFinish is called with the final response of a successful run. For
+	// streaming runs it is built from the complete event. It must not be
+	// modified.
+	OnFinish(ctx context.Context, resp *core.Response)
+}
+
+// LLMCall is an LLM request made by an agent.
+type LLMCall struct {
+	// Messages are the messages sent to the LLM.
+	Messages []core.Message
+
+	// Tools are the tools offered to the LLM. ReActAgent describes its tools
+	// in the system prompt instead.
+	Tools []core.Tool
+
+	// Iteration is the 1-based number of the call within the run.
+	Iteration int
+}
+
+// BaseHooks implements Hooks with methods that do nothing. Embed it in a
+// hook type to implement only some of the methods:
+//
+//	type auditHooks struct{ agent.BaseHooks }
+//
+//	func (auditHooks) BeforeToolCall(ctx context.Context, call *core.ToolCall) error {
+//		log.Printf("run %s calls %s(%v)", core.RunIDFromContext(ctx), call.Name, call.Args)
+//		return nil
+//	}
+type BaseHooks struct{}
+
+func (BaseHooks) BeforeLLMCall(context.Context, *LLMCall) error                { return nil }
+func (BaseHooks) AfterLLMCall(context.Context, *LLMCall, *core.Response) error { return nil }
+func (BaseHooks) BeforeToolCall(context.Context, *core.ToolCall) error         { return nil }
+func (BaseHooks) AfterToolCall(context.Context, *core.ToolCall)                {}
+func (BaseHooks) OnError(context.Context, error)                               {}
+func (BaseHooks) OnFinish(context.Context, *core.Response)                     {}
+
+// ChainHooks combines hooks into one, like a middleware chain: Before hooks
+// run in order and the first error stops the chain, while After, OnError and
+// OnFinish hooks run in reverse order, so the first hook sees the result last.
+func ChainHooks(hooks ...Hooks) Hooks {
+	return hookChain(nil).with(hooks...)
+}
+
+// hookChain is the hooks of an agent. The zero value does nothing.
+type hookChain []Hooks
+
+// with returns the chain followed by hooks, flattening nested chains and
+// dropping nil hooks.
+func (c hookChain) with(hooks ...Hooks) hookChain {
+	out := append(hookChain(nil), c...)
+	for _, h := range hooks {
+		switch h := h.(type) {
+		case nil:
+		case hookChain:
+			out = append(out, h...)
+		default:
+			out = append(out, h)
+		}
+	}
+	return out
+}
+
+func (c hookChain) BeforeLLMCall(ctx context.Context, call *LLMCall) error {
+	for _, h := range c {
+		if err := h.BeforeLLMCall(ctx, call); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c hookChain) AfterLLMCall(ctx context.Context, call *LLMCall, resp *core.Response) error {
+	for i := len(c) - 1; i >= 0; i-- {
+		if err := c[i].AfterLLMCall(ctx, call, resp); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c hookChain) BeforeToolCall(ctx context.Context, call *core.ToolCall) error {
+	for _, h := range c {
+		if err := h.BeforeToolCall(ctx, call); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c hookChain) AfterToolCall(ctx context.Context, call *core.ToolCall) {
+	for i := len(c) - 1; i >= 0; i-- {
+		c[i].AfterToolCall(ctx, call)
+	}
+}
+
+func (c hookChain) OnError(ctx context.Context, err error) {
+	for i := len(c) - 1; i >= 0; i-- {
+		c[i].OnError(ctx, err)
+	}
+}
+
+func (c hookChain) OnFinish(ctx context.Context, resp *core.Response) {
+	for i := len(c) - 1; i >= 0; i-- {
+		c[i].OnFinish(ctx, resp)
+	}
+}
+
+// finish reports the outcome of a run to OnError or OnFinish and returns it.
+func (c hookChain) finish(ctx context.Context, resp *core.Response, err error) (*core.Response, error) {
+	if err != nil {
+		c.OnError(ctx, err)
+	} else {
+		c.OnFinish(ctx, resp)
+	}
+	return resp, err
+}
+
+// observe reports the outcome of a streaming run as its error or complete
+// event is sent.
+func (c hookChain) observe(ctx context.Context, event core.StreamEvent) {
+	switch event.Type {
+	case core.EventTypeError:
+		c.OnError(ctx, event.Error)
+	case core.EventTypeComplete:
+		resp := &core.Response{Content: event.Content, Meta: event.Data}
+		if usage, ok := event.Data["usage"].(core.Usage); ok {
+			resp.Usage = &usage
+		}
+		c.OnFinish(ctx, resp)
+	}
+}
+
+// callLLM runs the LLM call hooks around do, which sends the call.
+func (c hookChain) callLLM(ctx context.Context, call *LLMCall, do func(call *LLMCall) (*core.Response, error)) (*core.Response, error) {
+	if err := c.BeforeLLMCall(ctx, call); err != nil {
+		return nil, err
+	}
+	resp, err := do(call)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.AfterLLMCall(ctx, call, resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// newLLMCall returns the call of an iteration. The messages are copied so
+// hooks can rewrite them without touching the history.
+func newLLMCall(messages []core.Message, tools []core.Tool, iteration int) *LLMCall {
+	return &LLMCall{
+		Messages:  append([]core.Message(nil), messages...),
+		Tools:     tools,
+		Iteration: iteration,
+	}
+}
@@ -0,0 +1,400 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/yashrahurikar23/goagents/core"
+	"github.com/yashrahurikar23/goagents/tests/mocks"
+)
+
+// testHooks records the hook calls it receives and runs the optional
+// functions set on it.
+type testHooks struct {
+	BaseHooks
+	beforeLLM  func(call *LLMCall) error
+	afterLLM   func(resp *core.Response) error
+	beforeTool func(call *core.ToolCall) error
+	afterTool  func(call *core.ToolCall)
+
+	mu       sync.Mutex
+	events   []string
+	errs     []error
+	finished []*core.Response
+}
+
+func (h *testHooks) record(event string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.events = append(h.events, event)
+}
+
+func (h *testHooks) BeforeLLMCall(ctx context.Context, call *LLMCall) error {
+	h.record(fmt.Sprintf("before_llm:%d", call.Iteration))
+	if h.beforeLLM != nil {
+		return h.beforeLLM(call)
+	}
+	return nil
+}
+
+func (h *testHooks) AfterLLMCall(ctx context.Context, call *LLMCall, resp *core.Response) error {
+	h.record(fmt.Sprintf("after_llm:%d", call.Iteration))
+	if h.afterLLM != nil {
+		return h.afterLLM(resp)
+	}
+	return nil
+}
+
+func (h *testHooks) BeforeToolCall(ctx context.Context, call *core.ToolCall) error {
+	h.record("before_tool:" + call.Name)
+	if h.beforeTool != nil {
+		return h.beforeTool(call)
+	}
+	return nil
+}
+
+func (h *testHooks) AfterToolCall(ctx context.Context, call *core.ToolCall) {
+	h.record("after_tool:" + call.Name)
+	if h.afterTool != nil {
+		h.afterTool(call)
+	}
+}
+
+func (h *testHooks) OnError(ctx context.Context, err error) {
+	h.record("error")
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.errs = append(h.errs, err)
+}
+
+func (h *testHooks) OnFinish(ctx context.Context, resp *core.Response) {
+	h.record("finish")
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.finished = append(h.finished, resp)
+}
+
+// logHooks appends its name and the hook to a shared log.
+type logHooks struct {
+	BaseHooks
+	name string
+	log  *[]string
+	err  error // returned by BeforeLLMCall
+}
+
+func (h logHooks) BeforeLLMCall(ctx context.Context, call *LLMCall) error {
+	*h.log = append(*h.log, h.name+".before")
+	return h.err
+}
+
+func (h logHooks) AfterLLMCall(ctx context.Context, call *LLMCall, resp *core.Response) error {
+	*h.log = append(*h.log, h.name+".after")
+	return nil
+}
+
+func (h logHooks) OnFinish(ctx context.Context, resp *core.Response) {
+	*h.log = append(*h.log, h.name+".finish")
+}
+
+// TestChainHooks tests that chained hooks run like middleware.
+func TestChainHooks(t *testing.T) {
+	var log []string
+	chain := ChainHooks(
+		logHooks{name: "a", log: &log},
+		nil,
+		ChainHooks(logHooks{name: "b", log: &log}, logHooks{name: "c", log: &log}),
+	)
+
+	call := &LLMCall{}
+	chain.BeforeLLMCall(context.Background(), call)
+	chain.AfterLLMCall(context.Background(), call, &core.Response{})
+	chain.OnFinish(context.Background(), &core.Response{})
+
+	want := "a.before b.before c.before c.after b.after a.after c.finish b.finish a.finish"
+	if got := strings.Join(log, " "); got != want {
+		t.Errorf("hook order = %q, want %q", got, want)
+	}
+
+	// The first error stops the chain
+	log = nil
+	stop := errors.New("stop")
+	chain = ChainHooks(logHooks{name: "a", log: &log, err: stop}, logHooks{name: "b", log: &log})
+	if err := chain.BeforeLLMCall(context.Background(), call); !errors.Is(err, stop) {
+		t.Errorf("BeforeLLMCall() error = %v, want %v", err, stop)
+	}
+	if got := strings.Join(log, " "); got != "a.before" {
+		t.Errorf("hooks run = %q, want only a.before", got)
+	}
+}
+
+// TestFunctionAgent_Hooks tests that hooks can rewrite the LLM request, the
+// tool arguments and the tool result.
+func TestFunctionAgent_Hooks(t *testing.T) {
+	llm := mocks.NewMockLLM()
+	llm.WithSequentialChatResponses([]*core.Response{
+		{ToolCalls: []core.ToolCall{{ID: "call_1", Name: "lookup", Args: map[string]interface{}{"key": "x"}}}},
+		{Content: "Done"},
+	}, nil)
+	tool := mocks.NewMockTool("lookup", "Looks up a key").WithExecuteResult("secret")
+
+	hooks := &testHooks{
+		beforeLLM: func(call *LLMCall) error {
+			call.Messages = append(call.Messages, core.SystemMessage("Be careful."))
+			return nil
+		},
+		beforeTool: func(call *core.ToolCall) error {
+			call.Args["key"] = "y"
+			return nil
+		},
+		afterTool: func(call *core.ToolCall) {
+			call.Result = "[redacted]"
+		},
+	}
+
+	agent := NewFunctionAgent(llm, WithHooks(hooks))
+	agent.AddTool(tool)
+
+	resp, err := agent.Run(context.Background(), "Look up x")
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	want := "before_llm:1 after_llm:1 before_tool:lookup after_tool:lookup before_llm:2 after_llm:2 finish"
+	if got := strings.Join(hooks.events, " "); got != want {
+		t.Errorf("hook calls = %q, want %q", got, want)
+	}
+
+	calls := llm.GetChatCalls()
+	if last := calls[0].Messages[len(calls[0].Messages)-1]; last.Content != "Be careful." {
+		t.Errorf("LLM received %+v last, want the message added by the hook", last)
+	}
+	for _, msg := range agent.GetMessages() {
+		if msg.Content == "Be careful." {
+			t.Error("message added by BeforeLLMCall was kept in the history")
+		}
+	}
+
+	if got := tool.GetCalls()[0].Args["key"]; got != "y" {
+		t.Errorf("tool called with key = %v, want y", got)
+	}
+	second := calls[1].Messages
+	if result := second[len(second)-2]; result.Role != "tool" || result.Content != "[redacted]" {
+		t.Errorf("tool result sent to LLM = %+v, want the rewritten result", result)
+	}
+
+	if len(hooks.finished) != 1 || hooks.finished[0] != resp {
+		t.Errorf("OnFinish received %v, want the response", hooks.finished)
+	}
+}
+
+// TestFunctionAgent_Hooks_VetoTool tests that BeforeToolCall can stop a call.
+func TestFunctionAgent_Hooks_VetoTool(t *testing.T) {
+	llm := mocks.NewMockLLM()
+	llm.WithSequentialChatResponses([]*core.Response{
+		{ToolCalls: []core.ToolCall{{ID: "call_1", Name: "delete_all"}}},
+		{Content: "I was not allowed to."},
+	}, nil)
+	tool := mocks.NewMockTool("delete_all", "Deletes everything")
+
+	denied := errors.New("not allowed")
+	var afterErr error
+	hooks := &testHooks{
+		beforeTool: func(call *core.ToolCall) error { return denied },
+		afterTool:  func(call *core.ToolCall) { afterErr = call.Error },
+	}
+
+	agent := NewFunctionAgent(llm, WithHooks(hooks))
+	agent.AddTool(tool)
+
+	if _, err := agent.Run(context.Background(), "Delete everything"); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	if tool.CallCount() != 0 {
+		t.Errorf("vetoed tool ran %d times", tool.CallCount())
+	}
+	var rejected *core.ErrToolCallRejected
+	if !errors.As(afterErr, &rejected) || !errors.Is(afterErr, denied) {
+		t.Errorf("AfterToolCall error = %v, want ErrToolCallRejected wrapping the veto", afterErr)
+	}
+
+	second := llm.GetChatCalls()[1].Messages
+	if result := second[len(second)-1]; !strings.Contains(result.Content, "rejected: not allowed") {
+		t.Errorf("tool result sent to LLM = %q, want the rejection", result.Content)
+	}
+}
+
+// TestFunctionAgent_Hooks_Errors tests that failed runs are reported to
+// OnError, and that BeforeLLMCall can stop a run.
+func TestFunctionAgent_Hooks_Errors(t *testing.T) {
+	blocked := errors.New("blocked")
+	hooks := &testHooks{beforeLLM: func(call *LLMCall) error { return blocked }}
+
+	llm := mocks.NewMockLLM()
+	agent := NewFunctionAgent(llm, WithHooks(hooks))
+
+	if _, err := agent.Run(context.Background(), "Hi"); !errors.Is(err, blocked) {
+		t.Fatalf("Run() error = %v, want %v", err, blocked)
+	}
+	if llm.ChatCallCount() != 0 {
+		t.Errorf("LLM called %d times after BeforeLLMCall failed", llm.ChatCallCount())
+	}
+	if len(hooks.errs) != 1 || !errors.Is(hooks.errs[0], blocked) || len(hooks.finished) != 0 {
+		t.Errorf("OnError received %v and OnFinish %v, want one error", hooks.errs, hooks.finished)
+	}
+
+	// Streaming runs report the error event
+	hooks = &testHooks{}
+	agent = NewFunctionAgent(mocks.NewMockLLM().WithChatError(errors.New("unavailable")), WithHooks(hooks))
+	stream, err := agent.RunStream(context.Background(), "Hi")
+	if err != nil {
+		t.Fatalf("RunStream() error = %v", err)
+	}
+	for range stream {
+	}
+	if len(hooks.errs) != 1 || !strings.Contains(hooks.errs[0].Error(), "unavailable") {
+		t.Errorf("OnError received %v, want the LLM error", hooks.errs)
+	}
+}
+
+// TestConversationalAgent_Hooks_RunStream tests that AfterLLMCall sees the
+// streamed reply and its rewrite reaches the history and complete event.
+func TestConversationalAgent_Hooks_RunStream(t *testing.T) {
+	llm := &streamingReActLLM{MockLLM: mocks.NewMockLLM(), responses: []string{"hello there"}}
+	hooks := &testHooks{
+		afterLLM: func(resp *core.Response) error {
+			resp.Content = strings.ToUpper(resp.Content)
+			return nil
+		},
+	}
+	agent := NewConversationalAgent(llm, ConvWithHooks(hooks))
+
+	stream, err := agent.RunStream(context.Background(), "Hi")
+	if err != nil {
+		t.Fatalf("RunStream() error = %v", err)
+	}
+	var complete core.StreamEvent
+	for event := range stream {
+		if event.Type == core.EventTypeComplete {
+			complete = event
+		}
+	}
+
+	if complete.Content != "HELLO THERE" {
+		t.Errorf("complete event = %q, want the rewritten reply", complete.Content)
+	}
+	if messages := agent.GetMessages(); messages[len(messages)-1].Content != "HELLO THERE" {
+		t.Errorf("history ends with %+v, want the rewritten reply", messages[len(messages)-1])
+	}
+	if want := "before_llm:1 after_llm:1 finish"; strings.Join(hooks.events, " ") != want {
+		t.Errorf("hook calls = %v, want %q", hooks.events, want)
+	}
+	if finished := hooks.finished[0]; finished.Content != "HELLO THERE" || finished.Usage == nil {
+		t.Errorf("OnFinish received %+v, want the reply with its usage", finished)
+	}
+}
+
+// TestConversationalAgent_Hooks_PromptedTools tests the hooks with tools
+// offered through the prompt.
+func TestConversationalAgent_Hooks_PromptedTools(t *testing.T) {
+	mock := mocks.NewMockLLM()
+	mock.WithSequentialChatResponses([]*core.Response{
+		{Content: `{"tool_calls": [{"name": "lookup", "arguments": {"key": "x"}}]}`},
+		{Content: "The value is 42."},
+	}, nil)
+	tool := mocks.NewMockTool("lookup", "Looks up a key").WithExecuteResult("42")
+
+	var offered []core.Tool
+	hooks := &testHooks{
+		beforeLLM: func(call *LLMCall) error {
+			offered = call.Tools
+			return nil
+		},
+	}
+	agent := NewConversationalAgent(plainLLM{mock}, ConvWithHooks(hooks))
+	agent.AddTool(tool)
+
+	if _, err := agent.Run(context.Background(), "What is x?"); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	want := "before_llm:1 after_llm:1 before_tool:lookup after_tool:lookup before_llm:2 after_llm:2 finish"
+	if got := strings.Join(hooks.events, " "); got != want {
+		t.Errorf("hook calls = %q, want %q", got, want)
+	}
+	if len(offered) != 1 || offered[0].Name() != "lookup" {
+		t.Errorf("LLMCall.Tools = %v, want the lookup tool", offered)
+	}
+}
+
+// TestReActAgent_Hooks tests the tool call hooks on ReAct actions.
+func TestReActAgent_Hooks(t *testing.T) {
+	llm := mocks.NewMockLLM()
+	llm.WithSequentialChatResponses([]*core.Response{
+		{Content: "Thought: Look it up\nAction: lookup(key=x)"},
+		{Content: "Thought: Done\nFinal Answer: found"},
+	}, nil)
+	tool := mocks.NewMockTool("lookup", "Looks up a key").WithExecuteResult("secret")
+
+	hooks := &testHooks{
+		beforeTool: func(call *core.ToolCall) error {
+			if call.ID != "step_1" {
+				t.Errorf("call.ID = %q, want step_1", call.ID)
+			}
+			call.Args["key"] = "y"
+			return nil
+		},
+		afterTool: func(call *core.ToolCall) {
+			call.Result = "[redacted]"
+		},
+	}
+	agent := NewReActAgent(llm, ReActWithHooks(hooks))
+	agent.AddTool(tool)
+
+	resp, err := agent.Run(context.Background(), "Look up x")
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	want := "before_llm:1 after_llm:1 before_tool:lookup after_tool:lookup before_llm:2 after_llm:2 finish"
+	if got := strings.Join(hooks.events, " "); got != want {
+		t.Errorf("hook calls = %q, want %q", got, want)
+	}
+	if got := tool.GetCalls()[0].Args["key"]; got != "y" {
+		t.Errorf("tool called with key = %v, want y", got)
+	}
+
+	trace := resp.Meta["trace"].([]ReActStep)
+	if trace[0].Observation != "[redacted]" || trace[0].ActionInput["key"] != "y" {
+		t.Errorf("trace[0] = %+v, want the rewritten arguments and result", trace[0])
+	}
+}
+
+// TestReActAgent_Hooks_VetoTool tests that a vetoed action is observed as rejected.
+func TestReActAgent_Hooks_VetoTool(t *testing.T) {
+	llm := mocks.NewMockLLM()
+	llm.WithSequentialChatResponses([]*core.Response{
+		{Content: "Thought: Delete it\nAction: delete_all()"},
+		{Content: "Thought: Not allowed\nFinal Answer: I could not."},
+	}, nil)
+	tool := mocks.NewMockTool("delete_all", "Deletes everything")
+
+	hooks := &testHooks{beforeTool: func(call *core.ToolCall) error { return errors.New("not allowed") }}
+	agent := NewReActAgent(llm, ReActWithHooks(hooks))
+	agent.AddTool(tool)
+
+	resp, err := agent.Run(context.Background(), "Delete everything")
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if tool.CallCount() != 0 {
+		t.Errorf("vetoed tool ran %d times", tool.CallCount())
+	}
+	if obs := resp.Meta["trace"].([]ReActStep)[0].Observation; !strings.Contains(obs, "rejected: not allowed") {
+		t.Errorf("observation = %q, want the rejection", obs)
+	}
+}
@@ -0,0 +1,541 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/yashrahurikar23/goagents/core"
+)
+
+// PlanExecuteAgent implements the plan-and-execute pattern. A planner LLM
+// first breaks the task into an explicit list of steps; an executor agent
+// then carries them out one at a time. After each step the planner sees the
+// results so far and either gives the final answer or revises the steps that
+// remain, so a failed step or a surprising result changes the plan instead
+// of derailing the run.
+//
+// Compared to ReActAgent, which decides one action at a time, committing to
+// a plan keeps long tasks on track. Any core.Agent can execute the steps,
+// typically a FunctionAgent or ReActAgent with tools:
+//
+//	executor := agent.NewFunctionAgent(llm)
+//	executor.AddTool(tools.NewHTTPTool())
+//
+//	planner := agent.NewPlanExecuteAgent(llm, executor)
+//	response, err := planner.Run(ctx, "Compare the latest releases of Go and Rust")
+//
+// Each step runs as one Run (or RunStream) of the executor, with a prompt
+// holding the task, the results of the previous steps and the step to do.
+// The executor keeps whatever state it has between steps and runs.
+//
+// A PlanExecuteAgent is safe for concurrent use if its executor is.
+type PlanExecuteAgent struct {
+	planner       core.LLM
+	executor      core.Agent
+	plannerPrompt string
+	maxSteps      int
+	costTracker   *core.CostTracker
+
+	hooks hookChain
+}
+
+// PlanStep is an executed step of a plan.
+type PlanStep struct {
+	// Number is the 1-based position of the step in the run.
+	Number int
+
+	// Task is the step as planned.
+	Task string
+
+	// Result is the executor's answer; empty when the step failed.
+	Result string
+
+	// Error is set when the executor failed.
+	Error error
+
+	// Duration is how long the step took.
+	Duration time.Duration
+}
+
+// PlanExecuteOption configures a PlanExecuteAgent.
+type PlanExecuteOption func(*PlanExecuteAgent)
+
+// PlanExecWithPlannerPrompt replaces the planner's system prompt. The planner
+// must still reply with the JSON described in the default prompt.
+func PlanExecWithPlannerPrompt(prompt string) PlanExecuteOption {
+	return func(a *PlanExecuteAgent) {
+		a.plannerPrompt = prompt
+	}
+}
+
+// PlanExecWithMaxSteps sets the maximum number of steps a run executes,
+// across all plan revisions (default 10).
+func PlanExecWithMaxSteps(max int) PlanExecuteOption {
+	return func(a *PlanExecuteAgent) {
+		a.maxSteps = max
+	}
+}
+
+// PlanExecWithCostTracker records the token usage and cost of the planner's
+// LLM calls. The executor runs under the same run ID, so a tracker given to
+// the executor too attributes its calls to the same run.
+func PlanExecWithCostTracker(tracker *core.CostTracker) PlanExecuteOption {
+	return func(a *PlanExecuteAgent) {
+		a.costTracker = tracker
+	}
+}
+
+// PlanExecWithHooks adds hooks that are called as the agent runs. The LLM
+// call hooks see the planner's calls; give the executor its own hooks to see
+// its LLM and tool calls. Hooks added by several options form one chain, in
+// order; see ChainHooks.
+func PlanExecWithHooks(hooks ...Hooks) PlanExecuteOption {
+	return func(a *PlanExecuteAgent) {
+		a.hooks = a.hooks.with(hooks...)
+	}
+}
+
+// NewPlanExecuteAgent creates a plan-and-execute agent that plans with
+// planner and executes each step with executor.
+func NewPlanExecuteAgent(planner core.LLM, executor core.Agent, opts ...PlanExecuteOption) *PlanExecuteAgent {
+	agent := &PlanExecuteAgent{
+		planner:       planner,
+		executor:      executor,
+		plannerPrompt: defaultPlannerPrompt,
+		maxSteps:      10,
+	}
+
+	for _, opt := range opts {
+		opt(agent)
+	}
+
+	return agent
+}
+
+// AddTool registers a tool with the executor.
+func (a *PlanExecuteAgent) AddTool(tool core.Tool) error {
+	return a.executor.AddTool(tool)
+}
+
+// Reset resets the executor.
+func (a *PlanExecuteAgent) Reset() error {
+	return a.executor.Reset()
+}
+
+// Run plans the task, executes the plan step by step and returns the
+// planner's final answer. The response's Meta holds the executed steps
+// ("steps", a []PlanStep) and the number of plan revisions ("revisions").
+//
+// The response's Usage is the total of the planner's and the executor's
+// LLM calls, as far as the executor reports it.
+func (a *PlanExecuteAgent) Run(ctx context.Context, input string) (*core.Response, error) {
+	ctx, usage := startRun(ctx, a.costTracker)
+
+	resp, err := a.run(ctx, input, usage, nil)
+	return a.hooks.finish(ctx, resp, err)
+}
+
+// RunStream executes the agent with streaming and emits events in real-time.
+//
+// Events emitted:
+// - "plan": The initial plan and each revision, with the remaining "steps" and the "revision" number
+// - "step_start": When a step starts, with its "step" number
+// - "step_end": When a step finishes, with its "step" number, "result" and "error" if it failed
+// - executor events: When the executor implements core.StreamingAgent, its
+// token, thought, tool and approval events, each with the "step" number
+// - "answer": The final answer
+// - "complete": When execution finishes (with the run's total "usage")
+// - "error": If an error occurs
+func (a *PlanExecuteAgent) RunStream(ctx context.Context, input string) (<-chan core.StreamEvent, error) {
+	ctx, usage := startRun(ctx, a.costTracker)
+	eventChan := make(chan core.StreamEvent, 10)
+
+	go func() {
+		defer close(eventChan)
+
+		emit := func(event core.StreamEvent) bool {
+			a.hooks.observe(ctx, event)
+			select {
+			case eventChan <- event:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		if _, err := a.run(ctx, input, usage, emit); err != nil {
+			emit(core.NewErrorEvent(err))
+		}
+	}()
+
+	return eventChan, nil
+}
+
+// run is the plan-and-execute loop shared by Run and RunStream. When emit is
+// non-nil it emits the plan, step and executor events, ending with the
+// answer and complete events.
+func (a *PlanExecuteAgent) run(ctx context.Context, input string, usage *runUsage, emit func(core.StreamEvent) bool) (*core.Response, error) {
+	calls := 0
+	ask := func(content string, revising bool) (planReply, error) {
+		return a.plan(ctx, content, revising, &calls, usage)
+	}
+
+	reply, err := ask(fmt.Sprintf("Objective: %s", input), false)
+	if err != nil {
+		return nil, err
+	}
+
+	var steps []PlanStep
+	remaining := reply.Steps
+	revision := 0
+	if len(remaining) > 0 && emit != nil && !emit(planEvent(remaining, revision, 0)) {
+		return nil, ctx.Err()
+	}
+
+	answer := reply.Answer
+	for answer == "" && len(remaining) > 0 {
+		if len(steps) >= a.maxSteps {
+			return nil, fmt.Errorf("max steps (%d) reached without final answer", a.maxSteps)
+		}
+
+		step, err := a.execute(ctx, input, steps, remaining[0], usage, emit)
+		if err != nil {
+			return nil, err
+		}
+		steps = append(steps, step)
+
+		// Let the planner revise the rest of the plan, or answer
+		reply, err = ask(replanPrompt(input, steps, remaining[1:]), true)
+		if err != nil {
+			return nil, err
+		}
+		answer = reply.Answer
+
+		if answer == "" && !equalSteps(reply.Steps, remaining[1:]) {
+			revision++
+			if len(reply.Steps) > 0 && emit != nil && !emit(planEvent(reply.Steps, revision, len(steps))) {
+				return nil, ctx.Err()
+			}
+		}
+		remaining = reply.Steps
+	}
+
+	if answer == "" {
+		// The planner has no steps left but gave no answer: the last
+		// result is the answer
+		if len(steps) == 0 {
+			return nil, errors.New("planner returned neither steps nor an answer")
+		}
+		last := steps[len(steps)-1]
+		if last.Error != nil {
+			return nil, fmt.Errorf("step %d failed: %w", last.Number, last.Error)
+		}
+		answer = last.Result
+	}
+
+	meta := usage.eventData()
+	meta["steps"] = steps
+	meta["revisions"] = revision
+
+	if emit != nil {
+		if emit(core.NewStreamEvent(core.EventTypeAnswer, answer)) {
+			emit(core.NewStreamEventWithData(core.EventTypeComplete, answer, meta))
+		}
+	}
+
+	return &core.Response{
+		Content: answer,
+		Usage:   usage.total,
+		Meta:    meta,
+	}, nil
+}
+
+// plan asks the planner for the plan, or a revision of it, through the LLM
+// call hooks; calls counts the planner calls of the run. A reply that cannot
+// be read is sent back with the expected format, as core.ChatStructured does,
+// up to core.DefaultStructuredRetries times.
+//
+// Revisions must be JSON: once steps have run, a list is as likely to be the
+// final answer as a new plan. A revision that is still not JSON after the
+// retries is taken as the final answer, so the completed steps are not lost.
+func (a *PlanExecuteAgent) plan(ctx context.Context, content string, revising bool, calls *int, usage *runUsage) (planReply, error) {
+	messages := []core.Message{
+		core.SystemMessage(a.plannerPrompt),
+		core.UserMessage(content),
+	}
+
+	for attempt := 0; ; attempt++ {
+		*calls++
+		call := newLLMCall(messages, nil, *calls)
+		resp, err := a.hooks.callLLM(ctx, call, func(call *LLMCall) (*core.Response, error) {
+			resp, err := a.planner.Chat(ctx, call.Messages)
+			if err != nil {
+				return nil, err
+			}
+			usage.addResponse(resp)
+			return resp, nil
+		})
+		if err != nil {
+			return planReply{}, fmt.Errorf("planner call failed: %w", err)
+		}
+
+		var reply planReply
+		if revising {
+			reply, err = parsePlanJSON(resp.Content)
+		} else {
+			reply, err = parsePlan(resp.Content)
+		}
+		switch {
+		case err == nil:
+			return reply, nil
+		case attempt < core.DefaultStructuredRetries:
+			messages = append(messages, core.AssistantMessage(resp.Content), core.UserMessage(planFeedback(err)))
+		case revising:
+			return planReply{Answer: strings.TrimSpace(resp.Content)}, nil
+		default:
+			return planReply{}, err
+		}
+	}
+}
+
+// planFeedback builds the re-prompt sent after a reply that is not a plan.
+func planFeedback(err error) string {
+	return fmt.Sprintf("Your reply could not be read: %v\n"+
+		`Reply again with only the JSON: {"steps": [...]} with the steps that remain, `+
+		`or {"answer": "<the final answer for the user>"} if the objective has been achieved.`, err)
+}
+
+// execute runs one step with the executor. A failed step is returned with
+// its Error set; the returned error is only set when ctx has ended.
+func (a *PlanExecuteAgent) execute(ctx context.Context, input string, done []PlanStep, task string, usage *runUsage, emit func(core.StreamEvent) bool) (PlanStep, error) {
+	step := PlanStep{Number: len(done) + 1, Task: task}
+
+	if emit != nil && !emit(core.NewStreamEventWithData(core.EventTypeStepStart, task, map[string]interface{}{
+		"step": step.Number,
+	})) {
+		return step, ctx.Err()
+	}
+
+	start := time.Now()
+	var resp *core.Response
+	var err error
+	if streaming, ok := a.executor.(core.StreamingAgent); ok && emit != nil {
+		resp, err = a.executeStream(ctx, streaming, stepPrompt(input, done, task), step.Number, emit)
+	} else {
+		resp, err = a.executor.Run(ctx, stepPrompt(input, done, task))
+	}
+	step.Duration = time.Since(start)
+
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return step, ctxErr
+	}
+	if err != nil {
+		step.Error = err
+	} else {
+		step.Result = resp.Content
+		if resp.Usage != nil {
+			usage.addExecuted(*resp.Usage)
+		}
+	}
+
+	if emit != nil {
+		data := map[string]interface{}{
+			"step":     step.Number,
+			"result":   step.Result,
+			"duration": step.Duration,
+		}
+		content := step.Result
+		if step.Error != nil {
+			data["error"] = step.Error.Error()
+			content = fmt.Sprintf("Error: %v", step.Error)
+		}
+		if !emit(core.NewStreamEventWithData(core.EventTypeStepEnd, content, data)) {
+			return step, ctx.Err()
+		}
+	}
+	return step, nil
+}
+
+// executeStream runs a step with a streaming executor, forwarding its
+// progress events tagged with the step number. Its answer and complete
+// events become the step's result.
+func (a *PlanExecuteAgent) executeStream(ctx context.Context, executor core.StreamingAgent, prompt string, number int, emit func(core.StreamEvent) bool) (*core.Response, error) {
+	events, err := executor.RunStream(ctx, prompt)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp *core.Response
+	var runErr error
+	for event := range events {
+		switch event.Type {
+		case core.EventTypeComplete:
+			resp = &core.Response{Content: event.Content, Meta: event.Data}
+			if usage, ok := event.Data["usage"].(core.Usage); ok {
+				resp.Usage = &usage
+			}
+		case core.EventTypeError:
+			runErr = event.Error
+		case core.EventTypeAnswer:
+		default:
+			data := make(map[string]interface{}, len(event.Data)+1)
+			for k, v := range event.Data {
+				data[k] = v
+			}
+			data["step"] = number
+			event.Data = data
+			emit(event)
+		}
+	}
+
+	switch {
+	case runErr != nil:
+		return nil, runErr
+	case resp == nil:
+		return nil, errors.New("executor stream ended without a result")
+	}
+	return resp, nil
+}
+
+// planReply is the planner's reply: the steps that remain, or the answer.
+type planReply struct {
+	Steps  []string `json:"steps"`
+	Answer string   `json:"answer"`
+}
+
+// planListRe matches a numbered or bulleted list item.
+var planListRe = regexp.MustCompile(`^\s*(?:\d+[.)]|[-*•])\s+(.+)$`)
+
+// parsePlan decodes the planner's first reply. Models that answer with a
+// plain numbered or bulleted list instead of JSON are understood too.
+func parsePlan(content string) (planReply, error) {
+	reply, err := parsePlanJSON(content)
+	if err == nil {
+		return reply, nil
+	}
+
+	for _, line := range strings.Split(content, "\n") {
+		if m := planListRe.FindStringSubmatch(line); m != nil {
+			reply.Steps = append(reply.Steps, m[1])
+		}
+	}
+	if len(reply.Steps) == 0 {
+		return planReply{}, err
+	}
+	return cleanPlan(reply), nil
+}
+
+// parsePlanJSON decodes a planner reply holding a JSON object, or a bare
+// JSON array of steps.
+func parsePlanJSON(content string) (planReply, error) {
+	var reply planReply
+
+	raw := core.ExtractJSON(content)
+	if err := json.Unmarshal([]byte(raw), &reply); err != nil {
+		// A bare JSON array of steps
+		reply = planReply{}
+		if err := json.Unmarshal([]byte(raw), &reply.Steps); err != nil {
+			return planReply{}, fmt.Errorf("could not parse the planner's reply: %q", content)
+		}
+	}
+	return cleanPlan(reply), nil
+}
+
+// cleanPlan trims the steps and answer of a reply, dropping empty steps.
+func cleanPlan(reply planReply) planReply {
+	steps := reply.Steps[:0]
+	for _, step := range reply.Steps {
+		if step = strings.TrimSpace(step); step != "" {
+			steps = append(steps, step)
+		}
+	}
+	reply.Steps = steps
+	reply.Answer = strings.TrimSpace(reply.Answer)
+	return reply
+}
+
+// equalSteps reports whether two plans have the same steps.
+func equalSteps(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// planEvent builds the plan event of a plan revision. Steps are the ones that
+// remain after the completed ones.
+func planEvent(steps []string, revision, completed int) core.StreamEvent {
+	return core.NewStreamEventWithData(core.EventTypePlan, formatSteps(steps, completed), map[string]interface{}{
+		"steps":     append([]string(nil), steps...),
+		"revision":  revision,
+		"completed": completed,
+	})
+}
+
+// formatSteps numbers steps as a list, starting after the completed ones.
+func formatSteps(steps []string, completed int) string {
+	var sb strings.Builder
+	for i, step := range steps {
+		fmt.Fprintf(&sb, "%d. %s\n", completed+i+1, step)
+	}
+	return strings.TrimSuffix(sb.String(), "\n")
+}
+
+// formatResults lists executed steps with their results.
+func formatResults(steps []PlanStep) string {
+	var sb strings.Builder
+	for _, step := range steps {
+		fmt.Fprintf(&sb, "%d. %s\n", step.Number, step.Task)
+		if step.Error != nil {
+			fmt.Fprintf(&sb, "   Failed: %v\n", step.Error)
+		} else {
+			fmt.Fprintf(&sb, "   Result: %s\n", step.Result)
+		}
+	}
+	return strings.TrimSuffix(sb.String(), "\n")
+}
+
+// stepPrompt is the executor's input for a step.
+func stepPrompt(input string, done []PlanStep, task string) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "You are carrying out one step of a plan for this objective: %s\n\n", input)
+	if len(done) > 0 {
+		fmt.Fprintf(&sb, "Steps completed so far:\n%s\n\n", formatResults(done))
+	}
+	fmt.Fprintf(&sb, "Current step: %s\n\nComplete only the current step and reply with its result.", task)
+	return sb.String()
+}
+
+// replanPrompt asks the planner to revise the plan after a step.
+func replanPrompt(input string, done []PlanStep, remaining []string) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Objective: %s\n\n", input)
+	fmt.Fprintf(&sb, "Steps completed so far:\n%s\n\n", formatResults(done))
+	if len(remaining) > 0 {
+		fmt.Fprintf(&sb, "Remaining plan:\n%s\n\n", formatSteps(remaining, len(done)))
+	} else {
+		sb.WriteString("Remaining plan: (none)\n\n")
+	}
+	sb.WriteString(`If the objective has been achieved, reply with {"answer": "<the final answer for the user>"}. ` +
+		`Otherwise reply with {"steps": [...]} listing only the steps that remain, ` +
+		`revised to account for the results and failures so far.`)
+	return sb.String()
+}
+
+// defaultPlannerPrompt is the planner's system prompt.
+const defaultPlannerPrompt = `You are a planner. Break the user's objective into a short list of concrete steps that an assistant with tools can carry out one at a time. Each step must make sense on its own and produce a result that later steps can use. Do not include steps for things that need no work.
+
+Respond only with JSON, without any other text:
+{"steps": ["first step", "second step"]}
+
+If the objective needs no steps at all, respond with {"answer": "<the answer>"} instead.`
@@ -0,0 +1,300 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/yashrahurikar23/goagents/core"
+	"github.com/yashrahurikar23/goagents/tests/mocks"
+)
+
+// TestParsePlan tests the planner reply formats that are understood.
+func TestParsePlan(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    planReply
+		wantErr bool
+	}{
+		{"json", `{"steps": ["Search", " Summarize ", ""]}`, planReply{Steps: []string{"Search", "Summarize"}}, false},
+		{"fenced json", "Here is the plan:\n```json\n{\"steps\": [\"Search\"]}\n```", planReply{Steps: []string{"Search"}}, false},
+		{"answer", `{"answer": " 42 "}`, planReply{Steps: []string{}, Answer: "42"}, false},
+		{"json array", `["Search", "Summarize"]`, planReply{Steps: []string{"Search", "Summarize"}}, false},
+		{"numbered list", "Plan:\n1. Search\n2) Summarize", planReply{Steps: []string{"Search", "Summarize"}}, false},
+		{"bulleted list", "- Search\n* Summarize", planReply{Steps: []string{"Search", "Summarize"}}, false},
+		{"prose", "I would search first.", planReply{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parsePlan(tt.content)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parsePlan() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if len(got.Steps) == 0 && len(tt.want.Steps) == 0 {
+				got.Steps, tt.want.Steps = nil, nil
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parsePlan() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+// plannerLLM replies with the given contents in order.
+func plannerLLM(replies ...string) *mocks.MockLLM {
+	responses := make([]*core.Response, len(replies))
+	for i, reply := range replies {
+		responses[i] = &core.Response{Content: reply, Usage: core.NewUsage(10, 5)}
+	}
+	return mocks.NewMockLLM().WithSequentialChatResponses(responses, nil)
+}
+
+// currentStep returns the step an executor prompt asks for.
+func currentStep(messages []core.Message) string {
+	prompt := lastUserMessage(messages)
+	step := prompt[strings.Index(prompt, "Current step: ")+len("Current step: "):]
+	return step[:strings.Index(step, "\n")]
+}
+
+// stepExecutor returns an executor that answers each step with "did <step>",
+// failing the steps listed in fail.
+func stepExecutor(fail ...string) (*FunctionAgent, *mocks.MockLLM) {
+	llm := mocks.NewMockLLM()
+	llm.ChatFunc = func(ctx context.Context, messages []core.Message) (*core.Response, error) {
+		step := currentStep(messages)
+		for _, f := range fail {
+			if step == f {
+				return nil, errors.New("service unavailable")
+			}
+		}
+		return &core.Response{Content: "did " + step, Usage: core.NewUsage(3, 2)}, nil
+	}
+	return NewFunctionAgent(llm), llm
+}
+
+// TestPlanExecuteAgent_Run tests executing a plan step by step.
+func TestPlanExecuteAgent_Run(t *testing.T) {
+	planner := plannerLLM(
+		`{"steps": ["Find flights", "Book the cheapest"]}`,
+		`{"steps": ["Book the cheapest"]}`,
+		`{"answer": "Booked flight 42"}`,
+	)
+	executor, executorLLM := stepExecutor()
+	agent := NewPlanExecuteAgent(planner, executor)
+
+	resp, err := agent.Run(context.Background(), "Book a flight to Paris")
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	if resp.Content != "Booked flight 42" {
+		t.Errorf("Run() = %q, want the planner's answer", resp.Content)
+	}
+	steps := resp.Meta["steps"].([]PlanStep)
+	if len(steps) != 2 || steps[0].Result != "did Find flights" || steps[1].Number != 2 || steps[1].Task != "Book the cheapest" {
+		t.Errorf("steps = %+v, want both steps with their results", steps)
+	}
+	if resp.Meta["revisions"] != 0 {
+		t.Errorf("revisions = %v, want 0", resp.Meta["revisions"])
+	}
+	if resp.Usage.TotalTokens != 3*15+2*5 {
+		t.Errorf("usage = %+v, want the planner's and the executor's tokens", resp.Usage)
+	}
+
+	// The second step sees the result of the first
+	prompt := lastUserMessage(executorLLM.GetChatCalls()[1].Messages)
+	if !strings.Contains(prompt, "Book a flight to Paris") || !strings.Contains(prompt, "Result: did Find flights") {
+		t.Errorf("second step prompt = %q, want the objective and the first result", prompt)
+	}
+
+	// The planner sees the results when revising
+	replan := lastUserMessage(planner.GetChatCalls()[2].Messages)
+	if !strings.Contains(replan, "2. Book the cheapest\n   Result: did Book the cheapest") {
+		t.Errorf("replan prompt = %q, want the executed steps", replan)
+	}
+}
+
+// TestPlanExecuteAgent_ReplanOnFailure tests that the planner revises the
+// plan when a step fails.
+func TestPlanExecuteAgent_ReplanOnFailure(t *testing.T) {
+	planner := plannerLLM(
+		`{"steps": ["Call the API", "Summarize"]}`,
+		`{"steps": ["Scrape the website", "Summarize"]}`,
+		`{"steps": ["Summarize"]}`,
+		`{"answer": "Summary"}`,
+	)
+	executor, _ := stepExecutor("Call the API")
+	agent := NewPlanExecuteAgent(planner, executor)
+
+	resp, err := agent.Run(context.Background(), "Summarize the news")
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	steps := resp.Meta["steps"].([]PlanStep)
+	var tasks []string
+	for _, step := range steps {
+		tasks = append(tasks, step.Task)
+	}
+	if want := []string{"Call the API", "Scrape the website", "Summarize"}; !reflect.DeepEqual(tasks, want) {
+		t.Errorf("executed %v, want %v", tasks, want)
+	}
+	if steps[0].Error == nil || steps[1].Error != nil {
+		t.Errorf("steps = %+v, want only the first to fail", steps)
+	}
+	if resp.Meta["revisions"] != 1 {
+		t.Errorf("revisions = %v, want 1", resp.Meta["revisions"])
+	}
+
+	replan := lastUserMessage(planner.GetChatCalls()[1].Messages)
+	if !strings.Contains(replan, "Failed: LLM call failed: service unavailable") {
+		t.Errorf("replan prompt = %q, want the failure", replan)
+	}
+}
+
+// TestPlanExecuteAgent_RunStream tests the plan, step and executor events.
+func TestPlanExecuteAgent_RunStream(t *testing.T) {
+	planner := plannerLLM(
+		`{"steps": ["Search", "Compare"]}`,
+		`{"steps": ["Compare", "Report"]}`,
+		`{"steps": ["Report"]}`,
+		`{"answer": "Go wins"}`,
+	)
+	executor, _ := stepExecutor()
+	agent := NewPlanExecuteAgent(planner, executor)
+
+	stream, err := agent.RunStream(context.Background(), "Compare Go and Rust")
+	if err != nil {
+		t.Fatalf("RunStream() error = %v", err)
+	}
+
+	var types []string
+	var plans []core.StreamEvent
+	for event := range stream {
+		types = append(types, event.Type)
+		switch event.Type {
+		case core.EventTypePlan:
+			plans = append(plans, event)
+		case core.EventTypeToken, core.EventTypeStepStart, core.EventTypeStepEnd:
+			if _, ok := event.Data["step"].(int); !ok {
+				t.Errorf("%s event %+v has no step number", event.Type, event)
+			}
+		case core.EventTypeError:
+			t.Fatalf("error event: %v", event.Error)
+		}
+	}
+
+	step := "step_start token step_end"
+	want := strings.Join([]string{"plan", step, "plan", step, step, "answer", "complete"}, " ")
+	if got := strings.Join(types, " "); got != want {
+		t.Errorf("events = %q, want %q", got, want)
+	}
+
+	if len(plans) != 2 {
+		t.Fatalf("got %d plan events, want the plan and one revision", len(plans))
+	}
+	if plans[1].Content != "2. Compare\n3. Report" || plans[1].Data["revision"] != 1 || plans[1].Data["completed"] != 1 {
+		t.Errorf("revised plan event = %+v", plans[1])
+	}
+}
+
+// TestPlanExecuteAgent_Endings tests runs that end without a planned answer.
+func TestPlanExecuteAgent_Endings(t *testing.T) {
+	t.Run("direct answer", func(t *testing.T) {
+		executor, executorLLM := stepExecutor()
+		agent := NewPlanExecuteAgent(plannerLLM(`{"answer": "4"}`), executor)
+
+		resp, err := agent.Run(context.Background(), "What is 2+2?")
+		if err != nil || resp.Content != "4" {
+			t.Fatalf("Run() = %v, %v, want the planner's answer", resp, err)
+		}
+		if executorLLM.ChatCallCount() != 0 {
+			t.Error("executor ran for a question that needed no steps")
+		}
+	})
+
+	t.Run("last result", func(t *testing.T) {
+		executor, _ := stepExecutor()
+		agent := NewPlanExecuteAgent(plannerLLM(`{"steps": ["Look it up"]}`, `{"steps": []}`), executor)
+
+		resp, err := agent.Run(context.Background(), "Find the capital")
+		if err != nil || resp.Content != "did Look it up" {
+			t.Fatalf("Run() = %v, %v, want the last step's result", resp, err)
+		}
+	})
+
+	t.Run("max steps", func(t *testing.T) {
+		executor, _ := stepExecutor()
+		agent := NewPlanExecuteAgent(plannerLLM(`["Try"]`, `["Try"]`, `["Try"]`), executor, PlanExecWithMaxSteps(2))
+
+		if _, err := agent.Run(context.Background(), "Loop"); err == nil || !strings.Contains(err.Error(), "max steps (2)") {
+			t.Errorf("Run() error = %v, want max steps", err)
+		}
+	})
+
+	t.Run("unparseable plan", func(t *testing.T) {
+		executor, _ := stepExecutor()
+		agent := NewPlanExecuteAgent(plannerLLM("I am not sure."), executor)
+
+		if _, err := agent.Run(context.Background(), "Plan"); err == nil {
+			t.Error("Run() succeeded with an unparseable plan")
+		}
+	})
+}
+
+// TestPlanExecuteAgent_RevisionNotJSON tests revisions that are not JSON:
+// the planner is asked again for the format, and a reply that still is not
+// JSON is the final answer rather than a new plan or an error.
+func TestPlanExecuteAgent_RevisionNotJSON(t *testing.T) {
+	plan := `{"steps": ["Look up Go", "Look up Rust"]}`
+
+	t.Run("prose", func(t *testing.T) {
+		planner := plannerLLM(plan, "Go 1.23 is the latest release.", `{"answer": "Go 1.23"}`)
+		executor, executorLLM := stepExecutor()
+		agent := NewPlanExecuteAgent(planner, executor)
+
+		resp, err := agent.Run(context.Background(), "Find the latest Go release")
+		if err != nil {
+			t.Fatalf("Run() error = %v", err)
+		}
+		if resp.Content != "Go 1.23" || len(resp.Meta["steps"].([]PlanStep)) != 1 {
+			t.Errorf("Run() = %q, %+v, want the answer after one step", resp.Content, resp.Meta["steps"])
+		}
+		if executorLLM.ChatCallCount() != 1 {
+			t.Errorf("executor ran %d steps, want 1", executorLLM.ChatCallCount())
+		}
+
+		retry := planner.GetChatCalls()[2].Messages
+		if len(retry) != 4 || retry[2].Content != "Go 1.23 is the latest release." || !strings.Contains(retry[3].Content, `{"answer":`) {
+			t.Errorf("retry messages = %+v, want the reply and the expected format", retry)
+		}
+	})
+
+	t.Run("list", func(t *testing.T) {
+		list := "1. Go 1.23\n2. Rust 1.80"
+		planner := plannerLLM(plan, list, list, list)
+		executor, executorLLM := stepExecutor()
+		agent := NewPlanExecuteAgent(planner, executor)
+
+		resp, err := agent.Run(context.Background(), "Compare Go and Rust releases")
+		if err != nil {
+			t.Fatalf("Run() error = %v", err)
+		}
+		if resp.Content != list {
+			t.Errorf("Run() = %q, want the list as the answer", resp.Content)
+		}
+		if executorLLM.ChatCallCount() != 1 {
+			t.Errorf("executor ran %d steps, want only the planned one", executorLLM.ChatCallCount())
+		}
+		if planner.ChatCallCount() != 1+1+core.DefaultStructuredRetries {
+			t.Errorf("planner called %d times, want the plan, the revision and its retries", planner.ChatCallCount())
+		}
+	})
+}
@@ -2,11 +2,15 @@ package agent
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
-	"regexp"
+	"sort"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/yashrahurikar23/goagents/core"
+	"github.com/yashrahurikar23/goagents/memory"
 )
 
 // ReActAgent implements the ReAct (Reasoning + Acting) pattern.
@@ -39,14 +43,54 @@ import (
 //	// Observation: 100
 //	// Thought: I have the answer
 //	// Final Answer: 100
+//
+// With ReActWithActionFormat(ReActActionFormatJSON) actions are written as
+// "Action: calculator" followed by "Action Input: {...}" instead. Output that
+// cannot be parsed is answered with a "Format error" observation.
+//
+// A ReActAgent is safe for concurrent use. Each run builds its own trace,
+// returned in the response's Meta["trace"]; GetTrace returns the trace of
+// the run that finished last.
 type ReActAgent struct {
 	llm          core.LLM
-	tools        map[string]core.Tool
+	tools        *toolSet
 	systemPrompt string
+	actionFormat ReActActionFormat
 	maxIter      int
-	trace        []ReActStep // Reasoning trace for debugging
+	costTracker  *core.CostTracker
+
+	mu    sync.Mutex  // guards trace
+	trace []ReActStep // Reasoning trace of the last completed run, for debugging
+
+	// scratchpadTokens caps the estimated tokens of previous steps sent to the LLM (0 = no limit)
+	scratchpadTokens int
+	tokenizer        core.Tokenizer
+
+	hooks     hookChain
+	approvals approvals
+
+	checkpoints memory.CheckpointStore
 }
 
+// reactRun is the state of one ReActAgent run.
+type reactRun struct {
+	input      string
+	tools      map[string]core.Tool
+	scratchpad []core.Message // previous steps and their observations
+	trace      []ReActStep
+	iteration  int           // LLM calls made
+	pending    *reactPending // the last step, when its action has not run
+}
+
+// reactPending is a step whose action has not run yet.
+type reactPending struct {
+	response string
+	call     core.ToolCall
+}
+
+// reactStopSequence ends generation before the LLM writes its own observation.
+const reactStopSequence = "\nObservation:"
+
 // ReActStep represents one step in the reasoning trace.
 type ReActStep struct {
 	Iteration   int
@@ -66,6 +110,41 @@ func ReActWithSystemPrompt(prompt string) ReActAgentOption {
 	}
 }
 
+// ReActWithActionFormat sets the action grammar taught by the default system
+// prompt (default ReActActionFormatCall). ReActActionFormatJSON suits tools
+// with structured or typed arguments.
+func ReActWithActionFormat(format ReActActionFormat) ReActAgentOption {
+	return func(a *ReActAgent) {
+		a.actionFormat = format
+	}
+}
+
+// ReActWithTokenizer sets the tokenizer used to measure the scratchpad
+// (default core.ApproxTokenizer).
+func ReActWithTokenizer(tokenizer core.Tokenizer) ReActAgentOption {
+	return func(a *ReActAgent) {
+		a.tokenizer = tokenizer
+	}
+}
+
+// ReActWithScratchpadTokens limits the previous steps sent to the LLM to about
+// the given number of tokens. The oldest steps are dropped first; the question
+// and the latest step are always sent. 0 (the default) sends every step.
+func ReActWithScratchpadTokens(tokens int) ReActAgentOption {
+	return func(a *ReActAgent) {
+		a.scratchpadTokens = tokens
+	}
+}
+
+// ReActWithCostTracker records the token usage and cost of every LLM call the
+// agent makes. Calls are attributed to the run and to the session set on the
+// context with core.ContextWithSessionID.
+func ReActWithCostTracker(tracker *core.CostTracker) ReActAgentOption {
+	return func(a *ReActAgent) {
+		a.costTracker = tracker
+	}
+}
+
 // ReActWithMaxIterations sets maximum reasoning iterations.
 func ReActWithMaxIterations(max int) ReActAgentOption {
 	return func(a *ReActAgent) {
@@ -73,12 +152,48 @@ func ReActWithMaxIterations(max int) ReActAgentOption {
 	}
 }
 
+// ReActWithHooks adds hooks that are called as the agent runs. Hooks added by
+// several options form one chain, in order; see ChainHooks. Each action is
+// reported to the tool call hooks as a core.ToolCall with the ID "step_<n>".
+func ReActWithHooks(hooks ...Hooks) ReActAgentOption {
+	return func(a *ReActAgent) {
+		a.hooks = a.hooks.with(hooks...)
+	}
+}
+
+// ReActWithApprover sets who approves the actions that need approval; see
+// ReActWithApprovalFor.
+func ReActWithApprover(approver Approver) ReActAgentOption {
+	return func(a *ReActAgent) {
+		a.approvals.approver = approver
+	}
+}
+
+// ReActWithApprovalFor sets when actions using the named tool need approval
+// before they run. A rejected action is observed with the reason. Actions
+// that need approval are rejected when no approver is set.
+func ReActWithApprovalFor(name string, policy ApprovalPolicy) ReActAgentOption {
+	return func(a *ReActAgent) {
+		a.approvals.setPolicy(name, policy)
+	}
+}
+
+// ReActWithCheckpointStore saves a checkpoint of every run to store after
+// each step, so a run that stopped can be continued with Resume. The
+// checkpoint is kept under the run's ID (set it with core.ContextWithRunID to
+// know it in advance) and deleted once the run completes.
+func ReActWithCheckpointStore(store memory.CheckpointStore) ReActAgentOption {
+	return func(a *ReActAgent) {
+		a.checkpoints = store
+	}
+}
+
 // NewReActAgent creates a new ReAct agent that works with any LLM.
 func NewReActAgent(llm core.LLM, opts ...ReActAgentOption) *ReActAgent {
 	agent := &ReActAgent{
 		llm:          llm,
-		tools:        make(map[string]core.Tool),
-		systemPrompt: buildReActSystemPrompt(),
+		tools:        newToolSet(),
+		actionFormat: ReActActionFormatCall,
 		maxIter:      10,
 		trace:        make([]ReActStep, 0),
 	}
@@ -87,98 +202,32 @@ func NewReActAgent(llm core.LLM, opts ...ReActAgentOption) *ReActAgent {
 		opt(agent)
 	}
 
+	if agent.systemPrompt == "" {
+		agent.systemPrompt = buildReActSystemPrompt(agent.actionFormat)
+	}
+
 	return agent
 }
 
-// AddTool registers a tool that the agent can use.
+// AddTool registers a tool that the agent can use. Runs already in progress
+// keep the tools they started with.
 func (a *ReActAgent) AddTool(tool core.Tool) error {
-	if tool == nil {
-		return &core.ErrInvalidArgument{
-			Argument: "tool",
-			Reason:   "cannot be nil",
-		}
-	}
-
-	name := tool.Name()
-	if name == "" {
-		return &core.ErrInvalidArgument{
-			Argument: "tool.Name()",
-			Reason:   "cannot be empty",
-		}
-	}
-
-	if _, exists := a.tools[name]; exists {
-		return fmt.Errorf("tool %s already registered", name)
-	}
-
-	a.tools[name] = tool
-	return nil
+	return a.tools.add(tool)
 }
 
 // Run executes the agent with ReAct reasoning loop.
+//
+// Each step is a Chat call: the system message holds the instructions and
+// tools, the question is a user message, the LLM's steps are assistant
+// messages and each observation is a user message. Generation stops at
+// "Observation:" so the model cannot invent tool results.
+//
+// The response's Usage is the total across all LLM calls of the run.
 func (a *ReActAgent) Run(ctx context.Context, input string) (*core.Response, error) {
-	// Reset trace for this run
-	a.trace = make([]ReActStep, 0)
-
-	// Build initial prompt with tools and input
-	prompt := a.buildPrompt(input)
-
-	conversationHistory := prompt
-
-	// ReAct reasoning loop
-	for iteration := 0; iteration < a.maxIter; iteration++ {
-		step := ReActStep{
-			Iteration: iteration + 1,
-		}
+	ctx, usage := startRun(ctx, a.costTracker)
 
-		// Get LLM response
-		response, err := a.llm.Complete(ctx, conversationHistory)
-		if err != nil {
-			return nil, fmt.Errorf("LLM call failed: %w", err)
-		}
-
-		// Parse the response for Thought, Action, or Final Answer
-		thought, action, actionInput, finalAnswer := a.parseResponse(response)
-
-		step.Thought = thought
-
-		// Check if we have a final answer
-		if finalAnswer != "" {
-			a.trace = append(a.trace, step)
-			return &core.Response{
-				Content: finalAnswer,
-				Meta: map[string]interface{}{
-					"iterations": iteration + 1,
-					"trace":      a.trace,
-				},
-			}, nil
-		}
-
-		// Check if we have an action
-		if action == "" {
-			// No action and no final answer - prompt for next step
-			conversationHistory += "\n" + response + "\n"
-			continue
-		}
-
-		step.Action = action
-		step.ActionInput = actionInput
-
-		// Execute the action (tool)
-		observation, err := a.executeAction(ctx, action, actionInput)
-		if err != nil {
-			observation = fmt.Sprintf("Error: %v", err)
-		}
-
-		step.Observation = observation
-		a.trace = append(a.trace, step)
-
-		// Add observation to conversation
-		conversationHistory += fmt.Sprintf("\n%s\nObservation: %s\n", response, observation)
-	}
-
-	// Max iterations reached
-	return nil, fmt.Errorf("max iterations (%d) reached without final answer", a.maxIter)
+	resp, err := a.run(ctx, a.begin(input), usage, nil)
+	return a.hooks.finish(ctx, resp, err)
 }
 
 // RunStream executes the agent with streaming and emits events in real-time.
@@ -186,233 +235,422 @@ func (a *ReActAgent) Run(ctx context.Context, input string) (*core.Response, err
 // the agent's decision-making.
 //
 // Events emitted:
+// - "token": Tokens of each step as they are generated (if the LLM implements core.StreamingLLM)
 // - "thought": Each reasoning step
-// - "token": Tokens from LLM responses (if LLM supports streaming)
+// - "approval_required": When an action waits for approval (see ReActWithApprovalFor)
 // - "tool_start": When a tool is about to be executed
 // - "tool_end": When a tool finishes executing
 // - "answer": The final answer
-// - "complete": When execution finishes
+// - "complete": When execution finishes (with the run's total "usage")
 // - "error": If an error occurs
 func (a *ReActAgent) RunStream(ctx context.Context, input string) (<-chan core.StreamEvent, error) {
-	// Reset trace for this run
-	a.trace = make([]ReActStep, 0)
+	ctx, usage := startRun(ctx, a.costTracker)
+	return a.stream(ctx, a.begin(input), usage), nil
+}
+
+// Resume continues a run from its last checkpoint (see
+// ReActWithCheckpointStore): an action that had not run yet runs first, then
+// the reasoning goes on as in Run. The run keeps its ID, trace and usage so
+// far. Tools are the ones registered now.
+func (a *ReActAgent) Resume(ctx context.Context, runID string) (*core.Response, error) {
+	ctx, cp, err := resumeContext(ctx, a.checkpoints, runID, checkpointReAct)
+	if err != nil {
+		return nil, err
+	}
+	ctx, usage := startRun(ctx, a.costTracker)
+	usage.restore(cp.Usage)
 
-	// Check if LLM supports streaming (optional for ReAct)
-	streamingLLM, supportsStreaming := a.llm.(core.StreamingLLM)
+	resp, err := a.run(ctx, a.restore(cp), usage, nil)
+	return a.hooks.finish(ctx, resp, err)
+}
 
-	// Create event channel
-	eventChan := make(chan core.StreamEvent, 10)
+// ResumeStream is the streaming form of Resume; it emits the events of
+// RunStream.
+func (a *ReActAgent) ResumeStream(ctx context.Context, runID string) (<-chan core.StreamEvent, error) {
+	ctx, cp, err := resumeContext(ctx, a.checkpoints, runID, checkpointReAct)
+	if err != nil {
+		return nil, err
+	}
+	ctx, usage := startRun(ctx, a.costTracker)
+	usage.restore(cp.Usage)
+
+	return a.stream(ctx, a.restore(cp), usage), nil
+}
 
-	// Build initial prompt
-	prompt := a.buildPrompt(input)
+// stream runs the reasoning loop in a goroutine, sending its events on the
+// returned channel.
+func (a *ReActAgent) stream(ctx context.Context, r *reactRun, usage *runUsage) <-chan core.StreamEvent {
+	eventChan := make(chan core.StreamEvent, 10)
 
-	// Start execution in goroutine
 	go func() {
 		defer close(eventChan)
 
-		conversationHistory := prompt
-
-		// ReAct reasoning loop
-		for iteration := 0; iteration < a.maxIter; iteration++ {
-			step := ReActStep{
-				Iteration: iteration + 1,
+		emit := func(event core.StreamEvent) bool {
+			a.hooks.observe(ctx, event)
+			select {
+			case eventChan <- event:
+				return true
+			case <-ctx.Done():
+				return false
 			}
+		}
 
-			var response string
-			var err error
+		if _, err := a.run(ctx, r, usage, emit); err != nil {
+			emit(core.NewErrorEvent(err))
+		}
+	}()
 
-			// Get LLM response (with or without streaming)
-			if supportsStreaming {
-				// Stream the LLM response
-				chunkChan, err := streamingLLM.CompleteStream(ctx, conversationHistory)
-				if err != nil {
-					select {
-					case eventChan <- core.NewErrorEvent(fmt.Errorf("LLM call failed: %w", err)):
-					case <-ctx.Done():
-					}
-					return
-				}
+	return eventChan
+}
 
-				// Collect response and emit tokens
-				for chunk := range chunkChan {
-					if chunk.Error != nil {
-						select {
-						case eventChan <- core.NewErrorEvent(chunk.Error):
-						case <-ctx.Done():
-						}
-						return
-					}
+// begin starts the state of a run.
+func (a *ReActAgent) begin(input string) *reactRun {
+	return &reactRun{
+		input: input,
+		tools: a.tools.snapshot(),
+		trace: make([]ReActStep, 0),
+	}
+}
 
-					// Emit token events
-					if chunk.Delta != "" {
-						tokenEvent := core.NewStreamEventWithData(
-							core.EventTypeToken,
-							chunk.Delta,
-							map[string]interface{}{
-								"index":     chunk.Index,
-								"iteration": iteration + 1,
-							},
-						)
-						select {
-						case eventChan <- tokenEvent:
-						case <-ctx.Done():
-							return
-						}
-					}
+// run is the reasoning loop shared by Run, RunStream and the resume methods.
+// A checkpoint is saved once a step's action is known and again once it has
+// been observed. When emit is non-nil it streams each step and emits progress
+// events, ending with the answer and complete events; it returns false once
+// the consumer has gone away.
+func (a *ReActAgent) run(ctx context.Context, r *reactRun, usage *runUsage, emit func(core.StreamEvent) bool) (*core.Response, error) {
+	system, question := a.buildMessages(r.input, r.tools)
 
-					response = chunk.Content
-				}
-			} else {
-				// Non-streaming fallback
-				response, err = a.llm.Complete(ctx, conversationHistory)
-				if err != nil {
-					select {
-					case eventChan <- core.NewErrorEvent(fmt.Errorf("LLM call failed: %w", err)):
-					case <-ctx.Done():
-					}
-					return
-				}
+	// ReAct reasoning loop
+	for {
+		var response string
+		var pending *core.ToolCall
+
+		if r.pending != nil {
+			// Resume the step whose action has not run yet
+			response, pending = r.pending.response, &r.pending.call
+			r.pending = nil
+		} else {
+			if r.iteration >= a.maxIter {
+				// Max iterations reached
+				return nil, fmt.Errorf("max iterations (%d) reached without final answer", a.maxIter)
 			}
 
-			// Parse the response for Thought, Action, or Final Answer
-			thought, action, actionInput, finalAnswer := a.parseResponse(response)
-
-			// Emit thought event
-			if thought != "" {
-				step.Thought = thought
-				thoughtEvent := core.NewStreamEventWithData(
-					core.EventTypeThought,
-					thought,
-					map[string]interface{}{
-						"iteration": iteration + 1,
-					},
-				)
-				select {
-				case eventChan <- thoughtEvent:
-				case <-ctx.Done():
-					return
-				}
+			messages := append([]core.Message{system}, a.trimScratchpad(question, r.scratchpad)...)
+			var err error
+			response, err = a.step(ctx, messages, r.iteration, usage, emit)
+			if err != nil {
+				return nil, err
 			}
+			r.iteration++
+		}
 
-			// Check if we have a final answer
-			if finalAnswer != "" {
-				a.trace = append(a.trace, step)
+		iteration := r.iteration
+		step := ReActStep{
+			Iteration: iteration,
+		}
+
+		// Parse the response for Thought, Action, or Final Answer
+		out := a.parse(response)
+		step.Thought = out.thought
+
+		if out.thought != "" && emit != nil {
+			if !emit(core.NewStreamEventWithData(core.EventTypeThought, out.thought, map[string]interface{}{
+				"iteration": iteration,
+			})) {
+				return nil, ctx.Err()
+			}
+		}
 
-				// Emit answer event
-				answerEvent := core.NewStreamEvent(core.EventTypeAnswer, finalAnswer)
-				select {
-				case eventChan <- answerEvent:
-				case <-ctx.Done():
-					return
+		// Check if we have a final answer
+		if out.finalAnswer != "" {
+			r.trace = append(r.trace, step)
+			a.mu.Lock()
+			a.trace = r.trace
+			a.mu.Unlock()
+
+			meta := usage.eventData()
+			meta["iterations"] = iteration
+			meta["trace"] = r.trace
+			deleteCheckpoint(ctx, a.checkpoints, usage.runID(), meta)
+
+			if emit != nil {
+				if emit(core.NewStreamEvent(core.EventTypeAnswer, out.finalAnswer)) {
+					emit(core.NewStreamEventWithData(core.EventTypeComplete, out.finalAnswer, meta))
 				}
+			}
+
+			return &core.Response{
+				Content: out.finalAnswer,
+				Usage:   usage.total,
+				Meta:    meta,
+			}, nil
+		}
+
+		step.Action = out.action
+		step.ActionInput = out.actionInput
 
-				// Emit complete event
-				completeEvent := core.NewStreamEventWithData(
-					core.EventTypeComplete,
-					finalAnswer,
-					map[string]interface{}{
-						"iterations": iteration + 1,
-						"trace":      a.trace,
-					},
-				)
-				select {
-				case eventChan <- completeEvent:
-				case <-ctx.Done():
+		if out.formatErr != nil {
+			// Unparseable output - tell the LLM how to fix it
+			step.Observation = a.formatErrorObservation(out.formatErr)
+		} else {
+			call := core.ToolCall{ID: fmt.Sprintf("step_%d", iteration), Name: out.action, Args: out.actionInput}
+			if pending != nil {
+				call = *pending
+			} else {
+				r.pending = &reactPending{response: response, call: call}
+				if err := a.checkpoint(ctx, r, usage); err != nil {
+					return nil, err
 				}
-				return
+				r.pending = nil
 			}
 
-			// Check if we have an action
-			if action == "" {
-				// No action and no final answer - continue conversation
-				conversationHistory += "\n" + response + "\n"
-				continue
+			if err := a.hooks.BeforeToolCall(ctx, &call); err != nil {
+				call.Error = &core.ErrToolCallRejected{ToolName: call.Name, Err: err}
+			} else {
+				var notify func(core.StreamEvent)
+				if emit != nil {
+					notify = func(event core.StreamEvent) { emit(event) }
+				}
+				a.approvals.review(ctx, &call, r.tools, notify)
 			}
+			step.ActionInput = call.Args
 
-			step.Action = action
-			step.ActionInput = actionInput
-
-			// Emit tool_start event
-			toolStartEvent := core.NewStreamEventWithData(
-				core.EventTypeToolStart,
-				action,
-				map[string]interface{}{
-					"input":     actionInput,
-					"iteration": iteration + 1,
-				},
-			)
-			select {
-			case eventChan <- toolStartEvent:
-			case <-ctx.Done():
-				return
+			if emit != nil && !emit(core.NewStreamEventWithData(core.EventTypeToolStart, out.action, map[string]interface{}{
+				"input":     call.Args,
+				"iteration": iteration,
+			})) {
+				return nil, ctx.Err()
 			}
 
 			// Execute the action (tool)
-			observation, err := a.executeAction(ctx, action, actionInput)
-			if err != nil {
-				observation = fmt.Sprintf("Error: %v", err)
+			if call.Error == nil {
+				start := time.Now()
+				call.Result, call.Error = a.executeAction(ctx, r.tools, call.Name, call.Args)
+				call.Duration = time.Since(start)
 			}
+			if call.Error != nil {
+				failToolArgs(&call, call.Error)
+			}
+			a.hooks.AfterToolCall(ctx, &call)
+			step.Observation = fmt.Sprintf("%v", call.Result)
 
-			step.Observation = observation
-			a.trace = append(a.trace, step)
-
-			// Emit tool_end event
-			toolEndEvent := core.NewStreamEventWithData(
-				core.EventTypeToolEnd,
-				action,
-				map[string]interface{}{
-					"result":    observation,
-					"iteration": iteration + 1,
-				},
-			)
-			select {
-			case eventChan <- toolEndEvent:
-			case <-ctx.Done():
-				return
+			// A run cancelled while waiting for approval or for the tool
+			// stops here, so resuming it runs the action again
+			if err := ctx.Err(); err != nil {
+				return nil, err
 			}
 
-			// Add observation to conversation
-			conversationHistory += fmt.Sprintf("\n%s\nObservation: %s\n", response, observation)
+			if emit != nil && !emit(core.NewStreamEventWithData(core.EventTypeToolEnd, out.action, map[string]interface{}{
+				"result":    step.Observation,
+				"iteration": iteration,
+			})) {
+				return nil, ctx.Err()
+			}
 		}
 
-		// Max iterations reached
-		select {
-		case eventChan <- core.NewErrorEvent(fmt.Errorf("max iterations (%d) reached without final answer", a.maxIter)):
-		case <-ctx.Done():
+		r.trace = append(r.trace, step)
+
+		// Add the step and its observation to the scratchpad
+		r.scratchpad = append(r.scratchpad,
+			core.AssistantMessage(stripObservation(response)),
+			core.UserMessage("Observation: "+step.Observation),
+		)
+		if err := a.checkpoint(ctx, r, usage); err != nil {
+			return nil, err
 		}
-	}()
+	}
+}
+
+// checkpoint saves the state of a run to the checkpoint store, if any. A
+// pending action is stored as the tool call of its step's message.
+func (a *ReActAgent) checkpoint(ctx context.Context, r *reactRun, usage *runUsage) error {
+	if a.checkpoints == nil {
+		return nil
+	}
 
-	return eventChan, nil
+	cp := &Checkpoint{
+		RunID:     usage.runID(),
+		SessionID: core.SessionIDFromContext(ctx),
+		Agent:     checkpointReAct,
+		Input:     r.input,
+		Iteration: r.iteration,
+		Messages:  r.scratchpad,
+		Trace:     r.trace,
+		Usage:     usage.total,
+	}
+	if r.pending != nil {
+		calls := []core.ToolCall{r.pending.call}
+		cp.Messages = append(append([]core.Message(nil), r.scratchpad...), core.Message{
+			Role:      "assistant",
+			Content:   stripObservation(r.pending.response),
+			ToolCalls: calls,
+		})
+		cp.PendingToolCalls = calls
+	}
+	return saveCheckpoint(ctx, a.checkpoints, cp)
+}
+
+// restore rebuilds a run from its checkpoint.
+func (a *ReActAgent) restore(cp *Checkpoint) *reactRun {
+	r := &reactRun{
+		input:      cp.Input,
+		tools:      a.tools.snapshot(),
+		scratchpad: cp.Messages,
+		trace:      append(make([]ReActStep, 0, len(cp.Trace)), cp.Trace...),
+		iteration:  cp.Iteration,
+	}
+	if len(cp.PendingToolCalls) > 0 && len(cp.Messages) > 0 {
+		last := len(cp.Messages) - 1
+		r.pending = &reactPending{response: cp.Messages[last].Content, call: cp.PendingToolCalls[0]}
+		r.scratchpad = cp.Messages[:last]
+	}
+	return r
+}
+
+// step asks the LLM for the next step, stopping before any "Observation:",
+// through the LLM call hooks. When streaming and the LLM supports it, tokens
+// are emitted as they arrive.
+func (a *ReActAgent) step(ctx context.Context, messages []core.Message, iteration int, usage *runUsage, emit func(core.StreamEvent) bool) (string, error) {
+	call := newLLMCall(messages, nil, iteration+1)
+	resp, err := a.hooks.callLLM(ctx, call, func(call *LLMCall) (*core.Response, error) {
+		return a.chat(ctx, call.Messages, iteration, usage, emit)
+	})
+	if err != nil {
+		return "", fmt.Errorf("LLM call failed: %w", err)
+	}
+	return resp.Content, nil
+}
+
+// chat makes the LLM call of a step, streaming it when emit is set.
+func (a *ReActAgent) chat(ctx context.Context, messages []core.Message, iteration int, usage *runUsage, emit func(core.StreamEvent) bool) (*core.Response, error) {
+	stop := core.WithStop(reactStopSequence)
+
+	streamingLLM, ok := a.llm.(core.StreamingLLM)
+	if emit == nil || !ok {
+		resp, err := a.llm.Chat(ctx, messages, stop)
+		if err != nil {
+			return nil, err
+		}
+		usage.addResponse(resp)
+		return resp, nil
+	}
+
+	chunks, err := streamingLLM.ChatStream(ctx, messages, stop)
+	if err != nil {
+		return nil, err
+	}
+
+	var last core.StreamChunk
+	for chunk := range chunks {
+		if chunk.Error != nil {
+			return nil, chunk.Error
+		}
+		usage.addChunk(chunk)
+
+		if chunk.Delta != "" {
+			if !emit(core.NewStreamEventWithData(core.EventTypeToken, chunk.Delta, map[string]interface{}{
+				"index":     chunk.Index,
+				"iteration": iteration + 1,
+			})) {
+				return nil, ctx.Err()
+			}
+		}
+		last = chunk
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return &core.Response{Content: last.Content, Usage: last.Usage, Meta: last.Metadata}, nil
+}
+
+// trimScratchpad returns the question followed by as many of the most recent
+// steps as fit in the scratchpad token budget. Steps are dropped in
+// assistant/observation pairs, and the question notes how many were dropped.
+// The latest step is always kept.
+func (a *ReActAgent) trimScratchpad(question core.Message, scratchpad []core.Message) []core.Message {
+	if a.scratchpadTokens <= 0 {
+		return append([]core.Message{question}, scratchpad...)
+	}
+
+	start, used := len(scratchpad), 0
+	for start >= 2 {
+		pair := a.countTokens(scratchpad[start-2].Content) + a.countTokens(scratchpad[start-1].Content)
+		if used+pair > a.scratchpadTokens && start < len(scratchpad) {
+			break
+		}
+		used += pair
+		start -= 2
+	}
+
+	if start > 0 {
+		question.Content += fmt.Sprintf("\n\n(%d earlier steps were omitted to stay within the context limit.)", start/2)
+	}
+	return append([]core.Message{question}, scratchpad[start:]...)
+}
+
+// countTokens counts the tokens of text with the configured tokenizer.
+func (a *ReActAgent) countTokens(text string) int {
+	if a.tokenizer == nil {
+		return core.ApproxTokenizer{}.CountTokens(text)
+	}
+	return a.tokenizer.CountTokens(text)
+}
+
+// stripObservation removes a trailing "Observation:" the LLM started writing
+// before the stop sequence took effect (or on providers that ignore it).
+func stripObservation(response string) string {
+	if loc := reactObservationRe.FindStringIndex(response); loc != nil {
+		response = response[:loc[0]]
+	}
+	return strings.TrimSpace(response)
 }
 
 // Reset clears the reasoning trace.
 func (a *ReActAgent) Reset() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
 	a.trace = make([]ReActStep, 0)
 	return nil
 }
 
-// GetTrace returns the reasoning trace from the last run.
+// GetTrace returns a copy of the reasoning trace of the last completed run.
+// With concurrent runs, use the trace in each response's Meta instead.
 func (a *ReActAgent) GetTrace() []ReActStep {
-	return a.trace
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return append([]ReActStep(nil), a.trace...)
 }
 
-// buildPrompt creates the initial prompt with system instructions and tools.
-func (a *ReActAgent) buildPrompt(input string) string {
+// buildMessages creates the system message, with instructions and tools, and
+// the user message asking the question.
+func (a *ReActAgent) buildMessages(input string, tools map[string]core.Tool) (system, question core.Message) {
 	var sb strings.Builder
 
 	// System prompt
 	sb.WriteString(a.systemPrompt)
-	sb.WriteString("\n\n")
 
-	// Available tools
-	if len(a.tools) > 0 {
-		sb.WriteString("Available tools:\n")
-		for _, tool := range a.tools {
+	// Available tools, sorted so the prompt is stable across runs
+	if len(tools) > 0 {
+		names := make([]string, 0, len(tools))
+		for name := range tools {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		sb.WriteString("\n\nAvailable tools:\n")
+		for _, name := range names {
+			tool := tools[name]
 			sb.WriteString(fmt.Sprintf("- %s: %s\n", tool.Name(), tool.Description()))
 
 			// Add parameter information
 			schema := tool.Schema()
-			if schema != nil && len(schema.Parameters) > 0 {
+			if schema != nil && a.actionFormat == ReActActionFormatJSON {
+				// The full schema shows nested objects and value types
+				if data, err := json.Marshal(schema.JSONSchema()); err == nil {
+					sb.WriteString(fmt.Sprintf("  Arguments (JSON Schema): %s\n", data))
+				}
+			} else if schema != nil && len(schema.Parameters) > 0 {
 				sb.WriteString("  Parameters:\n")
 				for _, param := range schema.Parameters {
 					required := ""
@@ -424,67 +662,29 @@ func (a *ReActAgent) buildPrompt(input string) string {
 				}
 			}
 		}
-		sb.WriteString("\n")
 	}
 
 	// User question
-	sb.WriteString(fmt.Sprintf("Question: %s\n", input))
-	sb.WriteString("Let's approach this step-by-step:\n")
-
-	return sb.String()
-}
-
-// parseResponse extracts Thought, Action, ActionInput, and Final Answer from LLM response.
-func (a *ReActAgent) parseResponse(response string) (thought, action string, actionInput map[string]interface{}, finalAnswer string) {
-	// Look for "Thought:" or "Think:"
-	thoughtRegex := regexp.MustCompile(`(?i)(?:Thought|Think):\s*(.+?)(?:\n|$)`)
-	if matches := thoughtRegex.FindStringSubmatch(response); len(matches) > 1 {
-		thought = strings.TrimSpace(matches[1])
-	}
-
-	// Look for "Final Answer:"
-	finalRegex := regexp.MustCompile(`(?i)Final Answer:\s*(.+?)(?:\n\n|$)`)
-	if matches := finalRegex.FindStringSubmatch(response); len(matches) > 1 {
-		finalAnswer = strings.TrimSpace(matches[1])
-		return
-	}
-
-	// Look for "Action:" followed by tool call
-	// Format: Action: tool_name(param1=value1, param2=value2)
-	actionRegex := regexp.MustCompile(`(?i)Action:\s*(\w+)\s*\(([^)]*)\)`)
-	if matches := actionRegex.FindStringSubmatch(response); len(matches) > 2 {
-		action = matches[1]
-
-		// Parse parameters
-		actionInput = make(map[string]interface{})
-		paramsStr := matches[2]
-
-		if paramsStr != "" {
-			// Split by comma
-			params := strings.Split(paramsStr, ",")
-			for _, param := range params {
-				// Split by = to get key-value
-				parts := strings.SplitN(strings.TrimSpace(param), "=", 2)
-				if len(parts) == 2 {
-					key := strings.TrimSpace(parts[0])
-					value := strings.Trim(strings.TrimSpace(parts[1]), `"'`)
-					actionInput[key] = value
-				}
-			}
-		}
-	}
+	question = core.UserMessage(fmt.Sprintf("Question: %s\nLet's approach this step-by-step.", input))
 
-	return
+	return core.SystemMessage(strings.TrimSpace(sb.String())), question
 }
 
 // executeAction executes a tool with given parameters.
-func (a *ReActAgent) executeAction(ctx context.Context, action string, input map[string]interface{}) (string, error) {
-	tool, exists := a.tools[action]
+func (a *ReActAgent) executeAction(ctx context.Context, tools map[string]core.Tool, action string, input map[string]interface{}) (string, error) {
+	tool, exists := tools[action]
 	if !exists {
 		return "", fmt.Errorf("tool '%s' not found", action)
 	}
 
-	result, err := tool.Execute(ctx, input)
+	// ReAct actions carry every argument as text, so coerce them to the
+	// schema's types and report invalid arguments back as the observation.
+	args, err := core.ValidateToolArgs(tool.Schema(), input)
+	if err != nil {
+		return "", err
+	}
+
+	result, err := tool.Execute(ctx, args)
 	if err != nil {
 		return "", err
 	}
@@ -493,13 +693,13 @@ func (a *ReActAgent) executeAction(ctx context.Context, action string, input map
 }
 
 // buildReActSystemPrompt creates the default system prompt for ReAct.
-func buildReActSystemPrompt() string {
+func buildReActSystemPrompt(format ReActActionFormat) string {
 	return `You are a helpful AI assistant that solves problems step-by-step using the ReAct framework.
 
 Follow this format exactly:
 
 Thought: [Your reasoning about what to do next]
-Action: tool_name(param1=value1, param2=value2)
+` + actionExample(format) + `
 Observation: [You will see the result here]
 
 After seeing the observation, continue:
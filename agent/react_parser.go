@@ -0,0 +1,228 @@
+package agent
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/yashrahurikar23/goagents/core"
+)
+
+// ReActActionFormat selects the grammar the ReAct agent asks the LLM to use for actions.
+// The parser accepts both grammars regardless of the format, since models
+// sometimes drift between them; the format decides what the prompt teaches.
+type ReActActionFormat string
+
+const (
+	// ReActActionFormatCall is the compact call grammar:
+	//
+	//	Action: calculator(operation=multiply, a=25, b=4)
+	//
+	// Argument values are text and are converted to the types in the tool
+	// schema before the tool runs.
+	ReActActionFormatCall ReActActionFormat = "call"
+
+	// ReActActionFormatJSON names the tool on the Action line and passes the
+	// arguments as a JSON object:
+	//
+	//	Action: calculator
+	//	Action Input: {"operation": "multiply", "a": 25, "b": 4}
+	//
+	// Values may contain commas, parentheses and nested objects, and keep
+	// their JSON types.
+	ReActActionFormatJSON ReActActionFormat = "json"
+)
+
+var (
+	reactThoughtRe     = regexp.MustCompile(`(?i)(?:Thought|Think):\s*(.+?)(?:\n|$)`)
+	reactFinalRe       = regexp.MustCompile(`(?is)Final Answer:\s*(.+?)(?:\n\n|$)`)
+	reactActionRe      = regexp.MustCompile(`(?im)^[ \t*]*Action[ \t*]*:[ \t*]*(.*)$`)
+	reactActionInputRe = regexp.MustCompile(`(?i)Action[ \t]*Input[ \t*]*:`)
+	reactObservationRe = regexp.MustCompile(`(?im)^[ \t*]*Observation[ \t*]*:`)
+	reactCallRe        = regexp.MustCompile(`(?s)^([\w.-]+)\s*\((.*)\)\s*$`)
+	reactToolNameRe    = regexp.MustCompile(`^[\w.-]+$`)
+)
+
+// reactOutput is one parsed LLM turn.
+type reactOutput struct {
+	thought     string
+	action      string
+	actionInput map[string]interface{}
+	finalAnswer string
+
+	// formatErr explains why the turn could not be parsed, for the corrective
+	// observation; nil when the turn has an action or a final answer
+	formatErr error
+}
+
+// parse extracts the thought, action and final answer of an LLM turn.
+//
+// Anything after the first "Observation:" is dropped: models often
+// hallucinate the observation, and sometimes a final answer, themselves.
+func (a *ReActAgent) parse(response string) reactOutput {
+	if loc := reactObservationRe.FindStringIndex(response); loc != nil {
+		response = response[:loc[0]]
+	}
+
+	var out reactOutput
+	if m := reactThoughtRe.FindStringSubmatch(response); len(m) > 1 {
+		out.thought = strings.TrimSpace(m[1])
+	}
+
+	if m := reactFinalRe.FindStringSubmatch(response); len(m) > 1 {
+		out.finalAnswer = strings.TrimSpace(m[1])
+		return out
+	}
+
+	loc := reactActionRe.FindStringSubmatchIndex(response)
+	if loc == nil {
+		out.formatErr = fmt.Errorf("no Action or Final Answer found")
+		return out
+	}
+	action := strings.Trim(strings.TrimSpace(response[loc[2]:loc[3]]), "`'\"")
+	rest := response[loc[1]:]
+
+	// Action: tool(args)
+	if m := reactCallRe.FindStringSubmatch(action); m != nil {
+		out.action = m[1]
+		out.actionInput, out.formatErr = parseCallArgs(m[2])
+		return out
+	}
+
+	// Action: tool, optionally followed by Action Input: {...}
+	if !reactToolNameRe.MatchString(action) {
+		out.formatErr = fmt.Errorf("could not read a tool name from %q", action)
+		return out
+	}
+	out.action = action
+	out.actionInput = map[string]interface{}{}
+
+	inputLoc := reactActionInputRe.FindStringIndex(rest)
+	if inputLoc == nil {
+		return out
+	}
+	input := strings.TrimSpace(rest[inputLoc[1]:])
+	out.actionInput, out.formatErr = a.parseActionInput(action, input)
+	return out
+}
+
+// parseResponse extracts Thought, Action, ActionInput, and Final Answer from LLM response.
+func (a *ReActAgent) parseResponse(response string) (thought, action string, actionInput map[string]interface{}, finalAnswer string) {
+	out := a.parse(response)
+	return out.thought, out.action, out.actionInput, out.finalAnswer
+}
+
+// parseActionInput decodes the JSON object after "Action Input:". Code fences
+// and surrounding prose are tolerated. A bare value is accepted for tools
+// with a single parameter.
+func (a *ReActAgent) parseActionInput(action, input string) (map[string]interface{}, error) {
+	if input == "" || strings.EqualFold(input, "none") || input == "{}" {
+		return map[string]interface{}{}, nil
+	}
+
+	var args map[string]interface{}
+	if err := json.Unmarshal([]byte(core.ExtractJSON(input)), &args); err == nil && args != nil {
+		return args, nil
+	} else if strings.ContainsAny(input, "{") {
+		return nil, fmt.Errorf("Action Input is not a valid JSON object: %v", err)
+	}
+
+	if tool, ok := a.tools.snapshot()[action]; ok {
+		if schema := tool.Schema(); schema != nil && len(schema.Parameters) == 1 {
+			value := strings.Trim(strings.TrimSpace(strings.SplitN(input, "\n", 2)[0]), "`'\"")
+			return map[string]interface{}{schema.Parameters[0].Name: value}, nil
+		}
+	}
+	return nil, fmt.Errorf("Action Input must be a JSON object, got %q", input)
+}
+
+// parseCallArgs parses the arguments of tool(name=value, ...). Commas and
+// equals signs inside quotes, brackets and braces do not split arguments.
+// A single JSON object, tool({...}), is also accepted.
+func parseCallArgs(s string) (map[string]interface{}, error) {
+	s = strings.TrimSpace(s)
+	args := make(map[string]interface{})
+	if s == "" {
+		return args, nil
+	}
+
+	if strings.HasPrefix(s, "{") {
+		if err := json.Unmarshal([]byte(s), &args); err != nil {
+			return nil, fmt.Errorf("arguments are not a valid JSON object: %v", err)
+		}
+		return args, nil
+	}
+
+	for _, part := range splitTopLevel(s, ',') {
+		kv := splitTopLevel(part, '=')
+		if len(kv) < 2 {
+			return nil, fmt.Errorf("argument %q is not in name=value form", strings.TrimSpace(part))
+		}
+		key := strings.TrimSpace(kv[0])
+		value := strings.TrimSpace(strings.Join(kv[1:], "="))
+		args[key] = parseCallValue(value)
+	}
+	return args, nil
+}
+
+// parseCallValue unquotes a quoted value and decodes JSON objects and arrays.
+// Everything else stays text for ValidateToolArgs to convert.
+func parseCallValue(value string) interface{} {
+	if len(value) >= 2 {
+		if q := value[0]; (q == '"' || q == '\'') && value[len(value)-1] == q {
+			return value[1 : len(value)-1]
+		}
+		if value[0] == '{' || value[0] == '[' {
+			var decoded interface{}
+			if err := json.Unmarshal([]byte(value), &decoded); err == nil {
+				return decoded
+			}
+		}
+	}
+	return value
+}
+
+// splitTopLevel splits s on sep, ignoring separators inside quotes, brackets and braces.
+func splitTopLevel(s string, sep byte) []string {
+	var parts []string
+	depth := 0
+	var quote byte
+	start := 0
+
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == '\\' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '{' || c == '[' || c == '(':
+			depth++
+		case c == '}' || c == ']' || c == ')':
+			depth--
+		case c == sep && depth == 0:
+			parts = append(parts, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, s[start:])
+}
+
+// formatErrorObservation is fed back to the LLM when its output could not be parsed.
+func (a *ReActAgent) formatErrorObservation(err error) string {
+	return fmt.Sprintf("Format error: %v. Respond with a Thought followed by either:\n%s\nor\nFinal Answer: <your answer>",
+		err, actionExample(a.actionFormat))
+}
+
+// actionExample shows the action grammar of a format.
+func actionExample(format ReActActionFormat) string {
+	if format == ReActActionFormatJSON {
+		return "Action: <tool name>\nAction Input: <JSON object with the tool arguments>"
+	}
+	return "Action: tool_name(param1=value1, param2=value2)"
+}
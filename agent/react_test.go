@@ -2,8 +2,10 @@ package agent
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"reflect"
 	"strings"
 	"testing"
 
@@ -70,8 +72,8 @@ func TestReActAgent_AddTool(t *testing.T) {
 		t.Fatalf("AddTool() error = %v", err)
 	}
 
-	if len(agent.tools) != 1 {
-		t.Errorf("len(tools) = %d, want 1", len(agent.tools))
+	if len(agent.tools.snapshot()) != 1 {
+		t.Errorf("len(tools) = %d, want 1", len(agent.tools.snapshot()))
 	}
 }
 
@@ -262,7 +264,7 @@ func TestReActAgent_ExecuteAction(t *testing.T) {
 	agent.AddTool(tool)
 
 	ctx := context.Background()
-	result, err := agent.executeAction(ctx, "calculator", map[string]interface{}{
+	result, err := agent.executeAction(ctx, agent.tools.snapshot(), "calculator", map[string]interface{}{
 		"operation": "add",
 		"a":         "10",
 		"b":         "32",
@@ -277,13 +279,46 @@ func TestReActAgent_ExecuteAction(t *testing.T) {
 	}
 }
 
+// TestReActAgent_ExecuteAction_CoercesArgs tests that text arguments are
+// converted to the types declared in the tool schema.
+func TestReActAgent_ExecuteAction_CoercesArgs(t *testing.T) {
+	llm := mocks.NewMockLLM()
+	agent := NewReActAgent(llm)
+
+	tool := mocks.NewMockTool("calculator", "Does math").WithSchema(&core.ToolSchema{
+		Name: "calculator",
+		Parameters: []core.Parameter{
+			{Name: "a", Type: "number", Required: true},
+			{Name: "b", Type: "number", Required: true},
+		},
+	})
+	agent.AddTool(tool)
+
+	ctx := context.Background()
+	if _, err := agent.executeAction(ctx, agent.tools.snapshot(), "calculator", map[string]interface{}{"a": "10", "b": "32"}); err != nil {
+		t.Fatalf("executeAction() error = %v", err)
+	}
+	if args := tool.GetCalls()[0].Args; args["a"] != 10.0 || args["b"] != 32.0 {
+		t.Errorf("tool args = %v, want numbers", args)
+	}
+
+	_, err := agent.executeAction(ctx, agent.tools.snapshot(), "calculator", map[string]interface{}{"a": "ten"})
+	var invalid *core.ErrInvalidToolArgs
+	if !errors.As(err, &invalid) || len(invalid.Violations) != 2 {
+		t.Errorf("executeAction() error = %v, want 2 argument violations", err)
+	}
+	if tool.CallCount() != 1 {
+		t.Errorf("tool call count = %d, want 1", tool.CallCount())
+	}
+}
+
 // TestReActAgent_ExecuteAction_ToolNotFound tests missing tool.
 func TestReActAgent_ExecuteAction_ToolNotFound(t *testing.T) {
 	llm := mocks.NewMockLLM()
 	agent := NewReActAgent(llm)
 
 	ctx := context.Background()
-	_, err := agent.executeAction(ctx, "nonexistent", map[string]interface{}{})
+	_, err := agent.executeAction(ctx, agent.tools.snapshot(), "nonexistent", map[string]interface{}{})
 
 	if err == nil {
 		t.Fatal("executeAction() expected error for missing tool, got nil")
@@ -307,7 +342,7 @@ func TestReActAgent_ExecuteAction_ToolError(t *testing.T) {
 	agent.AddTool(tool)
 
 	ctx := context.Background()
-	_, err := agent.executeAction(ctx, "calculator", map[string]interface{}{})
+	_, err := agent.executeAction(ctx, agent.tools.snapshot(), "calculator", map[string]interface{}{})
 
 	if err == nil {
 		t.Fatal("executeAction() expected error from tool, got nil")
@@ -321,7 +356,7 @@ func TestReActAgent_ExecuteAction_ToolError(t *testing.T) {
 // TestReActAgent_Run_SimpleFinalAnswer tests direct final answer.
 func TestReActAgent_Run_SimpleFinalAnswer(t *testing.T) {
 	llm := mocks.NewMockLLM()
-	llm.WithCompleteResponse("Thought: This is simple\nFinal Answer: 42")
+	llm.WithChatResponse("Thought: This is simple\nFinal Answer: 42", nil)
 
 	agent := NewReActAgent(llm)
 
@@ -357,13 +392,13 @@ func TestReActAgent_Run_WithToolCall(t *testing.T) {
 		"Thought: I have the result\nFinal Answer: 100",
 	}
 	responseIndex := 0
-	llm.CompleteFunc = func(ctx context.Context, prompt string) (string, error) {
+	llm.ChatFunc = func(ctx context.Context, messages []core.Message) (*core.Response, error) {
 		if responseIndex >= len(responses) {
-			return responses[len(responses)-1], nil
+			return &core.Response{Content: responses[len(responses)-1]}, nil
 		}
 		resp := responses[responseIndex]
 		responseIndex++
-		return resp, nil
+		return &core.Response{Content: resp}, nil
 	}
 
 	agent := NewReActAgent(llm)
@@ -412,12 +447,63 @@ func TestReActAgent_Run_WithToolCall(t *testing.T) {
 	}
 }
 
+// TestReActAgent_Run_InvalidArgsFeedback tests that invalid action arguments
+// are observed as the structured feedback, whether they fail when the action
+// runs or when it is reviewed for approval.
+func TestReActAgent_Run_InvalidArgsFeedback(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []ReActAgentOption
+	}{
+		{name: "execution"},
+		{name: "approval", opts: []ReActAgentOption{
+			ReActWithApprover(ApproverFunc(func(ctx context.Context, req ApprovalRequest) (ApprovalDecision, error) {
+				return ApprovalDecision{Approved: true}, nil
+			})),
+			ReActWithApprovalFor("calculator", ApprovalAlways),
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			llm := mocks.NewMockLLM().WithSequentialChatResponses([]*core.Response{
+				{Content: "Thought: I need to calculate\nAction: calculator(a=ten)"},
+				{Content: "Thought: I cannot calculate that\nFinal Answer: unknown"},
+			}, nil)
+			tool := mocks.NewMockTool("calculator", "Does math").WithSchema(&core.ToolSchema{
+				Name: "calculator",
+				Parameters: []core.Parameter{
+					{Name: "a", Type: "number", Required: true},
+				},
+			})
+			agent := NewReActAgent(llm, tt.opts...)
+			agent.AddTool(tool)
+
+			if _, err := agent.Run(context.Background(), "What is ten?"); err != nil {
+				t.Fatalf("Run() error = %v", err)
+			}
+			if tool.CallCount() != 0 {
+				t.Errorf("tool ran %d times, want none", tool.CallCount())
+			}
+
+			var feedback map[string]interface{}
+			observation := agent.GetTrace()[0].Observation
+			if err := json.Unmarshal([]byte(observation), &feedback); err != nil || feedback["error"] != "invalid_arguments" {
+				t.Fatalf("observation = %q, want the JSON feedback", observation)
+			}
+			if sent := llm.GetChatCalls()[1].Messages; !strings.Contains(sent[len(sent)-1].Content, observation) {
+				t.Errorf("last message sent = %q, want the feedback", sent[len(sent)-1].Content)
+			}
+		})
+	}
+}
+
 // TestReActAgent_Run_MaxIterations tests iteration limit.
 func TestReActAgent_Run_MaxIterations(t *testing.T) {
 	llm := mocks.NewMockLLM()
 
 	// Always return thought without final answer (infinite loop)
-	llm.WithCompleteResponse("Thought: Still thinking...")
+	llm.WithChatResponse("Thought: Still thinking...", nil)
 
 	agent := NewReActAgent(llm, ReActWithMaxIterations(3))
 
@@ -486,7 +572,12 @@ func TestReActAgent_BuildPrompt(t *testing.T) {
 	tool := mocks.NewMockTool("calculator", "Performs arithmetic operations")
 	agent.AddTool(tool)
 
-	prompt := agent.buildPrompt("What is 2 + 2?")
+	system, question := agent.buildMessages("What is 2 + 2?", agent.tools.snapshot())
+	prompt := system.Content + "\n" + question.Content
+
+	if system.Role != "system" || question.Role != "user" {
+		t.Errorf("roles = %s, %s; want system, user", system.Role, question.Role)
+	}
 
 	// Check prompt contains key elements
 	if !strings.Contains(prompt, "calculator") {
@@ -505,3 +596,385 @@ func TestReActAgent_BuildPrompt(t *testing.T) {
 		t.Error("prompt should contain reasoning instruction")
 	}
 }
+
+// TestReActAgent_Parse_JSONAction tests the JSON action grammar.
+func TestReActAgent_Parse_JSONAction(t *testing.T) {
+	agent := NewReActAgent(mocks.NewMockLLM())
+
+	tests := []struct {
+		name      string
+		response  string
+		wantTool  string
+		wantInput map[string]interface{}
+	}{
+		{
+			name:      "typed values",
+			response:  "Thought: Multiply\nAction: calculator\nAction Input: {\"operation\": \"multiply\", \"a\": 25, \"b\": 4}",
+			wantTool:  "calculator",
+			wantInput: map[string]interface{}{"operation": "multiply", "a": 25.0, "b": 4.0},
+		},
+		{
+			name:      "commas, parentheses and nesting",
+			response:  "Thought: Search\nAction: search\nAction Input: {\"query\": \"go (lang), generics\", \"filter\": {\"year\": 2024}}",
+			wantTool:  "search",
+			wantInput: map[string]interface{}{"query": "go (lang), generics", "filter": map[string]interface{}{"year": 2024.0}},
+		},
+		{
+			name:      "code fence",
+			response:  "Thought: Search\nAction: `search`\nAction Input:\n```json\n{\n  \"query\": \"weather\"\n}\n```",
+			wantTool:  "search",
+			wantInput: map[string]interface{}{"query": "weather"},
+		},
+		{
+			name:      "hallucinated observation",
+			response:  "Thought: Search\nAction: search\nAction Input: {\"query\": \"x\"}\nObservation: 42\nFinal Answer: 42",
+			wantTool:  "search",
+			wantInput: map[string]interface{}{"query": "x"},
+		},
+		{
+			name:      "call grammar with quoted commas",
+			response:  `Action: search(query="a, b", limit=5)`,
+			wantTool:  "search",
+			wantInput: map[string]interface{}{"query": "a, b", "limit": "5"},
+		},
+		{
+			name:      "call grammar with JSON object",
+			response:  `Action: search({"query": "x", "tags": ["a", "b"]})`,
+			wantTool:  "search",
+			wantInput: map[string]interface{}{"query": "x", "tags": []interface{}{"a", "b"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := agent.parse(tt.response)
+			if out.formatErr != nil {
+				t.Fatalf("formatErr = %v", out.formatErr)
+			}
+			if out.finalAnswer != "" {
+				t.Errorf("finalAnswer = %q, want none", out.finalAnswer)
+			}
+			if out.action != tt.wantTool {
+				t.Errorf("action = %q, want %q", out.action, tt.wantTool)
+			}
+			if !reflect.DeepEqual(out.actionInput, tt.wantInput) {
+				t.Errorf("actionInput = %v, want %v", out.actionInput, tt.wantInput)
+			}
+		})
+	}
+}
+
+// TestReActAgent_Parse_FormatErrors tests that unparseable outputs are reported.
+func TestReActAgent_Parse_FormatErrors(t *testing.T) {
+	agent := NewReActAgent(mocks.NewMockLLM())
+
+	responses := []string{
+		"Thought: I am not sure what to do",
+		"Thought: Search\nAction: search\nAction Input: {\"query\": \"x\"",
+		"Thought: Search\nAction: search\nAction Input: query is x",
+		"Thought: Search\nAction: search the web for x",
+		"Action: search(query)",
+	}
+
+	for _, response := range responses {
+		if out := agent.parse(response); out.formatErr == nil {
+			t.Errorf("parse(%q) expected a format error, got %+v", response, out)
+		}
+	}
+}
+
+// TestReActAgent_Parse_SingleParameterInput tests bare Action Input values.
+func TestReActAgent_Parse_SingleParameterInput(t *testing.T) {
+	agent := NewReActAgent(mocks.NewMockLLM())
+	agent.AddTool(mocks.NewMockTool("search", "Searches").WithSchema(&core.ToolSchema{
+		Name:       "search",
+		Parameters: []core.Parameter{{Name: "query", Type: "string", Required: true}},
+	}))
+
+	out := agent.parse("Thought: Search\nAction: search\nAction Input: \"golang generics\"")
+	if out.formatErr != nil || out.actionInput["query"] != "golang generics" {
+		t.Errorf("parse() = %+v, want query argument", out)
+	}
+}
+
+// TestReActAgent_Run_FormatErrorObservation tests the corrective observation.
+func TestReActAgent_Run_FormatErrorObservation(t *testing.T) {
+	llm := mocks.NewMockLLM()
+	responses := []string{
+		"Thought: Let me calculate\nAction: calculator\nAction Input: {a: 25}",
+		"Thought: Fixed\nAction: calculator\nAction Input: {\"operation\": \"multiply\", \"a\": 25, \"b\": 4}",
+		"Thought: Done\nFinal Answer: 100",
+	}
+	var prompts []string
+	llm.ChatFunc = func(ctx context.Context, messages []core.Message) (*core.Response, error) {
+		var sb strings.Builder
+		for _, m := range messages {
+			sb.WriteString(m.Content + "\n")
+		}
+		prompts = append(prompts, sb.String())
+		return &core.Response{Content: responses[len(prompts)-1]}, nil
+	}
+
+	agent := NewReActAgent(llm, ReActWithActionFormat(ReActActionFormatJSON))
+	tool := mocks.NewMockTool("calculator", "Does math").WithSchema(&core.ToolSchema{
+		Name: "calculator",
+		Parameters: []core.Parameter{
+			{Name: "operation", Type: "string", Required: true},
+			{Name: "a", Type: "number", Required: true},
+			{Name: "b", Type: "number", Required: true},
+		},
+	}).WithExecuteResult(100)
+	agent.AddTool(tool)
+
+	resp, err := agent.Run(context.Background(), "What is 25 * 4?")
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if resp.Content != "100" {
+		t.Errorf("resp.Content = %q, want 100", resp.Content)
+	}
+
+	if !strings.Contains(prompts[0], "Action Input: <JSON object") || !strings.Contains(prompts[0], `"type":"number"`) {
+		t.Errorf("expected JSON action format and argument schema in prompt, got %q", prompts[0])
+	}
+	if !strings.Contains(prompts[1], "Observation: Format error: Action Input is not a valid JSON object") {
+		t.Errorf("expected format error observation, got %q", prompts[1])
+	}
+
+	calls := tool.GetCalls()
+	if len(calls) != 1 || calls[0].Args["a"] != 25.0 {
+		t.Errorf("tool calls = %+v, want one call with typed arguments", calls)
+	}
+
+	trace := agent.GetTrace()
+	if len(trace) != 3 || !strings.HasPrefix(trace[0].Observation, "Format error:") {
+		t.Errorf("trace = %+v, want format error in first step", trace)
+	}
+}
+
+// TestReActAgent_Run_ChatMessages tests message roles and the Observation stop sequence.
+func TestReActAgent_Run_ChatMessages(t *testing.T) {
+	llm := mocks.NewMockLLM().WithSequentialChatResponses(
+		[]*core.Response{
+			{Content: "Thought: I need to calculate\nAction: calculator(operation=multiply, a=25, b=4)"},
+			{Content: "Thought: I have the result\nFinal Answer: 100"},
+		},
+		nil,
+	)
+
+	agent := NewReActAgent(llm)
+	agent.AddTool(mocks.NewMockTool("calculator", "Does math").WithExecuteResult(100))
+
+	if _, err := agent.Run(context.Background(), "What is 25 * 4?"); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	calls := llm.GetChatCalls()
+	if len(calls) != 2 {
+		t.Fatalf("len(chat calls) = %d, want 2", len(calls))
+	}
+
+	for _, call := range calls {
+		if len(call.Options.Stop) != 1 || call.Options.Stop[0] != "\nObservation:" {
+			t.Errorf("stop sequences = %q, want Observation stop", call.Options.Stop)
+		}
+	}
+
+	// system, question, assistant step, observation
+	messages := calls[1].Messages
+	roles := make([]string, len(messages))
+	for i, m := range messages {
+		roles[i] = m.Role
+	}
+	if !reflect.DeepEqual(roles, []string{"system", "user", "assistant", "user"}) {
+		t.Fatalf("roles = %v, want system, user, assistant, user", roles)
+	}
+	if !strings.HasPrefix(messages[1].Content, "Question: What is 25 * 4?") {
+		t.Errorf("question = %q", messages[1].Content)
+	}
+	if !strings.HasPrefix(messages[2].Content, "Thought: I need to calculate") {
+		t.Errorf("assistant step = %q", messages[2].Content)
+	}
+	if messages[3].Content != "Observation: 100" {
+		t.Errorf("observation = %q, want %q", messages[3].Content, "Observation: 100")
+	}
+}
+
+// TestReActAgent_Run_ScratchpadBudget tests that old steps are dropped past the token budget.
+func TestReActAgent_Run_ScratchpadBudget(t *testing.T) {
+	step := "Thought: " + strings.Repeat("think ", 20) + "\nAction: lookup(key=x)"
+	llm := mocks.NewMockLLM().WithSequentialChatResponses(
+		[]*core.Response{{Content: step}, {Content: step}, {Content: step}, {Content: "Final Answer: done"}},
+		nil,
+	)
+
+	agent := NewReActAgent(llm, ReActWithScratchpadTokens(80))
+	agent.AddTool(mocks.NewMockTool("lookup", "Looks up a key").WithExecuteResult("value"))
+
+	resp, err := agent.Run(context.Background(), "Look it up")
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if resp.Content != "done" {
+		t.Errorf("resp.Content = %q, want done", resp.Content)
+	}
+
+	last := llm.GetChatCalls()[3].Messages
+	if len(last) != 4 {
+		t.Fatalf("len(messages) = %d, want system, question and the latest step", len(last))
+	}
+	if !strings.Contains(last[1].Content, "2 earlier steps were omitted") {
+		t.Errorf("question = %q, want note about omitted steps", last[1].Content)
+	}
+
+	if len(agent.GetTrace()) != 4 {
+		t.Errorf("trace length = %d, want all 4 steps", len(agent.GetTrace()))
+	}
+}
+
+// wordTokenizer counts one token per word.
+type wordTokenizer struct{}
+
+func (wordTokenizer) CountTokens(text string) int {
+	return len(strings.Fields(text))
+}
+
+// TestReActAgent_Run_ScratchpadTokenizer tests that the scratchpad is measured with the tokenizer.
+func TestReActAgent_Run_ScratchpadTokenizer(t *testing.T) {
+	step := "Thought: " + strings.Repeat("think ", 20) + "\nAction: lookup(key=x)"
+	llm := mocks.NewMockLLM().WithSequentialChatResponses(
+		[]*core.Response{{Content: step}, {Content: step}, {Content: step}, {Content: "Final Answer: done"}},
+		nil,
+	)
+
+	// About 24 words per step and observation pair: two pairs fit in 50
+	agent := NewReActAgent(llm, ReActWithScratchpadTokens(50), ReActWithTokenizer(wordTokenizer{}))
+	agent.AddTool(mocks.NewMockTool("lookup", "Looks up a key").WithExecuteResult("value"))
+
+	if _, err := agent.Run(context.Background(), "Look it up"); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	last := llm.GetChatCalls()[3].Messages
+	if len(last) != 6 {
+		t.Fatalf("len(messages) = %d, want system, question and the latest two steps", len(last))
+	}
+	if !strings.Contains(last[1].Content, "1 earlier steps were omitted") {
+		t.Errorf("question = %q, want note about omitted steps", last[1].Content)
+	}
+}
+
+// TestReActAgent_Run_Usage tests usage totals and cost tracking.
+func TestReActAgent_Run_Usage(t *testing.T) {
+	meta := map[string]interface{}{"model": "gpt-4o-mini"}
+	llm := mocks.NewMockLLM().WithSequentialChatResponses(
+		[]*core.Response{
+			{Content: "Thought: Calculate\nAction: calculator()", Usage: core.NewUsage(100, 10), Meta: meta},
+			{Content: "Final Answer: 4", Usage: core.NewUsage(150, 5), Meta: meta},
+		},
+		nil,
+	)
+
+	tracker := core.NewCostTracker(nil)
+	agent := NewReActAgent(llm, ReActWithCostTracker(tracker))
+	agent.AddTool(mocks.NewMockTool("calculator", "Does math"))
+
+	resp, err := agent.Run(context.Background(), "2 + 2?")
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	if resp.Usage == nil || resp.Usage.TotalTokens != 265 {
+		t.Errorf("resp.Usage = %+v, want 265 total tokens", resp.Usage)
+	}
+
+	runID, _ := resp.Meta["run_id"].(string)
+	if run := tracker.Run(runID); run.Calls != 2 || run.Cost <= 0 {
+		t.Errorf("tracker.Run() = %+v, want 2 priced calls", run)
+	}
+}
+
+// streamingReActLLM streams scripted responses word by word.
+type streamingReActLLM struct {
+	*mocks.MockLLM
+	responses []string
+	calls     int
+}
+
+func (l *streamingReActLLM) ChatStream(ctx context.Context, messages []core.Message, opts ...core.CallOption) (<-chan core.StreamChunk, error) {
+	response := l.responses[l.calls]
+	l.calls++
+
+	ch := make(chan core.StreamChunk, 64)
+	go func() {
+		defer close(ch)
+		var content string
+		words := strings.SplitAfter(response, " ")
+		for i, word := range words {
+			content += word
+			chunk := core.StreamChunk{Content: content, Delta: word, Index: i}
+			if i == len(words)-1 {
+				chunk.FinishReason = "stop"
+				chunk.Usage = core.NewUsage(10, len(words))
+			}
+			ch <- chunk
+		}
+	}()
+	return ch, nil
+}
+
+func (l *streamingReActLLM) CompleteStream(ctx context.Context, prompt string, opts ...core.CallOption) (<-chan core.StreamChunk, error) {
+	return nil, fmt.Errorf("not used")
+}
+
+// TestReActAgent_RunStream_Tokens tests that steps are streamed token by token.
+func TestReActAgent_RunStream_Tokens(t *testing.T) {
+	llm := &streamingReActLLM{
+		MockLLM: mocks.NewMockLLM(),
+		responses: []string{
+			"Thought: I should look it up\nAction: lookup(key=x)",
+			"Thought: Found it\nFinal Answer: value",
+		},
+	}
+
+	agent := NewReActAgent(llm)
+	agent.AddTool(mocks.NewMockTool("lookup", "Looks up a key").WithExecuteResult("value"))
+
+	stream, err := agent.RunStream(context.Background(), "Look it up")
+	if err != nil {
+		t.Fatalf("RunStream() error = %v", err)
+	}
+
+	var tokens strings.Builder
+	var types []string
+	var complete core.StreamEvent
+	for event := range stream {
+		if event.Type == core.EventTypeError {
+			t.Fatalf("error event: %v", event.Error)
+		}
+		if event.Type == core.EventTypeToken {
+			tokens.WriteString(event.Content)
+			continue
+		}
+		types = append(types, event.Type)
+		if event.Type == core.EventTypeComplete {
+			complete = event
+		}
+	}
+
+	if !strings.Contains(tokens.String(), "Thought: I should look it up") {
+		t.Errorf("tokens = %q, want the streamed steps", tokens.String())
+	}
+
+	want := []string{core.EventTypeThought, core.EventTypeToolStart, core.EventTypeToolEnd, core.EventTypeThought, core.EventTypeAnswer, core.EventTypeComplete}
+	if !reflect.DeepEqual(types, want) {
+		t.Errorf("event types = %v, want %v", types, want)
+	}
+
+	if usage, ok := complete.Data["usage"].(core.Usage); !ok || usage.PromptTokens != 20 {
+		t.Errorf("complete usage = %v, want usage of both streamed calls", complete.Data["usage"])
+	}
+	if llm.ChatCallCount() != 0 {
+		t.Errorf("Chat() called %d times, want streaming only", llm.ChatCallCount())
+	}
+}
@@ -0,0 +1,201 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/yashrahurikar23/goagents/core"
+	"github.com/yashrahurikar23/goagents/memory"
+)
+
+// history is an agent's own conversation, used when it has no store.
+//
+// Runs never share its slice: each one works on a copy and commits the
+// messages it added when it finishes, so concurrent runs are safe and their
+// turns are added in the order they finish.
+//
+// A memory strategy bounds the history by rewriting a run's copy, replacing
+// its oldest messages with a head (the system prompt, a summary). The history
+// is therefore a head followed by the messages added since the first dropped
+// ones, and a run commits its rewrite as the position it dropped messages up
+// to: turns committed by other runs in the meantime come after that position
+// and are kept.
+type history struct {
+	mu       sync.Mutex
+	messages []core.Message
+	head     int // number of leading messages put in place by a rewrite
+	dropped  int // number of messages dropped before the ones after the head
+}
+
+// historyMark records where a copy of the history stood when a run began.
+type historyMark struct {
+	head    int
+	dropped int
+}
+
+// historyCut is a rewrite to commit: the messages before position cut are
+// replaced with head.
+type historyCut struct {
+	head []core.Message
+	cut  int
+}
+
+// begin returns a copy of the history to run with, starting the history with
+// the system prompt if it is empty.
+func (h *history) begin(systemPrompt string) ([]core.Message, historyMark) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if len(h.messages) == 0 && systemPrompt != "" {
+		h.messages = append(h.messages, core.SystemMessage(systemPrompt))
+	}
+	return append([]core.Message(nil), h.messages...), historyMark{head: h.head, dropped: h.dropped}
+}
+
+// commit adds the messages a run added, after applying the run's rewrite, if
+// any. A rewrite that drops no further than the history already has only
+// lost the race to another run's and is skipped.
+func (h *history) commit(added []core.Message, rw *historyCut) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if rw != nil && rw.cut >= h.dropped {
+		rest := h.messages[h.head:]
+		skip := rw.cut - h.dropped
+		if skip > len(rest) {
+			skip = len(rest)
+		}
+		messages := make([]core.Message, 0, len(rw.head)+len(rest)-skip+len(added))
+		messages = append(messages, rw.head...)
+		h.messages = append(messages, rest[skip:]...)
+		h.head = len(rw.head)
+		h.dropped = rw.cut
+	}
+	h.messages = append(h.messages, added...)
+}
+
+// reset clears the history, leaving the system prompt if one is given.
+func (h *history) reset(systemPrompt string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.messages = nil
+	h.head, h.dropped = 0, 0
+	if systemPrompt != "" {
+		h.messages = append(h.messages, core.SystemMessage(systemPrompt))
+	}
+}
+
+// get returns a copy of the history.
+func (h *history) get() []core.Message {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return append([]core.Message(nil), h.messages...)
+}
+
+// session is the conversation a run reads and writes: a session in the
+// agent's store, or the agent's own history when it has no store.
+type session struct {
+	store   memory.Store
+	id      string
+	history *history
+
+	loaded int         // number of messages in the history after loading
+	mark   historyMark // where the agent's history stood when loaded
+}
+
+// rewrite describes how a memory strategy rewrote a run's copy of the
+// history: its first head messages replace the messages before index from of
+// the copy as loaded, followed by the run's own messages.
+type rewrite struct {
+	head int
+	from int
+}
+
+// sessionContext returns ctx carrying a session ID: the one already set on
+// ctx, else defaultID. The ID also attributes the run's cost to the session.
+func sessionContext(ctx context.Context, defaultID string) context.Context {
+	if defaultID == "" || core.SessionIDFromContext(ctx) != "" {
+		return ctx
+	}
+	return core.ContextWithSessionID(ctx, defaultID)
+}
+
+// openSession returns the session of a run and the history to run with. With
+// a store, that is the system prompt followed by the messages stored for the
+// session named by ctx; otherwise it is a copy of h.
+func openSession(ctx context.Context, store memory.Store, h *history, systemPrompt string) (*session, []core.Message, error) {
+	if store == nil {
+		messages, mark := h.begin(systemPrompt)
+		return &session{history: h, loaded: len(messages), mark: mark}, messages, nil
+	}
+
+	id := core.SessionIDFromContext(ctx)
+	if id == "" {
+		return nil, nil, &core.ErrInvalidArgument{
+			Argument: "sessionID",
+			Reason:   "the agent has a store but no session ID was set on the context or with a session option",
+		}
+	}
+
+	stored, err := store.Load(ctx, id)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load session: %w", err)
+	}
+
+	messages := make([]core.Message, 0, len(stored)+1)
+	if systemPrompt != "" {
+		messages = append(messages, core.SystemMessage(systemPrompt))
+	}
+	s := &session{store: store, id: id}
+	messages = append(messages, stored...)
+	s.loaded = len(messages)
+	return s, messages, nil
+}
+
+// resumeSession returns the session of a run resumed from a checkpoint, with
+// the bookkeeping the checkpoint recorded. Without a store, the history is
+// started with the system prompt if it is empty, as for a new run. The
+// history the run was loaded from is gone, so its turn is committed without
+// the memory strategy's rewrite.
+func resumeSession(store memory.Store, h *history, systemPrompt, id string, loaded int) *session {
+	if store == nil {
+		h.begin(systemPrompt)
+		return &session{history: h, loaded: loaded}
+	}
+	return &session{store: store, id: id, loaded: loaded}
+}
+
+// save records the messages a run added. A store keeps the full transcript,
+// so they are appended even when a memory strategy rewrote the history: the
+// rewrite only shaped what the LLM was sent. The agent's own history takes
+// the rewrite too, so that it stays bounded; messages is the rewritten copy.
+func (s *session) save(ctx context.Context, messages, added []core.Message, rw *rewrite) error {
+	if s.store == nil {
+		s.history.commit(added, s.cut(messages, rw))
+		return nil
+	}
+
+	if err := s.store.Append(ctx, s.id, added...); err != nil {
+		return fmt.Errorf("failed to save session: %w", err)
+	}
+	return nil
+}
+
+// cut converts a run's rewrite into one of the agent's history. Messages of
+// the loaded head that the rewrite kept stay in the head.
+func (s *session) cut(messages []core.Message, rw *rewrite) *historyCut {
+	if rw == nil {
+		return nil
+	}
+
+	head := rw.head
+	cut := s.mark.dropped
+	if rw.from < s.mark.head {
+		head += s.mark.head - rw.from
+	} else {
+		cut += rw.from - s.mark.head
+	}
+	return &historyCut{head: append([]core.Message(nil), messages[:head]...), cut: cut}
+}
@@ -0,0 +1,299 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/yashrahurikar23/goagents/core"
+)
+
+// toolExecutor runs the tool calls of one LLM turn.
+//
+// Calls run concurrently, bounded by maxParallel (0 means no limit), and the
+// results are returned in the order of the calls so they line up with the
+// assistant message that requested them.
+type toolExecutor struct {
+	tools       map[string]core.Tool
+	maxParallel int
+	timeout     time.Duration
+	timeouts    map[string]time.Duration
+	hooks       hookChain
+	approvals   *approvals
+}
+
+// execute runs the tool calls and returns one result per call, in call order.
+//
+// Tool failures, unknown tools, timeouts and calls vetoed by a hook or
+// rejected by the approver are reported in the result (as an "Error: ..." string the LLM can read) rather
+// than as an error. The returned
+// error is only set when ctx ends before all calls have finished.
+//
+// If emit is non-nil it receives an approval_required event when a call waits
+// for approval, a tool_start event as each call starts and a tool_end event
+// as it finishes. It may be called from several goroutines.
+func (e *toolExecutor) execute(ctx context.Context, toolCalls []core.ToolCall, emit func(core.StreamEvent)) ([]core.ToolCall, error) {
+	results := make([]core.ToolCall, len(toolCalls))
+
+	limit := e.maxParallel
+	if limit <= 0 || limit > len(toolCalls) {
+		limit = len(toolCalls)
+	}
+	sem := make(chan struct{}, limit)
+
+	var wg sync.WaitGroup
+	for i, tc := range toolCalls {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			wg.Wait()
+			return nil, ctx.Err()
+		}
+
+		wg.Add(1)
+		go func(i int, tc core.ToolCall) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			call := e.prepare(ctx, tc, emit)
+			if emit != nil {
+				emit(toolStartEvent(call))
+			}
+			if call.Error == nil {
+				call = e.executeOne(ctx, call)
+			}
+			e.hooks.AfterToolCall(ctx, &call)
+			results[i] = call
+			if emit != nil {
+				emit(toolEndEvent(call))
+			}
+		}(i, tc)
+	}
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// prepare copies a tool call for execution, runs the BeforeToolCall hooks and
+// asks for approval if needed. A vetoed or rejected call is returned with its
+// Error and Result set.
+func (e *toolExecutor) prepare(ctx context.Context, tc core.ToolCall, emit func(core.StreamEvent)) core.ToolCall {
+	args := tc.Args
+	if args == nil {
+		args = make(map[string]interface{})
+	}
+	call := core.ToolCall{ID: tc.ID, Name: tc.Name, Args: args}
+
+	if err := e.hooks.BeforeToolCall(ctx, &call); err != nil {
+		call.Error = &core.ErrToolCallRejected{ToolName: call.Name, Err: err}
+		call.Result = fmt.Sprintf("Error: %v", call.Error)
+		return call
+	}
+
+	if e.approvals != nil {
+		e.approvals.review(ctx, &call, e.tools, emit)
+	}
+	return call
+}
+
+// executeOne runs a single tool call with its timeout.
+func (e *toolExecutor) executeOne(ctx context.Context, tc core.ToolCall) core.ToolCall {
+	args := tc.Args
+	if args == nil {
+		args = make(map[string]interface{})
+	}
+	call := core.ToolCall{ID: tc.ID, Name: tc.Name, Args: args}
+
+	tool, exists := e.tools[tc.Name]
+	if !exists {
+		call.Error = &core.ErrToolNotFound{ToolName: tc.Name}
+		call.Result = fmt.Sprintf("Error: tool '%s' not found", tc.Name)
+		return call
+	}
+
+	validated, err := core.ValidateToolArgs(tool.Schema(), args)
+	if err != nil {
+		failToolArgs(&call, err)
+		return call
+	}
+	args = validated
+	call.Args = args
+
+	timeout := e.timeout
+	if t, ok := e.timeouts[tc.Name]; ok {
+		timeout = t
+	}
+
+	callCtx := ctx
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		callCtx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	type outcome struct {
+		result interface{}
+		err    error
+	}
+	done := make(chan outcome, 1)
+
+	start := time.Now()
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				done <- outcome{err: fmt.Errorf("panic: %v", r)}
+			}
+		}()
+		result, err := tool.Execute(callCtx, args)
+		done <- outcome{result, err}
+	}()
+
+	// A tool that ignores its context is abandoned when the timeout fires;
+	// its goroutine finishes in the background.
+	var out outcome
+	select {
+	case out = <-done:
+	case <-callCtx.Done():
+		out.err = callCtx.Err()
+	}
+	call.Duration = time.Since(start)
+
+	switch {
+	case out.err == nil:
+		call.Result = fmt.Sprintf("%v", out.result)
+	case ctx.Err() == nil && callCtx.Err() == context.DeadlineExceeded:
+		call.Error = &core.ErrTimeout{Operation: "tool " + tc.Name}
+		call.Result = fmt.Sprintf("Error: tool '%s' timed out after %s", tc.Name, timeout)
+	default:
+		call.Error = &core.ErrToolExecution{ToolName: tc.Name, Err: out.err}
+		call.Result = fmt.Sprintf("Error: %v", out.err)
+	}
+	return call
+}
+
+// failToolArgs fails a call whose arguments did not validate. Invalid
+// arguments are sent back to the LLM as a structured error instead of
+// reaching the tool, so the model can correct its call.
+func failToolArgs(call *core.ToolCall, err error) {
+	call.Error = err
+	if invalid, ok := err.(*core.ErrInvalidToolArgs); ok {
+		call.Result = invalid.Feedback()
+	} else {
+		call.Result = fmt.Sprintf("Error: %v", err)
+	}
+}
+
+// toolSet holds the tools of an agent. Tools may be registered while the
+// agent runs: the map is replaced rather than modified, so each run keeps
+// the tools it started with.
+type toolSet struct {
+	mu    sync.RWMutex
+	tools map[string]core.Tool
+}
+
+func newToolSet() *toolSet {
+	return &toolSet{tools: make(map[string]core.Tool)}
+}
+
+// add validates a tool and registers it under its name.
+func (s *toolSet) add(tool core.Tool) error {
+	if tool == nil {
+		return &core.ErrInvalidArgument{
+			Argument: "tool",
+			Reason:   "cannot be nil",
+		}
+	}
+
+	name := tool.Name()
+	if name == "" {
+		return &core.ErrInvalidArgument{
+			Argument: "tool.Name()",
+			Reason:   "cannot be empty",
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.tools[name]; exists {
+		return fmt.Errorf("tool %s already registered", name)
+	}
+
+	tools := make(map[string]core.Tool, len(s.tools)+1)
+	for n, t := range s.tools {
+		tools[n] = t
+	}
+	tools[name] = tool
+	s.tools = tools
+	return nil
+}
+
+// snapshot returns the registered tools. The map must not be modified.
+func (s *toolSet) snapshot() map[string]core.Tool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.tools
+}
+
+// sortedTools returns the tools sorted by name.
+// Sorting keeps the tool order sent to the LLM stable across calls.
+func sortedTools(tools map[string]core.Tool) []core.Tool {
+	names := make([]string, 0, len(tools))
+	for name := range tools {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	list := make([]core.Tool, 0, len(names))
+	for _, name := range names {
+		list = append(list, tools[name])
+	}
+	return list
+}
+
+// toolTurnMessages returns an assistant tool-call message followed by one
+// tool result message per call, so the LLM sees its calls and their results.
+func toolTurnMessages(content string, toolResults []core.ToolCall) []core.Message {
+	messages := make([]core.Message, 0, len(toolResults)+1)
+	messages = append(messages, core.Message{
+		Role:      "assistant",
+		Content:   content,
+		ToolCalls: toolResults,
+	})
+
+	for _, result := range toolResults {
+		messages = append(messages, core.Message{
+			Role:       "tool",
+			Content:    fmt.Sprintf("%v", result.Result),
+			Name:       result.Name,
+			ToolCallID: result.ID,
+		})
+	}
+	return messages
+}
+
+// toolStartEvent builds the tool_start event for a call.
+func toolStartEvent(tc core.ToolCall) core.StreamEvent {
+	return core.NewStreamEventWithData(core.EventTypeToolStart, tc.Name, map[string]interface{}{
+		"tool_id":   tc.ID,
+		"arguments": tc.Args,
+	})
+}
+
+// toolEndEvent builds the tool_end event for a finished call.
+func toolEndEvent(result core.ToolCall) core.StreamEvent {
+	data := map[string]interface{}{
+		"tool_id":  result.ID,
+		"result":   result.Result,
+		"duration": result.Duration,
+	}
+	if result.Error != nil {
+		data["error"] = result.Error.Error()
+	}
+	return core.NewStreamEventWithData(core.EventTypeToolEnd, result.Name, data)
+}
@@ -0,0 +1,84 @@
+package agent
+
+import (
+	"context"
+
+	"github.com/yashrahurikar23/goagents/core"
+)
+
+// runUsage accumulates the token usage of the LLM calls made during one agent
+// run and reports each call to an optional core.CostTracker.
+type runUsage struct {
+	ctx     context.Context
+	tracker *core.CostTracker
+	total   *core.Usage
+}
+
+// startRun returns a context carrying a run ID (generating one if the caller
+// did not set it) and a usage accumulator for the run.
+func startRun(ctx context.Context, tracker *core.CostTracker) (context.Context, *runUsage) {
+	if core.RunIDFromContext(ctx) == "" {
+		ctx = core.ContextWithRunID(ctx, core.NewRunID())
+	}
+	return ctx, &runUsage{ctx: ctx, tracker: tracker}
+}
+
+// add records the usage of one LLM call. Calls without usage are ignored.
+func (u *runUsage) add(model string, usage *core.Usage) {
+	if usage == nil {
+		return
+	}
+
+	if u.total == nil {
+		u.total = &core.Usage{}
+	}
+	*u.total = u.total.Add(*usage)
+
+	if u.tracker != nil {
+		u.tracker.Record(u.ctx, model, *usage)
+	}
+}
+
+// restore starts the total from the usage of a resumed run's earlier calls,
+// which were already reported to the tracker.
+func (u *runUsage) restore(total *core.Usage) {
+	if total != nil {
+		restored := *total
+		u.total = &restored
+	}
+}
+
+// addExecuted adds the usage of calls made by an inner agent, which reports
+// them to its own tracker, to the total.
+func (u *runUsage) addExecuted(usage core.Usage) {
+	if u.total == nil {
+		u.total = &core.Usage{}
+	}
+	*u.total = u.total.Add(usage)
+}
+
+// addResponse records the usage of an LLM response.
+func (u *runUsage) addResponse(resp *core.Response) {
+	model, _ := resp.Meta["model"].(string)
+	u.add(model, resp.Usage)
+}
+
+// addChunk records the usage reported on a final stream chunk.
+func (u *runUsage) addChunk(chunk core.StreamChunk) {
+	model, _ := chunk.Metadata["model"].(string)
+	u.add(model, chunk.Usage)
+}
+
+// runID returns the ID of the run.
+func (u *runUsage) runID() string {
+	return core.RunIDFromContext(u.ctx)
+}
+
+// eventData returns the usage fields attached to a run's complete event.
+func (u *runUsage) eventData() map[string]interface{} {
+	data := map[string]interface{}{"run_id": u.runID()}
+	if u.total != nil {
+		data["usage"] = *u.total
+	}
+	return data
+}
@@ -0,0 +1,499 @@
+package core
+
+import (
+	"container/list"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ModelPricing is the price of a model in USD per million tokens.
+type ModelPricing struct {
+	// InputPerMillion is the price of one million prompt tokens
+	InputPerMillion float64
+
+	// OutputPerMillion is the price of one million completion tokens
+	OutputPerMillion float64
+}
+
+// Cost returns the price of the given usage in USD.
+func (p ModelPricing) Cost(usage Usage) float64 {
+	return (float64(usage.PromptTokens)*p.InputPerMillion +
+		float64(usage.CompletionTokens)*p.OutputPerMillion) / 1_000_000
+}
+
+// PricingTable maps model names to their prices.
+//
+// Lookups fall back to the longest matching prefix, so an entry for
+// "gpt-4o" also prices dated snapshots such as "gpt-4o-2024-08-06".
+type PricingTable map[string]ModelPricing
+
+// DefaultPricing returns a copy of the built-in list prices of common models.
+// Prices change over time; override entries with CostTracker.SetPricing or use your own table
+// when exact figures matter. Local models (Ollama) are not priced.
+func DefaultPricing() PricingTable {
+	return PricingTable{
+		// OpenAI
+		"gpt-5":         {InputPerMillion: 1.25, OutputPerMillion: 10},
+		"gpt-5-mini":    {InputPerMillion: 0.25, OutputPerMillion: 2},
+		"gpt-5-nano":    {InputPerMillion: 0.05, OutputPerMillion: 0.40},
+		"gpt-4.1":       {InputPerMillion: 2, OutputPerMillion: 8},
+		"gpt-4.1-mini":  {InputPerMillion: 0.40, OutputPerMillion: 1.60},
+		"gpt-4.1-nano":  {InputPerMillion: 0.10, OutputPerMillion: 0.40},
+		"gpt-4o":        {InputPerMillion: 2.50, OutputPerMillion: 10},
+		"gpt-4o-mini":   {InputPerMillion: 0.15, OutputPerMillion: 0.60},
+		"gpt-4-turbo":   {InputPerMillion: 10, OutputPerMillion: 30},
+		"gpt-4":         {InputPerMillion: 30, OutputPerMillion: 60},
+		"gpt-3.5-turbo": {InputPerMillion: 0.50, OutputPerMillion: 1.50},
+		"o1":            {InputPerMillion: 15, OutputPerMillion: 60},
+		"o1-mini":       {InputPerMillion: 1.10, OutputPerMillion: 4.40},
+		"o3-mini":       {InputPerMillion: 1.10, OutputPerMillion: 4.40},
+
+		// Anthropic
+		"claude-opus-4":     {InputPerMillion: 15, OutputPerMillion: 75},
+		"claude-sonnet-4":   {InputPerMillion: 3, OutputPerMillion: 15},
+		"claude-3-7-sonnet": {InputPerMillion: 3, OutputPerMillion: 15},
+		"claude-3-5-sonnet": {InputPerMillion: 3, OutputPerMillion: 15},
+		"claude-3-5-haiku":  {InputPerMillion: 0.80, OutputPerMillion: 4},
+		"claude-3-opus":     {InputPerMillion: 15, OutputPerMillion: 75},
+		"claude-3-sonnet":   {InputPerMillion: 3, OutputPerMillion: 15},
+		"claude-3-haiku":    {InputPerMillion: 0.25, OutputPerMillion: 1.25},
+
+		// Google Gemini
+		"gemini-2.5-pro":      {InputPerMillion: 1.25, OutputPerMillion: 10},
+		"gemini-2.5-flash":    {InputPerMillion: 0.30, OutputPerMillion: 2.50},
+		"gemini-2.0-flash":    {InputPerMillion: 0.10, OutputPerMillion: 0.40},
+		"gemini-1.5-pro":      {InputPerMillion: 1.25, OutputPerMillion: 5},
+		"gemini-1.5-flash":    {InputPerMillion: 0.075, OutputPerMillion: 0.30},
+		"gemini-1.5-flash-8b": {InputPerMillion: 0.0375, OutputPerMillion: 0.15},
+		"gemini-pro":          {InputPerMillion: 0.50, OutputPerMillion: 1.50},
+	}
+}
+
+// Lookup returns the pricing for a model, matching the exact name first and
+// then the longest entry that is a prefix of it.
+func (t PricingTable) Lookup(model string) (ModelPricing, bool) {
+	return lookupModel(t, model)
+}
+
+// lookupModel returns the entry for a model, matching the exact name first
+// and then the longest entry that is a prefix of it.
+func lookupModel[V any](table map[string]V, model string) (V, bool) {
+	if v, ok := table[model]; ok {
+		return v, true
+	}
+
+	best := ""
+	for name := range table {
+		if len(name) > len(best) && strings.HasPrefix(model, name) {
+			best = name
+		}
+	}
+	if best == "" {
+		var zero V
+		return zero, false
+	}
+	return table[best], true
+}
+
+// Cost returns the price of usage on model in USD, and whether the model is priced.
+func (t PricingTable) Cost(model string, usage Usage) (float64, bool) {
+	p, ok := t.Lookup(model)
+	if !ok {
+		return 0, false
+	}
+	return p.Cost(usage), true
+}
+
+type contextKey int
+
+const (
+	sessionIDKey contextKey = iota
+	runIDKey
+)
+
+// ContextWithSessionID returns a context carrying a session ID.
+// Cost is attributed to this session when usage is recorded with the context.
+func ContextWithSessionID(ctx context.Context, sessionID string) context.Context {
+	return context.WithValue(ctx, sessionIDKey, sessionID)
+}
+
+// SessionIDFromContext returns the session ID stored in ctx, if any.
+func SessionIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(sessionIDKey).(string)
+	return id
+}
+
+// ContextWithRunID returns a context carrying an agent run ID.
+// Agents set one for every Run if the caller has not.
+func ContextWithRunID(ctx context.Context, runID string) context.Context {
+	return context.WithValue(ctx, runIDKey, runID)
+}
+
+// RunIDFromContext returns the run ID stored in ctx, if any.
+func RunIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(runIDKey).(string)
+	return id
+}
+
+// NewRunID generates a random run identifier.
+func NewRunID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "run_" + time.Now().Format("20060102150405.000000000")
+	}
+	return "run_" + hex.EncodeToString(b)
+}
+
+// CostEntry records the usage and cost of a single LLM request.
+type CostEntry struct {
+	Model     string
+	SessionID string
+	RunID     string
+	Usage     Usage
+
+	// Cost is the price in USD (zero if the model is not priced)
+	Cost float64
+
+	// Priced reports whether the model was found in the pricing table
+	Priced bool
+
+	Time time.Time
+}
+
+// CostSummary aggregates usage and cost over several requests.
+type CostSummary struct {
+	Calls int
+	Usage Usage
+	Cost  float64
+}
+
+func (s CostSummary) add(e CostEntry) CostSummary {
+	return CostSummary{
+		Calls: s.Calls + 1,
+		Usage: s.Usage.Add(e.Usage),
+		Cost:  s.Cost + e.Cost,
+	}
+}
+
+// DefaultCostEntryLimit is the number of recent entries a CostTracker keeps
+// unless WithCostEntryLimit says otherwise.
+const DefaultCostEntryLimit = 1000
+
+// DefaultCostGroupLimit is the number of runs, and of sessions, a CostTracker
+// keeps totals for unless WithCostGroupLimit says otherwise.
+const DefaultCostGroupLimit = 10000
+
+// CostTrackerOption configures a CostTracker.
+type CostTrackerOption func(*CostTracker)
+
+// WithCostEntryLimit sets how many of the most recent entries are kept for
+// Entries. Zero keeps none. Totals are kept for every request regardless.
+func WithCostEntryLimit(n int) CostTrackerOption {
+	return func(t *CostTracker) {
+		if n < 0 {
+			n = 0
+		}
+		t.limit = n
+	}
+}
+
+// WithCostGroupLimit sets how many runs, and how many sessions, totals are
+// kept for. Once there are more, the totals of the least recently recorded
+// are dropped: Run and Session report nothing for them. Zero or less keeps
+// totals for all of them, which grows with every run.
+func WithCostGroupLimit(n int) CostTrackerOption {
+	return func(t *CostTracker) {
+		t.groupLimit = n
+	}
+}
+
+// WithCostSink calls sink with every recorded entry, for example to export
+// spend to a database or metrics system. Sink runs synchronously after the
+// entry is counted, so it should not block.
+func WithCostSink(sink func(CostEntry)) CostTrackerOption {
+	return func(t *CostTracker) {
+		t.sinks = append(t.sinks, sink)
+	}
+}
+
+// CostTracker accumulates token usage and spend per model, per agent run and
+// per session. It is safe for concurrent use.
+//
+// Totals are kept as running sums, so queries do not depend on the number of
+// requests recorded. Every run gets its own ID, so totals per run and per
+// session are only kept for the most recently active ones (see
+// WithCostGroupLimit), or until dropped with ForgetRun and ForgetSession, and
+// only the most recent entries are kept (see WithCostEntryLimit). Use
+// WithCostSink to keep the full history of every run and session elsewhere.
+//
+// Example:
+//
+//	tracker := core.NewCostTracker(nil)
+//	agent := agent.NewFunctionAgent(llm, agent.WithCostTracker(tracker))
+//
+//	ctx = core.ContextWithSessionID(ctx, "tenant-42/session-1")
+//	agent.Run(ctx, "What is 25 * 4?")
+//
+//	fmt.Printf("$%.4f\n", tracker.Session("tenant-42/session-1").Cost)
+type CostTracker struct {
+	mu      sync.RWMutex
+	pricing PricingTable
+	sinks   []func(CostEntry)
+
+	total      CostSummary
+	byModel    map[string]CostSummary
+	byRun      *costGroups
+	bySession  *costGroups
+	groupLimit int
+
+	// entries is a ring of the last limit entries; next is where the next
+	// entry goes once it is full
+	entries []CostEntry
+	next    int
+	limit   int
+}
+
+// NewCostTracker creates a cost tracker using a copy of the given prices.
+// A nil table uses DefaultPricing.
+func NewCostTracker(pricing PricingTable, opts ...CostTrackerOption) *CostTracker {
+	table := DefaultPricing()
+	if pricing != nil {
+		table = make(PricingTable, len(pricing))
+		for model, p := range pricing {
+			table[model] = p
+		}
+	}
+
+	t := &CostTracker{pricing: table, limit: DefaultCostEntryLimit, groupLimit: DefaultCostGroupLimit}
+	for _, opt := range opts {
+		opt(t)
+	}
+	t.clear()
+	return t
+}
+
+// SetPricing sets or overrides the price of a model.
+func (t *CostTracker) SetPricing(model string, pricing ModelPricing) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.pricing[model] = pricing
+}
+
+// Record adds the usage of one request on model. The session and run are
+// taken from ctx (see ContextWithSessionID and ContextWithRunID).
+func (t *CostTracker) Record(ctx context.Context, model string, usage Usage) CostEntry {
+	t.mu.Lock()
+
+	cost, priced := t.pricing.Cost(model, usage)
+	entry := CostEntry{
+		Model:     model,
+		SessionID: SessionIDFromContext(ctx),
+		RunID:     RunIDFromContext(ctx),
+		Usage:     usage,
+		Cost:      cost,
+		Priced:    priced,
+		Time:      time.Now(),
+	}
+
+	t.total = t.total.add(entry)
+	t.byModel[entry.Model] = t.byModel[entry.Model].add(entry)
+	t.byRun.add(entry.RunID, entry)
+	t.bySession.add(entry.SessionID, entry)
+
+	switch {
+	case t.limit == 0:
+	case len(t.entries) < t.limit:
+		t.entries = append(t.entries, entry)
+	default:
+		t.entries[t.next] = entry
+		t.next = (t.next + 1) % t.limit
+	}
+
+	sinks := t.sinks
+	t.mu.Unlock()
+
+	for _, sink := range sinks {
+		sink(entry)
+	}
+	return entry
+}
+
+// RecordResponse records the usage of an LLM response, if it has any.
+// The model is read from the response's "model" metadata.
+func (t *CostTracker) RecordResponse(ctx context.Context, resp *Response) {
+	if resp == nil || resp.Usage == nil {
+		return
+	}
+	model, _ := resp.Meta["model"].(string)
+	t.Record(ctx, model, *resp.Usage)
+}
+
+// Total returns the usage and cost of every recorded request.
+func (t *CostTracker) Total() CostSummary {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.total
+}
+
+// Run returns the usage and cost of one agent run.
+func (t *CostTracker) Run(runID string) CostSummary {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.byRun.get(runID)
+}
+
+// Session returns the usage and cost of one session.
+func (t *CostTracker) Session(sessionID string) CostSummary {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.bySession.get(sessionID)
+}
+
+// ByModel returns the usage and cost grouped by model.
+func (t *CostTracker) ByModel() map[string]CostSummary {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return copySummaries(t.byModel)
+}
+
+// BySession returns the usage and cost grouped by session ID.
+// Requests recorded without a session are grouped under "".
+func (t *CostTracker) BySession() map[string]CostSummary {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.bySession.all()
+}
+
+// ByRun returns the usage and cost grouped by run ID.
+// Requests recorded outside an agent run are grouped under "".
+func (t *CostTracker) ByRun() map[string]CostSummary {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.byRun.all()
+}
+
+// ForgetRun drops the totals of a run, for example once they have been
+// reported. The overall and per-model totals keep its usage.
+func (t *CostTracker) ForgetRun(runID string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.byRun.remove(runID)
+}
+
+// ForgetSession drops the totals of a session, for example when it ends.
+// The overall and per-model totals keep its usage.
+func (t *CostTracker) ForgetSession(sessionID string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.bySession.remove(sessionID)
+}
+
+// Entries returns a copy of the kept entries in recording order: the most
+// recent ones, up to the entry limit.
+func (t *CostTracker) Entries() []CostEntry {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	entries := make([]CostEntry, 0, len(t.entries))
+	entries = append(entries, t.entries[t.next:]...)
+	entries = append(entries, t.entries[:t.next]...)
+	return entries
+}
+
+// Models returns the sorted names of the models with recorded usage.
+func (t *CostTracker) Models() []string {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	models := make([]string, 0, len(t.byModel))
+	for model := range t.byModel {
+		models = append(models, model)
+	}
+	sort.Strings(models)
+	return models
+}
+
+// Reset discards all recorded usage. Pricing is kept.
+func (t *CostTracker) Reset() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.clear()
+}
+
+func (t *CostTracker) clear() {
+	t.total = CostSummary{}
+	t.byModel = make(map[string]CostSummary)
+	t.byRun = newCostGroups(t.groupLimit)
+	t.bySession = newCostGroups(t.groupLimit)
+	t.entries = nil
+	t.next = 0
+}
+
+func copySummaries(src map[string]CostSummary) map[string]CostSummary {
+	groups := make(map[string]CostSummary, len(src))
+	for k, v := range src {
+		groups[k] = v
+	}
+	return groups
+}
+
+// costGroups holds the totals of the most recently recorded keys, up to
+// limit of them (no limit when it is zero or less).
+type costGroups struct {
+	limit int
+	items map[string]*list.Element
+	order *list.List // of *costGroup, most recently recorded first
+}
+
+type costGroup struct {
+	key     string
+	summary CostSummary
+}
+
+func newCostGroups(limit int) *costGroups {
+	return &costGroups{limit: limit, items: make(map[string]*list.Element), order: list.New()}
+}
+
+// add counts an entry under key, dropping the least recently recorded key
+// if there are too many.
+func (g *costGroups) add(key string, e CostEntry) {
+	if el, ok := g.items[key]; ok {
+		group := el.Value.(*costGroup)
+		group.summary = group.summary.add(e)
+		g.order.MoveToFront(el)
+		return
+	}
+
+	g.items[key] = g.order.PushFront(&costGroup{key: key, summary: CostSummary{}.add(e)})
+	if g.limit > 0 && g.order.Len() > g.limit {
+		g.remove(g.order.Back().Value.(*costGroup).key)
+	}
+}
+
+func (g *costGroups) get(key string) CostSummary {
+	if el, ok := g.items[key]; ok {
+		return el.Value.(*costGroup).summary
+	}
+	return CostSummary{}
+}
+
+func (g *costGroups) remove(key string) {
+	if el, ok := g.items[key]; ok {
+		g.order.Remove(el)
+		delete(g.items, key)
+	}
+}
+
+// all returns a copy of the totals by key.
+func (g *costGroups) all() map[string]CostSummary {
+	groups := make(map[string]CostSummary, len(g.items))
+	for key, el := range g.items {
+		groups[key] = el.Value.(*costGroup).summary
+	}
+	return groups
+}
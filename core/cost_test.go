@@ -0,0 +1,259 @@
+package core
+
+import (
+	"context"
+	"math"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func almostEqual(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
+
+// TestUsage_Add tests summing usage
+func TestUsage_Add(t *testing.T) {
+	u := NewUsage(10, 5).Add(*NewUsage(3, 2))
+
+	if u.PromptTokens != 13 || u.CompletionTokens != 7 || u.TotalTokens != 20 {
+		t.Errorf("Add() = %+v, want 13/7/20", u)
+	}
+}
+
+// TestPricingTable_Lookup tests exact and longest-prefix lookups
+func TestPricingTable_Lookup(t *testing.T) {
+	table := DefaultPricing()
+
+	tests := []struct {
+		model string
+		want  string
+	}{
+		{"gpt-4o", "gpt-4o"},
+		{"gpt-4o-mini-2024-07-18", "gpt-4o-mini"},
+		{"gpt-4o-2024-08-06", "gpt-4o"},
+		{"gpt-4-0613", "gpt-4"},
+		{"claude-3-5-sonnet-20241022", "claude-3-5-sonnet"},
+		{"gemini-1.5-flash-8b", "gemini-1.5-flash-8b"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.model, func(t *testing.T) {
+			got, ok := table.Lookup(tt.model)
+			if !ok {
+				t.Fatalf("Lookup(%q) not found", tt.model)
+			}
+			if got != table[tt.want] {
+				t.Errorf("Lookup(%q) = %+v, want price of %q", tt.model, got, tt.want)
+			}
+		})
+	}
+
+	if _, ok := table.Lookup("llama3.2"); ok {
+		t.Error("Lookup(llama3.2) found a price, want none for local models")
+	}
+}
+
+// TestPricingTable_Cost tests cost calculation
+func TestPricingTable_Cost(t *testing.T) {
+	table := PricingTable{"m": {InputPerMillion: 2, OutputPerMillion: 8}}
+
+	cost, ok := table.Cost("m", *NewUsage(500_000, 250_000))
+	if !ok || !almostEqual(cost, 3) {
+		t.Errorf("Cost() = %v, %v; want 3, true", cost, ok)
+	}
+
+	if cost, ok := table.Cost("other", *NewUsage(1000, 1000)); ok || cost != 0 {
+		t.Errorf("Cost() of unpriced model = %v, %v; want 0, false", cost, ok)
+	}
+}
+
+// TestCostTracker tests aggregation per model, run and session
+func TestCostTracker(t *testing.T) {
+	tracker := NewCostTracker(PricingTable{
+		"cheap":  {InputPerMillion: 1, OutputPerMillion: 1},
+		"pricey": {InputPerMillion: 10, OutputPerMillion: 10},
+	})
+
+	tenantA := ContextWithSessionID(context.Background(), "tenant-a")
+	tenantB := ContextWithSessionID(context.Background(), "tenant-b")
+	run1 := ContextWithRunID(tenantA, "run-1")
+
+	tracker.Record(run1, "cheap", *NewUsage(1_000_000, 0))
+	tracker.Record(run1, "pricey", *NewUsage(0, 1_000_000))
+	tracker.Record(tenantB, "cheap", *NewUsage(500_000, 500_000))
+	entry := tracker.Record(tenantB, "local", *NewUsage(10, 10))
+
+	if entry.Priced || entry.Cost != 0 {
+		t.Errorf("unpriced entry = %+v, want zero cost", entry)
+	}
+
+	if total := tracker.Total(); total.Calls != 4 || !almostEqual(total.Cost, 12) {
+		t.Errorf("Total() = %+v, want 4 calls costing 12", total)
+	}
+
+	if run := tracker.Run("run-1"); run.Calls != 2 || !almostEqual(run.Cost, 11) {
+		t.Errorf("Run(run-1) = %+v, want 2 calls costing 11", run)
+	}
+
+	sessions := tracker.BySession()
+	if !almostEqual(sessions["tenant-a"].Cost, 11) || !almostEqual(sessions["tenant-b"].Cost, 1) {
+		t.Errorf("BySession() = %+v", sessions)
+	}
+	if s := tracker.Session("tenant-b"); s.Usage.TotalTokens != 1_000_020 {
+		t.Errorf("Session(tenant-b).Usage = %+v", s.Usage)
+	}
+
+	byModel := tracker.ByModel()
+	if byModel["cheap"].Calls != 2 || !almostEqual(byModel["cheap"].Cost, 2) {
+		t.Errorf("ByModel()[cheap] = %+v, want 2 calls costing 2", byModel["cheap"])
+	}
+
+	if models := strings.Join(tracker.Models(), ","); models != "cheap,local,pricey" {
+		t.Errorf("Models() = %s", models)
+	}
+
+	tracker.Reset()
+	if total := tracker.Total(); total.Calls != 0 {
+		t.Errorf("Total() after Reset = %+v", total)
+	}
+}
+
+// TestCostTracker_SetPricing tests that overrides do not leak into the caller's table
+func TestCostTracker_SetPricing(t *testing.T) {
+	table := PricingTable{}
+	tracker := NewCostTracker(table)
+	tracker.SetPricing("custom", ModelPricing{InputPerMillion: 4})
+
+	if entry := tracker.Record(context.Background(), "custom", *NewUsage(250_000, 0)); !almostEqual(entry.Cost, 1) {
+		t.Errorf("Record() cost = %v, want 1", entry.Cost)
+	}
+	if len(table) != 0 {
+		t.Errorf("caller's table was modified: %v", table)
+	}
+}
+
+// TestCostTracker_RecordResponse tests recording from a response
+func TestCostTracker_RecordResponse(t *testing.T) {
+	tracker := NewCostTracker(nil)
+
+	tracker.RecordResponse(context.Background(), &Response{Content: "no usage"})
+	tracker.RecordResponse(context.Background(), &Response{
+		Usage: NewUsage(1_000_000, 0),
+		Meta:  map[string]interface{}{"model": "gpt-4o-mini"},
+	})
+
+	total := tracker.Total()
+	if total.Calls != 1 || !almostEqual(total.Cost, 0.15) {
+		t.Errorf("Total() = %+v, want 1 call costing 0.15", total)
+	}
+}
+
+// TestCostTracker_Concurrent tests concurrent recording
+func TestCostTracker_Concurrent(t *testing.T) {
+	tracker := NewCostTracker(nil)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			tracker.Record(context.Background(), "gpt-4o", *NewUsage(1, 1))
+			tracker.Total()
+		}()
+	}
+	wg.Wait()
+
+	if total := tracker.Total(); total.Calls != 50 {
+		t.Errorf("Total().Calls = %d, want 50", total.Calls)
+	}
+}
+
+// TestCostTracker_EntryLimit tests that only recent entries are kept while
+// totals cover every request
+func TestCostTracker_EntryLimit(t *testing.T) {
+	var exported []string
+	tracker := NewCostTracker(nil,
+		WithCostEntryLimit(2),
+		WithCostSink(func(e CostEntry) { exported = append(exported, e.Model) }),
+	)
+
+	for _, model := range []string{"a", "b", "c"} {
+		tracker.Record(context.Background(), model, *NewUsage(1, 1))
+	}
+
+	var kept []string
+	for _, e := range tracker.Entries() {
+		kept = append(kept, e.Model)
+	}
+	if strings.Join(kept, ",") != "b,c" {
+		t.Errorf("Entries() = %v, want the last 2 in order", kept)
+	}
+	if strings.Join(exported, ",") != "a,b,c" {
+		t.Errorf("sink got %v, want every entry", exported)
+	}
+	if total := tracker.Total(); total.Calls != 3 || len(tracker.Models()) != 3 {
+		t.Errorf("Total() = %+v, Models() = %v, want all 3 requests", total, tracker.Models())
+	}
+
+	none := NewCostTracker(nil, WithCostEntryLimit(0))
+	none.Record(context.Background(), "a", *NewUsage(1, 1))
+	if len(none.Entries()) != 0 || none.Total().Calls != 1 {
+		t.Errorf("Entries() = %v, Total() = %+v, want no entries but the call counted", none.Entries(), none.Total())
+	}
+}
+
+// TestCostTracker_GroupLimit tests that totals are kept for the most
+// recently recorded runs and sessions only, and can be forgotten
+func TestCostTracker_GroupLimit(t *testing.T) {
+	tracker := NewCostTracker(nil, WithCostGroupLimit(2))
+	record := func(run, session string) {
+		ctx := ContextWithSessionID(ContextWithRunID(context.Background(), run), session)
+		tracker.Record(ctx, "gpt-4o", *NewUsage(1, 1))
+	}
+
+	record("run-1", "tenant-a")
+	record("run-2", "tenant-b")
+	record("run-1", "tenant-a") // run-1 is now the most recent
+	record("run-3", "tenant-c")
+
+	if runs := tracker.ByRun(); len(runs) != 2 || runs["run-1"].Calls != 2 || runs["run-3"].Calls != 1 {
+		t.Errorf("ByRun() = %+v, want run-1 and run-3", runs)
+	}
+	if run := tracker.Run("run-2"); run.Calls != 0 {
+		t.Errorf("Run(run-2) = %+v, want it dropped", run)
+	}
+	if sessions := tracker.BySession(); len(sessions) != 2 || sessions["tenant-b"].Calls != 0 {
+		t.Errorf("BySession() = %+v, want tenant-a and tenant-c", sessions)
+	}
+	if total := tracker.Total(); total.Calls != 4 {
+		t.Errorf("Total() = %+v, want every request", total)
+	}
+
+	tracker.ForgetRun("run-1")
+	tracker.ForgetSession("tenant-c")
+	if tracker.Run("run-1").Calls != 0 || tracker.Session("tenant-c").Calls != 0 || tracker.Session("tenant-a").Calls != 2 {
+		t.Errorf("ByRun() = %+v, BySession() = %+v, want run-1 and tenant-c forgotten", tracker.ByRun(), tracker.BySession())
+	}
+	if total := tracker.Total(); total.Calls != 4 {
+		t.Errorf("Total() = %+v, want forgotten requests still counted", total)
+	}
+}
+
+// TestRunIDContext tests run and session IDs on contexts
+func TestRunIDContext(t *testing.T) {
+	ctx := context.Background()
+	if RunIDFromContext(ctx) != "" || SessionIDFromContext(ctx) != "" {
+		t.Error("expected empty IDs on a background context")
+	}
+
+	id := NewRunID()
+	if !strings.HasPrefix(id, "run_") || id == NewRunID() {
+		t.Errorf("NewRunID() = %q, want unique run_ prefixed IDs", id)
+	}
+
+	ctx = ContextWithSessionID(ContextWithRunID(ctx, id), "s1")
+	if RunIDFromContext(ctx) != id || SessionIDFromContext(ctx) != "s1" {
+		t.Errorf("IDs not preserved on context")
+	}
+}
@@ -1,6 +1,10 @@
 package core
 
-import "fmt"
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
 
 // Error types for the GoAgent framework.
 
@@ -37,6 +41,21 @@ func (e *ErrToolExecution) Unwrap() error {
 	return e.Err
 }
 
+// ErrToolCallRejected indicates a tool call was not run because it was
+// rejected before execution, for example by an agent hook.
+type ErrToolCallRejected struct {
+	ToolName string
+	Err      error
+}
+
+func (e *ErrToolCallRejected) Error() string {
+	return fmt.Sprintf("tool %q call rejected: %v", e.ToolName, e.Err)
+}
+
+func (e *ErrToolCallRejected) Unwrap() error {
+	return e.Err
+}
+
 // ErrLLMFailure indicates an LLM request failed.
 type ErrLLMFailure struct {
 	Provider string
@@ -51,6 +70,42 @@ func (e *ErrLLMFailure) Unwrap() error {
 	return e.Err
 }
 
+// ErrInvalidToolArgs indicates the arguments of a tool call do not match the
+// tool's schema. See ValidateToolArgs.
+type ErrInvalidToolArgs struct {
+	ToolName   string
+	Violations []ArgViolation
+}
+
+// ArgViolation describes one invalid tool argument.
+type ArgViolation struct {
+	// Argument is the path of the argument, e.g. "limit", "filter.status" or "ids[2]"
+	Argument string `json:"argument"`
+
+	// Problem explains what is wrong, e.g. "is required" or "expected number, got string \"abc\""
+	Problem string `json:"problem"`
+}
+
+func (e *ErrInvalidToolArgs) Error() string {
+	problems := make([]string, len(e.Violations))
+	for i, v := range e.Violations {
+		problems[i] = v.Argument + " " + v.Problem
+	}
+	return fmt.Sprintf("invalid arguments for tool %q: %s", e.ToolName, strings.Join(problems, "; "))
+}
+
+// Feedback returns the error as a JSON tool result, so the model can see which
+// arguments were wrong and call the tool again with corrected ones.
+func (e *ErrInvalidToolArgs) Feedback() string {
+	data, _ := json.Marshal(map[string]interface{}{
+		"error":      "invalid_arguments",
+		"tool":       e.ToolName,
+		"violations": e.Violations,
+		"hint":       "Correct the listed arguments and call the tool again.",
+	})
+	return string(data)
+}
+
 // ErrTimeout indicates an operation timed out.
 type ErrTimeout struct {
 	Operation string
@@ -59,3 +114,14 @@ type ErrTimeout struct {
 func (e *ErrTimeout) Error() string {
 	return fmt.Sprintf("operation timed out: %s", e.Operation)
 }
+
+// ErrStructuredOutput indicates an LLM failed to produce output matching a schema.
+type ErrStructuredOutput struct {
+	Attempts int
+	Errors   []string
+	Raw      string
+}
+
+func (e *ErrStructuredOutput) Error() string {
+	return fmt.Sprintf("structured output invalid after %d attempt(s): %s", e.Attempts, strings.Join(e.Errors, "; "))
+}
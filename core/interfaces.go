@@ -11,7 +11,7 @@
 //	// Implement the LLM interface
 //	type MyLLM struct {}
 //
-//	func (m *MyLLM) Chat(ctx context.Context, messages []core.Message) (*core.Response, error) {
+//	func (m *MyLLM) Chat(ctx context.Context, messages []core.Message, opts ...core.CallOption) (*core.Response, error) {
 //	    // Implementation
 //	}
 package core
@@ -23,11 +23,12 @@ import "context"
 type LLM interface {
 	// Chat sends a conversation and receives a response.
 	// The messages slice should contain the conversation history.
-	Chat(ctx context.Context, messages []Message) (*Response, error)
+	// Call options override the client's defaults for this request only.
+	Chat(ctx context.Context, messages []Message, opts ...CallOption) (*Response, error)
 
 	// Complete sends a single prompt and receives a completion.
 	// This is a convenience wrapper around Chat for simple use cases.
-	Complete(ctx context.Context, prompt string) (string, error)
+	Complete(ctx context.Context, prompt string, opts ...CallOption) (string, error)
 }
 
 // Tool represents something an agent can use to accomplish tasks.
@@ -62,3 +63,39 @@ type Agent interface {
 	// Reset clears any conversation history or state.
 	Reset() error
 }
+
+// ToolCallingLLM extends the LLM interface with native tool (function) calling.
+// Providers that can advertise tools to the model and return structured tool
+// calls implement this interface, which lets agents such as FunctionAgent work
+// with any backend instead of being tied to a single provider.
+type ToolCallingLLM interface {
+	LLM
+
+	// ChatWithTools sends a conversation along with the tools the model may call.
+	// Tool invocations requested by the model are returned in Response.ToolCalls.
+	//
+	// Messages may include assistant messages carrying ToolCalls and "tool" role
+	// messages carrying results (linked via ToolCallID), so the caller can feed
+	// tool results back to the model on the next turn.
+	ChatWithTools(ctx context.Context, messages []Message, tools []Tool, opts ...CallOption) (*Response, error)
+}
+
+// StructuredOutputLLM extends the LLM interface with native structured output.
+// Providers implement it using their own mechanism (JSON Schema response formats,
+// response schemas, forced tool calls, ...). ChatStructured uses it when available
+// and falls back to prompting for JSON otherwise.
+type StructuredOutputLLM interface {
+	LLM
+
+	// ChatWithSchema sends a conversation and constrains the reply to the schema.
+	// The resulting JSON document is returned in Response.Content.
+	ChatWithSchema(ctx context.Context, messages []Message, schema *ResponseSchema, opts ...CallOption) (*Response, error)
+}
+
+// Embedder turns texts into vectors (embeddings) whose distance reflects how
+// close their meanings are. Long-term memory uses it to find the memories
+// relevant to a message.
+type Embedder interface {
+	// Embed returns one vector per text, in the order of texts.
+	Embed(ctx context.Context, texts []string) ([][]float64, error)
+}
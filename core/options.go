@@ -0,0 +1,135 @@
+package core
+
+// CallOption configures a single LLM request.
+//
+// Call options override the defaults a client was constructed with for one
+// call only, so a single client can serve both creative and deterministic
+// requests:
+//
+//	resp, err := llm.Chat(ctx, messages,
+//	    core.WithTemperature(0),
+//	    core.WithMaxTokens(256),
+//	    core.WithStop("\n\n"),
+//	)
+//
+// Providers ignore options they cannot express (for example, metadata on
+// providers without a metadata field).
+type CallOption func(*CallOptions)
+
+// Tool choice values for WithToolChoice. Any other value is treated as the
+// name of a specific tool the model must call.
+const (
+	ToolChoiceAuto     = "auto"     // The model decides whether to call a tool
+	ToolChoiceNone     = "none"     // The model must not call a tool
+	ToolChoiceRequired = "required" // The model must call at least one tool
+)
+
+// Response format types for WithResponseFormat.
+const (
+	ResponseFormatText       = "text"        // Plain text (provider default)
+	ResponseFormatJSON       = "json"        // Any valid JSON object
+	ResponseFormatJSONSchema = "json_schema" // JSON matching ResponseFormat.Schema
+)
+
+// ResponseFormat constrains the shape of the model's reply.
+type ResponseFormat struct {
+	// Type is one of the ResponseFormat* constants
+	Type string
+
+	// Schema is required when Type is ResponseFormatJSONSchema
+	Schema *ResponseSchema
+}
+
+// CallOptions holds the resolved per-call settings.
+// Nil pointers and empty values mean "use the client default".
+type CallOptions struct {
+	// Temperature controls randomness (0 is most deterministic)
+	Temperature *float64
+
+	// MaxTokens limits the length of the generated reply
+	MaxTokens *int
+
+	// Stop lists sequences that end generation
+	Stop []string
+
+	// Seed requests reproducible sampling where supported
+	Seed *int
+
+	// ToolChoice is one of the ToolChoice* constants or a tool name
+	ToolChoice string
+
+	// ResponseFormat constrains the reply format
+	ResponseFormat *ResponseFormat
+
+	// Metadata is attached to the request for providers that accept it
+	Metadata map[string]string
+}
+
+// NewCallOptions applies opts in order and returns the result.
+// Providers use it to resolve the options passed to a call.
+func NewCallOptions(opts ...CallOption) *CallOptions {
+	o := &CallOptions{}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(o)
+		}
+	}
+	return o
+}
+
+// WithTemperature sets the sampling temperature.
+func WithTemperature(temperature float64) CallOption {
+	return func(o *CallOptions) {
+		o.Temperature = &temperature
+	}
+}
+
+// WithMaxTokens sets the maximum number of tokens to generate.
+func WithMaxTokens(maxTokens int) CallOption {
+	return func(o *CallOptions) {
+		o.MaxTokens = &maxTokens
+	}
+}
+
+// WithStop sets the stop sequences, replacing any set by earlier options.
+func WithStop(stop ...string) CallOption {
+	return func(o *CallOptions) {
+		o.Stop = stop
+	}
+}
+
+// WithSeed sets the sampling seed.
+func WithSeed(seed int) CallOption {
+	return func(o *CallOptions) {
+		o.Seed = &seed
+	}
+}
+
+// WithToolChoice controls whether and which tools the model calls.
+// Use ToolChoiceAuto, ToolChoiceNone, ToolChoiceRequired or a tool name.
+// Only honoured by calls that advertise tools.
+func WithToolChoice(choice string) CallOption {
+	return func(o *CallOptions) {
+		o.ToolChoice = choice
+	}
+}
+
+// WithResponseFormat constrains the reply format.
+func WithResponseFormat(format ResponseFormat) CallOption {
+	return func(o *CallOptions) {
+		o.ResponseFormat = &format
+	}
+}
+
+// WithMetadata attaches key/value metadata to the request.
+// Keys from repeated options are merged.
+func WithMetadata(metadata map[string]string) CallOption {
+	return func(o *CallOptions) {
+		if o.Metadata == nil {
+			o.Metadata = make(map[string]string, len(metadata))
+		}
+		for k, v := range metadata {
+			o.Metadata[k] = v
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package core
+
+import "testing"
+
+// TestNewCallOptions_Defaults tests that no options leave every field unset
+func TestNewCallOptions_Defaults(t *testing.T) {
+	o := NewCallOptions()
+
+	if o.Temperature != nil || o.MaxTokens != nil || o.Seed != nil {
+		t.Errorf("expected nil pointers, got %+v", o)
+	}
+	if o.Stop != nil || o.ToolChoice != "" || o.ResponseFormat != nil || o.Metadata != nil {
+		t.Errorf("expected empty options, got %+v", o)
+	}
+}
+
+// TestNewCallOptions tests that each option sets its field
+func TestNewCallOptions(t *testing.T) {
+	schema := &ResponseSchema{Name: "answer", Schema: map[string]interface{}{"type": "object"}}
+
+	o := NewCallOptions(
+		WithTemperature(0),
+		WithMaxTokens(128),
+		WithStop("\n\n", "END"),
+		WithSeed(42),
+		WithToolChoice(ToolChoiceRequired),
+		WithResponseFormat(ResponseFormat{Type: ResponseFormatJSONSchema, Schema: schema}),
+		WithMetadata(map[string]string{"user": "u1"}),
+		nil, // nil options are skipped
+	)
+
+	if o.Temperature == nil || *o.Temperature != 0 {
+		t.Errorf("Temperature = %v, want 0", o.Temperature)
+	}
+	if o.MaxTokens == nil || *o.MaxTokens != 128 {
+		t.Errorf("MaxTokens = %v, want 128", o.MaxTokens)
+	}
+	if len(o.Stop) != 2 || o.Stop[1] != "END" {
+		t.Errorf("Stop = %v, want [\\n\\n END]", o.Stop)
+	}
+	if o.Seed == nil || *o.Seed != 42 {
+		t.Errorf("Seed = %v, want 42", o.Seed)
+	}
+	if o.ToolChoice != ToolChoiceRequired {
+		t.Errorf("ToolChoice = %q, want %q", o.ToolChoice, ToolChoiceRequired)
+	}
+	if o.ResponseFormat == nil || o.ResponseFormat.Schema != schema {
+		t.Errorf("ResponseFormat = %+v, want json_schema with schema", o.ResponseFormat)
+	}
+	if o.Metadata["user"] != "u1" {
+		t.Errorf("Metadata = %v, want user=u1", o.Metadata)
+	}
+}
+
+// TestNewCallOptions_Override tests that later options win and metadata merges
+func TestNewCallOptions_Override(t *testing.T) {
+	o := NewCallOptions(
+		WithTemperature(1),
+		WithTemperature(0.2),
+		WithMetadata(map[string]string{"a": "1", "b": "1"}),
+		WithMetadata(map[string]string{"b": "2"}),
+	)
+
+	if *o.Temperature != 0.2 {
+		t.Errorf("Temperature = %v, want 0.2", *o.Temperature)
+	}
+	if o.Metadata["a"] != "1" || o.Metadata["b"] != "2" {
+		t.Errorf("Metadata = %v, want a=1 b=2", o.Metadata)
+	}
+}
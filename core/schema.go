@@ -0,0 +1,401 @@
+package core
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// JSONSchemaFor derives a JSON Schema from the Go type of v.
+//
+// Struct fields are described using their tags:
+//
+//	type Person struct {
+//	    Name  string `json:"name" desc:"Full name"`
+//	    Role  string `json:"role" enum:"admin,user"`
+//	    Email string `json:"email,omitempty"`
+//	    Age   int    `json:"age,omitempty" required:"true"`
+//	}
+//
+// Fields are required unless tagged omitempty; the required tag overrides this.
+// Fields tagged json:"-" and unexported fields are skipped. Structs are closed
+// (additionalProperties: false) so providers with strict modes can enforce them.
+func JSONSchemaFor(v interface{}) (map[string]interface{}, error) {
+	if v == nil {
+		return nil, &ErrInvalidArgument{Argument: "v", Reason: "cannot be nil"}
+	}
+	return JSONSchemaForType(reflect.TypeOf(v))
+}
+
+// JSONSchemaForType derives a JSON Schema from a reflect.Type.
+// See JSONSchemaFor for the supported tags.
+func JSONSchemaForType(t reflect.Type) (map[string]interface{}, error) {
+	return schemaForType(t, make(map[reflect.Type]bool))
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// schemaForType builds the schema for t. seen guards against recursive types,
+// which JSON Schema cannot express without $ref.
+func schemaForType(t reflect.Type, seen map[reflect.Type]bool) (map[string]interface{}, error) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if t == timeType {
+		return map[string]interface{}{"type": "string", "format": "date-time"}, nil
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}, nil
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}, nil
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}, nil
+	case reflect.Interface:
+		// Any JSON value
+		return map[string]interface{}{}, nil
+	case reflect.Slice, reflect.Array:
+		items, err := schemaForType(t.Elem(), seen)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{"type": "array", "items": items}, nil
+	case reflect.Map:
+		if t.Key().Kind() != reflect.String {
+			return nil, fmt.Errorf("unsupported map key type %s: JSON objects need string keys", t.Key())
+		}
+		values, err := schemaForType(t.Elem(), seen)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{"type": "object", "additionalProperties": values}, nil
+	case reflect.Struct:
+		return schemaForStruct(t, seen)
+	default:
+		return nil, fmt.Errorf("unsupported type %s", t)
+	}
+}
+
+// schemaForStruct builds an object schema from the exported fields of t.
+func schemaForStruct(t reflect.Type, seen map[reflect.Type]bool) (map[string]interface{}, error) {
+	fields, err := structFields(t, seen)
+	if err != nil {
+		return nil, err
+	}
+
+	properties := make(map[string]interface{}, len(fields))
+	required := make([]string, 0)
+	for _, f := range fields {
+		properties[f.name] = f.schema
+		if f.required {
+			required = append(required, f.name)
+		}
+	}
+
+	schema := map[string]interface{}{
+		"type":                 "object",
+		"properties":           properties,
+		"additionalProperties": false,
+	}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema, nil
+}
+
+// fieldSchema is the schema of one struct field.
+type fieldSchema struct {
+	name     string
+	schema   map[string]interface{}
+	required bool
+}
+
+// structFields returns the schemas of the exported fields of t, in field order.
+func structFields(t reflect.Type, seen map[reflect.Type]bool) ([]fieldSchema, error) {
+	if seen[t] {
+		return nil, fmt.Errorf("recursive type %s is not supported", t)
+	}
+	seen[t] = true
+	defer delete(seen, t)
+
+	fields := make([]fieldSchema, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name, omitEmpty, skip := parseJSONTag(field)
+		if skip {
+			continue
+		}
+
+		prop, err := schemaForType(field.Type, seen)
+		if err != nil {
+			return nil, fmt.Errorf("field %s: %w", field.Name, err)
+		}
+
+		if desc := field.Tag.Get("desc"); desc != "" {
+			prop["description"] = desc
+		}
+
+		if enumTag := field.Tag.Get("enum"); enumTag != "" {
+			enum, err := parseEnumTag(enumTag, prop["type"])
+			if err != nil {
+				return nil, fmt.Errorf("field %s: %w", field.Name, err)
+			}
+			prop["enum"] = enum
+		}
+
+		isRequired := !omitEmpty
+		if tag := field.Tag.Get("required"); tag != "" {
+			isRequired = tag == "true"
+		}
+
+		fields = append(fields, fieldSchema{name: name, schema: prop, required: isRequired})
+	}
+	return fields, nil
+}
+
+// ToolSchemaForType derives a tool schema from a struct type describing the
+// tool's arguments, using the tags supported by JSONSchemaFor. Each field
+// becomes a Parameter, in field order, whose Schema holds the field's full
+// JSON Schema so nested structs and slices are described precisely.
+func ToolSchemaForType(name, description string, t reflect.Type) (*ToolSchema, error) {
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil, &ErrInvalidArgument{Argument: "t", Reason: fmt.Sprintf("must be a struct type, got %v", t)}
+	}
+
+	fields, err := structFields(t, make(map[reflect.Type]bool))
+	if err != nil {
+		return nil, err
+	}
+
+	params := make([]Parameter, 0, len(fields))
+	for _, f := range fields {
+		typ, _ := f.schema["type"].(string)
+		desc, _ := f.schema["description"].(string)
+		enum, _ := f.schema["enum"].([]interface{})
+		params = append(params, Parameter{
+			Name:        f.name,
+			Type:        typ,
+			Description: desc,
+			Required:    f.required,
+			Enum:        enum,
+			Schema:      f.schema,
+		})
+	}
+
+	return &ToolSchema{Name: name, Description: description, Parameters: params}, nil
+}
+
+// parseJSONTag returns the JSON name of a field, whether it is omitempty,
+// and whether it should be skipped entirely.
+func parseJSONTag(field reflect.StructField) (name string, omitEmpty bool, skip bool) {
+	tag := field.Tag.Get("json")
+	if tag == "-" {
+		return "", false, true
+	}
+
+	parts := strings.Split(tag, ",")
+	name = parts[0]
+	if name == "" {
+		name = field.Name
+	}
+	for _, opt := range parts[1:] {
+		if opt == "omitempty" {
+			omitEmpty = true
+		}
+	}
+	return name, omitEmpty, false
+}
+
+// parseEnumTag parses a comma-separated enum tag into values of the schema type.
+func parseEnumTag(tag string, schemaType interface{}) ([]interface{}, error) {
+	raw := strings.Split(tag, ",")
+	values := make([]interface{}, 0, len(raw))
+
+	for _, r := range raw {
+		r = strings.TrimSpace(r)
+		switch schemaType {
+		case "integer":
+			n, err := strconv.ParseInt(r, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid integer enum value %q", r)
+			}
+			values = append(values, n)
+		case "number":
+			f, err := strconv.ParseFloat(r, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid number enum value %q", r)
+			}
+			values = append(values, f)
+		default:
+			values = append(values, r)
+		}
+	}
+	return values, nil
+}
+
+// ValidateJSONSchema checks a decoded JSON value (as produced by encoding/json
+// into interface{}) against a schema. It supports the keywords produced by
+// JSONSchemaFor: type, properties, required, additionalProperties, items and enum.
+// It returns one message per violation, or nil if the value is valid.
+func ValidateJSONSchema(schema map[string]interface{}, value interface{}) []string {
+	var errs []string
+	validateValue(schema, value, "$", &errs)
+	return errs
+}
+
+func validateValue(schema map[string]interface{}, value interface{}, path string, errs *[]string) {
+	if schema == nil {
+		return
+	}
+
+	if typ, ok := schema["type"].(string); ok && !matchesType(typ, value) {
+		*errs = append(*errs, fmt.Sprintf("%s: expected %s, got %s", path, typ, jsonTypeName(value)))
+		return
+	}
+
+	if enum, ok := schema["enum"].([]interface{}); ok && !inEnum(enum, value) {
+		*errs = append(*errs, fmt.Sprintf("%s: value %v is not one of %v", path, value, enum))
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for _, name := range stringList(schema["required"]) {
+			if _, present := v[name]; !present {
+				*errs = append(*errs, fmt.Sprintf("%s: missing required field %q", path, name))
+			}
+		}
+
+		// Sorted so that error messages are deterministic
+		names := make([]string, 0, len(v))
+		for name := range v {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		properties, _ := schema["properties"].(map[string]interface{})
+		for _, name := range names {
+			fieldValue := v[name]
+			if prop, ok := properties[name].(map[string]interface{}); ok {
+				validateValue(prop, fieldValue, path+"."+name, errs)
+				continue
+			}
+
+			switch extra := schema["additionalProperties"].(type) {
+			case bool:
+				if !extra && properties != nil {
+					*errs = append(*errs, fmt.Sprintf("%s: unexpected field %q", path, name))
+				}
+			case map[string]interface{}:
+				validateValue(extra, fieldValue, path+"."+name, errs)
+			}
+		}
+	case []interface{}:
+		if items, ok := schema["items"].(map[string]interface{}); ok {
+			for i, item := range v {
+				validateValue(items, item, fmt.Sprintf("%s[%d]", path, i), errs)
+			}
+		}
+	}
+}
+
+// matchesType reports whether a decoded JSON value matches a JSON Schema type.
+func matchesType(typ string, value interface{}) bool {
+	switch typ {
+	case "object":
+		_, ok := value.(map[string]interface{})
+		return ok
+	case "array":
+		_, ok := value.([]interface{})
+		return ok
+	case "string":
+		_, ok := value.(string)
+		return ok
+	case "boolean":
+		_, ok := value.(bool)
+		return ok
+	case "number":
+		_, ok := value.(float64)
+		return ok
+	case "integer":
+		f, ok := value.(float64)
+		return ok && f == float64(int64(f))
+	case "null":
+		return value == nil
+	}
+	return true
+}
+
+// jsonTypeName returns the JSON type name of a decoded value.
+func jsonTypeName(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case float64:
+		return "number"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+// inEnum reports whether value equals one of the enum values.
+// Numbers are compared as float64 since that is how JSON decodes them.
+func inEnum(enum []interface{}, value interface{}) bool {
+	for _, e := range enum {
+		if e == value {
+			return true
+		}
+		if n, ok := value.(float64); ok {
+			switch ev := e.(type) {
+			case int64:
+				if float64(ev) == n {
+					return true
+				}
+			case int:
+				if float64(ev) == n {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// stringList converts a []string or []interface{} of strings to []string.
+// Schemas built in Go use []string while decoded JSON schemas use []interface{}.
+func stringList(v interface{}) []string {
+	switch list := v.(type) {
+	case []string:
+		return list
+	case []interface{}:
+		result := make([]string, 0, len(list))
+		for _, item := range list {
+			if s, ok := item.(string); ok {
+				result = append(result, s)
+			}
+		}
+		return result
+	}
+	return nil
+}
@@ -0,0 +1,162 @@
+package core
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type schemaTestAddress struct {
+	City string `json:"city"`
+}
+
+type schemaTestPerson struct {
+	Name     string             `json:"name" desc:"Full name"`
+	Role     string             `json:"role" enum:"admin,user"`
+	Level    int                `json:"level" enum:"1,2,3"`
+	Email    string             `json:"email,omitempty"`
+	Age      int                `json:"age,omitempty" required:"true"`
+	Nickname string             `json:"nickname" required:"false"`
+	Tags     []string           `json:"tags"`
+	Address  *schemaTestAddress `json:"address"`
+	Extra    map[string]int     `json:"extra,omitempty"`
+	Ignored  string             `json:"-"`
+	internal string
+}
+
+// TestJSONSchemaFor tests schema derivation from struct tags
+func TestJSONSchemaFor(t *testing.T) {
+	schema, err := JSONSchemaFor(schemaTestPerson{})
+	if err != nil {
+		t.Fatalf("JSONSchemaFor() error = %v", err)
+	}
+
+	if schema["type"] != "object" || schema["additionalProperties"] != false {
+		t.Errorf("expected closed object schema, got %v", schema)
+	}
+
+	props := schema["properties"].(map[string]interface{})
+	if len(props) != 9 {
+		t.Errorf("expected 9 properties, got %d", len(props))
+	}
+	if _, ok := props["Ignored"]; ok {
+		t.Error("expected json:\"-\" field to be skipped")
+	}
+
+	name := props["name"].(map[string]interface{})
+	if name["description"] != "Full name" {
+		t.Errorf("expected description, got %v", name)
+	}
+
+	role := props["role"].(map[string]interface{})
+	if !reflect.DeepEqual(role["enum"], []interface{}{"admin", "user"}) {
+		t.Errorf("expected string enum, got %v", role["enum"])
+	}
+
+	level := props["level"].(map[string]interface{})
+	if level["type"] != "integer" || !reflect.DeepEqual(level["enum"], []interface{}{int64(1), int64(2), int64(3)}) {
+		t.Errorf("expected integer enum, got %v", level)
+	}
+
+	tags := props["tags"].(map[string]interface{})
+	if tags["type"] != "array" || tags["items"].(map[string]interface{})["type"] != "string" {
+		t.Errorf("expected string array, got %v", tags)
+	}
+
+	address := props["address"].(map[string]interface{})
+	if address["type"] != "object" {
+		t.Errorf("expected nested object for pointer field, got %v", address)
+	}
+
+	want := []string{"name", "role", "level", "age", "tags", "address"}
+	if !reflect.DeepEqual(schema["required"], want) {
+		t.Errorf("required = %v, want %v", schema["required"], want)
+	}
+}
+
+// TestJSONSchemaFor_Recursive tests that recursive types are rejected
+func TestJSONSchemaFor_Recursive(t *testing.T) {
+	type node struct {
+		Children []node `json:"children"`
+	}
+
+	if _, err := JSONSchemaFor(node{}); err == nil {
+		t.Error("expected error for recursive type")
+	}
+}
+
+// TestValidateJSONSchema tests validation of decoded JSON values
+func TestValidateJSONSchema(t *testing.T) {
+	schema, _ := JSONSchemaFor(schemaTestPerson{})
+
+	valid := map[string]interface{}{
+		"name": "Ada", "role": "admin", "level": 2.0, "age": 36.0,
+		"tags": []interface{}{"math"}, "address": map[string]interface{}{"city": "London"},
+	}
+	if errs := ValidateJSONSchema(schema, valid); len(errs) != 0 {
+		t.Errorf("expected no errors, got %v", errs)
+	}
+
+	invalid := map[string]interface{}{
+		"name": 42.0, "role": "guest", "level": 2.5, "age": 36.0,
+		"tags": []interface{}{1.0}, "unknown": true,
+	}
+	errs := ValidateJSONSchema(schema, invalid)
+
+	for _, want := range []string{
+		`$.name: expected string, got number`,
+		`$.role: value guest is not one of [admin user]`,
+		`$.level: expected integer, got number`,
+		`$.tags[0]: expected string, got number`,
+		`$: missing required field "address"`,
+		`$: unexpected field "unknown"`,
+	} {
+		found := false
+		for _, e := range errs {
+			if e == want {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("expected error %q in:\n%s", want, strings.Join(errs, "\n"))
+		}
+	}
+}
+
+// TestToolSchemaForType tests deriving tool parameters from a struct
+func TestToolSchemaForType(t *testing.T) {
+	schema, err := ToolSchemaForType("people", "Manages people", reflect.TypeOf(&schemaTestPerson{}))
+	if err != nil {
+		t.Fatalf("ToolSchemaForType() error = %v", err)
+	}
+
+	if schema.Name != "people" || schema.Description != "Manages people" {
+		t.Errorf("unexpected name/description: %+v", schema)
+	}
+
+	names := make([]string, len(schema.Parameters))
+	for i, p := range schema.Parameters {
+		names[i] = p.Name
+	}
+	want := []string{"name", "role", "level", "email", "age", "nickname", "tags", "address", "extra"}
+	if !reflect.DeepEqual(names, want) {
+		t.Errorf("parameters = %v, want %v (field order)", names, want)
+	}
+
+	role := schema.Parameters[1]
+	if role.Type != "string" || !role.Required || len(role.Enum) != 2 {
+		t.Errorf("unexpected role parameter: %+v", role)
+	}
+	if schema.Parameters[3].Required || !schema.Parameters[4].Required {
+		t.Error("expected email optional and age required")
+	}
+
+	address := schema.Parameters[7].JSONSchema()
+	if address["type"] != "object" || address["properties"] == nil {
+		t.Errorf("expected nested address schema, got %v", address)
+	}
+
+	if _, err := ToolSchemaForType("bad", "", reflect.TypeOf("")); err == nil {
+		t.Error("expected an error for a non-struct type")
+	}
+}
@@ -30,6 +30,14 @@ type StreamChunk struct {
 	//   - "content_filter": Content was filtered by safety systems
 	FinishReason string
 
+	// ToolCalls contains the tool calls requested by the LLM.
+	// Only set on the final chunk, once the arguments have been fully streamed.
+	ToolCalls []ToolCall
+
+	// Usage reports the tokens consumed by the request.
+	// Only set on the final chunk, and only if the provider reports usage.
+	Usage *Usage
+
 	// Metadata contains provider-specific information about this chunk.
 	// May include model name, token counts, timing information, etc.
 	Metadata map[string]interface{}
@@ -93,6 +101,23 @@ const (
 	// Contains the tool result or error.
 	EventTypeToolEnd = "tool_end"
 
+	// EventTypeApprovalRequired indicates a tool call is waiting for a
+	// human's approval before it runs.
+	// Contains the tool name, the call ID and its arguments.
+	EventTypeApprovalRequired = "approval_required"
+
+	// EventTypePlan carries the plan of a plan-and-execute agent: the
+	// initial plan and each revision of the steps that remain.
+	EventTypePlan = "plan"
+
+	// EventTypeStepStart indicates a plan step is about to be executed.
+	// Contains the step's task and number.
+	EventTypeStepStart = "step_start"
+
+	// EventTypeStepEnd indicates a plan step has finished.
+	// Contains the step's result or error.
+	EventTypeStepEnd = "step_end"
+
 	// EventTypeAnswer represents the final answer from the agent.
 	// This is the agent's complete response to the user's input.
 	EventTypeAnswer = "answer"
@@ -132,11 +157,11 @@ type StreamingLLM interface {
 	//       }
 	//       fmt.Print(chunk.Delta)
 	//   }
-	ChatStream(ctx context.Context, messages []Message, opts ...interface{}) (<-chan StreamChunk, error)
+	ChatStream(ctx context.Context, messages []Message, opts ...CallOption) (<-chan StreamChunk, error)
 
 	// CompleteStream generates a completion as a stream of chunks.
 	// Similar to ChatStream but for completion-style prompts.
-	CompleteStream(ctx context.Context, prompt string, opts ...interface{}) (<-chan StreamChunk, error)
+	CompleteStream(ctx context.Context, prompt string, opts ...CallOption) (<-chan StreamChunk, error)
 }
 
 // StreamingAgent extends the Agent interface with streaming capabilities.
@@ -159,6 +184,7 @@ type StreamingAgent interface {
 	// - FunctionAgent: token, tool_start, tool_end, complete, error
 	// - ReActAgent: thought, token, tool_start, tool_end, answer, complete, error
 	// - ConversationalAgent: token, complete, error
+	// - PlanExecuteAgent: plan, step_start, step_end (with the executor's events in between), answer, complete, error
 	//
 	// Context cancellation will stop execution and close the channel.
 	//
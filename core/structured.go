@@ -0,0 +1,251 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+// DefaultStructuredRetries is the default number of re-prompts ChatStructured
+// makes after the first attempt fails validation.
+const DefaultStructuredRetries = 2
+
+// StructuredOption configures ChatStructured.
+type StructuredOption func(*structuredConfig)
+
+type structuredConfig struct {
+	maxRetries  int
+	name        string
+	description string
+	callOpts    []CallOption
+}
+
+// WithStructuredRetries sets how many times the model is re-prompted with the
+// validation errors after an invalid reply. Zero disables retries.
+func WithStructuredRetries(n int) StructuredOption {
+	return func(c *structuredConfig) {
+		c.maxRetries = n
+	}
+}
+
+// WithSchemaName sets the schema name sent to the provider.
+// Defaults to the snake_case name of the result type.
+func WithSchemaName(name string) StructuredOption {
+	return func(c *structuredConfig) {
+		c.name = name
+	}
+}
+
+// WithSchemaDescription sets a description of the expected document.
+func WithSchemaDescription(description string) StructuredOption {
+	return func(c *structuredConfig) {
+		c.description = description
+	}
+}
+
+// WithCallOptions sets the per-call options (temperature, max tokens, ...)
+// used for every request ChatStructured makes.
+func WithCallOptions(opts ...CallOption) StructuredOption {
+	return func(c *structuredConfig) {
+		c.callOpts = append(c.callOpts, opts...)
+	}
+}
+
+// Validator can be implemented by structured output types to add checks
+// beyond the JSON Schema (cross-field rules, ranges, ...). Returned errors
+// are fed back to the model like schema violations.
+type Validator interface {
+	Validate() error
+}
+
+// ChatStructured asks the LLM for a reply matching the JSON Schema of T and
+// decodes it into a T.
+//
+// The schema is derived from T with JSONSchemaFor. If llm implements
+// StructuredOutputLLM, the provider's native mechanism enforces the schema;
+// otherwise the schema is included in a system instruction. Replies are
+// validated against the schema (and T's Validate method, if any). Invalid
+// replies are sent back to the model together with the errors, up to the
+// configured number of retries, before an *ErrStructuredOutput is returned.
+//
+// Example:
+//
+//	type Sentiment struct {
+//	    Label string  `json:"label" enum:"positive,negative,neutral"`
+//	    Score float64 `json:"score" desc:"Confidence between 0 and 1"`
+//	}
+//
+//	result, err := core.ChatStructured[Sentiment](ctx, llm, messages)
+func ChatStructured[T any](ctx context.Context, llm LLM, messages []Message, opts ...StructuredOption) (T, error) {
+	var result T
+
+	typ := reflect.TypeOf((*T)(nil)).Elem()
+	schema, err := JSONSchemaForType(typ)
+	if err != nil {
+		return result, &ErrInvalidArgument{Argument: "T", Reason: err.Error()}
+	}
+
+	cfg := &structuredConfig{
+		maxRetries: DefaultStructuredRetries,
+		name:       schemaName(typ),
+	}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	responseSchema := &ResponseSchema{
+		Name:        cfg.name,
+		Description: cfg.description,
+		Schema:      schema,
+	}
+
+	structuredLLM, native := llm.(StructuredOutputLLM)
+
+	conversation := make([]Message, 0, len(messages)+1)
+	if !native {
+		schemaJSON, _ := json.Marshal(schema)
+		conversation = append(conversation, SystemMessage(
+			"Respond only with a JSON value that matches this JSON Schema, without any other text:\n"+string(schemaJSON)))
+	}
+	conversation = append(conversation, messages...)
+
+	var lastErrs []string
+	var lastRaw string
+
+	for attempt := 0; attempt <= cfg.maxRetries; attempt++ {
+		var resp *Response
+		if native {
+			resp, err = structuredLLM.ChatWithSchema(ctx, conversation, responseSchema, cfg.callOpts...)
+		} else {
+			resp, err = llm.Chat(ctx, conversation, cfg.callOpts...)
+		}
+		if err != nil {
+			return result, err
+		}
+
+		lastRaw = resp.Content
+		var decoded T
+		lastErrs = decodeStructured(resp.Content, schema, &decoded)
+		if len(lastErrs) == 0 {
+			return decoded, nil
+		}
+
+		conversation = append(conversation,
+			AssistantMessage(resp.Content),
+			UserMessage(structuredFeedback(lastErrs)),
+		)
+	}
+
+	return result, &ErrStructuredOutput{
+		Attempts: cfg.maxRetries + 1,
+		Errors:   lastErrs,
+		Raw:      lastRaw,
+	}
+}
+
+// decodeStructured extracts, validates and decodes a JSON reply into out.
+// It returns the validation errors, or nil on success.
+func decodeStructured(content string, schema map[string]interface{}, out interface{}) []string {
+	raw := ExtractJSON(content)
+
+	var value interface{}
+	if err := json.Unmarshal([]byte(raw), &value); err != nil {
+		return []string{fmt.Sprintf("reply is not valid JSON: %v", err)}
+	}
+
+	if errs := ValidateJSONSchema(schema, value); len(errs) > 0 {
+		return errs
+	}
+
+	if err := json.Unmarshal([]byte(raw), out); err != nil {
+		return []string{fmt.Sprintf("reply does not decode into the expected type: %v", err)}
+	}
+
+	if v, ok := out.(Validator); ok {
+		if err := v.Validate(); err != nil {
+			return []string{err.Error()}
+		}
+	} else if v, ok := reflect.ValueOf(out).Elem().Interface().(Validator); ok {
+		if err := v.Validate(); err != nil {
+			return []string{err.Error()}
+		}
+	}
+
+	return nil
+}
+
+// structuredFeedback builds the re-prompt sent after an invalid reply.
+func structuredFeedback(errs []string) string {
+	var b strings.Builder
+	b.WriteString("Your previous reply did not match the required JSON schema:\n")
+	for _, e := range errs {
+		b.WriteString("- ")
+		b.WriteString(e)
+		b.WriteString("\n")
+	}
+	b.WriteString("Reply again with only the corrected JSON.")
+	return b.String()
+}
+
+var camelBoundary = regexp.MustCompile(`([a-z0-9])([A-Z])`)
+
+// schemaName derives a provider-safe schema name from a Go type.
+func schemaName(t reflect.Type) string {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	name := t.Name()
+	if name == "" {
+		return "response"
+	}
+	return strings.ToLower(camelBoundary.ReplaceAllString(name, "${1}_${2}"))
+}
+
+// ExtractJSON returns the first JSON object or array embedded in s.
+// It tolerates Markdown code fences and surrounding prose, which models often
+// add even when asked for bare JSON. If no object or array is found, the
+// trimmed input is returned unchanged so the caller's decoder reports the error.
+func ExtractJSON(s string) string {
+	s = strings.TrimSpace(s)
+
+	start := strings.IndexAny(s, "{[")
+	if start < 0 {
+		return s
+	}
+
+	depth := 0
+	inString := false
+	escaped := false
+	for i := start; i < len(s); i++ {
+		c := s[i]
+		if inString {
+			switch {
+			case escaped:
+				escaped = false
+			case c == '\\':
+				escaped = true
+			case c == '"':
+				inString = false
+			}
+			continue
+		}
+
+		switch c {
+		case '"':
+			inString = true
+		case '{', '[':
+			depth++
+		case '}', ']':
+			depth--
+			if depth == 0 {
+				return s[start : i+1]
+			}
+		}
+	}
+
+	// Unbalanced: return from the first brace so the decoder reports the problem
+	return s[start:]
+}
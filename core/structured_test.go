@@ -0,0 +1,188 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+type structuredTestSentiment struct {
+	Label string  `json:"label" enum:"positive,negative,neutral"`
+	Score float64 `json:"score"`
+}
+
+func (s structuredTestSentiment) Validate() error {
+	if s.Score < 0 || s.Score > 1 {
+		return fmt.Errorf("score must be between 0 and 1, got %v", s.Score)
+	}
+	return nil
+}
+
+// scriptedLLM returns the given replies in order and records the conversations.
+type scriptedLLM struct {
+	replies []string
+	calls   [][]Message
+	options []*CallOptions
+}
+
+func (l *scriptedLLM) Chat(ctx context.Context, messages []Message, opts ...CallOption) (*Response, error) {
+	l.calls = append(l.calls, messages)
+	l.options = append(l.options, NewCallOptions(opts...))
+	if len(l.calls) > len(l.replies) {
+		return nil, errors.New("no more replies")
+	}
+	return &Response{Content: l.replies[len(l.calls)-1]}, nil
+}
+
+func (l *scriptedLLM) Complete(ctx context.Context, prompt string, opts ...CallOption) (string, error) {
+	return "", errors.New("not implemented")
+}
+
+// schemaLLM is a scriptedLLM with native structured output.
+type schemaLLM struct {
+	scriptedLLM
+	schemas []*ResponseSchema
+}
+
+func (l *schemaLLM) ChatWithSchema(ctx context.Context, messages []Message, schema *ResponseSchema, opts ...CallOption) (*Response, error) {
+	l.schemas = append(l.schemas, schema)
+	return l.Chat(ctx, messages, opts...)
+}
+
+// TestChatStructured_Fallback tests prompting for JSON on LLMs without native support
+func TestChatStructured_Fallback(t *testing.T) {
+	llm := &scriptedLLM{replies: []string{"Sure!\n```json\n{\"label\": \"positive\", \"score\": 0.9}\n```"}}
+
+	result, err := ChatStructured[structuredTestSentiment](context.Background(), llm, []Message{UserMessage("I love it")})
+	if err != nil {
+		t.Fatalf("ChatStructured() error = %v", err)
+	}
+
+	if result.Label != "positive" || result.Score != 0.9 {
+		t.Errorf("unexpected result: %+v", result)
+	}
+
+	first := llm.calls[0][0]
+	if first.Role != "system" || !strings.Contains(first.Content, `"enum":["positive","negative","neutral"]`) {
+		t.Errorf("expected schema instruction as system message, got %+v", first)
+	}
+}
+
+// TestChatStructured_Native tests that native structured output is used when available
+func TestChatStructured_Native(t *testing.T) {
+	llm := &schemaLLM{scriptedLLM: scriptedLLM{replies: []string{`{"label": "neutral", "score": 0.5}`}}}
+
+	result, err := ChatStructured[structuredTestSentiment](context.Background(), llm, []Message{UserMessage("Meh")})
+	if err != nil {
+		t.Fatalf("ChatStructured() error = %v", err)
+	}
+
+	if result.Label != "neutral" {
+		t.Errorf("unexpected result: %+v", result)
+	}
+
+	if len(llm.schemas) != 1 || llm.schemas[0].Name != "structured_test_sentiment" {
+		t.Errorf("expected schema named structured_test_sentiment, got %+v", llm.schemas)
+	}
+
+	if len(llm.calls[0]) != 1 {
+		t.Errorf("expected no schema instruction with native support, got %d messages", len(llm.calls[0]))
+	}
+}
+
+// TestChatStructured_CallOptions tests that call options reach every request
+func TestChatStructured_CallOptions(t *testing.T) {
+	llm := &schemaLLM{scriptedLLM: scriptedLLM{replies: []string{
+		`{"label": "great", "score": 0.9}`,
+		`{"label": "positive", "score": 0.9}`,
+	}}}
+
+	_, err := ChatStructured[structuredTestSentiment](context.Background(), llm,
+		[]Message{UserMessage("I love it")}, WithCallOptions(WithTemperature(0), WithSeed(7)))
+	if err != nil {
+		t.Fatalf("ChatStructured() error = %v", err)
+	}
+
+	if len(llm.options) != 2 {
+		t.Fatalf("expected 2 calls, got %d", len(llm.options))
+	}
+	for i, o := range llm.options {
+		if o.Temperature == nil || *o.Temperature != 0 || o.Seed == nil || *o.Seed != 7 {
+			t.Errorf("call %d options = %+v, want temperature 0 and seed 7", i, o)
+		}
+	}
+}
+
+// TestChatStructured_Retry tests re-prompting with validation errors
+func TestChatStructured_Retry(t *testing.T) {
+	llm := &scriptedLLM{replies: []string{
+		`{"label": "great", "score": 0.9}`,
+		`{"label": "positive", "score": 7}`,
+		`{"label": "positive", "score": 0.7}`,
+	}}
+
+	result, err := ChatStructured[structuredTestSentiment](context.Background(), llm, []Message{UserMessage("I love it")})
+	if err != nil {
+		t.Fatalf("ChatStructured() error = %v", err)
+	}
+
+	if result.Score != 0.7 {
+		t.Errorf("unexpected result: %+v", result)
+	}
+
+	if len(llm.calls) != 3 {
+		t.Fatalf("expected 3 calls, got %d", len(llm.calls))
+	}
+
+	feedback := llm.calls[1][len(llm.calls[1])-1]
+	if feedback.Role != "user" || !strings.Contains(feedback.Content, "value great is not one of") {
+		t.Errorf("expected schema error feedback, got %+v", feedback)
+	}
+
+	feedback = llm.calls[2][len(llm.calls[2])-1]
+	if !strings.Contains(feedback.Content, "score must be between 0 and 1") {
+		t.Errorf("expected Validate() error feedback, got %+v", feedback)
+	}
+}
+
+// TestChatStructured_RetriesExhausted tests the error after the last retry
+func TestChatStructured_RetriesExhausted(t *testing.T) {
+	llm := &scriptedLLM{replies: []string{"not json", "still not json"}}
+
+	_, err := ChatStructured[structuredTestSentiment](context.Background(), llm,
+		[]Message{UserMessage("I love it")}, WithStructuredRetries(1))
+
+	var structuredErr *ErrStructuredOutput
+	if !errors.As(err, &structuredErr) {
+		t.Fatalf("expected *ErrStructuredOutput, got %T (%v)", err, err)
+	}
+
+	if structuredErr.Attempts != 2 || structuredErr.Raw != "still not json" {
+		t.Errorf("unexpected error: %+v", structuredErr)
+	}
+}
+
+// TestExtractJSON tests tolerant JSON extraction
+func TestExtractJSON(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"bare", `{"a": 1}`, `{"a": 1}`},
+		{"fenced", "```json\n{\"a\": 1}\n```", `{"a": 1}`},
+		{"prose", `Here you go: {"a": {"b": "}"}} hope that helps`, `{"a": {"b": "}"}}`},
+		{"array", `[1, 2] and more`, `[1, 2]`},
+		{"none", `  no json  `, `no json`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ExtractJSON(tt.in); got != tt.want {
+				t.Errorf("ExtractJSON(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,376 @@
+package core
+
+import (
+	"bufio"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Tokenizer counts the tokens a model sees in text.
+//
+// Counts are used for budgeting (trimming history to fit a context window),
+// so an estimate that errs on the high side is fine when the model's exact
+// tokenizer is not available.
+type Tokenizer interface {
+	// CountTokens returns the number of tokens in text.
+	CountTokens(text string) int
+}
+
+// DefaultCharsPerToken is the characters-per-token ratio ApproxTokenizer uses
+// when none is set. It is typical of English text for current BPE tokenizers.
+const DefaultCharsPerToken = 4.0
+
+// ApproxTokenizer estimates token counts from the length of the text. Use it
+// for models whose tokenizer is not available (Claude, Gemini, local models).
+type ApproxTokenizer struct {
+	// CharsPerToken is the average number of characters per token.
+	// Zero means DefaultCharsPerToken. Lower values overestimate more.
+	CharsPerToken float64
+}
+
+// CountTokens returns the estimated number of tokens in text, rounded up.
+func (t ApproxTokenizer) CountTokens(text string) int {
+	ratio := t.CharsPerToken
+	if ratio <= 0 {
+		ratio = DefaultCharsPerToken
+	}
+	return int(math.Ceil(float64(utf8.RuneCountInString(text)) / ratio))
+}
+
+// Tiktoken encoding names.
+const (
+	// EncodingCL100kBase is used by GPT-4, GPT-3.5 Turbo and the text-embedding-3 models
+	EncodingCL100kBase = "cl100k_base"
+
+	// EncodingO200kBase is used by GPT-4o, GPT-4.1, GPT-5 and the o-series models
+	EncodingO200kBase = "o200k_base"
+)
+
+// EncodingForModel returns the tiktoken encoding of an OpenAI model, or ""
+// if the model is not a known OpenAI model.
+func EncodingForModel(model string) string {
+	switch {
+	case strings.HasPrefix(model, "gpt-4o"), strings.HasPrefix(model, "gpt-4.1"),
+		strings.HasPrefix(model, "gpt-4.5"), strings.HasPrefix(model, "gpt-5"),
+		strings.HasPrefix(model, "o1"), strings.HasPrefix(model, "o3"), strings.HasPrefix(model, "o4"):
+		return EncodingO200kBase
+	case strings.HasPrefix(model, "gpt-4"), strings.HasPrefix(model, "gpt-3.5"),
+		strings.HasPrefix(model, "text-embedding-"):
+		return EncodingCL100kBase
+	}
+	return ""
+}
+
+// BPETokenizer is a byte-pair encoding tokenizer compatible with OpenAI's
+// tiktoken. It produces the same tokens as tiktoken's encode_ordinary
+// (special tokens such as <|endoftext|> are treated as plain text).
+//
+// The merge ranks are not bundled; load the published rank file of the
+// encoding, e.g. https://openaipublic.blob.core.windows.net/encodings/o200k_base.tiktoken:
+//
+//	tok, err := core.LoadBPETokenizer(core.EncodingO200kBase, "o200k_base.tiktoken")
+//	n := tok.CountTokens("Hello, world!")
+type BPETokenizer struct {
+	encoding string
+	ranks    map[string]int
+	head     *regexp.Regexp
+}
+
+// Pre-tokenization patterns. tiktoken splits text with a regular expression
+// before merging; these are its patterns without the trailing whitespace
+// alternatives `\s*[\r\n]+|\s+(?!\S)|\s+`, which need a lookahead Go's regexp
+// does not support and are handled by splitWhitespace. `\s` is spelled out as
+// Unicode whitespace, as in tiktoken.
+const (
+	bpeSpace = `\t\n\v\f\r\x{85}\p{Z}`
+
+	cl100kHead = `^(?:(?i:'s|'t|'re|'ve|'m|'ll|'d)` +
+		`|[^\r\n\p{L}\p{N}]?\p{L}+` +
+		`|\p{N}{1,3}` +
+		`| ?[^` + bpeSpace + `\p{L}\p{N}]+[\r\n]*)`
+
+	o200kHead = `^(?:[^\r\n\p{L}\p{N}]?[\p{Lu}\p{Lt}\p{Lm}\p{Lo}\p{M}]*[\p{Ll}\p{Lm}\p{Lo}\p{M}]+(?i:'s|'t|'re|'ve|'m|'ll|'d)?` +
+		`|[^\r\n\p{L}\p{N}]?[\p{Lu}\p{Lt}\p{Lm}\p{Lo}\p{M}]+[\p{Ll}\p{Lm}\p{Lo}\p{M}]*(?i:'s|'t|'re|'ve|'m|'ll|'d)?` +
+		`|\p{N}{1,3}` +
+		`| ?[^` + bpeSpace + `\p{L}\p{N}]+[\r\n/]*)`
+)
+
+// NewBPETokenizer creates a tokenizer for a tiktoken encoding (EncodingCL100kBase
+// or EncodingO200kBase) from its rank file: one "<base64 token> <rank>" line per token.
+func NewBPETokenizer(encoding string, ranks io.Reader) (*BPETokenizer, error) {
+	var head string
+	switch encoding {
+	case EncodingCL100kBase:
+		head = cl100kHead
+	case EncodingO200kBase:
+		head = o200kHead
+	default:
+		return nil, &ErrInvalidArgument{Argument: "encoding", Reason: fmt.Sprintf("unsupported encoding %q", encoding)}
+	}
+
+	table := make(map[string]int)
+	scanner := bufio.NewScanner(ranks)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		fields := strings.Fields(text)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("rank file line %d: expected \"<token> <rank>\"", line)
+		}
+		token, err := base64.StdEncoding.DecodeString(fields[0])
+		if err != nil {
+			return nil, fmt.Errorf("rank file line %d: %w", line, err)
+		}
+		rank, err := strconv.Atoi(fields[1])
+		if err != nil {
+			return nil, fmt.Errorf("rank file line %d: %w", line, err)
+		}
+		table[string(token)] = rank
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read rank file: %w", err)
+	}
+
+	// Every byte must be a token, or some text could not be encoded
+	for b := 0; b < 256; b++ {
+		if _, ok := table[string([]byte{byte(b)})]; !ok {
+			return nil, fmt.Errorf("rank file has no token for byte %#02x", b)
+		}
+	}
+
+	return &BPETokenizer{
+		encoding: encoding,
+		ranks:    table,
+		head:     regexp.MustCompile(head),
+	}, nil
+}
+
+// LoadBPETokenizer creates a tokenizer for a tiktoken encoding from a rank file on disk.
+func LoadBPETokenizer(encoding, path string) (*BPETokenizer, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return NewBPETokenizer(encoding, f)
+}
+
+// Encoding returns the name of the tokenizer's encoding.
+func (t *BPETokenizer) Encoding() string {
+	return t.encoding
+}
+
+// Encode returns the tokens (ranks) of text.
+func (t *BPETokenizer) Encode(text string) []int {
+	var tokens []int
+	for _, piece := range t.split(text) {
+		if rank, ok := t.ranks[piece]; ok {
+			tokens = append(tokens, rank)
+			continue
+		}
+		tokens = append(tokens, t.merge(piece)...)
+	}
+	return tokens
+}
+
+// CountTokens returns the number of tokens in text.
+func (t *BPETokenizer) CountTokens(text string) int {
+	return len(t.Encode(text))
+}
+
+// split pre-tokenizes text into the pieces BPE merges are applied to.
+func (t *BPETokenizer) split(text string) []string {
+	var pieces []string
+	for text != "" {
+		n := 0
+		if loc := t.head.FindStringIndex(text); loc != nil {
+			n = loc[1]
+		}
+		if n == 0 {
+			n = splitWhitespace(text)
+		}
+		pieces = append(pieces, text[:n])
+		text = text[n:]
+	}
+	return pieces
+}
+
+// splitWhitespace returns the length of the whitespace piece at the start of
+// text, matching `\s*[\r\n]+|\s+(?!\S)|\s+`:
+//   - up to and including the last line break of the whitespace run, if any
+//   - otherwise the whole run if it ends the text, or all but its last
+//     character (which joins the next word) if the run is longer than one
+func splitWhitespace(text string) int {
+	end, lastBreak := 0, -1
+	for end < len(text) {
+		r, size := utf8.DecodeRuneInString(text[end:])
+		if !unicode.IsSpace(r) {
+			break
+		}
+		if r == '\r' || r == '\n' {
+			lastBreak = end + size
+		}
+		end += size
+	}
+
+	switch {
+	case end == 0:
+		// Not whitespace; never reached for valid UTF-8 with the patterns
+		// above, but consume a character so splitting always progresses
+		_, size := utf8.DecodeRuneInString(text)
+		return size
+	case lastBreak > 0:
+		return lastBreak
+	case end == len(text):
+		return end
+	}
+
+	_, lastSize := utf8.DecodeLastRuneInString(text[:end])
+	if end-lastSize > 0 {
+		return end - lastSize
+	}
+	return end
+}
+
+// merge applies the BPE merges to a piece, starting from its bytes and
+// repeatedly joining the adjacent pair with the lowest rank.
+func (t *BPETokenizer) merge(piece string) []int {
+	// parts[i] is the start of the i-th part; the last entry is len(piece)
+	parts := make([]int, len(piece)+1)
+	for i := range parts {
+		parts[i] = i
+	}
+
+	for len(parts) > 2 {
+		best, bestRank := -1, math.MaxInt
+		for i := 0; i+2 < len(parts); i++ {
+			if rank, ok := t.ranks[piece[parts[i]:parts[i+2]]]; ok && rank < bestRank {
+				best, bestRank = i, rank
+			}
+		}
+		if best < 0 {
+			break
+		}
+		parts = append(parts[:best+1], parts[best+2:]...)
+	}
+
+	tokens := make([]int, len(parts)-1)
+	for i := range tokens {
+		tokens[i] = t.ranks[piece[parts[i]:parts[i+1]]]
+	}
+	return tokens
+}
+
+// Per-message overhead of chat formats, as documented for OpenAI models.
+const (
+	messageOverheadTokens = 3 // role and separators of each message
+	replyPrimingTokens    = 3 // start of the assistant reply
+	partTokens            = 85
+)
+
+// MessageTokens estimates the prompt tokens of a message: its role, content,
+// name, parts and tool calls plus the formatting overhead chat models add.
+// Non-text parts (images, documents) count as a low-detail image.
+func MessageTokens(t Tokenizer, m Message) int {
+	n := messageOverheadTokens + t.CountTokens(m.Role) + t.CountTokens(m.Content)
+	if m.Name != "" {
+		n += 1 + t.CountTokens(m.Name)
+	}
+	for _, part := range m.Parts {
+		if part.Type == ContentPartText {
+			n += t.CountTokens(part.Text)
+		} else {
+			n += partTokens
+		}
+	}
+	for _, tc := range m.ToolCalls {
+		args, _ := json.Marshal(tc.Args)
+		n += t.CountTokens(tc.Name) + t.CountTokens(string(args))
+	}
+	return n
+}
+
+// CountMessageTokens estimates the prompt tokens of a conversation.
+func CountMessageTokens(t Tokenizer, messages []Message) int {
+	n := replyPrimingTokens
+	for _, m := range messages {
+		n += MessageTokens(t, m)
+	}
+	return n
+}
+
+// ToolTokens estimates the prompt tokens taken by the definitions of tools.
+func ToolTokens(t Tokenizer, tools []Tool) int {
+	n := 0
+	for _, tool := range tools {
+		n += t.CountTokens(tool.Name()) + t.CountTokens(tool.Description())
+		if schema := tool.Schema(); schema != nil {
+			if data, err := json.Marshal(schema.JSONSchema()); err == nil {
+				n += t.CountTokens(string(data))
+			}
+		}
+	}
+	return n
+}
+
+// ContextWindowTable maps model names to their context window sizes in tokens.
+//
+// Like PricingTable, lookups fall back to the longest matching prefix.
+type ContextWindowTable map[string]int
+
+// DefaultContextWindows returns a copy of the context window sizes of common
+// models. Local models (Ollama) are listed with their maximum; the server may
+// be configured with a smaller num_ctx.
+func DefaultContextWindows() ContextWindowTable {
+	return ContextWindowTable{
+		// OpenAI
+		"gpt-5":         400_000,
+		"gpt-4.1":       1_047_576,
+		"gpt-4o":        128_000,
+		"gpt-4-turbo":   128_000,
+		"gpt-4-32k":     32_768,
+		"gpt-4":         8_192,
+		"gpt-3.5-turbo": 16_385,
+		"o1":            200_000,
+		"o1-mini":       128_000,
+		"o3":            200_000,
+		"o4-mini":       200_000,
+
+		// Anthropic
+		"claude-": 200_000,
+
+		// Google Gemini
+		"gemini-2.5":       1_048_576,
+		"gemini-2.0-flash": 1_048_576,
+		"gemini-1.5-pro":   2_097_152,
+		"gemini-1.5-flash": 1_048_576,
+		"gemini-pro":       32_760,
+
+		// Ollama
+		"llama3.1":  131_072,
+		"llama3.2":  131_072,
+		"llama3":    8_192,
+		"mistral":   32_768,
+		"qwen2.5":   32_768,
+		"gemma2":    8_192,
+		"phi3":      4_096,
+		"deepseek-": 65_536,
+	}
+}
+
+// Lookup returns the context window of a model, matching the exact name first
+// and then the longest entry that is a prefix of it.
+func (t ContextWindowTable) Lookup(model string) (int, bool) {
+	return lookupModel(t, model)
+}
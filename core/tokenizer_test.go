@@ -0,0 +1,197 @@
+package core
+
+import (
+	"encoding/base64"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// testRankFile builds a rank file with every byte (rank = byte value)
+// followed by the given merged tokens.
+func testRankFile(merges ...string) string {
+	var sb strings.Builder
+	for b := 0; b < 256; b++ {
+		fmt.Fprintf(&sb, "%s %d\n", base64.StdEncoding.EncodeToString([]byte{byte(b)}), b)
+	}
+	for i, token := range merges {
+		fmt.Fprintf(&sb, "%s %d\n", base64.StdEncoding.EncodeToString([]byte(token)), 256+i)
+	}
+	return sb.String()
+}
+
+func TestApproxTokenizer(t *testing.T) {
+	tests := []struct {
+		name string
+		tok  ApproxTokenizer
+		text string
+		want int
+	}{
+		{"empty", ApproxTokenizer{}, "", 0},
+		{"default ratio rounds up", ApproxTokenizer{}, "hello", 2},
+		{"runes not bytes", ApproxTokenizer{}, "日本語です", 2},
+		{"custom ratio", ApproxTokenizer{CharsPerToken: 2}, "hello", 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.tok.CountTokens(tt.text); got != tt.want {
+				t.Errorf("CountTokens(%q) = %d, want %d", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBPETokenizer_Split(t *testing.T) {
+	tests := []struct {
+		encoding string
+		text     string
+		want     []string
+	}{
+		{
+			EncodingCL100kBase,
+			"Hello  world!!!  \n\n  foo's 12345",
+			[]string{"Hello", " ", " world", "!!!", "  \n\n", " ", " foo", "'s", " ", "123", "45"},
+		},
+		{
+			EncodingCL100kBase,
+			"I'LL go\ttrailing   ",
+			[]string{"I", "'LL", " go", "\ttrailing", "   "},
+		},
+		{
+			EncodingO200kBase,
+			"HelloWorld don't 1/\n",
+			[]string{"Hello", "World", " don't", " ", "1", "/\n"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.encoding, func(t *testing.T) {
+			tok, err := NewBPETokenizer(tt.encoding, strings.NewReader(testRankFile()))
+			if err != nil {
+				t.Fatalf("NewBPETokenizer() error = %v", err)
+			}
+			if got := tok.split(tt.text); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("split(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBPETokenizer_Encode(t *testing.T) {
+	// Merges apply lowest rank first: ll, he, hell, hello
+	tok, err := NewBPETokenizer(EncodingCL100kBase, strings.NewReader(testRankFile("ll", "he", "hell", "hello", " w")))
+	if err != nil {
+		t.Fatalf("NewBPETokenizer() error = %v", err)
+	}
+
+	tests := []struct {
+		text string
+		want []int
+	}{
+		{"hello", []int{259}},
+		{"hellos", []int{259, 's'}},
+		{"help", []int{257, 'l', 'p'}},
+		{"hello world", []int{259, 260, 'o', 'r', 'l', 'd'}},
+		{"é", []int{0xc3, 0xa9}},
+	}
+
+	for _, tt := range tests {
+		if got := tok.Encode(tt.text); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Encode(%q) = %v, want %v", tt.text, got, tt.want)
+		}
+		if got := tok.CountTokens(tt.text); got != len(tt.want) {
+			t.Errorf("CountTokens(%q) = %d, want %d", tt.text, got, len(tt.want))
+		}
+	}
+}
+
+func TestNewBPETokenizer_Errors(t *testing.T) {
+	tests := []struct {
+		name     string
+		encoding string
+		ranks    string
+	}{
+		{"unknown encoding", "p50k_base", testRankFile()},
+		{"missing bytes", EncodingCL100kBase, "YQ== 0\n"},
+		{"bad base64", EncodingCL100kBase, testRankFile() + "!!! 300\n"},
+		{"bad rank", EncodingCL100kBase, testRankFile() + "YWI= x\n"},
+		{"bad line", EncodingCL100kBase, testRankFile() + "YWI=\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewBPETokenizer(tt.encoding, strings.NewReader(tt.ranks)); err == nil {
+				t.Error("NewBPETokenizer() expected error, got nil")
+			}
+		})
+	}
+}
+
+func TestEncodingForModel(t *testing.T) {
+	tests := map[string]string{
+		"gpt-4o-mini":            EncodingO200kBase,
+		"gpt-5":                  EncodingO200kBase,
+		"o3-mini":                EncodingO200kBase,
+		"gpt-4":                  EncodingCL100kBase,
+		"gpt-3.5-turbo":          EncodingCL100kBase,
+		"text-embedding-3-small": EncodingCL100kBase,
+		"claude-sonnet-4":        "",
+	}
+
+	for model, want := range tests {
+		if got := EncodingForModel(model); got != want {
+			t.Errorf("EncodingForModel(%q) = %q, want %q", model, got, want)
+		}
+	}
+}
+
+func TestCountMessageTokens(t *testing.T) {
+	// One token per character makes the arithmetic visible
+	tok := ApproxTokenizer{CharsPerToken: 1}
+
+	messages := []Message{
+		SystemMessage("Be brief"),
+		{Role: "assistant", ToolCalls: []ToolCall{{Name: "calc", Args: map[string]interface{}{"a": 1}}}},
+		{Role: "tool", Content: "1", Name: "calc"},
+	}
+
+	want := 3 + // reply priming
+		(3 + 6 + 8) + // system
+		(3 + 9 + 0 + 4 + 7) + // assistant with tool call {"a":1}
+		(3 + 4 + 1 + 1 + 4) // tool result with name
+	if got := CountMessageTokens(tok, messages); got != want {
+		t.Errorf("CountMessageTokens() = %d, want %d", got, want)
+	}
+
+	image := UserMessageWithParts("Hi", ImageURLPart("https://example.com/cat.png"))
+	if got := MessageTokens(tok, image); got != 3+4+2+partTokens {
+		t.Errorf("MessageTokens(image) = %d, want %d", got, 3+4+2+partTokens)
+	}
+}
+
+func TestContextWindowTable_Lookup(t *testing.T) {
+	table := DefaultContextWindows()
+
+	tests := []struct {
+		model string
+		want  int
+		found bool
+	}{
+		{"gpt-4o", 128_000, true},
+		{"gpt-4o-2024-08-06", 128_000, true},
+		{"gpt-4", 8_192, true},
+		{"gpt-4-turbo-preview", 128_000, true},
+		{"claude-3-5-sonnet-20241022", 200_000, true},
+		{"llama3.2:1b", 131_072, true},
+		{"unknown-model", 0, false},
+	}
+
+	for _, tt := range tests {
+		got, ok := table.Lookup(tt.model)
+		if got != tt.want || ok != tt.found {
+			t.Errorf("Lookup(%q) = %d, %v, want %d, %v", tt.model, got, ok, tt.want, tt.found)
+		}
+	}
+}
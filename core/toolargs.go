@@ -0,0 +1,349 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// ValidateToolArgs checks the arguments of a tool call against the tool's
+// schema before it is executed, and returns the arguments to pass to Execute.
+//
+// It:
+//   - reports missing required parameters
+//   - fills in the Default of absent parameters (a JSON null counts as absent)
+//   - coerces values the model commonly gets slightly wrong: numeric strings
+//     ("42") for numbers and integers, "true"/"false" for booleans, enum values
+//     in the wrong case, and objects or arrays sent as JSON-encoded strings
+//   - checks types, enums, bounds (minimum, maxLength, minItems, ...) and
+//     patterns, recursing into nested objects and arrays
+//
+// Arguments not declared in the schema are passed through unchanged. The
+// input map is never modified. If any check fails, the error is an
+// *ErrInvalidToolArgs listing every violation, suitable for feeding back to
+// the model so it can correct the call.
+func ValidateToolArgs(schema *ToolSchema, args map[string]interface{}) (map[string]interface{}, error) {
+	result := make(map[string]interface{}, len(args))
+	for k, v := range args {
+		result[k] = v
+	}
+	if schema == nil {
+		return result, nil
+	}
+
+	var violations []ArgViolation
+	if schema.InputSchema != nil {
+		coerced := coerceArg(schema.InputSchema, result, "", &violations)
+		if len(violations) > 0 {
+			return nil, &ErrInvalidToolArgs{ToolName: schema.Name, Violations: violations}
+		}
+		obj, _ := coerced.(map[string]interface{})
+		return obj, nil
+	}
+
+	for _, param := range schema.Parameters {
+		value, present := result[param.Name]
+		if !present || value == nil {
+			switch {
+			case param.Default != nil:
+				result[param.Name] = param.Default
+			case param.Required:
+				violations = append(violations, ArgViolation{Argument: param.Name, Problem: "is required"})
+			default:
+				delete(result, param.Name)
+			}
+			continue
+		}
+
+		result[param.Name] = coerceArg(param.JSONSchema(), value, param.Name, &violations)
+	}
+
+	if len(violations) > 0 {
+		return nil, &ErrInvalidToolArgs{ToolName: schema.Name, Violations: violations}
+	}
+	return result, nil
+}
+
+// coerceArg coerces value to the JSON Schema type and checks its enum and
+// bounds, recording violations under path. It returns the (possibly converted) value.
+func coerceArg(schema map[string]interface{}, value interface{}, path string, violations *[]ArgViolation) interface{} {
+	addViolation := func(format string, args ...interface{}) {
+		*violations = append(*violations, ArgViolation{Argument: path, Problem: fmt.Sprintf(format, args...)})
+	}
+
+	converted, ok := coerceTypes(schema["type"], value)
+	if !ok {
+		addViolation("expected %s, got %s", typeNames(schema["type"]), describeValue(value))
+		return value
+	}
+	value = converted
+
+	if enum, ok := schema["enum"].([]interface{}); ok && len(enum) > 0 {
+		matched, ok := matchEnum(enum, value)
+		if !ok {
+			addViolation("%s is not one of %s", describeValue(value), describeEnum(enum))
+			return value
+		}
+		value = matched
+	}
+
+	switch v := value.(type) {
+	case float64:
+		if min, ok := toFloat(schema["minimum"]); ok && v < min {
+			addViolation("must be >= %v, got %v", min, v)
+		}
+		if max, ok := toFloat(schema["maximum"]); ok && v > max {
+			addViolation("must be <= %v, got %v", max, v)
+		}
+	case string:
+		length := utf8.RuneCountInString(v)
+		if min, ok := toFloat(schema["minLength"]); ok && float64(length) < min {
+			addViolation("must be at least %v characters long, got %d", min, length)
+		}
+		if max, ok := toFloat(schema["maxLength"]); ok && float64(length) > max {
+			addViolation("must be at most %v characters long, got %d", max, length)
+		}
+		if pattern, ok := schema["pattern"].(string); ok && pattern != "" {
+			// An invalid pattern is the schema author's mistake, not the model's
+			if re, err := regexp.Compile(pattern); err == nil && !re.MatchString(v) {
+				addViolation("%s does not match pattern %s", describeValue(v), pattern)
+			}
+		}
+	case map[string]interface{}:
+		return coerceObject(schema, v, path, violations)
+	case []interface{}:
+		if min, ok := toFloat(schema["minItems"]); ok && float64(len(v)) < min {
+			addViolation("must have at least %v items, got %d", min, len(v))
+		}
+		if max, ok := toFloat(schema["maxItems"]); ok && float64(len(v)) > max {
+			addViolation("must have at most %v items, got %d", max, len(v))
+		}
+
+		items, ok := schema["items"].(map[string]interface{})
+		if !ok {
+			return v
+		}
+		arr := make([]interface{}, len(v))
+		for i, item := range v {
+			arr[i] = coerceArg(items, item, fmt.Sprintf("%s[%d]", path, i), violations)
+		}
+		return arr
+	}
+
+	return value
+}
+
+// coerceObject applies defaults to an object, checks its required fields and
+// coerces its fields against their property (or additionalProperties) schemas.
+func coerceObject(schema map[string]interface{}, v map[string]interface{}, path string, violations *[]ArgViolation) map[string]interface{} {
+	properties, _ := schema["properties"].(map[string]interface{})
+	extra, _ := schema["additionalProperties"].(map[string]interface{})
+	if len(properties) == 0 && extra == nil && schema["required"] == nil {
+		return v
+	}
+
+	obj := make(map[string]interface{}, len(v))
+	for k, field := range v {
+		obj[k] = field
+	}
+
+	for name, prop := range properties {
+		propSchema, _ := prop.(map[string]interface{})
+		if field, present := obj[name]; (!present || field == nil) && propSchema["default"] != nil {
+			obj[name] = propSchema["default"]
+		}
+	}
+	for _, name := range stringList(schema["required"]) {
+		if field, present := obj[name]; !present || field == nil {
+			*violations = append(*violations, ArgViolation{Argument: joinPath(path, name), Problem: "is required"})
+		}
+	}
+
+	// Sorted so that violations are reported in a stable order
+	names := make([]string, 0, len(obj))
+	for name := range obj {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		field := obj[name]
+		if field == nil {
+			continue
+		}
+		if prop, ok := properties[name].(map[string]interface{}); ok {
+			obj[name] = coerceArg(prop, field, joinPath(path, name), violations)
+		} else if extra != nil {
+			obj[name] = coerceArg(extra, field, joinPath(path, name), violations)
+		}
+	}
+	return obj
+}
+
+// joinPath returns the path of a field of the object at path.
+func joinPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+// coerceTypes coerces value to the schema's type, which may be a single type
+// name or a list of them (e.g. ["string", "null"]).
+func coerceTypes(typ interface{}, value interface{}) (interface{}, bool) {
+	types := stringList(typ)
+	if name, ok := typ.(string); ok {
+		types = []string{name}
+	}
+	if len(types) == 0 {
+		return value, true
+	}
+
+	for _, t := range types {
+		if t == "null" && value == nil {
+			return nil, true
+		}
+	}
+	for _, t := range types {
+		if t == "null" {
+			continue
+		}
+		if converted, ok := coerceType(t, value); ok {
+			return converted, true
+		}
+	}
+	return value, false
+}
+
+// typeNames formats a schema type for an error message.
+func typeNames(typ interface{}) string {
+	if name, ok := typ.(string); ok {
+		return name
+	}
+	return strings.Join(stringList(typ), " or ")
+}
+
+// coerceType converts value to the given JSON Schema type if it is, or can be
+// losslessly read as, a value of that type. Unknown types accept anything.
+func coerceType(typ string, value interface{}) (interface{}, bool) {
+	switch typ {
+	case "string":
+		s, ok := value.(string)
+		return s, ok
+	case "number":
+		f, ok := toFloat(value)
+		return f, ok
+	case "integer":
+		f, ok := toFloat(value)
+		if !ok || f != math.Trunc(f) {
+			return value, false
+		}
+		return f, true
+	case "boolean":
+		switch v := value.(type) {
+		case bool:
+			return v, true
+		case string:
+			b, err := strconv.ParseBool(strings.TrimSpace(v))
+			return b, err == nil
+		}
+		return value, false
+	case "object":
+		switch v := value.(type) {
+		case map[string]interface{}:
+			return v, true
+		case string:
+			var obj map[string]interface{}
+			if err := json.Unmarshal([]byte(v), &obj); err == nil && obj != nil {
+				return obj, true
+			}
+		}
+		return value, false
+	case "array":
+		switch v := value.(type) {
+		case []interface{}:
+			return v, true
+		case string:
+			var arr []interface{}
+			if err := json.Unmarshal([]byte(v), &arr); err == nil && arr != nil {
+				return arr, true
+			}
+		}
+		return value, false
+	}
+	return value, true
+}
+
+// toFloat reads a number from a decoded JSON number, a Go numeric type or a
+// numeric string. Numbers are returned as float64, as encoding/json decodes them.
+func toFloat(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case int32:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case json.Number:
+		f, err := v.Float64()
+		return f, err == nil
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		if err != nil || math.IsNaN(f) || math.IsInf(f, 0) {
+			return 0, false
+		}
+		return f, true
+	}
+	return 0, false
+}
+
+// matchEnum returns the enum value equal to value. Strings that differ only
+// in case match, and are replaced by the declared spelling.
+func matchEnum(enum []interface{}, value interface{}) (interface{}, bool) {
+	if inEnum(enum, value) {
+		return value, true
+	}
+
+	if s, ok := value.(string); ok {
+		for _, e := range enum {
+			if es, ok := e.(string); ok && strings.EqualFold(es, s) {
+				return es, true
+			}
+		}
+	}
+	return value, false
+}
+
+// describeValue formats a value and its JSON type for an error message.
+func describeValue(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return fmt.Sprintf("string %q", v)
+	case map[string]interface{}, []interface{}:
+		return jsonTypeName(v)
+	case nil:
+		return "null"
+	}
+	return fmt.Sprintf("%s %v", jsonTypeName(value), value)
+}
+
+// describeEnum formats enum values for an error message.
+func describeEnum(enum []interface{}) string {
+	values := make([]string, len(enum))
+	for i, e := range enum {
+		if s, ok := e.(string); ok {
+			values[i] = strconv.Quote(s)
+		} else {
+			values[i] = fmt.Sprintf("%v", e)
+		}
+	}
+	return "[" + strings.Join(values, ", ") + "]"
+}
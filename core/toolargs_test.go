@@ -0,0 +1,251 @@
+package core
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func toolArgsTestSchema() *ToolSchema {
+	return &ToolSchema{
+		Name: "search",
+		Parameters: []Parameter{
+			{Name: "query", Type: "string", Required: true},
+			{Name: "limit", Type: "integer", Default: float64(10)},
+			{Name: "min_score", Type: "number"},
+			{Name: "exact", Type: "boolean"},
+			{Name: "sort", Type: "string", Enum: []interface{}{"Relevance", "Date"}},
+			{Name: "filter", Type: "object"},
+			{Name: "ids", Type: "array"},
+		},
+	}
+}
+
+// TestValidateToolArgs_Coercion tests defaults and coercion of near-miss values
+func TestValidateToolArgs_Coercion(t *testing.T) {
+	args := map[string]interface{}{
+		"query":     "golang",
+		"min_score": " 0.5",
+		"exact":     "true",
+		"sort":      "date",
+		"filter":    `{"lang": "en"}`,
+		"ids":       `[1, 2]`,
+		"unknown":   "kept",
+	}
+
+	got, err := ValidateToolArgs(toolArgsTestSchema(), args)
+	if err != nil {
+		t.Fatalf("ValidateToolArgs() error = %v", err)
+	}
+
+	want := map[string]interface{}{
+		"query":     "golang",
+		"limit":     float64(10),
+		"min_score": 0.5,
+		"exact":     true,
+		"sort":      "Date",
+		"filter":    map[string]interface{}{"lang": "en"},
+		"ids":       []interface{}{float64(1), float64(2)},
+		"unknown":   "kept",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ValidateToolArgs() = %v, want %v", got, want)
+	}
+
+	if args["exact"] != "true" || len(args) != 7 {
+		t.Errorf("expected input args to be left unchanged, got %v", args)
+	}
+}
+
+// TestValidateToolArgs_Violations tests that every violation is reported
+func TestValidateToolArgs_Violations(t *testing.T) {
+	_, err := ValidateToolArgs(toolArgsTestSchema(), map[string]interface{}{
+		"limit":  "2.5",
+		"exact":  "maybe",
+		"sort":   "popularity",
+		"filter": "lang=en",
+	})
+
+	var invalid *ErrInvalidToolArgs
+	if !errors.As(err, &invalid) {
+		t.Fatalf("expected *ErrInvalidToolArgs, got %T: %v", err, err)
+	}
+	if invalid.ToolName != "search" {
+		t.Errorf("expected tool name search, got %q", invalid.ToolName)
+	}
+
+	want := []ArgViolation{
+		{Argument: "query", Problem: "is required"},
+		{Argument: "limit", Problem: `expected integer, got string "2.5"`},
+		{Argument: "exact", Problem: `expected boolean, got string "maybe"`},
+		{Argument: "sort", Problem: `string "popularity" is not one of ["Relevance", "Date"]`},
+		{Argument: "filter", Problem: `expected object, got string "lang=en"`},
+	}
+	if !reflect.DeepEqual(invalid.Violations, want) {
+		t.Errorf("violations = %+v, want %+v", invalid.Violations, want)
+	}
+}
+
+// TestValidateToolArgs_NullArgument tests that null is treated as absent
+func TestValidateToolArgs_NullArgument(t *testing.T) {
+	got, err := ValidateToolArgs(toolArgsTestSchema(), map[string]interface{}{
+		"query":     "x",
+		"limit":     nil,
+		"min_score": nil,
+	})
+	if err != nil {
+		t.Fatalf("ValidateToolArgs() error = %v", err)
+	}
+	if got["limit"] != float64(10) {
+		t.Errorf("expected default limit, got %v", got["limit"])
+	}
+	if _, ok := got["min_score"]; ok {
+		t.Errorf("expected null optional argument to be dropped, got %v", got)
+	}
+}
+
+// TestValidateToolArgs_NilSchema tests that tools without a schema accept anything
+func TestValidateToolArgs_NilSchema(t *testing.T) {
+	got, err := ValidateToolArgs(nil, map[string]interface{}{"a": "1"})
+	if err != nil || got["a"] != "1" {
+		t.Errorf("ValidateToolArgs(nil) = %v, %v", got, err)
+	}
+}
+
+// TestCoerceArg_Nested tests recursion into object properties and array items
+func TestCoerceArg_Nested(t *testing.T) {
+	schema := map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"page": map[string]interface{}{"type": "integer"},
+			"tags": map[string]interface{}{
+				"type":  "array",
+				"items": map[string]interface{}{"type": "number"},
+			},
+		},
+		"required": []string{"page", "owner"},
+	}
+
+	var violations []ArgViolation
+	got := coerceArg(schema, map[string]interface{}{
+		"page": "3",
+		"tags": []interface{}{"1.5", "x"},
+	}, "filter", &violations)
+
+	want := []ArgViolation{
+		{Argument: "filter.owner", Problem: "is required"},
+		{Argument: "filter.tags[1]", Problem: `expected number, got string "x"`},
+	}
+	if !reflect.DeepEqual(violations, want) {
+		t.Errorf("violations = %+v, want %+v", violations, want)
+	}
+
+	obj := got.(map[string]interface{})
+	if obj["page"] != float64(3) || obj["tags"].([]interface{})[0] != 1.5 {
+		t.Errorf("expected nested values to be coerced, got %v", obj)
+	}
+}
+
+// TestValidateToolArgs_Bounds tests bounds, patterns and map values
+func TestValidateToolArgs_Bounds(t *testing.T) {
+	schema := &ToolSchema{
+		Name: "order",
+		Parameters: []Parameter{
+			{Name: "quantity", Type: "integer", Minimum: Ptr(1.0), Maximum: Ptr(10.0)},
+			{Name: "sku", Type: "string", Pattern: "^[A-Z]+-\\d+$", MaxLength: Ptr(8)},
+			{Name: "tags", Type: "array", MinItems: Ptr(1), Items: &Parameter{Type: "string"}},
+			{Name: "labels", Type: "object", AdditionalProperties: &Parameter{Type: "string"}},
+			{Name: "shipping", Type: "object", Properties: []Parameter{
+				{Name: "method", Type: "string", Default: "ground"},
+				{Name: "insured", Type: "boolean"},
+			}},
+		},
+	}
+
+	got, err := ValidateToolArgs(schema, map[string]interface{}{
+		"quantity": "10",
+		"sku":      "ABC-12",
+		"tags":     []interface{}{"gift"},
+		"shipping": map[string]interface{}{"insured": "false"},
+	})
+	if err != nil {
+		t.Fatalf("ValidateToolArgs() error = %v", err)
+	}
+	want := map[string]interface{}{"method": "ground", "insured": false}
+	if !reflect.DeepEqual(got["shipping"], want) {
+		t.Errorf("shipping = %v, want %v", got["shipping"], want)
+	}
+
+	_, err = ValidateToolArgs(schema, map[string]interface{}{
+		"quantity": 0.0,
+		"sku":      "abc-12",
+		"tags":     []interface{}{},
+		"labels":   map[string]interface{}{"team": 7.0},
+	})
+	var invalid *ErrInvalidToolArgs
+	if !errors.As(err, &invalid) {
+		t.Fatalf("expected *ErrInvalidToolArgs, got %v", err)
+	}
+
+	wantViolations := []ArgViolation{
+		{Argument: "quantity", Problem: "must be >= 1, got 0"},
+		{Argument: "sku", Problem: `string "abc-12" does not match pattern ^[A-Z]+-\d+$`},
+		{Argument: "tags", Problem: "must have at least 1 items, got 0"},
+		{Argument: "labels.team", Problem: "expected string, got number 7"},
+	}
+	if !reflect.DeepEqual(invalid.Violations, wantViolations) {
+		t.Errorf("violations = %+v, want %+v", invalid.Violations, wantViolations)
+	}
+}
+
+// TestValidateToolArgs_InputSchema tests validation against a raw input schema
+func TestValidateToolArgs_InputSchema(t *testing.T) {
+	schema := &ToolSchema{
+		Name: "lookup",
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"id":   map[string]interface{}{"type": []interface{}{"integer", "null"}},
+				"page": map[string]interface{}{"type": "integer", "default": 1.0},
+			},
+			"required": []interface{}{"id"},
+		},
+	}
+
+	got, err := ValidateToolArgs(schema, map[string]interface{}{"id": "42"})
+	if err != nil {
+		t.Fatalf("ValidateToolArgs() error = %v", err)
+	}
+	if got["id"] != 42.0 || got["page"] != 1.0 {
+		t.Errorf("ValidateToolArgs() = %v, want id 42 and page 1", got)
+	}
+
+	_, err = ValidateToolArgs(schema, map[string]interface{}{"id": "x"})
+	if err == nil || !strings.Contains(err.Error(), "id expected integer or null") {
+		t.Errorf("expected type list violation, got %v", err)
+	}
+}
+
+// TestErrInvalidToolArgs tests the error message and the feedback sent to the LLM
+func TestErrInvalidToolArgs(t *testing.T) {
+	err := &ErrInvalidToolArgs{
+		ToolName: "calculator",
+		Violations: []ArgViolation{
+			{Argument: "a", Problem: `expected number, got string "abc"`},
+			{Argument: "b", Problem: "is required"},
+		},
+	}
+
+	expected := `invalid arguments for tool "calculator": a expected number, got string "abc"; b is required`
+	if err.Error() != expected {
+		t.Errorf("expected error message %q, got %q", expected, err.Error())
+	}
+
+	feedback := err.Feedback()
+	for _, want := range []string{`"error":"invalid_arguments"`, `"tool":"calculator"`, `"argument":"b"`, `"problem":"is required"`} {
+		if !strings.Contains(feedback, want) {
+			t.Errorf("expected feedback to contain %s, got %s", want, feedback)
+		}
+	}
+}
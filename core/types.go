@@ -19,10 +19,77 @@ type Message struct {
 	// ToolCalls contains tool calls made by the assistant (for function calling)
 	ToolCalls []ToolCall
 
+	// Parts optionally carries multimodal content (images, documents, extra text).
+	// Providers send Content first (as a text part) followed by Parts.
+	Parts []ContentPart
+
 	// Meta contains additional metadata (model-specific fields, etc.)
 	Meta map[string]interface{}
 }
 
+// Content part types.
+const (
+	ContentPartText     = "text"      // Plain text
+	ContentPartImageURL = "image_url" // Image referenced by URL
+	ContentPartImage    = "image"     // Inline image bytes with a MIME type
+	ContentPartDocument = "document"  // Inline document bytes (e.g. PDF) with a MIME type
+)
+
+// ContentPart is a single piece of multimodal message content.
+// Each provider translates parts into its own format; providers that
+// cannot represent a part type return an error instead of dropping it.
+type ContentPart struct {
+	// Type is one of the ContentPart* constants
+	Type string
+
+	// Text is the text for ContentPartText parts
+	Text string
+
+	// URL is the image location for ContentPartImageURL parts
+	URL string
+
+	// Data holds the raw bytes for ContentPartImage and ContentPartDocument parts
+	Data []byte
+
+	// MIMEType describes Data (e.g. "image/png", "application/pdf").
+	// Optional for image URLs.
+	MIMEType string
+
+	// Detail is an optional image fidelity hint ("low", "high", "auto").
+	// Only honoured by providers that support it.
+	Detail string
+}
+
+// TextPart creates a text content part.
+func TextPart(text string) ContentPart {
+	return ContentPart{Type: ContentPartText, Text: text}
+}
+
+// ImageURLPart creates an image content part referenced by URL.
+func ImageURLPart(url string) ContentPart {
+	return ContentPart{Type: ContentPartImageURL, URL: url}
+}
+
+// ImagePart creates an inline image content part from raw bytes.
+func ImagePart(data []byte, mimeType string) ContentPart {
+	return ContentPart{Type: ContentPartImage, Data: data, MIMEType: mimeType}
+}
+
+// DocumentPart creates an inline document content part (e.g. a PDF) from raw bytes.
+func DocumentPart(data []byte, mimeType string) ContentPart {
+	return ContentPart{Type: ContentPartDocument, Data: data, MIMEType: mimeType}
+}
+
+// ContentParts returns the message content as a list of parts:
+// Content as a leading text part (when non-empty) followed by Parts.
+func (m Message) ContentParts() []ContentPart {
+	parts := make([]ContentPart, 0, len(m.Parts)+1)
+	if m.Content != "" {
+		parts = append(parts, TextPart(m.Content))
+	}
+	return append(parts, m.Parts...)
+}
+
 // Response represents a response from an LLM or agent.
 type Response struct {
 	// Content is the main text response
@@ -31,11 +98,45 @@ type Response struct {
 	// ToolCalls contains any tools that were invoked
 	ToolCalls []ToolCall
 
+	// Usage reports the tokens consumed by the request.
+	// Nil when the provider did not report usage.
+	Usage *Usage
+
 	// Meta contains metadata about the response
 	// Common fields: "tokens_used", "model", "latency_ms", "cost"
 	Meta map[string]interface{}
 }
 
+// Usage is the token usage of an LLM request, normalized across providers.
+type Usage struct {
+	// PromptTokens is the number of input tokens
+	PromptTokens int
+
+	// CompletionTokens is the number of generated tokens
+	CompletionTokens int
+
+	// TotalTokens is the sum of prompt and completion tokens
+	TotalTokens int
+}
+
+// NewUsage creates a Usage from prompt and completion token counts.
+func NewUsage(promptTokens, completionTokens int) *Usage {
+	return &Usage{
+		PromptTokens:     promptTokens,
+		CompletionTokens: completionTokens,
+		TotalTokens:      promptTokens + completionTokens,
+	}
+}
+
+// Add returns the sum of u and other.
+func (u Usage) Add(other Usage) Usage {
+	return Usage{
+		PromptTokens:     u.PromptTokens + other.PromptTokens,
+		CompletionTokens: u.CompletionTokens + other.CompletionTokens,
+		TotalTokens:      u.TotalTokens + other.TotalTokens,
+	}
+}
+
 // ToolCall represents a single invocation of a tool.
 type ToolCall struct {
 	// ID is a unique identifier for this call
@@ -67,6 +168,11 @@ type ToolSchema struct {
 
 	// Parameters defines the tool's inputs
 	Parameters []Parameter
+
+	// InputSchema optionally replaces Parameters with a raw JSON Schema object
+	// for inputs Parameter cannot express (oneOf, $defs, ...). It is sent to
+	// providers as-is.
+	InputSchema map[string]interface{}
 }
 
 // Parameter defines a single parameter for a tool.
@@ -88,6 +194,165 @@ type Parameter struct {
 
 	// Default is the default value if not provided
 	Default interface{}
+
+	// Items describes the elements of an "array" parameter
+	Items *Parameter
+
+	// Properties describes the fields of an "object" parameter; fields marked
+	// Required must be present. Objects with properties are closed.
+	Properties []Parameter
+
+	// AdditionalProperties describes the values of an "object" parameter used
+	// as a map, such as HTTP headers
+	AdditionalProperties *Parameter
+
+	// Minimum and Maximum bound "number" and "integer" values (inclusive)
+	Minimum *float64
+	Maximum *float64
+
+	// MinLength and MaxLength bound the length of "string" values
+	MinLength *int
+	MaxLength *int
+
+	// MinItems and MaxItems bound the length of "array" values
+	MinItems *int
+	MaxItems *int
+
+	// Pattern is a regular expression "string" values must match
+	Pattern string
+
+	// Format is a hint such as "date-time", "email" or "uri"; it is not enforced
+	Format string
+
+	// Schema optionally replaces all of the above with a raw JSON Schema.
+	// Description is added to it when it has none.
+	Schema map[string]interface{}
+}
+
+// Ptr returns a pointer to v, for optional fields such as Parameter.Minimum:
+//
+//	core.Parameter{Name: "limit", Type: "integer", Minimum: core.Ptr(1.0), Maximum: core.Ptr(100.0)}
+func Ptr[T any](v T) *T {
+	return &v
+}
+
+// JSONSchema converts the tool's parameters into a JSON Schema object.
+// The result has the shape expected by function calling APIs:
+//
+//	{"type": "object", "properties": {...}, "required": [...], "additionalProperties": false}
+//
+// If InputSchema is set it is returned instead.
+// Providers use this to advertise tools without each re-implementing the conversion.
+func (s *ToolSchema) JSONSchema() map[string]interface{} {
+	if s != nil && s.InputSchema != nil {
+		return s.InputSchema
+	}
+
+	var params []Parameter
+	if s != nil {
+		params = s.Parameters
+	}
+	return objectSchema(params)
+}
+
+// objectSchema builds a closed object schema from a list of parameters.
+func objectSchema(params []Parameter) map[string]interface{} {
+	properties := make(map[string]interface{}, len(params))
+	required := make([]string, 0)
+
+	for _, param := range params {
+		properties[param.Name] = param.JSONSchema()
+
+		if param.Required {
+			required = append(required, param.Name)
+		}
+	}
+
+	schema := map[string]interface{}{
+		"type":                 "object",
+		"properties":           properties,
+		"additionalProperties": false,
+	}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+
+	return schema
+}
+
+// JSONSchema returns the JSON Schema of a single parameter.
+func (p Parameter) JSONSchema() map[string]interface{} {
+	if p.Schema != nil {
+		prop := make(map[string]interface{}, len(p.Schema)+1)
+		for k, v := range p.Schema {
+			prop[k] = v
+		}
+		if _, ok := prop["description"]; !ok && p.Description != "" {
+			prop["description"] = p.Description
+		}
+		return prop
+	}
+
+	prop := map[string]interface{}{
+		"type": p.Type,
+	}
+	if len(p.Properties) > 0 {
+		prop = objectSchema(p.Properties)
+	}
+	if p.Description != "" {
+		prop["description"] = p.Description
+	}
+	if len(p.Enum) > 0 {
+		prop["enum"] = p.Enum
+	}
+	if p.Default != nil {
+		prop["default"] = p.Default
+	}
+	if p.Items != nil {
+		prop["items"] = p.Items.JSONSchema()
+	}
+	if p.AdditionalProperties != nil {
+		prop["additionalProperties"] = p.AdditionalProperties.JSONSchema()
+	}
+	if p.Format != "" {
+		prop["format"] = p.Format
+	}
+	if p.Pattern != "" {
+		prop["pattern"] = p.Pattern
+	}
+
+	if p.Minimum != nil {
+		prop["minimum"] = *p.Minimum
+	}
+	if p.Maximum != nil {
+		prop["maximum"] = *p.Maximum
+	}
+	if p.MinLength != nil {
+		prop["minLength"] = *p.MinLength
+	}
+	if p.MaxLength != nil {
+		prop["maxLength"] = *p.MaxLength
+	}
+	if p.MinItems != nil {
+		prop["minItems"] = *p.MinItems
+	}
+	if p.MaxItems != nil {
+		prop["maxItems"] = *p.MaxItems
+	}
+
+	return prop
+}
+
+// ResponseSchema describes the JSON document an LLM must produce.
+type ResponseSchema struct {
+	// Name identifies the schema (letters, digits, underscores and dashes)
+	Name string
+
+	// Description optionally explains what the document represents
+	Description string
+
+	// Schema is the JSON Schema of the document (see JSONSchemaFor)
+	Schema map[string]interface{}
 }
 
 // NewMessage creates a new message with the given role and content.
@@ -113,3 +378,11 @@ func UserMessage(content string) Message {
 func AssistantMessage(content string) Message {
 	return NewMessage("assistant", content)
 }
+
+// UserMessageWithParts creates a user message with text and additional
+// multimodal parts such as images or documents.
+func UserMessageWithParts(text string, parts ...ContentPart) Message {
+	msg := NewMessage("user", text)
+	msg.Parts = parts
+	return msg
+}
@@ -1,6 +1,7 @@
 package core
 
 import (
+	"reflect"
 	"testing"
 	"time"
 )
@@ -428,6 +429,90 @@ func TestParameter_Types(t *testing.T) {
 	}
 }
 
+// TestParameter_JSONSchema tests nested objects, array items and bounds
+func TestParameter_JSONSchema(t *testing.T) {
+	param := Parameter{
+		Name:        "orders",
+		Type:        "array",
+		Description: "Orders to create",
+		MinItems:    Ptr(1),
+		Items: &Parameter{
+			Type: "object",
+			Properties: []Parameter{
+				{Name: "sku", Type: "string", Required: true, Pattern: "^[A-Z]{3}-\\d+$"},
+				{Name: "quantity", Type: "integer", Required: true, Minimum: Ptr(1.0), Maximum: Ptr(100.0)},
+				{Name: "deliver_by", Type: "string", Format: "date"},
+			},
+		},
+	}
+
+	want := map[string]interface{}{
+		"type":        "array",
+		"description": "Orders to create",
+		"minItems":    1,
+		"items": map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"sku":        map[string]interface{}{"type": "string", "pattern": "^[A-Z]{3}-\\d+$"},
+				"quantity":   map[string]interface{}{"type": "integer", "minimum": 1.0, "maximum": 100.0},
+				"deliver_by": map[string]interface{}{"type": "string", "format": "date"},
+			},
+			"required":             []string{"sku", "quantity"},
+			"additionalProperties": false,
+		},
+	}
+
+	if got := param.JSONSchema(); !reflect.DeepEqual(got, want) {
+		t.Errorf("JSONSchema() = %v, want %v", got, want)
+	}
+}
+
+// TestParameter_JSONSchema_Map tests objects used as maps
+func TestParameter_JSONSchema_Map(t *testing.T) {
+	param := Parameter{Name: "headers", Type: "object", AdditionalProperties: &Parameter{Type: "string"}}
+
+	want := map[string]interface{}{
+		"type":                 "object",
+		"additionalProperties": map[string]interface{}{"type": "string"},
+	}
+	if got := param.JSONSchema(); !reflect.DeepEqual(got, want) {
+		t.Errorf("JSONSchema() = %v, want %v", got, want)
+	}
+}
+
+// TestParameter_JSONSchema_Raw tests the raw schema escape hatch
+func TestParameter_JSONSchema_Raw(t *testing.T) {
+	raw := map[string]interface{}{
+		"oneOf": []interface{}{
+			map[string]interface{}{"type": "string"},
+			map[string]interface{}{"type": "integer"},
+		},
+	}
+	param := Parameter{Name: "id", Type: "string", Description: "Order ID or number", Schema: raw}
+
+	got := param.JSONSchema()
+	if got["description"] != "Order ID or number" || got["type"] != nil || got["oneOf"] == nil {
+		t.Errorf("JSONSchema() = %v, want the raw schema with a description", got)
+	}
+	if _, ok := raw["description"]; ok {
+		t.Error("expected the raw schema not to be modified")
+	}
+}
+
+// TestToolSchema_InputSchema tests that a raw input schema replaces Parameters
+func TestToolSchema_InputSchema(t *testing.T) {
+	raw := map[string]interface{}{"type": "object", "properties": map[string]interface{}{}}
+	schema := &ToolSchema{
+		Name:        "raw",
+		Parameters:  []Parameter{{Name: "ignored", Type: "string"}},
+		InputSchema: raw,
+	}
+
+	if got := schema.JSONSchema(); !reflect.DeepEqual(got, raw) {
+		t.Errorf("JSONSchema() = %v, want the input schema", got)
+	}
+}
+
 // TestMessage_EmptyContent tests message with empty content
 func TestMessage_EmptyContent(t *testing.T) {
 	msg := UserMessage("")
@@ -497,3 +582,42 @@ func BenchmarkAssistantMessage(b *testing.B) {
 		_ = AssistantMessage("Response")
 	}
 }
+
+// TestUserMessageWithParts tests multimodal message construction
+func TestUserMessageWithParts(t *testing.T) {
+	msg := UserMessageWithParts("What is in this image?",
+		ImageURLPart("https://example.com/cat.png"),
+		ImagePart([]byte{0x89, 0x50}, "image/png"),
+	)
+
+	if msg.Role != "user" {
+		t.Errorf("expected role 'user', got %q", msg.Role)
+	}
+
+	parts := msg.ContentParts()
+	if len(parts) != 3 {
+		t.Fatalf("expected 3 parts, got %d", len(parts))
+	}
+
+	if parts[0].Type != ContentPartText || parts[0].Text != "What is in this image?" {
+		t.Errorf("expected leading text part, got %+v", parts[0])
+	}
+
+	if parts[1].Type != ContentPartImageURL || parts[1].URL != "https://example.com/cat.png" {
+		t.Errorf("expected image URL part, got %+v", parts[1])
+	}
+
+	if parts[2].Type != ContentPartImage || parts[2].MIMEType != "image/png" {
+		t.Errorf("expected inline image part, got %+v", parts[2])
+	}
+}
+
+// TestMessage_ContentPartsEmptyText tests that empty content adds no text part
+func TestMessage_ContentPartsEmptyText(t *testing.T) {
+	msg := Message{Role: "user", Parts: []ContentPart{DocumentPart([]byte("%PDF"), "application/pdf")}}
+
+	parts := msg.ContentParts()
+	if len(parts) != 1 || parts[0].Type != ContentPartDocument {
+		t.Errorf("expected only the document part, got %+v", parts)
+	}
+}
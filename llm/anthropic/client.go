@@ -26,6 +26,9 @@
 // METHODS:
 // - New(opts): Creates a client with functional options for flexible configuration
 // - Chat(ctx, messages): Send conversation history, get response (implements core.LLM)
+// - ChatWithTools(ctx, messages, tools): Chat with native tool calling (implements core.ToolCallingLLM)
+// - ChatWithSchema(ctx, messages, schema): Structured JSON output via a forced tool call (implements core.StructuredOutputLLM)
+// - ChatStream / ChatStreamWithTools: Streaming variants, including streamed tool calls
 // - Complete(ctx, prompt): Convenience method for single-turn completions
 // - Model(): Returns the model name being used
 //
@@ -39,6 +42,7 @@ import (
 	"bufio"
 	"bytes"
 	"context"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -101,15 +105,24 @@ func New(opts ...Option) *Client {
 // - Extracts all system messages and combines them into Claude's system parameter
 // - Sends user/assistant messages as conversation history
 // - Includes token usage in response metadata for cost tracking and optimization
-func (c *Client) Chat(ctx context.Context, messages []core.Message) (*core.Response, error) {
+func (c *Client) Chat(ctx context.Context, messages []core.Message, opts ...core.CallOption) (*core.Response, error) {
 	if c.apiKey == "" {
 		return nil, fmt.Errorf("anthropic: API key is required")
 	}
 
+	// WHY: Claude has no JSON response mode; schema-constrained replies go through
+	// the forced tool call used by ChatWithSchema
+	if f := core.NewCallOptions(opts...).ResponseFormat; f != nil && f.Type == core.ResponseFormatJSONSchema && f.Schema != nil {
+		return c.ChatWithSchema(ctx, messages, f.Schema, opts...)
+	}
+
 	// Convert core.Message to Anthropic format and extract system prompts
 	// WHY: Anthropic treats system prompts differently from other providers,
 	// requiring them in a separate "system" field rather than in the messages array
-	anthropicMessages, systemPrompt := c.convertMessages(messages)
+	anthropicMessages, systemPrompt, err := c.convertMessages(messages)
+	if err != nil {
+		return nil, err
+	}
 
 	// Build request with all configured parameters
 	req := Request{
@@ -121,6 +134,7 @@ func (c *Client) Chat(ctx context.Context, messages []core.Message) (*core.Respo
 		TopK:        c.topK,
 		System:      systemPrompt,
 	}
+	applyCallOptions(&req, opts)
 
 	// Make API call with context for cancellation and timeouts
 	resp, err := c.doRequest(ctx, req)
@@ -132,6 +146,170 @@ func (c *Client) Chat(ctx context.Context, messages []core.Message) (*core.Respo
 	return c.convertResponse(resp), nil
 }
 
+// ChatWithTools sends a conversation along with tools Claude may call (implements core.ToolCallingLLM).
+// WHY: Claude supports native tool use. Advertising tools through the "tools" request
+// field and reading "tool_use" response blocks lets provider-agnostic agents such as
+// FunctionAgent drive Claude the same way they drive other providers.
+//
+// BUSINESS LOGIC:
+// - Tools without a schema are skipped (Claude requires an input_schema)
+// - tool_use blocks are returned as core.Response.ToolCalls
+// - Any text Claude emits alongside tool calls is kept in Content
+func (c *Client) ChatWithTools(ctx context.Context, messages []core.Message, tools []core.Tool, opts ...core.CallOption) (*core.Response, error) {
+	if c.apiKey == "" {
+		return nil, fmt.Errorf("anthropic: API key is required")
+	}
+
+	anthropicMessages, systemPrompt, err := c.convertMessages(messages)
+	if err != nil {
+		return nil, err
+	}
+
+	req := Request{
+		Model:       c.model,
+		Messages:    anthropicMessages,
+		MaxTokens:   c.maxTokens,
+		Temperature: c.temperature,
+		TopP:        c.topP,
+		TopK:        c.topK,
+		System:      systemPrompt,
+		Tools:       convertTools(tools),
+	}
+	applyCallOptions(&req, opts)
+
+	resp, err := c.doRequest(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("anthropic: request failed: %w", err)
+	}
+
+	return c.convertResponse(resp), nil
+}
+
+// ChatWithSchema sends a conversation and returns a JSON document matching the schema
+// (implements core.StructuredOutputLLM).
+// WHY: Claude has no JSON response mode, but forcing a call to a tool whose input_schema
+// is the requested schema yields schema-shaped arguments. The tool input is returned as
+// the JSON document in Content.
+//
+// BUSINESS LOGIC:
+// - Non-object schemas are wrapped in {"value": ...} because input_schema must be an object
+// - The wrapper is removed again before returning
+// - Fails if Claude does not call the forced tool
+func (c *Client) ChatWithSchema(ctx context.Context, messages []core.Message, schema *core.ResponseSchema, opts ...core.CallOption) (*core.Response, error) {
+	if c.apiKey == "" {
+		return nil, fmt.Errorf("anthropic: API key is required")
+	}
+	if schema == nil {
+		return nil, &core.ErrInvalidArgument{Argument: "schema", Reason: "cannot be nil"}
+	}
+
+	anthropicMessages, systemPrompt, err := c.convertMessages(messages)
+	if err != nil {
+		return nil, err
+	}
+
+	inputSchema := schema.Schema
+	wrapped := inputSchema["type"] != "object"
+	if wrapped {
+		inputSchema = map[string]interface{}{
+			"type":       "object",
+			"properties": map[string]interface{}{"value": schema.Schema},
+			"required":   []string{"value"},
+		}
+	}
+
+	description := schema.Description
+	if description == "" {
+		description = "Respond with a document matching this schema."
+	}
+
+	req := Request{
+		Model:       c.model,
+		Messages:    anthropicMessages,
+		MaxTokens:   c.maxTokens,
+		Temperature: c.temperature,
+		TopP:        c.topP,
+		TopK:        c.topK,
+		System:      systemPrompt,
+		Tools: []Tool{{
+			Name:        schema.Name,
+			Description: description,
+			InputSchema: inputSchema,
+		}},
+	}
+	applyCallOptions(&req, opts)
+
+	// WHY: The forced tool call is what produces the document, so it always
+	// wins over a per-call tool choice
+	req.ToolChoice = &ToolChoice{Type: "tool", Name: schema.Name}
+
+	resp, err := c.doRequest(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("anthropic: request failed: %w", err)
+	}
+
+	result := c.convertResponse(resp)
+	for _, tc := range result.ToolCalls {
+		if tc.Name != schema.Name {
+			continue
+		}
+
+		var doc interface{} = tc.Args
+		if wrapped {
+			doc = tc.Args["value"]
+		}
+		data, err := json.Marshal(doc)
+		if err != nil {
+			return nil, fmt.Errorf("anthropic: failed to encode structured output: %w", err)
+		}
+
+		result.Content = string(data)
+		result.ToolCalls = nil
+		return result, nil
+	}
+
+	return nil, fmt.Errorf("anthropic: model did not call the %s tool", schema.Name)
+}
+
+// applyCallOptions merges per-call options into a request.
+// WHY: Options override the client defaults for one call only, so a single client
+// can serve both creative and deterministic requests.
+//
+// BUSINESS LOGIC:
+// - "required" maps to Claude's "any"; other names force that specific tool
+// - tool_choice is only sent alongside tools (Claude rejects it otherwise)
+// - Only the "user_id" metadata key is sent; Claude has no seed parameter
+func applyCallOptions(req *Request, opts []core.CallOption) {
+	if len(opts) == 0 {
+		return
+	}
+	o := core.NewCallOptions(opts...)
+
+	if o.Temperature != nil {
+		req.Temperature = o.Temperature
+	}
+	if o.MaxTokens != nil {
+		req.MaxTokens = *o.MaxTokens
+	}
+	if o.Stop != nil {
+		req.StopSequences = o.Stop
+	}
+	if userID := o.Metadata["user_id"]; userID != "" {
+		req.Metadata = &Metadata{UserID: userID}
+	}
+
+	if o.ToolChoice != "" && len(req.Tools) > 0 {
+		switch o.ToolChoice {
+		case core.ToolChoiceAuto, core.ToolChoiceNone:
+			req.ToolChoice = &ToolChoice{Type: o.ToolChoice}
+		case core.ToolChoiceRequired:
+			req.ToolChoice = &ToolChoice{Type: "any"}
+		default:
+			req.ToolChoice = &ToolChoice{Type: "tool", Name: o.ToolChoice}
+		}
+	}
+}
+
 // Complete sends a single prompt and receives a completion (implements core.LLM).
 // WHY: This is a convenience method for simple, single-turn interactions where you
 // don't need conversation history. It wraps Chat() but provides a simpler interface
@@ -143,12 +321,12 @@ func (c *Client) Chat(ctx context.Context, messages []core.Message) (*core.Respo
 // - Quick prototyping and testing
 //
 // For multi-turn conversations or when you need metadata, use Chat() directly.
-func (c *Client) Complete(ctx context.Context, prompt string) (string, error) {
+func (c *Client) Complete(ctx context.Context, prompt string, opts ...core.CallOption) (string, error) {
 	messages := []core.Message{
 		{Role: "user", Content: prompt},
 	}
 
-	resp, err := c.Chat(ctx, messages)
+	resp, err := c.Chat(ctx, messages, opts...)
 	if err != nil {
 		return "", err
 	}
@@ -169,13 +347,31 @@ func (c *Client) Complete(ctx context.Context, prompt string) (string, error) {
 // - Converts Anthropic events to core.StreamChunk format
 // - Runs stream processing in a goroutine
 // - Closes channel when stream completes or errors occur
-func (c *Client) ChatStream(ctx context.Context, messages []core.Message, opts ...interface{}) (<-chan core.StreamChunk, error) {
+func (c *Client) ChatStream(ctx context.Context, messages []core.Message, opts ...core.CallOption) (<-chan core.StreamChunk, error) {
+	return c.ChatStreamWithTools(ctx, messages, nil, opts...)
+}
+
+// ChatStreamWithTools streams a chat completion while advertising tools Claude may call.
+//
+// WHY THIS METHOD:
+// Claude streams tool calls as a "tool_use" content_block_start (ID and name) followed by
+// "input_json_delta" fragments of the arguments. Text deltas are forwarded as they
+// arrive; tool inputs are buffered per content block and parsed once the block stops.
+//
+// BUSINESS LOGIC:
+// - Completed tool calls are attached to the final chunk's ToolCalls
+// - The final FinishReason is "tool_calls" when Claude stopped to use tools
+// - Malformed tool input JSON is reported as an error chunk
+func (c *Client) ChatStreamWithTools(ctx context.Context, messages []core.Message, tools []core.Tool, opts ...core.CallOption) (<-chan core.StreamChunk, error) {
 	if c.apiKey == "" {
 		return nil, fmt.Errorf("API key is required")
 	}
 
 	// Convert messages to Anthropic format
-	anthropicMessages, systemPrompt := c.convertMessages(messages)
+	anthropicMessages, systemPrompt, err := c.convertMessages(messages)
+	if err != nil {
+		return nil, err
+	}
 
 	// Build generation config with streaming enabled
 	genConfig := Request{
@@ -187,7 +383,9 @@ func (c *Client) ChatStream(ctx context.Context, messages []core.Message, opts .
 		Temperature: c.temperature,
 		TopP:        c.topP,
 		TopK:        c.topK,
+		Tools:       convertTools(tools),
 	}
+	applyCallOptions(&genConfig, opts)
 
 	// Marshal request body
 	body, err := json.Marshal(genConfig)
@@ -213,8 +411,8 @@ func (c *Client) ChatStream(ctx context.Context, messages []core.Message, opts .
 
 	// Check status code
 	if httpResp.StatusCode != http.StatusOK {
-		httpResp.Body.Close()
 		bodyBytes, _ := io.ReadAll(httpResp.Body)
+		httpResp.Body.Close()
 		return nil, fmt.Errorf("API error (status %d): %s", httpResp.StatusCode, string(bodyBytes))
 	}
 
@@ -225,26 +423,36 @@ func (c *Client) ChatStream(ctx context.Context, messages []core.Message, opts .
 	var content string
 	index := 0
 
+	// Track tool_use blocks by content block index
+	// WHY: input_json_delta events only carry the block index, not the tool call ID
+	toolBlocks := make(map[int]*streamToolBlock)
+	var toolCalls []core.ToolCall
+	var usage Usage
+
 	// Start goroutine to read streaming response
 	go func() {
 		defer close(chunkChan)
 		defer httpResp.Body.Close()
 
+		sendError := func(err error) {
+			errorChunk := core.StreamChunk{
+				Content:   content,
+				Index:     index,
+				Error:     err,
+				Timestamp: time.Now(),
+			}
+			select {
+			case chunkChan <- errorChunk:
+			case <-ctx.Done():
+			}
+		}
+
 		scanner := bufio.NewScanner(httpResp.Body)
 		for scanner.Scan() {
 			// Check for context cancellation
 			select {
 			case <-ctx.Done():
-				errorChunk := core.StreamChunk{
-					Content:   content,
-					Index:     index,
-					Error:     ctx.Err(),
-					Timestamp: time.Now(),
-				}
-				select {
-				case chunkChan <- errorChunk:
-				case <-ctx.Done():
-				}
+				sendError(ctx.Err())
 				return
 			default:
 			}
@@ -267,15 +475,12 @@ func (c *Client) ChatStream(ctx context.Context, messages []core.Message, opts .
 
 			// Parse the event
 			var event struct {
-				Type  string `json:"type"`
-				Delta struct {
-					Type string `json:"type"`
-					Text string `json:"text"`
-				} `json:"delta"`
-				ContentBlock struct {
-					Type string `json:"type"`
-					Text string `json:"text"`
-				} `json:"content_block"`
+				Type         string             `json:"type"`
+				Index        int                `json:"index"`
+				Delta        StreamDelta        `json:"delta"`
+				ContentBlock StreamContentBlock `json:"content_block"`
+				Message      Response           `json:"message"`
+				Usage        Usage              `json:"usage"`
 			}
 
 			if err := json.Unmarshal([]byte(data), &event); err != nil {
@@ -287,15 +492,52 @@ func (c *Client) ChatStream(ctx context.Context, messages []core.Message, opts .
 			var finishReason string
 
 			switch event.Type {
+			case "message_start":
+				// WHY: Input tokens are only reported when the message starts
+				usage.InputTokens = event.Message.Usage.InputTokens
+				continue
+			case "content_block_start":
+				if event.ContentBlock.Type == "tool_use" {
+					toolBlocks[event.Index] = &streamToolBlock{
+						id:   event.ContentBlock.ID,
+						name: event.ContentBlock.Name,
+					}
+				}
+				continue
 			case "content_block_delta":
+				if event.Delta.Type == "input_json_delta" {
+					// WHY: Buffer partial JSON until the block is complete
+					if block, ok := toolBlocks[event.Index]; ok {
+						block.input.WriteString(event.Delta.PartialJSON)
+					}
+					continue
+				}
 				delta = event.Delta.Text
 				content += delta
+			case "content_block_stop":
+				block, ok := toolBlocks[event.Index]
+				if !ok {
+					continue
+				}
+				delete(toolBlocks, event.Index)
+
+				toolCall, err := block.toolCall()
+				if err != nil {
+					sendError(err)
+					return
+				}
+				toolCalls = append(toolCalls, toolCall)
+				continue
 			case "message_delta":
-				// Message complete
-				finishReason = "stop"
+				// Message complete; output tokens are reported with the final delta
+				finishReason = convertStopReason(event.Delta.StopReason)
+				usage.OutputTokens = event.Usage.OutputTokens
 			case "message_stop":
 				// Stream complete
 				finishReason = "stop"
+				if len(toolCalls) > 0 {
+					finishReason = "tool_calls"
+				}
 			default:
 				continue
 			}
@@ -312,6 +554,12 @@ func (c *Client) ChatStream(ctx context.Context, messages []core.Message, opts .
 				Timestamp: time.Now(),
 			}
 
+			// Attach completed tool calls and usage to the final chunk
+			if finishReason != "" {
+				streamChunk.ToolCalls = toolCalls
+				streamChunk.Usage = core.NewUsage(usage.InputTokens, usage.OutputTokens)
+			}
+
 			index++
 
 			// Send chunk on channel
@@ -327,29 +575,58 @@ func (c *Client) ChatStream(ctx context.Context, messages []core.Message, opts .
 		}
 
 		if err := scanner.Err(); err != nil {
-			errorChunk := core.StreamChunk{
-				Content:   content,
-				Index:     index,
-				Error:     fmt.Errorf("error reading stream: %w", err),
-				Timestamp: time.Now(),
-			}
-			select {
-			case chunkChan <- errorChunk:
-			case <-ctx.Done():
-			}
+			sendError(fmt.Errorf("error reading stream: %w", err))
 		}
 	}()
 
 	return chunkChan, nil
 }
 
+// streamToolBlock accumulates a streamed tool_use content block.
+type streamToolBlock struct {
+	id    string
+	name  string
+	input strings.Builder
+}
+
+// toolCall parses the buffered input JSON into a core.ToolCall.
+// WHY: Tools without arguments stream no input_json_delta at all, so an empty
+// buffer is treated as an empty object.
+func (b *streamToolBlock) toolCall() (core.ToolCall, error) {
+	args := make(map[string]interface{})
+	if raw := strings.TrimSpace(b.input.String()); raw != "" {
+		if err := json.Unmarshal([]byte(raw), &args); err != nil {
+			return core.ToolCall{}, fmt.Errorf("failed to parse input for tool %s: %w", b.name, err)
+		}
+	}
+
+	return core.ToolCall{
+		ID:   b.id,
+		Name: b.name,
+		Args: args,
+	}, nil
+}
+
+// convertStopReason maps Anthropic stop reasons to core.StreamChunk finish reasons.
+// WHY: Callers check FinishReason uniformly across providers ("stop", "length", "tool_calls").
+func convertStopReason(stopReason string) string {
+	switch stopReason {
+	case "tool_use":
+		return "tool_calls"
+	case "max_tokens":
+		return "length"
+	default:
+		return "stop"
+	}
+}
+
 // CompleteStream implements the core.StreamingLLM interface for streaming completions.
 //
 // WHY THIS METHOD:
 // - Provides simple streaming interface for single-prompt use cases
 // - Wraps prompt as user message and delegates to ChatStream
 // - Maintains consistency with Complete() method signature
-func (c *Client) CompleteStream(ctx context.Context, prompt string, opts ...interface{}) (<-chan core.StreamChunk, error) {
+func (c *Client) CompleteStream(ctx context.Context, prompt string, opts ...core.CallOption) (<-chan core.StreamChunk, error) {
 	messages := []core.Message{
 		{Role: "user", Content: prompt},
 	}
@@ -435,16 +712,24 @@ func (c *Client) doRequest(ctx context.Context, req Request) (*Response, error)
 // BUSINESS LOGIC:
 // 1. Extract all messages with role="system" and combine them
 // 2. Convert user/assistant messages to Anthropic's ContentItem array format
-// 3. Return both the message array and the combined system prompt
+// 3. Replay assistant ToolCalls as "tool_use" blocks after any text
+// 4. Convert role="tool" messages to "tool_result" blocks inside a user message
+// 5. Return both the message array and the combined system prompt
+// 6. Fail on content parts Claude cannot represent rather than dropping them
 //
 // WHY CONTENTITEM ARRAY:
 // Anthropic uses ContentItem arrays to support multimodal content (text, images, etc.)
 // in the future without breaking the API. Even for text-only, we use this structure.
 //
+// WHY MERGE TOOL RESULTS:
+// Anthropic has no "tool" role and requires every tool_result answering an assistant
+// turn to be in the single user message that follows it. Consecutive tool messages are
+// therefore folded into one user message.
+//
 // WHY COMBINE SYSTEM PROMPTS:
 // If multiple system messages are provided, we join them with double newlines to
 // preserve structure while consolidating into Anthropic's single system parameter.
-func (c *Client) convertMessages(messages []core.Message) ([]Message, string) {
+func (c *Client) convertMessages(messages []core.Message) ([]Message, string, error) {
 	var anthropicMessages []Message
 	var systemPrompts []string
 
@@ -454,14 +739,42 @@ func (c *Client) convertMessages(messages []core.Message) ([]Message, string) {
 			// System messages are handled separately in Anthropic API
 			// WHY: Anthropic requires system prompts in a separate "system" parameter
 			systemPrompts = append(systemPrompts, msg.Content)
-		case "user", "assistant":
+		case "user":
 			// Convert to Anthropic's message format with ContentItem array
-			// WHY: ContentItem array enables future multimodal support (images, etc.)
+			// WHY: ContentItem array carries multimodal parts (images, documents) next to text
+			content := []ContentItem{{Type: "text", Text: msg.Content}}
+			if len(msg.Parts) > 0 {
+				parts, err := convertContentParts(msg.ContentParts())
+				if err != nil {
+					return nil, "", err
+				}
+				content = parts
+			}
 			anthropicMessages = append(anthropicMessages, Message{
-				Role: msg.Role,
-				Content: []ContentItem{
-					{Type: "text", Text: msg.Content},
-				},
+				Role:    msg.Role,
+				Content: content,
+			})
+		case "assistant":
+			anthropicMessages = append(anthropicMessages, Message{
+				Role:    msg.Role,
+				Content: assistantContent(msg),
+			})
+		case "tool":
+			result := ContentItem{
+				Type:      "tool_result",
+				ToolUseID: msg.ToolCallID,
+				Content:   msg.Content,
+			}
+
+			// WHY: Fold consecutive tool results into the preceding tool_result message
+			if n := len(anthropicMessages); n > 0 && isToolResultMessage(anthropicMessages[n-1]) {
+				anthropicMessages[n-1].Content = append(anthropicMessages[n-1].Content, result)
+				continue
+			}
+
+			anthropicMessages = append(anthropicMessages, Message{
+				Role:    "user",
+				Content: []ContentItem{result},
 			})
 		}
 	}
@@ -474,7 +787,84 @@ func (c *Client) convertMessages(messages []core.Message) ([]Message, string) {
 		systemPrompt = strings.Join(systemPrompts, "\n\n")
 	}
 
-	return anthropicMessages, systemPrompt
+	return anthropicMessages, systemPrompt, nil
+}
+
+// assistantContent builds the content blocks for an assistant message.
+// WHY: Anthropic rejects empty text blocks, so text is only included when present,
+// and each earlier tool call is replayed as a tool_use block so that the following
+// tool_result blocks have something to refer to.
+func assistantContent(msg core.Message) []ContentItem {
+	var content []ContentItem
+	if msg.Content != "" || len(msg.ToolCalls) == 0 {
+		content = append(content, ContentItem{Type: "text", Text: msg.Content})
+	}
+
+	for _, tc := range msg.ToolCalls {
+		// WHY: input must always be a JSON object, even for tools without arguments
+		input := tc.Args
+		if input == nil {
+			input = make(map[string]interface{})
+		}
+		content = append(content, ContentItem{
+			Type:  "tool_use",
+			ID:    tc.ID,
+			Name:  tc.Name,
+			Input: input,
+		})
+	}
+
+	return content
+}
+
+// convertContentParts converts core multimodal parts to Anthropic content blocks.
+// WHY: Claude takes images and PDFs as "image"/"document" blocks with a source that is
+// either inline base64 or a URL. Plain-text documents use the "text" source type so
+// they are not base64-encoded needlessly. Unknown part types are an error, as
+// core.ContentPart promises, so nothing is silently lost.
+func convertContentParts(parts []core.ContentPart) ([]ContentItem, error) {
+	items := make([]ContentItem, 0, len(parts))
+	for _, part := range parts {
+		switch part.Type {
+		case core.ContentPartText:
+			items = append(items, ContentItem{Type: "text", Text: part.Text})
+		case core.ContentPartImageURL:
+			items = append(items, ContentItem{
+				Type:   "image",
+				Source: &Source{Type: "url", URL: part.URL},
+			})
+		case core.ContentPartImage:
+			items = append(items, ContentItem{
+				Type:   "image",
+				Source: &Source{Type: "base64", MediaType: part.MIMEType, Data: base64.StdEncoding.EncodeToString(part.Data)},
+			})
+		case core.ContentPartDocument:
+			source := &Source{Type: "base64", MediaType: part.MIMEType, Data: base64.StdEncoding.EncodeToString(part.Data)}
+			if strings.HasPrefix(part.MIMEType, "text/") {
+				source = &Source{Type: "text", MediaType: "text/plain", Data: string(part.Data)}
+			}
+			items = append(items, ContentItem{
+				Type:   "document",
+				Source: source,
+			})
+		default:
+			return nil, fmt.Errorf("anthropic: unsupported content part type %q", part.Type)
+		}
+	}
+	return items, nil
+}
+
+// isToolResultMessage reports whether msg is a user message made of tool_result blocks.
+func isToolResultMessage(msg Message) bool {
+	if msg.Role != "user" || len(msg.Content) == 0 {
+		return false
+	}
+	for _, item := range msg.Content {
+		if item.Type != "tool_result" {
+			return false
+		}
+	}
+	return true
 }
 
 // convertResponse converts Anthropic response to core format with enriched metadata.
@@ -490,16 +880,28 @@ func (c *Client) convertMessages(messages []core.Message) ([]Message, string) {
 // - Helps optimize max_tokens settings
 // - Useful for debugging unexpected truncation (if stop_reason is "max_tokens")
 //
-// WHY EXTRACT FIRST CONTENT ITEM:
-// Claude currently returns single text content item, but API supports multiple
-// items for future multimodal responses. We extract first item for simplicity.
+// WHY WALK ALL CONTENT ITEMS:
+// With tool use, Claude can return text followed by one or more tool_use blocks.
+// Text blocks are concatenated into Content and tool_use blocks become ToolCalls.
 func (c *Client) convertResponse(resp *Response) *core.Response {
-	// Extract text content from response
-	// WHY: Response.Content is an array to support future multimodal content,
-	// but for text responses we just need the first item's text
-	var contentText string
-	if len(resp.Content) > 0 {
-		contentText = resp.Content[0].Text
+	var contentText strings.Builder
+	var toolCalls []core.ToolCall
+
+	for _, item := range resp.Content {
+		switch item.Type {
+		case "tool_use":
+			args := item.Input
+			if args == nil {
+				args = make(map[string]interface{})
+			}
+			toolCalls = append(toolCalls, core.ToolCall{
+				ID:   item.ID,
+				Name: item.Name,
+				Args: args,
+			})
+		default:
+			contentText.WriteString(item.Text)
+		}
 	}
 
 	// Build enriched metadata
@@ -522,9 +924,36 @@ func (c *Client) convertResponse(resp *Response) *core.Response {
 	}
 
 	return &core.Response{
-		Content: contentText,
-		Meta:    meta,
+		Content:   contentText.String(),
+		ToolCalls: toolCalls,
+		Usage:     core.NewUsage(resp.Usage.InputTokens, resp.Usage.OutputTokens),
+		Meta:      meta,
+	}
+}
+
+// convertTools converts core tools to Anthropic tool definitions.
+// WHY: Anthropic expects the JSON Schema under "input_schema"; the schema itself is
+// shared with other providers via core.ToolSchema.JSONSchema.
+func convertTools(tools []core.Tool) []Tool {
+	if len(tools) == 0 {
+		return nil
 	}
+
+	result := make([]Tool, 0, len(tools))
+	for _, tool := range tools {
+		schema := tool.Schema()
+		if schema == nil {
+			continue
+		}
+
+		result = append(result, Tool{
+			Name:        tool.Name(),
+			Description: tool.Description(),
+			InputSchema: schema.JSONSchema(),
+		})
+	}
+
+	return result
 }
 
 // Model returns the model name being used.
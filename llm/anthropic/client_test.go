@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"github.com/yashrahurikar23/goagents/core"
+	"github.com/yashrahurikar23/goagents/tests/mocks"
 )
 
 func TestNew(t *testing.T) {
@@ -200,6 +201,11 @@ func TestChatSuccess(t *testing.T) {
 	if resp.Meta["output_tokens"] != 20 {
 		t.Errorf("Expected output_tokens in metadata")
 	}
+
+	// Check typed usage
+	if resp.Usage == nil || resp.Usage.PromptTokens != 10 || resp.Usage.CompletionTokens != 20 || resp.Usage.TotalTokens != 30 {
+		t.Errorf("Unexpected usage: %+v", resp.Usage)
+	}
 }
 
 func TestChatWithSystemMessage(t *testing.T) {
@@ -326,7 +332,10 @@ func TestConvertMessages(t *testing.T) {
 		{Role: "user", Content: "Another user message"},
 	}
 
-	anthropicMsgs, systemPrompt := client.convertMessages(messages)
+	anthropicMsgs, systemPrompt, err := client.convertMessages(messages)
+	if err != nil {
+		t.Fatalf("convertMessages failed: %v", err)
+	}
 
 	// Should have 3 non-system messages
 	if len(anthropicMsgs) != 3 {
@@ -435,3 +444,336 @@ func TestRequestMarshaling(t *testing.T) {
 		t.Error("Temperature not in JSON")
 	}
 }
+
+func TestChatWithTools(t *testing.T) {
+	mockResponse := Response{
+		ID:         "msg_test123",
+		Type:       "message",
+		Role:       "assistant",
+		Model:      ModelClaude35Sonnet,
+		StopReason: "tool_use",
+		Content: []ResponseContent{
+			{Type: "text", Text: "Let me calculate that."},
+			{Type: "tool_use", ID: "toolu_1", Name: "calculator", Input: map[string]interface{}{"a": 25.0, "b": 4.0}},
+		},
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req Request
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatalf("Failed to decode request: %v", err)
+		}
+
+		if len(req.Tools) != 1 || req.Tools[0].Name != "calculator" {
+			t.Errorf("Expected calculator tool in request, got %+v", req.Tools)
+		}
+		if req.Tools[0].InputSchema["type"] != "object" {
+			t.Errorf("Expected object input_schema, got %v", req.Tools[0].InputSchema)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(mockResponse)
+	}))
+	defer server.Close()
+
+	client := New(
+		WithAPIKey("test-key"),
+		WithBaseURL(server.URL),
+	)
+
+	tool := mocks.NewMockTool("calculator", "Performs arithmetic")
+
+	resp, err := client.ChatWithTools(context.Background(), []core.Message{{Role: "user", Content: "25 * 4?"}}, []core.Tool{tool})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if resp.Content != "Let me calculate that." {
+		t.Errorf("Unexpected response content: %s", resp.Content)
+	}
+
+	if len(resp.ToolCalls) != 1 {
+		t.Fatalf("Expected 1 tool call, got %d", len(resp.ToolCalls))
+	}
+	tc := resp.ToolCalls[0]
+	if tc.ID != "toolu_1" || tc.Name != "calculator" || tc.Args["a"] != 25.0 {
+		t.Errorf("Unexpected tool call: %+v", tc)
+	}
+}
+
+func TestConvertMessagesWithTools(t *testing.T) {
+	client := New()
+
+	messages := []core.Message{
+		{Role: "user", Content: "What is 25 * 4 and 2 + 2?"},
+		{
+			Role: "assistant",
+			ToolCalls: []core.ToolCall{
+				{ID: "toolu_1", Name: "calculator", Args: map[string]interface{}{"a": 25.0}},
+				{ID: "toolu_2", Name: "calculator"},
+			},
+		},
+		{Role: "tool", Content: "100", Name: "calculator", ToolCallID: "toolu_1"},
+		{Role: "tool", Content: "4", Name: "calculator", ToolCallID: "toolu_2"},
+		{Role: "assistant", Content: "100 and 4"},
+	}
+
+	anthropicMsgs, _, err := client.convertMessages(messages)
+	if err != nil {
+		t.Fatalf("convertMessages failed: %v", err)
+	}
+
+	// user, assistant(tool_use), user(tool_results), assistant
+	if len(anthropicMsgs) != 4 {
+		t.Fatalf("Expected 4 messages, got %d", len(anthropicMsgs))
+	}
+
+	assistant := anthropicMsgs[1]
+	if len(assistant.Content) != 2 {
+		t.Fatalf("Expected 2 tool_use blocks without empty text, got %+v", assistant.Content)
+	}
+	if assistant.Content[0].Type != "tool_use" || assistant.Content[0].ID != "toolu_1" {
+		t.Errorf("Unexpected tool_use block: %+v", assistant.Content[0])
+	}
+
+	results := anthropicMsgs[2]
+	if results.Role != "user" || len(results.Content) != 2 {
+		t.Fatalf("Expected one user message with 2 tool_result blocks, got %+v", results)
+	}
+	if results.Content[1].Type != "tool_result" || results.Content[1].ToolUseID != "toolu_2" || results.Content[1].Content != "4" {
+		t.Errorf("Unexpected tool_result block: %+v", results.Content[1])
+	}
+
+	// tool_use input must serialize as an object even when args are nil
+	data, err := json.Marshal(assistant.Content[1])
+	if err != nil {
+		t.Fatalf("Failed to marshal tool_use block: %v", err)
+	}
+	if !strings.Contains(string(data), `"input":{}`) {
+		t.Errorf("Expected empty input object, got %s", data)
+	}
+}
+
+func TestConvertMessagesWithParts(t *testing.T) {
+	client := New()
+
+	messages := []core.Message{
+		core.UserMessageWithParts("Describe these",
+			core.ImageURLPart("https://example.com/cat.png"),
+			core.ImagePart([]byte("png"), "image/png"),
+			core.DocumentPart([]byte("pdf"), "application/pdf"),
+			core.DocumentPart([]byte("notes"), "text/plain"),
+		),
+	}
+
+	anthropicMsgs, _, err := client.convertMessages(messages)
+	if err != nil {
+		t.Fatalf("convertMessages failed: %v", err)
+	}
+
+	content := anthropicMsgs[0].Content
+	if len(content) != 5 {
+		t.Fatalf("Expected 5 content blocks, got %d", len(content))
+	}
+
+	if content[1].Type != "image" || content[1].Source.Type != "url" || content[1].Source.URL != "https://example.com/cat.png" {
+		t.Errorf("Unexpected URL image block: %+v", content[1])
+	}
+	if content[2].Type != "image" || content[2].Source.Type != "base64" || content[2].Source.MediaType != "image/png" || content[2].Source.Data != "cG5n" {
+		t.Errorf("Unexpected base64 image block: %+v", content[2])
+	}
+	if content[3].Type != "document" || content[3].Source.MediaType != "application/pdf" {
+		t.Errorf("Unexpected PDF document block: %+v", content[3])
+	}
+	if content[4].Type != "document" || content[4].Source.Type != "text" || content[4].Source.Data != "notes" {
+		t.Errorf("Unexpected text document block: %+v", content[4])
+	}
+}
+
+func TestConvertMessagesUnsupportedPart(t *testing.T) {
+	client := New()
+
+	messages := []core.Message{
+		core.UserMessageWithParts("Listen to this", core.ContentPart{Type: "audio", Data: []byte("wav")}),
+	}
+
+	if _, _, err := client.convertMessages(messages); err == nil || !strings.Contains(err.Error(), `"audio"`) {
+		t.Errorf("Expected unsupported part type error, got %v", err)
+	}
+}
+
+func TestChatWithSchema(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req Request
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatalf("Failed to decode request: %v", err)
+		}
+
+		if req.ToolChoice == nil || req.ToolChoice.Type != "tool" || req.ToolChoice.Name != "tags" {
+			t.Errorf("Expected forced tool choice, got %+v", req.ToolChoice)
+		}
+		if len(req.Tools) != 1 || req.Tools[0].InputSchema["type"] != "object" {
+			t.Fatalf("Expected wrapped object input_schema, got %+v", req.Tools)
+		}
+
+		json.NewEncoder(w).Encode(Response{
+			Content: []ResponseContent{
+				{Type: "tool_use", ID: "toolu_1", Name: "tags", Input: map[string]interface{}{"value": []interface{}{"go", "ai"}}},
+			},
+		})
+	}))
+	defer server.Close()
+
+	client := New(
+		WithAPIKey("test-key"),
+		WithBaseURL(server.URL),
+	)
+
+	// A top-level array schema must be wrapped because input_schema must be an object
+	schema := &core.ResponseSchema{
+		Name:   "tags",
+		Schema: map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}},
+	}
+
+	resp, err := client.ChatWithSchema(context.Background(), []core.Message{{Role: "user", Content: "Tag this"}}, schema)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if resp.Content != `["go","ai"]` {
+		t.Errorf("Expected unwrapped JSON array, got %s", resp.Content)
+	}
+	if len(resp.ToolCalls) != 0 {
+		t.Errorf("Expected no tool calls, got %+v", resp.ToolCalls)
+	}
+}
+
+func TestChatCallOptions(t *testing.T) {
+	var requests []Request
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req Request
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatalf("Failed to decode request: %v", err)
+		}
+		requests = append(requests, req)
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(Response{
+			Type:    "message",
+			Role:    "assistant",
+			Content: []ResponseContent{{Type: "text", Text: "ok"}},
+		})
+	}))
+	defer server.Close()
+
+	client := New(
+		WithAPIKey("test-key"),
+		WithBaseURL(server.URL),
+		WithTemperature(1.0),
+		WithMaxTokens(1024),
+	)
+	messages := []core.Message{{Role: "user", Content: "Hi"}}
+
+	_, err := client.Chat(context.Background(), messages,
+		core.WithTemperature(0),
+		core.WithMaxTokens(50),
+		core.WithStop("END"),
+		core.WithMetadata(map[string]string{"user_id": "u1", "team": "ignored"}),
+	)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// Client defaults must be untouched by the previous call
+	if _, err := client.Chat(context.Background(), messages); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	req := requests[0]
+	if req.Temperature == nil || *req.Temperature != 0 {
+		t.Errorf("Expected temperature 0, got %v", req.Temperature)
+	}
+	if req.MaxTokens != 50 {
+		t.Errorf("Expected max_tokens 50, got %d", req.MaxTokens)
+	}
+	if len(req.StopSequences) != 1 || req.StopSequences[0] != "END" {
+		t.Errorf("Expected stop_sequences [END], got %v", req.StopSequences)
+	}
+	if req.Metadata == nil || req.Metadata.UserID != "u1" {
+		t.Errorf("Expected metadata user_id u1, got %+v", req.Metadata)
+	}
+
+	req = requests[1]
+	if req.Temperature == nil || *req.Temperature != 1.0 || req.MaxTokens != 1024 || req.Metadata != nil {
+		t.Errorf("Expected client defaults on second call, got %+v", req)
+	}
+}
+
+func TestChatWithToolsToolChoice(t *testing.T) {
+	var choice *ToolChoice
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req Request
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatalf("Failed to decode request: %v", err)
+		}
+		choice = req.ToolChoice
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(Response{
+			Type:    "message",
+			Role:    "assistant",
+			Content: []ResponseContent{{Type: "text", Text: "ok"}},
+		})
+	}))
+	defer server.Close()
+
+	client := New(WithAPIKey("test-key"), WithBaseURL(server.URL))
+	tools := []core.Tool{mocks.NewMockTool("calculator", "Performs arithmetic")}
+	messages := []core.Message{{Role: "user", Content: "25 * 4?"}}
+
+	tests := []struct {
+		choice   string
+		wantType string
+		wantName string
+	}{
+		{core.ToolChoiceAuto, "auto", ""},
+		{core.ToolChoiceNone, "none", ""},
+		{core.ToolChoiceRequired, "any", ""},
+		{"calculator", "tool", "calculator"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.choice, func(t *testing.T) {
+			if _, err := client.ChatWithTools(context.Background(), messages, tools, core.WithToolChoice(tt.choice)); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			if choice == nil || choice.Type != tt.wantType || choice.Name != tt.wantName {
+				t.Errorf("Expected tool_choice %s/%s, got %+v", tt.wantType, tt.wantName, choice)
+			}
+		})
+	}
+}
+
+func TestConvertToolsNestedSchema(t *testing.T) {
+	tool := mocks.NewMockTool("create_orders", "Creates orders").WithSchema(&core.ToolSchema{
+		Name: "create_orders",
+		Parameters: []core.Parameter{
+			{Name: "orders", Type: "array", Required: true, Items: &core.Parameter{
+				Type:       "object",
+				Properties: []core.Parameter{{Name: "sku", Type: "string", Required: true}},
+			}},
+		},
+	})
+
+	tools := convertTools([]core.Tool{tool})
+	if len(tools) != 1 {
+		t.Fatalf("Expected 1 tool, got %d", len(tools))
+	}
+
+	orders := tools[0].InputSchema["properties"].(map[string]interface{})["orders"].(map[string]interface{})
+	items, ok := orders["items"].(map[string]interface{})
+	if !ok || items["type"] != "object" || items["properties"] == nil {
+		t.Errorf("Expected nested item schema in input_schema, got %v", orders)
+	}
+}
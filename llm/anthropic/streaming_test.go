@@ -3,6 +3,7 @@ package anthropic
 import (
 "context"
 "fmt"
+"io"
 "net/http"
 "net/http/httptest"
 "strings"
@@ -10,6 +11,7 @@ import (
 "time"
 
 "github.com/yashrahurikar23/goagents/core"
+"github.com/yashrahurikar23/goagents/tests/mocks"
 )
 
 func TestChatStream(t *testing.T) {
@@ -341,3 +343,110 @@ WithModel("claude-3-5-sonnet-20241022"),
 		t.Errorf("Expected model metadata 'claude-3-5-sonnet-20241022', got: %v", lastChunk.Metadata["model"])
 	}
 }
+
+func TestChatStreamWithTools(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if !strings.Contains(string(body), `"tools"`) {
+			t.Errorf("Expected tools in request body, got %s", body)
+		}
+
+		w.Header().Set("Content-Type", "text/event-stream")
+
+		events := []string{
+			"event: content_block_start\ndata: {\"type\": \"content_block_start\", \"index\": 0, \"content_block\": {\"type\": \"text\", \"text\": \"\"}}\n\n",
+			"event: content_block_delta\ndata: {\"type\": \"content_block_delta\", \"index\": 0, \"delta\": {\"type\": \"text_delta\", \"text\": \"Checking\"}}\n\n",
+			"event: content_block_stop\ndata: {\"type\": \"content_block_stop\", \"index\": 0}\n\n",
+			"event: content_block_start\ndata: {\"type\": \"content_block_start\", \"index\": 1, \"content_block\": {\"type\": \"tool_use\", \"id\": \"toolu_1\", \"name\": \"weather\", \"input\": {}}}\n\n",
+			"event: content_block_delta\ndata: {\"type\": \"content_block_delta\", \"index\": 1, \"delta\": {\"type\": \"input_json_delta\", \"partial_json\": \"{\\\"city\\\": \"}}\n\n",
+			"event: content_block_delta\ndata: {\"type\": \"content_block_delta\", \"index\": 1, \"delta\": {\"type\": \"input_json_delta\", \"partial_json\": \"\\\"Paris\\\"}\"}}\n\n",
+			"event: content_block_stop\ndata: {\"type\": \"content_block_stop\", \"index\": 1}\n\n",
+			"event: message_delta\ndata: {\"type\": \"message_delta\", \"delta\": {\"stop_reason\": \"tool_use\"}}\n\n",
+			"event: message_stop\ndata: {\"type\": \"message_stop\"}\n\n",
+		}
+
+		for _, event := range events {
+			fmt.Fprint(w, event)
+		}
+	}))
+	defer server.Close()
+
+	client := New(
+		WithAPIKey("test-key"),
+		WithBaseURL(server.URL),
+	)
+
+	tool := mocks.NewMockTool("weather", "Gets the weather")
+
+	stream, err := client.ChatStreamWithTools(context.Background(), []core.Message{{Role: "user", Content: "Weather in Paris?"}}, []core.Tool{tool})
+	if err != nil {
+		t.Fatalf("ChatStreamWithTools failed: %v", err)
+	}
+
+	var last core.StreamChunk
+	for chunk := range stream {
+		if chunk.Error != nil {
+			t.Fatalf("Chunk error: %v", chunk.Error)
+		}
+		last = chunk
+	}
+
+	if last.FinishReason != "tool_calls" {
+		t.Errorf("Expected finish_reason 'tool_calls', got '%s'", last.FinishReason)
+	}
+	if last.Content != "Checking" {
+		t.Errorf("Expected content 'Checking', got '%s'", last.Content)
+	}
+	if len(last.ToolCalls) != 1 {
+		t.Fatalf("Expected 1 tool call, got %d", len(last.ToolCalls))
+	}
+	if tc := last.ToolCalls[0]; tc.ID != "toolu_1" || tc.Name != "weather" || tc.Args["city"] != "Paris" {
+		t.Errorf("Unexpected tool call: %+v", tc)
+	}
+}
+
+func TestChatStreamUsage(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+
+		events := []string{
+			"event: message_start\ndata: {\"type\": \"message_start\", \"message\": {\"id\": \"msg_1\", \"model\": \"claude-3-5-sonnet-20241022\", \"usage\": {\"input_tokens\": 25, \"output_tokens\": 1}}}\n\n",
+			"event: content_block_delta\ndata: {\"type\": \"content_block_delta\", \"index\": 0, \"delta\": {\"type\": \"text_delta\", \"text\": \"Hi\"}}\n\n",
+			"event: message_delta\ndata: {\"type\": \"message_delta\", \"delta\": {\"stop_reason\": \"end_turn\"}, \"usage\": {\"output_tokens\": 7}}\n\n",
+			"event: message_stop\ndata: {\"type\": \"message_stop\"}\n\n",
+		}
+
+		for _, event := range events {
+			fmt.Fprint(w, event)
+		}
+	}))
+	defer server.Close()
+
+	client := New(
+		WithAPIKey("test-key"),
+		WithBaseURL(server.URL),
+	)
+
+	stream, err := client.ChatStream(context.Background(), []core.Message{{Role: "user", Content: "Hi"}})
+	if err != nil {
+		t.Fatalf("ChatStream failed: %v", err)
+	}
+
+	var last core.StreamChunk
+	for chunk := range stream {
+		if chunk.Error != nil {
+			t.Fatalf("Chunk error: %v", chunk.Error)
+		}
+		if chunk.FinishReason == "" && chunk.Usage != nil {
+			t.Errorf("Usage set on intermediate chunk: %+v", chunk)
+		}
+		last = chunk
+	}
+
+	if last.Usage == nil {
+		t.Fatal("Expected usage on final chunk")
+	}
+	if last.Usage.PromptTokens != 25 || last.Usage.CompletionTokens != 7 || last.Usage.TotalTokens != 32 {
+		t.Errorf("Unexpected usage: %+v", last.Usage)
+	}
+}
@@ -45,14 +45,39 @@ type Message struct {
 	Content []ContentItem `json:"content"` // Array to support multiple content types
 }
 
-// ContentItem represents a single content item (text, image, etc.).
+// ContentItem represents a single content item (text, image, document, tool use, tool result).
 //
 // WHY ARRAY OF ITEMS:
 // Anthropic's API supports rich content including text, images, and tools.
 // The array structure allows mixing content types in a single message.
+//
+// WHY TOOL FIELDS:
+// Tool calling round-trips through content blocks rather than dedicated roles:
+// - Assistant messages replay earlier calls as "tool_use" blocks (ID, Name, Input)
+// - Tool results are sent back in a user message as "tool_result" blocks (ToolUseID, Content)
 type ContentItem struct {
-	Type string `json:"type"`           // "text", "image", etc.
-	Text string `json:"text,omitempty"` // Text content when type is "text"
+	Type      string      `json:"type"`                  // "text", "image", "document", "tool_use", "tool_result"
+	Text      string      `json:"text,omitempty"`        // Text content when type is "text"
+	ID        string      `json:"id,omitempty"`          // Tool call ID (tool_use only)
+	Name      string      `json:"name,omitempty"`        // Tool name (tool_use only)
+	Input     interface{} `json:"input,omitempty"`       // Tool arguments (tool_use only, must be an object)
+	ToolUseID string      `json:"tool_use_id,omitempty"` // ID of the tool_use being answered (tool_result only)
+	Content   string      `json:"content,omitempty"`     // Tool output (tool_result only)
+	IsError   bool        `json:"is_error,omitempty"`    // Marks a failed tool execution (tool_result only)
+	Source    *Source     `json:"source,omitempty"`      // Media source (image and document only)
+}
+
+// Source describes where the bytes of an image or document block come from.
+//
+// WHY MULTIPLE SOURCE TYPES:
+// - "base64": Inline bytes with a media type (images, PDFs)
+// - "url": Claude fetches the file itself, avoiding large request bodies
+// - "text": Plain-text documents sent as-is
+type Source struct {
+	Type      string `json:"type"`                 // "base64", "url" or "text"
+	MediaType string `json:"media_type,omitempty"` // e.g. "image/png", "application/pdf"
+	Data      string `json:"data,omitempty"`       // base64 data (or raw text for "text")
+	URL       string `json:"url,omitempty"`        // Location for "url" sources
 }
 
 // Request represents the Anthropic API request structure.
@@ -68,15 +93,46 @@ type ContentItem struct {
 // - System prompts are separate from messages (API design)
 // - Temperature/TopP/TopK control randomness for different use cases
 type Request struct {
-	Model         string    `json:"model"`                    // Model identifier (e.g., "claude-3-5-sonnet-20241022")
-	Messages      []Message `json:"messages"`                 // Conversation history
-	MaxTokens     int       `json:"max_tokens"`               // Maximum tokens to generate (required)
-	Temperature   *float64  `json:"temperature,omitempty"`    // 0.0-1.0, controls randomness
-	TopP          *float64  `json:"top_p,omitempty"`          // 0.0-1.0, nucleus sampling
-	TopK          *int      `json:"top_k,omitempty"`          // Top-k sampling
-	StopSequences []string  `json:"stop_sequences,omitempty"` // Sequences that stop generation
-	Stream        bool      `json:"stream,omitempty"`         // Enable streaming (not yet exposed)
-	System        string    `json:"system,omitempty"`         // System prompt (separate from messages)
+	Model         string      `json:"model"`                    // Model identifier (e.g., "claude-3-5-sonnet-20241022")
+	Messages      []Message   `json:"messages"`                 // Conversation history
+	MaxTokens     int         `json:"max_tokens"`               // Maximum tokens to generate (required)
+	Temperature   *float64    `json:"temperature,omitempty"`    // 0.0-1.0, controls randomness
+	TopP          *float64    `json:"top_p,omitempty"`          // 0.0-1.0, nucleus sampling
+	TopK          *int        `json:"top_k,omitempty"`          // Top-k sampling
+	StopSequences []string    `json:"stop_sequences,omitempty"` // Sequences that stop generation
+	Stream        bool        `json:"stream,omitempty"`         // Enable streaming (not yet exposed)
+	System        string      `json:"system,omitempty"`         // System prompt (separate from messages)
+	Tools         []Tool      `json:"tools,omitempty"`          // Tools Claude may call
+	ToolChoice    *ToolChoice `json:"tool_choice,omitempty"`    // How Claude must use the tools
+	Metadata      *Metadata   `json:"metadata,omitempty"`       // Request metadata (end-user ID)
+}
+
+// Metadata describes the request for abuse detection.
+// WHY: Anthropic only accepts an opaque end-user identifier here, so other
+// core.WithMetadata keys are not sent.
+type Metadata struct {
+	UserID string `json:"user_id,omitempty"` // Opaque identifier of the end user
+}
+
+// ToolChoice controls whether and which tool Claude must call.
+//
+// WHY FORCING A TOOL:
+// Setting Type to "tool" makes Claude call the named tool, which is how structured
+// output is obtained: the tool's input_schema is the desired document schema.
+type ToolChoice struct {
+	Type string `json:"type"`           // "auto", "any", "tool" or "none"
+	Name string `json:"name,omitempty"` // Tool to call when Type is "tool"
+}
+
+// Tool describes a tool Claude may call.
+//
+// WHY INPUT_SCHEMA:
+// Anthropic describes tool arguments with a JSON Schema object under "input_schema",
+// unlike OpenAI which nests it under function.parameters.
+type Tool struct {
+	Name        string                 `json:"name"`                  // Tool identifier
+	Description string                 `json:"description,omitempty"` // Shown to Claude to decide when to call
+	InputSchema map[string]interface{} `json:"input_schema"`          // JSON Schema for the tool input
 }
 
 // Response represents the Anthropic API response structure.
@@ -98,9 +154,16 @@ type Response struct {
 }
 
 // ResponseContent represents content in the response.
+//
+// WHY TOOL FIELDS:
+// When Claude decides to call a tool it returns a "tool_use" block carrying
+// the call ID, tool name and JSON input instead of text.
 type ResponseContent struct {
-	Type string `json:"type"` // Content type ("text")
-	Text string `json:"text"` // Generated text
+	Type  string                 `json:"type"`            // Content type ("text" or "tool_use")
+	Text  string                 `json:"text"`            // Generated text
+	ID    string                 `json:"id,omitempty"`    // Tool call ID (tool_use only)
+	Name  string                 `json:"name,omitempty"`  // Tool name (tool_use only)
+	Input map[string]interface{} `json:"input,omitempty"` // Tool arguments (tool_use only)
 }
 
 // Usage represents token usage information.
@@ -143,10 +206,27 @@ type StreamEvent struct {
 }
 
 // StreamDelta represents incremental updates in a stream.
+//
+// WHY PARTIAL_JSON:
+// Tool inputs are streamed as "input_json_delta" fragments that only form
+// valid JSON once the content block is complete.
 type StreamDelta struct {
-	Type       string `json:"type,omitempty"`        // Delta type
-	Text       string `json:"text,omitempty"`        // Incremental text
-	StopReason string `json:"stop_reason,omitempty"` // Final stop reason
+	Type        string `json:"type,omitempty"`         // Delta type ("text_delta", "input_json_delta")
+	Text        string `json:"text,omitempty"`         // Incremental text
+	PartialJSON string `json:"partial_json,omitempty"` // Incremental tool input JSON
+	StopReason  string `json:"stop_reason,omitempty"`  // Final stop reason
+}
+
+// StreamContentBlock represents the content block announced by a
+// "content_block_start" event.
+//
+// WHY: tool_use blocks carry the call ID and tool name only in the start
+// event; their input arrives later through input_json_delta events.
+type StreamContentBlock struct {
+	Type string `json:"type"`           // "text" or "tool_use"
+	Text string `json:"text,omitempty"` // Initial text (usually empty)
+	ID   string `json:"id,omitempty"`   // Tool call ID (tool_use only)
+	Name string `json:"name,omitempty"` // Tool name (tool_use only)
 }
 
 // Model constants for Anthropic Claude models.
@@ -27,6 +27,8 @@
 // METHODS:
 // - New(opts): Creates a client with functional options for flexible configuration
 // - Chat(ctx, messages): Send conversation history, get response (implements core.LLM)
+// - ChatWithSchema(ctx, messages, schema): JSON output constrained by responseSchema (implements core.StructuredOutputLLM)
+// - ChatWithTools(ctx, messages, tools): Chat with native tool calling (implements core.ToolCallingLLM)
 // - Complete(ctx, prompt): Convenience method for single-turn completions
 // - Model(): Returns the model name being used
 //
@@ -40,10 +42,14 @@ import (
 	"bufio"
 	"bytes"
 	"context"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"io"
+	"mime"
 	"net/http"
+	"net/url"
+	"path"
 	"strings"
 	"time"
 
@@ -62,6 +68,8 @@ type Client struct {
 	topP        *float64     // WHY: Pointer for optional nucleus sampling
 	topK        *int         // WHY: Pointer for optional top-k sampling
 	httpClient  *http.Client // WHY: Allows custom HTTP configuration (timeouts, proxies, TLS)
+
+	functionCalling *FunctionCallingConfig // WHY: Optional toolConfig mode (AUTO/ANY/NONE) for ChatWithTools
 }
 
 // New creates a new Gemini client with the given options.
@@ -102,7 +110,7 @@ func New(opts ...Option) *Client {
 // - Only includes GenerationConfig if at least one parameter is set (cleaner API calls)
 // - Converts "assistant" role to "model" (Gemini requirement)
 // - Checks for blocked prompts before returning response
-func (c *Client) Chat(ctx context.Context, messages []core.Message) (*core.Response, error) {
+func (c *Client) Chat(ctx context.Context, messages []core.Message, opts ...core.CallOption) (*core.Response, error) {
 	if c.apiKey == "" {
 		return nil, fmt.Errorf("gemini: API key is required")
 	}
@@ -110,7 +118,10 @@ func (c *Client) Chat(ctx context.Context, messages []core.Message) (*core.Respo
 	// Convert core.Message to Gemini format and extract system instructions
 	// WHY: Gemini requires "model" role instead of "assistant", and system messages
 	// must be in separate systemInstruction field
-	contents, systemInstruction := c.convertMessages(messages)
+	contents, systemInstruction, err := c.convertMessages(messages)
+	if err != nil {
+		return nil, err
+	}
 
 	// Build generation config only if at least one parameter is set
 	// WHY: Omitting the entire config object when not needed results in cleaner API calls
@@ -131,6 +142,7 @@ func (c *Client) Chat(ctx context.Context, messages []core.Message) (*core.Respo
 		GenerationConfig:  genConfig,
 		SystemInstruction: systemInstruction,
 	}
+	applyCallOptions(&req, opts)
 
 	// Make API call with context for cancellation and timeouts
 	resp, err := c.doRequest(ctx, req)
@@ -142,6 +154,171 @@ func (c *Client) Chat(ctx context.Context, messages []core.Message) (*core.Respo
 	return c.convertResponse(resp), nil
 }
 
+// ChatWithTools sends a conversation along with tools the model may call (implements core.ToolCallingLLM).
+// WHY: Gemini supports native function calling through functionDeclarations. Exposing it
+// through the provider-agnostic interface lets FunctionAgent use Gemini as its brain.
+//
+// BUSINESS LOGIC:
+// - Tools are declared via tools[].functionDeclarations built from core.ToolSchema
+// - functionCall parts in the response become core.Response.ToolCalls
+// - Text parts are still combined into Content
+// - The function calling mode (WithFunctionCallingMode) is sent as toolConfig
+func (c *Client) ChatWithTools(ctx context.Context, messages []core.Message, tools []core.Tool, opts ...core.CallOption) (*core.Response, error) {
+	if c.apiKey == "" {
+		return nil, fmt.Errorf("gemini: API key is required")
+	}
+
+	contents, systemInstruction, err := c.convertMessages(messages)
+	if err != nil {
+		return nil, err
+	}
+
+	var genConfig *GenerationConfig
+	if c.temperature != nil || c.topP != nil || c.topK != nil || c.maxTokens != nil {
+		genConfig = &GenerationConfig{
+			Temperature:     c.temperature,
+			TopP:            c.topP,
+			TopK:            c.topK,
+			MaxOutputTokens: c.maxTokens,
+		}
+	}
+
+	req := GenerateContentRequest{
+		Contents:          contents,
+		GenerationConfig:  genConfig,
+		SystemInstruction: systemInstruction,
+		Tools:             convertTools(tools),
+	}
+
+	// WHY: Gemini rejects toolConfig when no tools are declared
+	if len(req.Tools) > 0 && c.functionCalling != nil {
+		req.ToolConfig = &ToolConfig{FunctionCallingConfig: c.functionCalling}
+	}
+	applyCallOptions(&req, opts)
+
+	resp, err := c.doRequest(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("gemini: request failed: %w", err)
+	}
+
+	return c.convertResponse(resp), nil
+}
+
+// ChatWithSchema sends a conversation and constrains the reply to a JSON schema
+// (implements core.StructuredOutputLLM).
+// WHY: Gemini's JSON mode (responseMimeType "application/json") combined with
+// responseSchema makes the model emit a document matching the schema, so callers
+// don't have to parse JSON out of free-form text.
+//
+// BUSINESS LOGIC:
+// - The JSON Schema is converted to Gemini's OpenAPI-subset Schema
+// - Keywords Gemini does not support (additionalProperties, default) are dropped
+// - The JSON document is returned in core.Response.Content
+func (c *Client) ChatWithSchema(ctx context.Context, messages []core.Message, schema *core.ResponseSchema, opts ...core.CallOption) (*core.Response, error) {
+	if c.apiKey == "" {
+		return nil, fmt.Errorf("gemini: API key is required")
+	}
+	if schema == nil {
+		return nil, &core.ErrInvalidArgument{Argument: "schema", Reason: "cannot be nil"}
+	}
+
+	contents, systemInstruction, err := c.convertMessages(messages)
+	if err != nil {
+		return nil, err
+	}
+
+	req := GenerateContentRequest{
+		Contents: contents,
+		GenerationConfig: &GenerationConfig{
+			Temperature:     c.temperature,
+			TopP:            c.topP,
+			TopK:            c.topK,
+			MaxOutputTokens: c.maxTokens,
+		},
+		SystemInstruction: systemInstruction,
+	}
+	applyCallOptions(&req, opts)
+
+	// WHY: The schema argument always wins over a per-call response format
+	req.GenerationConfig.ResponseMIMEType = "application/json"
+	req.GenerationConfig.ResponseSchema = schemaFromJSON(schema.Schema)
+
+	resp, err := c.doRequest(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("gemini: request failed: %w", err)
+	}
+
+	return c.convertResponse(resp), nil
+}
+
+// applyCallOptions merges per-call options into a request.
+// WHY: Options override the client defaults for one call only, so a single client
+// can serve both creative and deterministic requests.
+//
+// BUSINESS LOGIC:
+// - GenerationConfig is only created when an option needs it
+// - Tool choice maps to toolConfig modes: auto→AUTO, none→NONE, required→ANY
+// - A tool name maps to ANY restricted to that function
+// - JSON response formats use responseMimeType (plus responseSchema for schemas)
+// - Metadata is not supported by the Gemini API and is ignored
+func applyCallOptions(req *GenerateContentRequest, opts []core.CallOption) {
+	if len(opts) == 0 {
+		return
+	}
+	o := core.NewCallOptions(opts...)
+
+	config := func() *GenerationConfig {
+		if req.GenerationConfig == nil {
+			req.GenerationConfig = &GenerationConfig{}
+		}
+		return req.GenerationConfig
+	}
+
+	if o.Temperature != nil {
+		config().Temperature = o.Temperature
+	}
+	if o.MaxTokens != nil {
+		config().MaxOutputTokens = o.MaxTokens
+	}
+	if o.Stop != nil {
+		config().StopSequences = o.Stop
+	}
+	if o.Seed != nil {
+		config().Seed = o.Seed
+	}
+
+	if f := o.ResponseFormat; f != nil {
+		switch f.Type {
+		case core.ResponseFormatJSON:
+			config().ResponseMIMEType = "application/json"
+		case core.ResponseFormatJSONSchema:
+			config().ResponseMIMEType = "application/json"
+			if f.Schema != nil {
+				config().ResponseSchema = schemaFromJSON(f.Schema.Schema)
+			}
+		case core.ResponseFormatText:
+			config().ResponseMIMEType = "text/plain"
+		}
+	}
+
+	// WHY: Gemini rejects toolConfig when no tools are declared
+	if o.ToolChoice != "" && len(req.Tools) > 0 {
+		fc := &FunctionCallingConfig{}
+		switch o.ToolChoice {
+		case core.ToolChoiceAuto:
+			fc.Mode = FunctionCallingModeAuto
+		case core.ToolChoiceNone:
+			fc.Mode = FunctionCallingModeNone
+		case core.ToolChoiceRequired:
+			fc.Mode = FunctionCallingModeAny
+		default:
+			fc.Mode = FunctionCallingModeAny
+			fc.AllowedFunctionNames = []string{o.ToolChoice}
+		}
+		req.ToolConfig = &ToolConfig{FunctionCallingConfig: fc}
+	}
+}
+
 // Complete sends a single prompt and receives a completion (implements core.LLM).
 // WHY: This is a convenience method for simple, single-turn interactions where you
 // don't need conversation history. It wraps Chat() but provides a simpler interface
@@ -153,12 +330,12 @@ func (c *Client) Chat(ctx context.Context, messages []core.Message) (*core.Respo
 // - Quick prototyping and testing
 //
 // For multi-turn conversations or when you need metadata, use Chat() directly.
-func (c *Client) Complete(ctx context.Context, prompt string) (string, error) {
+func (c *Client) Complete(ctx context.Context, prompt string, opts ...core.CallOption) (string, error) {
 	messages := []core.Message{
 		{Role: "user", Content: prompt},
 	}
 
-	resp, err := c.Chat(ctx, messages)
+	resp, err := c.Chat(ctx, messages, opts...)
 	if err != nil {
 		return "", err
 	}
@@ -179,13 +356,16 @@ func (c *Client) Complete(ctx context.Context, prompt string) (string, error) {
 // - Converts Gemini responses to core.StreamChunk format
 // - Runs stream processing in a goroutine
 // - Closes channel when stream completes or errors occur
-func (c *Client) ChatStream(ctx context.Context, messages []core.Message, opts ...interface{}) (<-chan core.StreamChunk, error) {
+func (c *Client) ChatStream(ctx context.Context, messages []core.Message, opts ...core.CallOption) (<-chan core.StreamChunk, error) {
 	if c.apiKey == "" {
 		return nil, fmt.Errorf("API key is required")
 	}
 
 	// Convert messages to Gemini format
-	geminiContents, systemInstruction := c.convertMessages(messages)
+	geminiContents, systemInstruction, err := c.convertMessages(messages)
+	if err != nil {
+		return nil, err
+	}
 
 	// Build generation config
 	var genConfig *GenerationConfig
@@ -203,6 +383,7 @@ func (c *Client) ChatStream(ctx context.Context, messages []core.Message, opts .
 		SystemInstruction: systemInstruction,
 		GenerationConfig:  genConfig,
 	}
+	applyCallOptions(&req, opts)
 
 	// Marshal request body
 	body, err := json.Marshal(req)
@@ -237,8 +418,9 @@ func (c *Client) ChatStream(ctx context.Context, messages []core.Message, opts .
 	// Create buffered channel for chunks
 	chunkChan := make(chan core.StreamChunk, 10)
 
-	// Track accumulated content and index
+	// Track accumulated content, usage and index
 	var content string
+	var usage *core.Usage
 	index := 0
 
 	// Start goroutine to read streaming response
@@ -292,6 +474,11 @@ func (c *Client) ChatStream(ctx context.Context, messages []core.Message, opts .
 				continue
 			}
 
+			// WHY: Usage metadata is cumulative; the last reported value is the total
+			if u := convertUsage(resp.UsageMetadata); u != nil {
+				usage = u
+			}
+
 			// Extract content from candidates
 			if len(resp.Candidates) == 0 {
 				continue
@@ -327,6 +514,9 @@ func (c *Client) ChatStream(ctx context.Context, messages []core.Message, opts .
 				},
 				Timestamp: time.Now(),
 			}
+			if finishReason != "" {
+				streamChunk.Usage = usage
+			}
 
 			index++
 
@@ -365,7 +555,7 @@ func (c *Client) ChatStream(ctx context.Context, messages []core.Message, opts .
 // - Provides simple streaming interface for single-prompt use cases
 // - Wraps prompt as user message and delegates to ChatStream
 // - Maintains consistency with Complete() method signature
-func (c *Client) CompleteStream(ctx context.Context, prompt string, opts ...interface{}) (<-chan core.StreamChunk, error) {
+func (c *Client) CompleteStream(ctx context.Context, prompt string, opts ...core.CallOption) (<-chan core.StreamChunk, error) {
 	messages := []core.Message{
 		{Role: "user", Content: prompt},
 	}
@@ -465,7 +655,10 @@ func (c *Client) doRequest(ctx context.Context, req GenerateContentRequest) (*Ge
 // 1. Extract all messages with role="system" into systemInstruction
 // 2. Convert user messages as-is with role="user"
 // 3. Convert assistant messages to role="model" (Gemini requirement)
-// 4. Wrap text in Parts arrays for future multimodal support
+// 4. Replay assistant ToolCalls as functionCall parts
+// 5. Convert role="tool" messages to functionResponse parts in a user turn
+// 6. Wrap text in Parts arrays for future multimodal support
+// 7. Fail on content parts Gemini cannot represent rather than dropping them
 //
 // WHY COMBINE SYSTEM MESSAGES:
 // If multiple system messages exist, we combine them with double newlines to
@@ -474,10 +667,18 @@ func (c *Client) doRequest(ctx context.Context, req GenerateContentRequest) (*Ge
 // WHY "MODEL" ROLE:
 // Gemini's API design uses "model" to represent the AI's role in conversation,
 // distinguishing it from "user". Other providers use "assistant" or "ai".
-func (c *Client) convertMessages(messages []core.Message) ([]Content, *Content) {
+//
+// WHY MERGE TOOL RESULTS:
+// When the model calls several functions in one turn, Gemini expects all of the
+// functionResponse parts in the single turn that follows, in the same order.
+func (c *Client) convertMessages(messages []core.Message) ([]Content, *Content, error) {
 	var contents []Content
 	var systemParts []Part
 
+	// Map tool call IDs to function names
+	// WHY: functionResponse requires the function name, which tool messages may omit
+	toolNames := make(map[string]string)
+
 	for _, msg := range messages {
 		switch msg.Role {
 		case "system":
@@ -486,16 +687,57 @@ func (c *Client) convertMessages(messages []core.Message) ([]Content, *Content)
 			systemParts = append(systemParts, Part{Text: msg.Content})
 		case "user":
 			// User messages map directly
+			// WHY: Multimodal parts become inlineData/fileData parts next to the text
+			parts := []Part{{Text: msg.Content}}
+			if len(msg.Parts) > 0 {
+				converted, err := convertContentParts(msg.ContentParts())
+				if err != nil {
+					return nil, nil, err
+				}
+				parts = converted
+			}
 			contents = append(contents, Content{
 				Role:  "user",
-				Parts: []Part{{Text: msg.Content}},
+				Parts: parts,
 			})
 		case "assistant":
 			// Assistant role must be converted to "model" for Gemini
 			// WHY: Gemini's API uses "model" instead of "assistant"
+			var parts []Part
+			if msg.Content != "" || len(msg.ToolCalls) == 0 {
+				parts = append(parts, Part{Text: msg.Content})
+			}
+			for _, tc := range msg.ToolCalls {
+				// WHY: Call IDs are not echoed back; they may have been generated locally
+				// (see convertFunctionCall) and Gemini pairs calls and results by name and order
+				toolNames[tc.ID] = tc.Name
+				parts = append(parts, Part{FunctionCall: &FunctionCall{
+					Name: tc.Name,
+					Args: tc.Args,
+				}})
+			}
 			contents = append(contents, Content{
 				Role:  "model",
-				Parts: []Part{{Text: msg.Content}},
+				Parts: parts,
+			})
+		case "tool":
+			name := msg.Name
+			if name == "" {
+				name = toolNames[msg.ToolCallID]
+			}
+			part := Part{FunctionResponse: &FunctionResponse{
+				Name:     name,
+				Response: functionResponseBody(msg.Content),
+			}}
+
+			// WHY: Consecutive tool results answer the same model turn
+			if n := len(contents); n > 0 && isFunctionResponseContent(contents[n-1]) {
+				contents[n-1].Parts = append(contents[n-1].Parts, part)
+				continue
+			}
+			contents = append(contents, Content{
+				Role:  "user",
+				Parts: []Part{part},
 			})
 		}
 	}
@@ -521,7 +763,7 @@ func (c *Client) convertMessages(messages []core.Message) ([]Content, *Content)
 		}
 	}
 
-	return contents, systemInstruction
+	return contents, systemInstruction, nil
 }
 
 // convertResponse converts Gemini response to core format with enriched metadata.
@@ -546,19 +788,24 @@ func (c *Client) convertMessages(messages []core.Message) ([]Content, *Content)
 // Response Parts is an array to support future multimodal content (text + images),
 // but for text-only responses we combine all parts into single string.
 func (c *Client) convertResponse(resp *GenerateContentResponse) *core.Response {
-	// Extract text from first candidate
+	// Extract text and function calls from first candidate
 	var contentText string
 	var finishReason string
+	var toolCalls []core.ToolCall
 
 	if len(resp.Candidates) > 0 {
 		candidate := resp.Candidates[0]
 		finishReason = candidate.FinishReason
 
-		// Combine all parts from response
-		// WHY: Parts array supports multimodal content, but for text-only
-		// we join all text parts into single string
+		// Combine all text parts and collect function calls
+		// WHY: Parts array supports multimodal content; text parts are joined into
+		// a single string while functionCall parts become tool calls
 		var parts []string
 		for _, part := range candidate.Content.Parts {
+			if part.FunctionCall != nil {
+				toolCalls = append(toolCalls, convertFunctionCall(part.FunctionCall, len(toolCalls)))
+				continue
+			}
 			if part.Text != "" {
 				parts = append(parts, part.Text)
 			}
@@ -585,9 +832,238 @@ func (c *Client) convertResponse(resp *GenerateContentResponse) *core.Response {
 	}
 
 	return &core.Response{
-		Content: contentText,
-		Meta:    meta,
+		Content:   contentText,
+		ToolCalls: toolCalls,
+		Usage:     convertUsage(resp.UsageMetadata),
+		Meta:      meta,
+	}
+}
+
+// convertUsage converts Gemini usage metadata to core.Usage.
+// WHY: Gemini omits usageMetadata on some responses (e.g. blocked prompts), which
+// decodes as all zeros; that is reported as "no usage" rather than zero tokens.
+func convertUsage(usage UsageMetadata) *core.Usage {
+	if usage.TotalTokenCount == 0 {
+		return nil
+	}
+	return &core.Usage{
+		PromptTokens:     usage.PromptTokenCount,
+		CompletionTokens: usage.CandidatesTokenCount,
+		TotalTokens:      usage.TotalTokenCount,
+	}
+}
+
+// convertFunctionCall converts a Gemini functionCall part to a core.ToolCall.
+// WHY: Older Gemini models do not return call IDs, but agents need an ID to link
+// tool results back to calls, so one is derived from the name and position.
+func convertFunctionCall(fc *FunctionCall, index int) core.ToolCall {
+	id := fc.ID
+	if id == "" {
+		id = fmt.Sprintf("%s_%d", fc.Name, index)
+	}
+
+	args := fc.Args
+	if args == nil {
+		args = make(map[string]interface{})
+	}
+
+	return core.ToolCall{
+		ID:   id,
+		Name: fc.Name,
+		Args: args,
+	}
+}
+
+// convertContentParts converts core multimodal parts to Gemini parts.
+// WHY: Gemini takes inline bytes (images and documents alike) as inlineData and
+// remote files as fileData. fileData needs a MIME type, so for image URLs it is
+// derived from the file extension when the part does not specify one. Unknown
+// part types are an error, as core.ContentPart promises, so nothing is silently lost.
+func convertContentParts(parts []core.ContentPart) ([]Part, error) {
+	result := make([]Part, 0, len(parts))
+	for _, part := range parts {
+		switch part.Type {
+		case core.ContentPartText:
+			result = append(result, Part{Text: part.Text})
+		case core.ContentPartImageURL:
+			mimeType := part.MIMEType
+			if mimeType == "" {
+				if u, err := url.Parse(part.URL); err == nil {
+					mimeType = mime.TypeByExtension(path.Ext(u.Path))
+				}
+			}
+			result = append(result, Part{FileData: &FileData{
+				MimeType: mimeType,
+				FileURI:  part.URL,
+			}})
+		case core.ContentPartImage, core.ContentPartDocument:
+			result = append(result, Part{InlineData: &Blob{
+				MimeType: part.MIMEType,
+				Data:     base64.StdEncoding.EncodeToString(part.Data),
+			}})
+		default:
+			return nil, fmt.Errorf("gemini: unsupported content part type %q", part.Type)
+		}
+	}
+	return result, nil
+}
+
+// functionResponseBody converts tool output into a functionResponse payload.
+// WHY: Gemini requires an object; JSON object output is passed through as-is so the
+// model sees structured data, anything else is wrapped as {"result": output}.
+func functionResponseBody(content string) map[string]interface{} {
+	var obj map[string]interface{}
+	if err := json.Unmarshal([]byte(content), &obj); err == nil && obj != nil {
+		return obj
+	}
+	return map[string]interface{}{"result": content}
+}
+
+// isFunctionResponseContent reports whether content is a turn made of functionResponse parts.
+func isFunctionResponseContent(content Content) bool {
+	if content.Role != "user" || len(content.Parts) == 0 {
+		return false
+	}
+	for _, part := range content.Parts {
+		if part.FunctionResponse == nil {
+			return false
+		}
+	}
+	return true
+}
+
+// convertTools converts core tools to Gemini function declarations.
+// WHY: Gemini groups all functions under a single tools entry and describes
+// parameters with its own OpenAPI-subset schema.
+func convertTools(tools []core.Tool) []Tool {
+	declarations := make([]FunctionDeclaration, 0, len(tools))
+	for _, tool := range tools {
+		schema := tool.Schema()
+		if schema == nil {
+			continue
+		}
+
+		declarations = append(declarations, FunctionDeclaration{
+			Name:        tool.Name(),
+			Description: tool.Description(),
+			Parameters:  convertSchema(schema),
+		})
+	}
+
+	if len(declarations) == 0 {
+		return nil
+	}
+
+	return []Tool{{FunctionDeclarations: declarations}}
+}
+
+// convertSchema converts a core.ToolSchema to Gemini's parameter schema.
+// WHY: Gemini rejects empty object schemas, so functions without parameters
+// are declared without a parameters field.
+func convertSchema(schema *core.ToolSchema) *Schema {
+	result := schemaFromJSON(schema.JSONSchema())
+	if result == nil || (result.Type == "object" && len(result.Properties) == 0) {
+		return nil
+	}
+	return result
+}
+
+// schemaFromJSON converts a JSON Schema map to Gemini's Schema.
+// WHY: Gemini only understands an OpenAPI subset; unknown keywords are dropped
+// rather than forwarded, since Gemini rejects requests containing them.
+func schemaFromJSON(schema map[string]interface{}) *Schema {
+	if schema == nil {
+		return nil
+	}
+
+	result := &Schema{}
+	switch typ := schema["type"].(type) {
+	case string:
+		result.Type = typ
+	case []interface{}, []string:
+		// WHY: Gemini has a single type plus a nullable flag, so ["string", "null"]
+		// becomes {type: string, nullable: true}
+		for _, t := range stringList(typ) {
+			if t == "null" {
+				result.Nullable = true
+			} else if result.Type == "" {
+				result.Type = t
+			}
+		}
+	}
+	result.Description, _ = schema["description"].(string)
+	result.Format, _ = schema["format"].(string)
+	result.Pattern, _ = schema["pattern"].(string)
+	result.Minimum = floatKeyword(schema, "minimum")
+	result.Maximum = floatKeyword(schema, "maximum")
+	result.MinLength = intKeyword(schema, "minLength")
+	result.MaxLength = intKeyword(schema, "maxLength")
+	result.MinItems = intKeyword(schema, "minItems")
+	result.MaxItems = intKeyword(schema, "maxItems")
+
+	if enum, ok := schema["enum"].([]interface{}); ok {
+		for _, v := range enum {
+			result.Enum = append(result.Enum, fmt.Sprintf("%v", v))
+		}
+	}
+
+	if properties, ok := schema["properties"].(map[string]interface{}); ok && len(properties) > 0 {
+		result.Properties = make(map[string]*Schema, len(properties))
+		for name, prop := range properties {
+			propSchema, _ := prop.(map[string]interface{})
+			result.Properties[name] = schemaFromJSON(propSchema)
+		}
+	}
+
+	result.Required = stringList(schema["required"])
+
+	if items, ok := schema["items"].(map[string]interface{}); ok {
+		result.Items = schemaFromJSON(items)
+	}
+
+	return result
+}
+
+// stringList converts a []string or []interface{} of strings to []string.
+// WHY: Schemas built in Go use []string while decoded JSON schemas use []interface{}
+func stringList(v interface{}) []string {
+	switch list := v.(type) {
+	case []string:
+		return list
+	case []interface{}:
+		var result []string
+		for _, item := range list {
+			if s, ok := item.(string); ok {
+				result = append(result, s)
+			}
+		}
+		return result
+	}
+	return nil
+}
+
+// floatKeyword returns a numeric schema keyword, or nil if it is not set.
+func floatKeyword(schema map[string]interface{}, key string) *float64 {
+	switch v := schema[key].(type) {
+	case float64:
+		return &v
+	case int:
+		f := float64(v)
+		return &f
+	case int64:
+		f := float64(v)
+		return &f
+	}
+	return nil
+}
+
+// intKeyword returns an integer schema keyword, or nil if it is not set.
+func intKeyword(schema map[string]interface{}, key string) *int64 {
+	if f := floatKeyword(schema, key); f != nil {
+		n := int64(*f)
+		return &n
 	}
+	return nil
 }
 
 // Model returns the model name being used.
@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"github.com/yashrahurikar23/goagents/core"
+	"github.com/yashrahurikar23/goagents/tests/mocks"
 )
 
 func TestNew(t *testing.T) {
@@ -199,6 +200,11 @@ func TestChatSuccess(t *testing.T) {
 	if resp.Meta["completion_tokens"] != 20 {
 		t.Errorf("Expected completion_tokens in metadata")
 	}
+
+	// Check typed usage
+	if resp.Usage == nil || resp.Usage.PromptTokens != 10 || resp.Usage.CompletionTokens != 20 || resp.Usage.TotalTokens != 30 {
+		t.Errorf("Unexpected usage: %+v", resp.Usage)
+	}
 }
 
 func TestChatWithSystemMessage(t *testing.T) {
@@ -366,7 +372,10 @@ func TestConvertMessages(t *testing.T) {
 		{Role: "user", Content: "Another user message"},
 	}
 
-	contents, systemInstruction := client.convertMessages(messages)
+	contents, systemInstruction, err := client.convertMessages(messages)
+	if err != nil {
+		t.Fatalf("convertMessages failed: %v", err)
+	}
 
 	// Should have 3 non-system messages
 	if len(contents) != 3 {
@@ -530,3 +539,380 @@ func TestMultiPartResponse(t *testing.T) {
 		t.Errorf("Expected content '%s', got '%s'", expected, resp.Content)
 	}
 }
+
+func TestChatWithTools(t *testing.T) {
+	mockResponse := GenerateContentResponse{
+		Candidates: []Candidate{
+			{
+				Content: Content{
+					Role: "model",
+					Parts: []Part{
+						{FunctionCall: &FunctionCall{Name: "weather", Args: map[string]interface{}{"city": "Paris"}}},
+					},
+				},
+				FinishReason: "STOP",
+			},
+		},
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req GenerateContentRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatalf("Failed to decode request: %v", err)
+		}
+
+		if len(req.Tools) != 1 || len(req.Tools[0].FunctionDeclarations) != 1 {
+			t.Fatalf("Expected 1 function declaration, got %+v", req.Tools)
+		}
+		decl := req.Tools[0].FunctionDeclarations[0]
+		if decl.Name != "weather" || decl.Parameters == nil || decl.Parameters.Properties["city"] == nil {
+			t.Errorf("Unexpected function declaration: %+v", decl)
+		}
+
+		if req.ToolConfig == nil || req.ToolConfig.FunctionCallingConfig.Mode != FunctionCallingModeAny {
+			t.Errorf("Expected toolConfig mode ANY, got %+v", req.ToolConfig)
+		}
+		if names := req.ToolConfig.FunctionCallingConfig.AllowedFunctionNames; len(names) != 1 || names[0] != "weather" {
+			t.Errorf("Expected allowed function names [weather], got %v", names)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(mockResponse)
+	}))
+	defer server.Close()
+
+	client := New(
+		WithAPIKey("test-key"),
+		WithBaseURL(server.URL),
+		WithFunctionCallingMode(FunctionCallingModeAny, "weather"),
+	)
+
+	tool := mocks.NewMockTool("weather", "Gets the weather").WithSchema(&core.ToolSchema{
+		Name: "weather",
+		Parameters: []core.Parameter{
+			{Name: "city", Type: "string", Required: true},
+		},
+	})
+
+	resp, err := client.ChatWithTools(context.Background(), []core.Message{{Role: "user", Content: "Weather in Paris?"}}, []core.Tool{tool})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if len(resp.ToolCalls) != 1 {
+		t.Fatalf("Expected 1 tool call, got %d", len(resp.ToolCalls))
+	}
+	if tc := resp.ToolCalls[0]; tc.Name != "weather" || tc.ID != "weather_0" || tc.Args["city"] != "Paris" {
+		t.Errorf("Unexpected tool call: %+v", tc)
+	}
+}
+
+func TestChatWithToolsOmitsToolConfigWithoutTools(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req GenerateContentRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatalf("Failed to decode request: %v", err)
+		}
+		if req.ToolConfig != nil {
+			t.Errorf("Expected no toolConfig without tools, got %+v", req.ToolConfig)
+		}
+
+		json.NewEncoder(w).Encode(GenerateContentResponse{
+			Candidates: []Candidate{{Content: Content{Role: "model", Parts: []Part{{Text: "Hi"}}}}},
+		})
+	}))
+	defer server.Close()
+
+	client := New(
+		WithAPIKey("test-key"),
+		WithBaseURL(server.URL),
+		WithFunctionCallingMode(FunctionCallingModeNone),
+	)
+
+	if _, err := client.ChatWithTools(context.Background(), []core.Message{{Role: "user", Content: "Hi"}}, nil); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+}
+
+func TestConvertMessagesWithTools(t *testing.T) {
+	client := New()
+
+	messages := []core.Message{
+		{Role: "user", Content: "Weather in Paris and Rome?"},
+		{
+			Role: "assistant",
+			ToolCalls: []core.ToolCall{
+				{ID: "weather_0", Name: "weather", Args: map[string]interface{}{"city": "Paris"}},
+				{ID: "weather_1", Name: "weather", Args: map[string]interface{}{"city": "Rome"}},
+			},
+		},
+		{Role: "tool", Content: `{"temp": 21}`, ToolCallID: "weather_0"},
+		{Role: "tool", Content: "sunny", Name: "weather", ToolCallID: "weather_1"},
+	}
+
+	contents, _, err := client.convertMessages(messages)
+	if err != nil {
+		t.Fatalf("convertMessages failed: %v", err)
+	}
+
+	// user, model(functionCall x2), user(functionResponse x2)
+	if len(contents) != 3 {
+		t.Fatalf("Expected 3 contents, got %d", len(contents))
+	}
+
+	model := contents[1]
+	if model.Role != "model" || len(model.Parts) != 2 || model.Parts[0].FunctionCall == nil {
+		t.Fatalf("Expected model turn with 2 functionCall parts, got %+v", model)
+	}
+
+	results := contents[2]
+	if results.Role != "user" || len(results.Parts) != 2 {
+		t.Fatalf("Expected user turn with 2 functionResponse parts, got %+v", results)
+	}
+
+	first := results.Parts[0].FunctionResponse
+	if first == nil || first.Name != "weather" || first.Response["temp"] != 21.0 {
+		t.Errorf("Expected name resolved from call ID and JSON passed through, got %+v", first)
+	}
+
+	second := results.Parts[1].FunctionResponse
+	if second == nil || second.Response["result"] != "sunny" {
+		t.Errorf("Expected plain output wrapped in result, got %+v", second)
+	}
+}
+
+func TestConvertMessagesWithParts(t *testing.T) {
+	client := New()
+
+	messages := []core.Message{
+		core.UserMessageWithParts("Describe these",
+			core.ImageURLPart("https://example.com/cat.png?size=large"),
+			core.DocumentPart([]byte("pdf"), "application/pdf"),
+		),
+	}
+
+	contents, _, err := client.convertMessages(messages)
+	if err != nil {
+		t.Fatalf("convertMessages failed: %v", err)
+	}
+
+	parts := contents[0].Parts
+	if len(parts) != 3 {
+		t.Fatalf("Expected 3 parts, got %d", len(parts))
+	}
+
+	if parts[1].FileData == nil || parts[1].FileData.FileURI != "https://example.com/cat.png?size=large" || parts[1].FileData.MimeType != "image/png" {
+		t.Errorf("Unexpected fileData part: %+v", parts[1].FileData)
+	}
+	if parts[2].InlineData == nil || parts[2].InlineData.MimeType != "application/pdf" || parts[2].InlineData.Data != "cGRm" {
+		t.Errorf("Unexpected inlineData part: %+v", parts[2].InlineData)
+	}
+}
+
+func TestConvertMessagesUnsupportedPart(t *testing.T) {
+	client := New()
+
+	messages := []core.Message{
+		core.UserMessageWithParts("Listen to this", core.ContentPart{Type: "audio", Data: []byte("wav")}),
+	}
+
+	if _, _, err := client.convertMessages(messages); err == nil || !strings.Contains(err.Error(), `"audio"`) {
+		t.Errorf("Expected unsupported part type error, got %v", err)
+	}
+}
+
+func TestChatWithSchema(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req GenerateContentRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatalf("Failed to decode request: %v", err)
+		}
+
+		cfg := req.GenerationConfig
+		if cfg == nil || cfg.ResponseMIMEType != "application/json" || cfg.ResponseSchema == nil {
+			t.Fatalf("Expected JSON mode with response schema, got %+v", cfg)
+		}
+		label := cfg.ResponseSchema.Properties["label"]
+		if label == nil || len(label.Enum) != 2 || cfg.ResponseSchema.Required[0] != "label" {
+			t.Errorf("Unexpected response schema: %+v", cfg.ResponseSchema)
+		}
+
+		json.NewEncoder(w).Encode(GenerateContentResponse{
+			Candidates: []Candidate{{Content: Content{Role: "model", Parts: []Part{{Text: `{"label":"positive"}`}}}}},
+		})
+	}))
+	defer server.Close()
+
+	client := New(WithAPIKey("test-key"), WithBaseURL(server.URL))
+
+	schema := &core.ResponseSchema{
+		Name: "sentiment",
+		Schema: map[string]interface{}{
+			"type":                 "object",
+			"properties":           map[string]interface{}{"label": map[string]interface{}{"type": "string", "enum": []interface{}{"positive", "negative"}}},
+			"required":             []string{"label"},
+			"additionalProperties": false,
+		},
+	}
+
+	resp, err := client.ChatWithSchema(context.Background(), []core.Message{{Role: "user", Content: "I love it"}}, schema)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if resp.Content != `{"label":"positive"}` {
+		t.Errorf("Unexpected content: %s", resp.Content)
+	}
+}
+
+func TestChatCallOptions(t *testing.T) {
+	var requests []GenerateContentRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req GenerateContentRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatalf("Failed to decode request: %v", err)
+		}
+		requests = append(requests, req)
+
+		json.NewEncoder(w).Encode(GenerateContentResponse{
+			Candidates: []Candidate{{Content: Content{Role: "model", Parts: []Part{{Text: "{}"}}}}},
+		})
+	}))
+	defer server.Close()
+
+	client := New(WithAPIKey("test-key"), WithBaseURL(server.URL))
+	messages := []core.Message{{Role: "user", Content: "Hi"}}
+
+	_, err := client.Chat(context.Background(), messages,
+		core.WithTemperature(0.1),
+		core.WithMaxTokens(32),
+		core.WithStop("END"),
+		core.WithSeed(3),
+		core.WithResponseFormat(core.ResponseFormat{Type: core.ResponseFormatJSON}),
+	)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if _, err := client.Chat(context.Background(), messages); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	config := requests[0].GenerationConfig
+	if config == nil {
+		t.Fatal("Expected generationConfig")
+	}
+	if config.Temperature == nil || *config.Temperature != 0.1 {
+		t.Errorf("Expected temperature 0.1, got %v", config.Temperature)
+	}
+	if config.MaxOutputTokens == nil || *config.MaxOutputTokens != 32 {
+		t.Errorf("Expected maxOutputTokens 32, got %v", config.MaxOutputTokens)
+	}
+	if len(config.StopSequences) != 1 || config.StopSequences[0] != "END" {
+		t.Errorf("Expected stopSequences [END], got %v", config.StopSequences)
+	}
+	if config.Seed == nil || *config.Seed != 3 {
+		t.Errorf("Expected seed 3, got %v", config.Seed)
+	}
+	if config.ResponseMIMEType != "application/json" {
+		t.Errorf("Expected JSON mode, got %q", config.ResponseMIMEType)
+	}
+
+	if requests[1].GenerationConfig != nil {
+		t.Errorf("Expected no generationConfig on second call, got %+v", requests[1].GenerationConfig)
+	}
+}
+
+func TestChatWithToolsToolChoice(t *testing.T) {
+	var toolConfig *ToolConfig
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req GenerateContentRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatalf("Failed to decode request: %v", err)
+		}
+		toolConfig = req.ToolConfig
+
+		json.NewEncoder(w).Encode(GenerateContentResponse{
+			Candidates: []Candidate{{Content: Content{Role: "model", Parts: []Part{{Text: "Hi"}}}}},
+		})
+	}))
+	defer server.Close()
+
+	// The per-call choice overrides the client's function calling mode
+	client := New(
+		WithAPIKey("test-key"),
+		WithBaseURL(server.URL),
+		WithFunctionCallingMode(FunctionCallingModeNone),
+	)
+	tools := []core.Tool{mocks.NewMockTool("weather", "Gets the weather")}
+	messages := []core.Message{{Role: "user", Content: "Weather?"}}
+
+	tests := []struct {
+		choice      string
+		wantMode    string
+		wantAllowed []string
+	}{
+		{core.ToolChoiceAuto, FunctionCallingModeAuto, nil},
+		{core.ToolChoiceRequired, FunctionCallingModeAny, nil},
+		{"weather", FunctionCallingModeAny, []string{"weather"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.choice, func(t *testing.T) {
+			if _, err := client.ChatWithTools(context.Background(), messages, tools, core.WithToolChoice(tt.choice)); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			if toolConfig == nil || toolConfig.FunctionCallingConfig.Mode != tt.wantMode {
+				t.Fatalf("Expected mode %s, got %+v", tt.wantMode, toolConfig)
+			}
+			if got := toolConfig.FunctionCallingConfig.AllowedFunctionNames; len(got) != len(tt.wantAllowed) {
+				t.Errorf("Expected allowed names %v, got %v", tt.wantAllowed, got)
+			}
+		})
+	}
+}
+
+func TestConvertSchemaNested(t *testing.T) {
+	schema := &core.ToolSchema{
+		Name: "create_orders",
+		Parameters: []core.Parameter{
+			{Name: "orders", Type: "array", Required: true, MaxItems: core.Ptr(10), Items: &core.Parameter{
+				Type: "object",
+				Properties: []core.Parameter{
+					{Name: "sku", Type: "string", Required: true, Pattern: "^[A-Z]+$"},
+					{Name: "quantity", Type: "integer", Minimum: core.Ptr(1.0)},
+				},
+			}},
+			{Name: "note", Type: "string", Schema: map[string]interface{}{"type": []interface{}{"string", "null"}}},
+		},
+	}
+
+	result := convertSchema(schema)
+	if result == nil || result.Type != "object" {
+		t.Fatalf("Expected object schema, got %+v", result)
+	}
+
+	orders := result.Properties["orders"]
+	if orders.Type != "array" || orders.MaxItems == nil || *orders.MaxItems != 10 || orders.Items == nil {
+		t.Fatalf("Unexpected orders schema: %+v", orders)
+	}
+	item := orders.Items
+	if item.Type != "object" || len(item.Required) != 1 || item.Required[0] != "sku" {
+		t.Errorf("Unexpected item schema: %+v", item)
+	}
+	if item.Properties["sku"].Pattern != "^[A-Z]+$" {
+		t.Errorf("Expected pattern, got %+v", item.Properties["sku"])
+	}
+	if min := item.Properties["quantity"].Minimum; min == nil || *min != 1 {
+		t.Errorf("Expected minimum 1, got %v", min)
+	}
+
+	if note := result.Properties["note"]; note.Type != "string" || !note.Nullable {
+		t.Errorf("Expected nullable string, got %+v", note)
+	}
+
+	if convertSchema(&core.ToolSchema{Name: "empty"}) != nil {
+		t.Error("Expected no parameters for an empty schema")
+	}
+}
@@ -23,6 +23,7 @@
 // - Model Selection: WithModel (defaults to Gemini 1.5 Flash)
 // - Network: WithBaseURL, WithHTTPClient, WithTimeout
 // - Generation: WithMaxTokens, WithTemperature, WithTopP, WithTopK
+// - Tools: WithFunctionCallingMode
 package gemini
 
 import (
@@ -149,3 +150,17 @@ func WithTopK(topK int) Option {
 		c.topK = &topK
 	}
 }
+
+// WithFunctionCallingMode sets the function calling mode used by ChatWithTools.
+// WHY: Gemini's toolConfig controls whether the model may (AUTO), must (ANY) or must not
+// (NONE) call the declared functions. In ANY mode, allowedFunctionNames optionally restricts
+// which functions may be called. The config is only sent when tools are declared, since
+// Gemini rejects a toolConfig without tools.
+func WithFunctionCallingMode(mode string, allowedFunctionNames ...string) Option {
+	return func(c *Client) {
+		c.functionCalling = &FunctionCallingConfig{
+			Mode:                 mode,
+			AllowedFunctionNames: allowedFunctionNames,
+		}
+	}
+}
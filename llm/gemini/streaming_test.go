@@ -487,3 +487,51 @@ func TestStreamChunkMetadata(t *testing.T) {
 		t.Errorf("Expected finish reason 'stop', got %q", lastChunk.FinishReason)
 	}
 }
+
+func TestChatStreamUsage(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Gemini reports running usage on every chunk; only the final one should surface
+		responses := []GenerateContentResponse{
+			{
+				Candidates:    []Candidate{{Content: Content{Role: "model", Parts: []Part{{Text: "Hi"}}}}},
+				UsageMetadata: UsageMetadata{PromptTokenCount: 12, TotalTokenCount: 12},
+			},
+			{
+				Candidates:    []Candidate{{Content: Content{Role: "model", Parts: []Part{{Text: " there"}}}, FinishReason: "STOP"}},
+				UsageMetadata: UsageMetadata{PromptTokenCount: 12, CandidatesTokenCount: 4, TotalTokenCount: 16},
+			},
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		for _, resp := range responses {
+			data, _ := json.Marshal(resp)
+			fmt.Fprintf(w, "%s\n", data)
+		}
+	}))
+	defer server.Close()
+
+	client := New(WithAPIKey("test-key"), WithBaseURL(server.URL))
+
+	stream, err := client.ChatStream(context.Background(), []core.Message{{Role: "user", Content: "Hi"}})
+	if err != nil {
+		t.Fatalf("ChatStream failed: %v", err)
+	}
+
+	var last core.StreamChunk
+	for chunk := range stream {
+		if chunk.Error != nil {
+			t.Fatalf("Chunk error: %v", chunk.Error)
+		}
+		if chunk.FinishReason == "" && chunk.Usage != nil {
+			t.Errorf("Usage set on intermediate chunk: %+v", chunk)
+		}
+		last = chunk
+	}
+
+	if last.Usage == nil {
+		t.Fatal("Expected usage on final chunk")
+	}
+	if last.Usage.PromptTokens != 12 || last.Usage.CompletionTokens != 4 || last.Usage.TotalTokens != 16 {
+		t.Errorf("Unexpected usage: %+v", last.Usage)
+	}
+}
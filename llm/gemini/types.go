@@ -47,11 +47,81 @@ type Content struct {
 	Parts []Part `json:"parts"`          // WHY: Array enables multimodal content mixing
 }
 
-// Part represents a single part of content (text, image, etc.).
+// Part represents a single part of content (text, function call, etc.).
 // WHY: Gemini's multimodal architecture requires content to be split into parts.
-// Currently we only use Text, but future versions will support ImagePart, VideoPart, etc.
+// A part carries exactly one kind of data, so every field is omitted when unset.
 type Part struct {
-	Text string `json:"text"` // WHY: Text content for this part
+	Text             string            `json:"text,omitempty"`             // WHY: Text content for this part
+	FunctionCall     *FunctionCall     `json:"functionCall,omitempty"`     // WHY: Set when the model asks to call a tool
+	FunctionResponse *FunctionResponse `json:"functionResponse,omitempty"` // WHY: Carries a tool result back to the model
+	InlineData       *Blob             `json:"inlineData,omitempty"`       // WHY: Inline media bytes (images, PDFs)
+	FileData         *FileData         `json:"fileData,omitempty"`         // WHY: Media referenced by URI instead of bytes
+}
+
+// Blob holds inline media bytes.
+// WHY: Gemini accepts images, audio, video and PDFs as base64 data tagged with a MIME type.
+type Blob struct {
+	MimeType string `json:"mimeType"` // WHY: Tells Gemini how to decode the data
+	Data     string `json:"data"`     // WHY: Base64-encoded bytes
+}
+
+// FileData references media by URI.
+// WHY: Avoids inlining large files; Gemini fetches the URI itself.
+type FileData struct {
+	MimeType string `json:"mimeType,omitempty"` // WHY: Media type of the referenced file
+	FileURI  string `json:"fileUri"`            // WHY: Location of the file
+}
+
+// FunctionCall represents a tool invocation requested by the model.
+// WHY: Gemini returns tool calls as functionCall parts with the function name and
+// already-decoded arguments (an object, not a JSON string like OpenAI).
+type FunctionCall struct {
+	ID   string                 `json:"id,omitempty"`   // WHY: Only newer models return call IDs
+	Name string                 `json:"name"`           // WHY: Name of the declared function to call
+	Args map[string]interface{} `json:"args,omitempty"` // WHY: Arguments matching the declared schema
+}
+
+// FunctionResponse represents the result of a tool invocation sent back to the model.
+// WHY: Gemini matches results to calls by function name and requires the result
+// to be a JSON object, so plain tool output is wrapped under a "result" key.
+type FunctionResponse struct {
+	Name     string                 `json:"name"`     // WHY: Name of the function that produced the result
+	Response map[string]interface{} `json:"response"` // WHY: Tool output (must be an object)
+}
+
+// Tool groups function declarations the model may call.
+// WHY: Gemini wraps declarations in a tools array so other tool kinds
+// (code execution, search grounding) can sit alongside functions.
+type Tool struct {
+	FunctionDeclarations []FunctionDeclaration `json:"functionDeclarations,omitempty"` // WHY: Callable functions
+}
+
+// FunctionDeclaration describes a function the model may call.
+type FunctionDeclaration struct {
+	Name        string  `json:"name"`                  // WHY: Identifier the model uses in functionCall
+	Description string  `json:"description,omitempty"` // WHY: Helps the model decide when to call it
+	Parameters  *Schema `json:"parameters,omitempty"`  // WHY: Omitted for functions without arguments
+}
+
+// Schema is the OpenAPI-subset schema Gemini uses for function parameters.
+// WHY: Gemini rejects JSON Schema keywords it does not know (e.g. "default"),
+// so parameters are described with this typed subset instead of a raw map.
+type Schema struct {
+	Type        string             `json:"type"`                  // WHY: string, number, integer, boolean, object, array
+	Description string             `json:"description,omitempty"` // WHY: Parameter documentation for the model
+	Enum        []string           `json:"enum,omitempty"`        // WHY: Restricts string values
+	Properties  map[string]*Schema `json:"properties,omitempty"`  // WHY: Fields of an object
+	Required    []string           `json:"required,omitempty"`    // WHY: Required object fields
+	Items       *Schema            `json:"items,omitempty"`       // WHY: Element schema of an array
+	Format      string             `json:"format,omitempty"`      // WHY: String format hint (e.g. "date-time")
+	Pattern     string             `json:"pattern,omitempty"`     // WHY: Regular expression string values must match
+	Nullable    bool               `json:"nullable,omitempty"`    // WHY: OpenAPI's way of saying type ["x", "null"]
+	Minimum     *float64           `json:"minimum,omitempty"`     // WHY: Pointer so a bound of 0 is still sent
+	Maximum     *float64           `json:"maximum,omitempty"`
+	MinLength   *int64             `json:"minLength,omitempty"`
+	MaxLength   *int64             `json:"maxLength,omitempty"`
+	MinItems    *int64             `json:"minItems,omitempty"`
+	MaxItems    *int64             `json:"maxItems,omitempty"`
 }
 
 // GenerateContentRequest represents a request to generate content.
@@ -62,8 +132,31 @@ type GenerateContentRequest struct {
 	Contents          []Content         `json:"contents"`                    // WHY: Full conversation history for context
 	GenerationConfig  *GenerationConfig `json:"generationConfig,omitempty"`  // WHY: Pointer allows omitting entire config if using defaults
 	SystemInstruction *Content          `json:"systemInstruction,omitempty"` // WHY: Separate system instruction like Anthropic (not in Contents array)
+	Tools             []Tool            `json:"tools,omitempty"`             // WHY: Functions the model may call
+	ToolConfig        *ToolConfig       `json:"toolConfig,omitempty"`        // WHY: Controls whether/which functions must be called
 }
 
+// ToolConfig configures how the model uses the declared tools.
+// WHY: Without it Gemini decides on its own (AUTO). Agents sometimes need to force
+// a function call (ANY) or temporarily disable calling (NONE) without removing tools.
+type ToolConfig struct {
+	FunctionCallingConfig *FunctionCallingConfig `json:"functionCallingConfig,omitempty"` // WHY: Function calling behavior
+}
+
+// FunctionCallingConfig selects the function calling mode.
+type FunctionCallingConfig struct {
+	Mode                 string   `json:"mode"`                           // WHY: AUTO, ANY or NONE
+	AllowedFunctionNames []string `json:"allowedFunctionNames,omitempty"` // WHY: Restricts ANY mode to these functions
+}
+
+// Function calling modes for FunctionCallingConfig.Mode.
+// WHY: Constants prevent typos in the upper-case values Gemini expects.
+const (
+	FunctionCallingModeAuto = "AUTO" // WHY: Model decides between text and function calls (default)
+	FunctionCallingModeAny  = "ANY"  // WHY: Model must call one of the (allowed) functions
+	FunctionCallingModeNone = "NONE" // WHY: Model must not call functions, even though they are declared
+)
+
 // GenerationConfig contains parameters that control text generation behavior.
 // WHY: Provides fine-grained control over randomness, length, and stopping conditions.
 // All fields are pointers to distinguish "not set" from "set to zero".
@@ -73,6 +166,10 @@ type GenerationConfig struct {
 	TopK            *int     `json:"topK,omitempty"`            // WHY: Top-k sampling parameter
 	MaxOutputTokens *int     `json:"maxOutputTokens,omitempty"` // WHY: Controls response length and costs
 	StopSequences   []string `json:"stopSequences,omitempty"`   // WHY: Custom sequences that stop generation
+	Seed            *int     `json:"seed,omitempty"`            // WHY: Reproducible sampling for deterministic calls
+
+	ResponseMIMEType string  `json:"responseMimeType,omitempty"` // WHY: "application/json" enables JSON mode
+	ResponseSchema   *Schema `json:"responseSchema,omitempty"`   // WHY: Constrains JSON output to a schema
 }
 
 // GenerateContentResponse represents the API response from Gemini.
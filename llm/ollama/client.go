@@ -4,6 +4,7 @@ import (
 	"bufio"
 	"bytes"
 	"context"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -98,15 +99,78 @@ func WithStop(stop []string) ClientOption {
 	}
 }
 
+// Model returns the default model
+func (c *Client) Model() string {
+	return c.model
+}
+
 // Chat sends a chat request to Ollama
-func (c *Client) Chat(ctx context.Context, messages []core.Message) (*core.Response, error) {
+func (c *Client) Chat(ctx context.Context, messages []core.Message, opts ...core.CallOption) (*core.Response, error) {
 	// Convert messages to Ollama format
-	ollamaMessages := make([]ChatMessage, len(messages))
-	for i, msg := range messages {
-		ollamaMessages[i] = ChatMessage{
-			Role:    msg.Role,
-			Content: msg.Content,
-		}
+	ollamaMessages, err := convertMessages(messages)
+	if err != nil {
+		return nil, err
+	}
+
+	// Create request
+	req := ChatRequest{
+		Model:    c.model,
+		Messages: ollamaMessages,
+		Stream:   false,
+		Options:  c.options,
+	}
+	applyCallOptions(&req, opts)
+
+	// Send request
+	var resp ChatResponse
+	if err := c.doRequest(ctx, "/api/chat", req, &resp); err != nil {
+		return nil, fmt.Errorf("chat request failed: %w", err)
+	}
+
+	return convertChatResponse(&resp), nil
+}
+
+// ChatWithTools sends a chat request with tools the model may call.
+// It implements core.ToolCallingLLM for models with native function calling
+// support (llama3.1, qwen2.5, mistral-nemo, etc.).
+func (c *Client) ChatWithTools(ctx context.Context, messages []core.Message, tools []core.Tool, opts ...core.CallOption) (*core.Response, error) {
+	// Convert messages to Ollama format
+	ollamaMessages, err := convertMessages(messages)
+	if err != nil {
+		return nil, err
+	}
+
+	// Create request
+	req := ChatRequest{
+		Model:    c.model,
+		Messages: ollamaMessages,
+		Stream:   false,
+		Options:  c.options,
+		Tools:    convertTools(tools),
+	}
+	applyCallOptions(&req, opts)
+
+	// Send request
+	var resp ChatResponse
+	if err := c.doRequest(ctx, "/api/chat", req, &resp); err != nil {
+		return nil, fmt.Errorf("chat request failed: %w", err)
+	}
+
+	return convertChatResponse(&resp), nil
+}
+
+// ChatWithSchema sends a chat request whose reply is constrained to a JSON schema.
+// It implements core.StructuredOutputLLM using Ollama's structured outputs
+// (a JSON schema passed as the request format)
+func (c *Client) ChatWithSchema(ctx context.Context, messages []core.Message, schema *core.ResponseSchema, opts ...core.CallOption) (*core.Response, error) {
+	if schema == nil {
+		return nil, &core.ErrInvalidArgument{Argument: "schema", Reason: "cannot be nil"}
+	}
+
+	// Convert messages to Ollama format
+	ollamaMessages, err := convertMessages(messages)
+	if err != nil {
+		return nil, err
 	}
 
 	// Create request
@@ -116,6 +180,10 @@ func (c *Client) Chat(ctx context.Context, messages []core.Message) (*core.Respo
 		Stream:   false,
 		Options:  c.options,
 	}
+	applyCallOptions(&req, opts)
+
+	// The schema argument always wins over a per-call response format
+	req.Format = schema.Schema
 
 	// Send request
 	var resp ChatResponse
@@ -123,7 +191,11 @@ func (c *Client) Chat(ctx context.Context, messages []core.Message) (*core.Respo
 		return nil, fmt.Errorf("chat request failed: %w", err)
 	}
 
-	// Convert response
+	return convertChatResponse(&resp), nil
+}
+
+// convertChatResponse converts an Ollama chat response to a core.Response
+func convertChatResponse(resp *ChatResponse) *core.Response {
 	response := &core.Response{
 		Content: resp.Message.Content,
 		Meta: map[string]interface{}{
@@ -139,22 +211,198 @@ func (c *Client) Chat(ctx context.Context, messages []core.Message) (*core.Respo
 	}
 
 	// Convert tool calls if any
-	if len(resp.Message.ToolCalls) > 0 {
-		response.ToolCalls = make([]core.ToolCall, len(resp.Message.ToolCalls))
-		for i, tc := range resp.Message.ToolCalls {
-			response.ToolCalls[i] = core.ToolCall{
+	response.ToolCalls = convertToolCalls(resp.Message.ToolCalls, 0)
+	response.Usage = convertUsage(resp)
+
+	return response
+}
+
+// convertUsage extracts token usage from a (final) chat response.
+// Ollama omits the counts when nothing was evaluated, e.g. on cached prompts
+func convertUsage(resp *ChatResponse) *core.Usage {
+	if resp.PromptEvalCount == 0 && resp.EvalCount == 0 {
+		return nil
+	}
+	return core.NewUsage(resp.PromptEvalCount, resp.EvalCount)
+}
+
+// convertToolCalls converts Ollama tool calls to core tool calls.
+// offset is the number of calls already seen, so generated IDs stay unique
+// when calls arrive across several stream chunks
+func convertToolCalls(toolCalls []ToolCall, offset int) []core.ToolCall {
+	if len(toolCalls) == 0 {
+		return nil
+	}
+
+	result := make([]core.ToolCall, len(toolCalls))
+	for i, tc := range toolCalls {
+		// Ollama usually omits call IDs, but agents need one to link results
+		id := tc.ID
+		if id == "" {
+			id = fmt.Sprintf("call_%d", offset+i)
+		}
+
+		args := tc.Function.Arguments
+		if args == nil {
+			args = make(map[string]interface{})
+		}
+
+		result[i] = core.ToolCall{
+			ID:   id,
+			Name: tc.Function.Name,
+			Args: args,
+		}
+	}
+
+	return result
+}
+
+// convertMessages converts core messages to Ollama chat messages, keeping
+// assistant tool calls, the tool name/call ID of tool results and inline images
+func convertMessages(messages []core.Message) ([]ChatMessage, error) {
+	result := make([]ChatMessage, len(messages))
+	for i, msg := range messages {
+		result[i] = ChatMessage{
+			Role:    msg.Role,
+			Content: msg.Content,
+		}
+
+		if len(msg.Parts) > 0 {
+			if err := applyContentParts(&result[i], msg.Parts); err != nil {
+				return nil, err
+			}
+		}
+
+		if msg.Role == "tool" {
+			result[i].ToolName = msg.Name
+			result[i].ToolCallID = msg.ToolCallID
+		}
+
+		for _, tc := range msg.ToolCalls {
+			// Ollama expects arguments as an object, never null
+			args := tc.Args
+			if args == nil {
+				args = make(map[string]interface{})
+			}
+			result[i].ToolCalls = append(result[i].ToolCalls, ToolCall{
 				ID:   tc.ID,
-				Name: tc.Function.Name,
-				Args: tc.Function.Arguments,
+				Type: "function",
+				Function: ToolCallFunction{
+					Name:      tc.Name,
+					Arguments: args,
+				},
+			})
+		}
+	}
+	return result, nil
+}
+
+// applyContentParts adds multimodal parts to an Ollama message.
+// Ollama only takes base64 image bytes (in the images array) besides text,
+// so image URLs and documents are rejected rather than silently dropped
+func applyContentParts(msg *ChatMessage, parts []core.ContentPart) error {
+	for _, part := range parts {
+		switch part.Type {
+		case core.ContentPartImage:
+			msg.Images = append(msg.Images, base64.StdEncoding.EncodeToString(part.Data))
+		case core.ContentPartText:
+			if msg.Content != "" {
+				msg.Content += "\n"
 			}
+			msg.Content += part.Text
+		default:
+			return fmt.Errorf("ollama: unsupported content part type %q (only text and inline images are supported)", part.Type)
+		}
+	}
+	return nil
+}
+
+// convertTools converts core tools to Ollama tool definitions
+func convertTools(tools []core.Tool) []Tool {
+	if len(tools) == 0 {
+		return nil
+	}
+
+	result := make([]Tool, 0, len(tools))
+	for _, tool := range tools {
+		schema := tool.Schema()
+		if schema == nil {
+			continue
 		}
+
+		result = append(result, Tool{
+			Type: "function",
+			Function: ToolFunction{
+				Name:        tool.Name(),
+				Description: tool.Description(),
+				Parameters:  schema.JSONSchema(),
+			},
+		})
+	}
+
+	return result
+}
+
+// applyCallOptions merges per-call options into a chat request.
+// Ollama has no tool choice parameter, so ToolChoiceNone is honoured by not
+// offering the tools; other choices are left to the model. Metadata is ignored
+func applyCallOptions(req *ChatRequest, opts []core.CallOption) {
+	if len(opts) == 0 {
+		return
+	}
+	o := core.NewCallOptions(opts...)
+
+	req.Options = mergeCallOptions(req.Options, o)
+	if format := responseFormat(o.ResponseFormat); format != nil {
+		req.Format = format
 	}
+	if o.ToolChoice == core.ToolChoiceNone {
+		req.Tools = nil
+	}
+}
 
-	return response, nil
+// mergeCallOptions returns a copy of base with the per-call overrides applied.
+// The client's options are shared between requests and must not be modified
+func mergeCallOptions(base *RequestOptions, o *core.CallOptions) *RequestOptions {
+	merged := &RequestOptions{}
+	if base != nil {
+		*merged = *base
+	}
+
+	if o.Temperature != nil {
+		merged.Temperature = o.Temperature
+	}
+	if o.MaxTokens != nil {
+		merged.NumPredict = o.MaxTokens
+	}
+	if o.Stop != nil {
+		merged.Stop = o.Stop
+	}
+	if o.Seed != nil {
+		merged.Seed = o.Seed
+	}
+	return merged
+}
+
+// responseFormat converts a core response format to Ollama's format field
+func responseFormat(f *core.ResponseFormat) interface{} {
+	if f == nil {
+		return nil
+	}
+	switch f.Type {
+	case core.ResponseFormatJSON:
+		return "json"
+	case core.ResponseFormatJSONSchema:
+		if f.Schema != nil {
+			return f.Schema.Schema
+		}
+		return "json"
+	}
+	return nil
 }
 
 // Complete sends a completion request to Ollama
-func (c *Client) Complete(ctx context.Context, prompt string) (string, error) {
+func (c *Client) Complete(ctx context.Context, prompt string, opts ...core.CallOption) (string, error) {
 	// Create request
 	req := GenerateRequest{
 		Model:   c.model,
@@ -162,6 +410,11 @@ func (c *Client) Complete(ctx context.Context, prompt string) (string, error) {
 		Stream:  false,
 		Options: c.options,
 	}
+	if len(opts) > 0 {
+		o := core.NewCallOptions(opts...)
+		req.Options = mergeCallOptions(c.options, o)
+		req.Format = responseFormat(o.ResponseFormat)
+	}
 
 	// Send request
 	var resp GenerateResponse
@@ -185,14 +438,18 @@ func (c *Client) Complete(ctx context.Context, prompt string) (string, error) {
 // - Converts Ollama chunks to core.StreamChunk format
 // - Runs stream processing in a goroutine
 // - Closes channel when stream completes or errors occur
-func (c *Client) ChatStream(ctx context.Context, messages []core.Message, opts ...interface{}) (<-chan core.StreamChunk, error) {
+func (c *Client) ChatStream(ctx context.Context, messages []core.Message, opts ...core.CallOption) (<-chan core.StreamChunk, error) {
+	return c.ChatStreamWithTools(ctx, messages, nil, opts...)
+}
+
+// ChatStreamWithTools streams a chat completion while offering tools to the model.
+// Ollama sends each tool call whole (not as argument fragments); the calls are
+// collected and attached to the final chunk, whose FinishReason is "tool_calls"
+func (c *Client) ChatStreamWithTools(ctx context.Context, messages []core.Message, tools []core.Tool, opts ...core.CallOption) (<-chan core.StreamChunk, error) {
 	// Convert messages to Ollama format
-	ollamaMessages := make([]ChatMessage, len(messages))
-	for i, msg := range messages {
-		ollamaMessages[i] = ChatMessage{
-			Role:    msg.Role,
-			Content: msg.Content,
-		}
+	ollamaMessages, err := convertMessages(messages)
+	if err != nil {
+		return nil, err
 	}
 
 	// Create request
@@ -201,7 +458,9 @@ func (c *Client) ChatStream(ctx context.Context, messages []core.Message, opts .
 		Messages: ollamaMessages,
 		Stream:   true,
 		Options:  c.options,
+		Tools:    convertTools(tools),
 	}
+	applyCallOptions(&req, opts)
 
 	// Create HTTP request
 	body, err := json.Marshal(req)
@@ -230,8 +489,9 @@ func (c *Client) ChatStream(ctx context.Context, messages []core.Message, opts .
 	// Create buffered channel for chunks
 	chunkChan := make(chan core.StreamChunk, 10)
 
-	// Track accumulated content and index
+	// Track accumulated content, tool calls and index
 	var content string
+	var toolCalls []core.ToolCall
 	index := 0
 
 	// Start goroutine to read streaming response
@@ -275,10 +535,14 @@ func (c *Client) ChatStream(ctx context.Context, messages []core.Message, opts .
 
 			delta := resp.Message.Content
 			content += delta
+			toolCalls = append(toolCalls, convertToolCalls(resp.Message.ToolCalls, len(toolCalls))...)
 
 			finishReason := ""
 			if resp.Done {
 				finishReason = "stop"
+				if len(toolCalls) > 0 {
+					finishReason = "tool_calls"
+				}
 			}
 
 			// Create StreamChunk
@@ -293,6 +557,10 @@ func (c *Client) ChatStream(ctx context.Context, messages []core.Message, opts .
 				},
 				Timestamp: time.Now(),
 			}
+			if resp.Done {
+				streamChunk.ToolCalls = toolCalls
+				streamChunk.Usage = convertUsage(&resp)
+			}
 
 			index++
 
@@ -331,7 +599,7 @@ func (c *Client) ChatStream(ctx context.Context, messages []core.Message, opts .
 // - Provides simple streaming interface for single-prompt use cases
 // - Wraps prompt as user message and delegates to ChatStream
 // - Maintains consistency with Complete() method signature
-func (c *Client) CompleteStream(ctx context.Context, prompt string, opts ...interface{}) (<-chan core.StreamChunk, error) {
+func (c *Client) CompleteStream(ctx context.Context, prompt string, opts ...core.CallOption) (<-chan core.StreamChunk, error) {
 	messages := []core.Message{
 		{Role: "user", Content: prompt},
 	}
@@ -348,12 +616,9 @@ type StreamChunk struct {
 // Stream sends a streaming chat request to Ollama
 func (c *Client) Stream(ctx context.Context, messages []core.Message) (<-chan StreamChunk, error) {
 	// Convert messages to Ollama format
-	ollamaMessages := make([]ChatMessage, len(messages))
-	for i, msg := range messages {
-		ollamaMessages[i] = ChatMessage{
-			Role:    msg.Role,
-			Content: msg.Content,
-		}
+	ollamaMessages, err := convertMessages(messages)
+	if err != nil {
+		return nil, err
 	}
 
 	// Create request
@@ -519,3 +784,16 @@ func (c *Client) Embedding(ctx context.Context, prompt string) ([]float64, error
 
 	return resp.Embedding, nil
 }
+
+// Embed implements core.Embedder, embedding the texts one at a time
+func (c *Client) Embed(ctx context.Context, texts []string) ([][]float64, error) {
+	vectors := make([][]float64, len(texts))
+	for i, text := range texts {
+		vector, err := c.Embedding(ctx, text)
+		if err != nil {
+			return nil, err
+		}
+		vectors[i] = vector
+	}
+	return vectors, nil
+}
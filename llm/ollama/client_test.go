@@ -11,6 +11,7 @@ import (
 	"time"
 
 	"github.com/yashrahurikar23/goagents/core"
+	"github.com/yashrahurikar23/goagents/tests/mocks"
 )
 
 func TestChatStream(t *testing.T) {
@@ -353,3 +354,340 @@ func TestStreamChunkMetadata(t *testing.T) {
 		t.Errorf("Expected done metadata to be true, got: %v", lastChunk.Metadata["done"])
 	}
 }
+
+func TestChatWithTools(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req ChatRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatalf("Failed to decode request: %v", err)
+		}
+
+		if len(req.Tools) != 1 || req.Tools[0].Function.Name != "calculator" {
+			t.Errorf("Expected calculator tool, got %+v", req.Tools)
+		}
+
+		// Assistant tool calls and tool results must be sent back intact
+		if len(req.Messages) != 3 {
+			t.Fatalf("Expected 3 messages, got %d", len(req.Messages))
+		}
+		if calls := req.Messages[1].ToolCalls; len(calls) != 1 || calls[0].Function.Name != "calculator" {
+			t.Errorf("Expected assistant tool call, got %+v", req.Messages[1])
+		}
+		if msg := req.Messages[2]; msg.Role != "tool" || msg.ToolName != "calculator" || msg.ToolCallID != "call_0" {
+			t.Errorf("Expected tool result for call_0, got %+v", msg)
+		}
+
+		json.NewEncoder(w).Encode(ChatResponse{
+			Model: "llama3.1",
+			Message: ChatMessage{
+				Role: "assistant",
+				ToolCalls: []ToolCall{
+					{Type: "function", Function: ToolCallFunction{Name: "calculator", Arguments: map[string]interface{}{"a": 2.0}}},
+				},
+			},
+			Done: true,
+		})
+	}))
+	defer server.Close()
+
+	client := New(WithBaseURL(server.URL), WithModel("llama3.1"))
+
+	messages := []core.Message{
+		{Role: "user", Content: "What is 25 * 4?"},
+		{Role: "assistant", ToolCalls: []core.ToolCall{{ID: "call_0", Name: "calculator", Args: map[string]interface{}{"a": 25.0}}}},
+		{Role: "tool", Content: "100", Name: "calculator", ToolCallID: "call_0"},
+	}
+
+	tool := mocks.NewMockTool("calculator", "Performs arithmetic")
+
+	resp, err := client.ChatWithTools(context.Background(), messages, []core.Tool{tool})
+	if err != nil {
+		t.Fatalf("ChatWithTools failed: %v", err)
+	}
+
+	if len(resp.ToolCalls) != 1 {
+		t.Fatalf("Expected 1 tool call, got %d", len(resp.ToolCalls))
+	}
+	if tc := resp.ToolCalls[0]; tc.ID != "call_0" || tc.Name != "calculator" || tc.Args["a"] != 2.0 {
+		t.Errorf("Unexpected tool call: %+v", tc)
+	}
+}
+
+func TestChatStreamWithTools(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req ChatRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatalf("Failed to decode request: %v", err)
+		}
+		if len(req.Tools) != 1 {
+			t.Errorf("Expected 1 tool, got %d", len(req.Tools))
+		}
+
+		responses := []ChatResponse{
+			{Message: ChatMessage{Role: "assistant", ToolCalls: []ToolCall{
+				{Function: ToolCallFunction{Name: "weather", Arguments: map[string]interface{}{"city": "Paris"}}},
+			}}},
+			{Message: ChatMessage{Role: "assistant", ToolCalls: []ToolCall{
+				{Function: ToolCallFunction{Name: "weather", Arguments: map[string]interface{}{"city": "Rome"}}},
+			}}},
+			{Message: ChatMessage{Role: "assistant"}, Done: true},
+		}
+		for _, resp := range responses {
+			data, _ := json.Marshal(resp)
+			fmt.Fprintf(w, "%s\n", data)
+		}
+	}))
+	defer server.Close()
+
+	client := New(WithBaseURL(server.URL))
+
+	tool := mocks.NewMockTool("weather", "Gets the weather")
+
+	stream, err := client.ChatStreamWithTools(context.Background(), []core.Message{{Role: "user", Content: "Weather?"}}, []core.Tool{tool})
+	if err != nil {
+		t.Fatalf("ChatStreamWithTools failed: %v", err)
+	}
+
+	var last core.StreamChunk
+	for chunk := range stream {
+		if chunk.Error != nil {
+			t.Fatalf("Chunk error: %v", chunk.Error)
+		}
+		last = chunk
+	}
+
+	if last.FinishReason != "tool_calls" {
+		t.Errorf("Expected finish_reason 'tool_calls', got '%s'", last.FinishReason)
+	}
+	if len(last.ToolCalls) != 2 {
+		t.Fatalf("Expected 2 tool calls, got %d", len(last.ToolCalls))
+	}
+	if last.ToolCalls[1].ID != "call_1" || last.ToolCalls[1].Args["city"] != "Rome" {
+		t.Errorf("Unexpected second tool call: %+v", last.ToolCalls[1])
+	}
+}
+
+func TestConvertMessagesWithParts(t *testing.T) {
+	messages := []core.Message{
+		core.UserMessageWithParts("What is this?", core.ImagePart([]byte("png"), "image/png")),
+	}
+
+	ollamaMessages, err := convertMessages(messages)
+	if err != nil {
+		t.Fatalf("convertMessages failed: %v", err)
+	}
+
+	if ollamaMessages[0].Content != "What is this?" {
+		t.Errorf("Expected text content, got '%s'", ollamaMessages[0].Content)
+	}
+	if len(ollamaMessages[0].Images) != 1 || ollamaMessages[0].Images[0] != "cG5n" {
+		t.Errorf("Expected base64 image, got %v", ollamaMessages[0].Images)
+	}
+
+	// Image URLs cannot be represented and must not be silently dropped
+	_, err = convertMessages([]core.Message{
+		core.UserMessageWithParts("What is this?", core.ImageURLPart("https://example.com/cat.png")),
+	})
+	if err == nil {
+		t.Error("Expected error for image URL part")
+	}
+}
+
+func TestChatWithSchema(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req map[string]interface{}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatalf("Failed to decode request: %v", err)
+		}
+
+		format, ok := req["format"].(map[string]interface{})
+		if !ok || format["type"] != "object" {
+			t.Errorf("Expected schema object as format, got %v", req["format"])
+		}
+
+		json.NewEncoder(w).Encode(ChatResponse{
+			Message: ChatMessage{Role: "assistant", Content: `{"label":"positive"}`},
+			Done:    true,
+		})
+	}))
+	defer server.Close()
+
+	client := New(WithBaseURL(server.URL))
+
+	schema := &core.ResponseSchema{
+		Name:   "sentiment",
+		Schema: map[string]interface{}{"type": "object", "properties": map[string]interface{}{"label": map[string]interface{}{"type": "string"}}},
+	}
+
+	resp, err := client.ChatWithSchema(context.Background(), []core.Message{{Role: "user", Content: "I love it"}}, schema)
+	if err != nil {
+		t.Fatalf("ChatWithSchema failed: %v", err)
+	}
+
+	if resp.Content != `{"label":"positive"}` {
+		t.Errorf("Unexpected content: %s", resp.Content)
+	}
+}
+
+func TestChatCallOptions(t *testing.T) {
+	var requests []ChatRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req ChatRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatalf("Failed to decode request: %v", err)
+		}
+		requests = append(requests, req)
+
+		json.NewEncoder(w).Encode(ChatResponse{
+			Message: ChatMessage{Role: "assistant", Content: "{}"},
+			Done:    true,
+		})
+	}))
+	defer server.Close()
+
+	client := New(WithBaseURL(server.URL), WithTemperature(0.8))
+	messages := []core.Message{{Role: "user", Content: "Hi"}}
+	tools := []core.Tool{mocks.NewMockTool("calculator", "Performs arithmetic")}
+
+	_, err := client.ChatWithTools(context.Background(), messages, tools,
+		core.WithTemperature(0),
+		core.WithMaxTokens(16),
+		core.WithStop("END"),
+		core.WithSeed(9),
+		core.WithResponseFormat(core.ResponseFormat{Type: core.ResponseFormatJSON}),
+		core.WithToolChoice(core.ToolChoiceNone),
+	)
+	if err != nil {
+		t.Fatalf("ChatWithTools failed: %v", err)
+	}
+
+	// The client's shared options must not be modified by the previous call
+	if _, err := client.Chat(context.Background(), messages); err != nil {
+		t.Fatalf("Chat failed: %v", err)
+	}
+
+	opts := requests[0].Options
+	if opts == nil || opts.Temperature == nil || *opts.Temperature != 0 {
+		t.Fatalf("Expected temperature 0, got %+v", opts)
+	}
+	if opts.NumPredict == nil || *opts.NumPredict != 16 {
+		t.Errorf("Expected num_predict 16, got %v", opts.NumPredict)
+	}
+	if len(opts.Stop) != 1 || opts.Stop[0] != "END" {
+		t.Errorf("Expected stop [END], got %v", opts.Stop)
+	}
+	if opts.Seed == nil || *opts.Seed != 9 {
+		t.Errorf("Expected seed 9, got %v", opts.Seed)
+	}
+	if requests[0].Format != "json" {
+		t.Errorf("Expected json format, got %v", requests[0].Format)
+	}
+	if len(requests[0].Tools) != 0 {
+		t.Errorf("Expected no tools with tool choice none, got %d", len(requests[0].Tools))
+	}
+
+	opts = requests[1].Options
+	if opts == nil || opts.Temperature == nil || *opts.Temperature != 0.8 || opts.NumPredict != nil || opts.Seed != nil {
+		t.Errorf("Expected client defaults on second call, got %+v", opts)
+	}
+}
+
+func TestChatUsage(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req ChatRequest
+		json.NewDecoder(r.Body).Decode(&req)
+
+		if !req.Stream {
+			json.NewEncoder(w).Encode(ChatResponse{
+				Message:         ChatMessage{Role: "assistant", Content: "Hi"},
+				Done:            true,
+				PromptEvalCount: 9,
+				EvalCount:       3,
+			})
+			return
+		}
+
+		responses := []ChatResponse{
+			{Message: ChatMessage{Role: "assistant", Content: "Hi"}},
+			{Message: ChatMessage{Role: "assistant"}, Done: true, PromptEvalCount: 9, EvalCount: 3},
+		}
+		for _, resp := range responses {
+			data, _ := json.Marshal(resp)
+			fmt.Fprintf(w, "%s\n", data)
+		}
+	}))
+	defer server.Close()
+
+	client := New(WithBaseURL(server.URL))
+	messages := []core.Message{{Role: "user", Content: "Hi"}}
+
+	resp, err := client.Chat(context.Background(), messages)
+	if err != nil {
+		t.Fatalf("Chat failed: %v", err)
+	}
+	if resp.Usage == nil || resp.Usage.PromptTokens != 9 || resp.Usage.CompletionTokens != 3 || resp.Usage.TotalTokens != 12 {
+		t.Errorf("Unexpected usage: %+v", resp.Usage)
+	}
+
+	stream, err := client.ChatStream(context.Background(), messages)
+	if err != nil {
+		t.Fatalf("ChatStream failed: %v", err)
+	}
+
+	var last core.StreamChunk
+	for chunk := range stream {
+		if chunk.Error != nil {
+			t.Fatalf("Chunk error: %v", chunk.Error)
+		}
+		if chunk.FinishReason == "" && chunk.Usage != nil {
+			t.Errorf("Usage set on intermediate chunk: %+v", chunk)
+		}
+		last = chunk
+	}
+	if last.Usage == nil || last.Usage.TotalTokens != 12 {
+		t.Errorf("Unexpected stream usage: %+v", last.Usage)
+	}
+}
+
+func TestConvertToolsNestedSchema(t *testing.T) {
+	tool := mocks.NewMockTool("create_orders", "Creates orders").WithSchema(&core.ToolSchema{
+		Name: "create_orders",
+		Parameters: []core.Parameter{
+			{Name: "orders", Type: "array", Required: true, Items: &core.Parameter{
+				Type:       "object",
+				Properties: []core.Parameter{{Name: "sku", Type: "string", Required: true}},
+			}},
+		},
+	})
+
+	tools := convertTools([]core.Tool{tool})
+	if len(tools) != 1 {
+		t.Fatalf("Expected 1 tool, got %d", len(tools))
+	}
+
+	orders := tools[0].Function.Parameters["properties"].(map[string]interface{})["orders"].(map[string]interface{})
+	items, ok := orders["items"].(map[string]interface{})
+	if !ok || items["type"] != "object" || items["properties"] == nil {
+		t.Errorf("Expected nested item schema in parameters, got %v", orders)
+	}
+}
+
+func TestEmbed(t *testing.T) {
+	var prompts []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req EmbeddingRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		prompts = append(prompts, req.Prompt)
+		json.NewEncoder(w).Encode(EmbeddingResponse{Embedding: []float64{float64(len(req.Prompt))}})
+	}))
+	defer server.Close()
+
+	var embedder core.Embedder = New(WithBaseURL(server.URL), WithModel("nomic-embed-text"))
+	vectors, err := embedder.Embed(context.Background(), []string{"a", "bb"})
+	if err != nil {
+		t.Fatalf("Embed failed: %v", err)
+	}
+	if len(prompts) != 2 || len(vectors) != 2 || vectors[0][0] != 1 || vectors[1][0] != 2 {
+		t.Errorf("Embed() = %v for prompts %v", vectors, prompts)
+	}
+}
@@ -7,17 +7,19 @@ type ChatRequest struct {
 	Model    string          `json:"model"`
 	Messages []ChatMessage   `json:"messages"`
 	Stream   bool            `json:"stream"`           // Must explicitly set to false
-	Format   string          `json:"format,omitempty"` // json, etc.
+	Format   interface{}     `json:"format,omitempty"` // "json" or a JSON schema object
 	Options  *RequestOptions `json:"options,omitempty"`
 	Tools    []Tool          `json:"tools,omitempty"`
 }
 
 // ChatMessage represents a message in a chat conversation
 type ChatMessage struct {
-	Role      string     `json:"role"` // user, assistant, system, tool
-	Content   string     `json:"content"`
-	Images    []string   `json:"images,omitempty"` // base64 encoded images
-	ToolCalls []ToolCall `json:"tool_calls,omitempty"`
+	Role       string     `json:"role"` // user, assistant, system, tool
+	Content    string     `json:"content"`
+	Images     []string   `json:"images,omitempty"` // base64 encoded images
+	ToolCalls  []ToolCall `json:"tool_calls,omitempty"`
+	ToolName   string     `json:"tool_name,omitempty"`    // tool messages: name of the tool that ran
+	ToolCallID string     `json:"tool_call_id,omitempty"` // tool messages: ID of the call being answered
 }
 
 // ToolCall represents a tool call in a chat response
@@ -65,8 +67,8 @@ type ChatResponse struct {
 type GenerateRequest struct {
 	Model   string          `json:"model"`
 	Prompt  string          `json:"prompt"`
-	Stream  bool            `json:"stream"` // Must explicitly set to false
-	Format  string          `json:"format,omitempty"`
+	Stream  bool            `json:"stream"`           // Must explicitly set to false
+	Format  interface{}     `json:"format,omitempty"` // "json" or a JSON schema object
 	Options *RequestOptions `json:"options,omitempty"`
 	System  string          `json:"system,omitempty"`
 	Context []int           `json:"context,omitempty"`
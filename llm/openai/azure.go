@@ -0,0 +1,138 @@
+package openai
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+)
+
+// DefaultAzureAPIVersion is the Azure OpenAI API version used when none is set.
+// WHY: Latest GA version at the time of writing; supports tools, json_schema and stream usage
+const DefaultAzureAPIVersion = "2024-10-21"
+
+// AzureConfig configures a Client for Azure OpenAI.
+//
+// WHY DEPLOYMENTS:
+// - Azure routes requests by deployment name, not by the "model" field
+// - Chat and embedding models are separate deployments with customer-chosen names
+// - When a deployment is not set, the request's model is used as the deployment name
+type AzureConfig struct {
+	// Endpoint is the resource endpoint, e.g. https://my-resource.openai.azure.com
+	Endpoint string
+
+	// APIVersion is sent as the api-version query parameter (default DefaultAzureAPIVersion)
+	APIVersion string
+
+	// Deployment is the deployment used for chat completions
+	Deployment string
+
+	// EmbeddingDeployment is the deployment used for embeddings
+	EmbeddingDeployment string
+}
+
+// WithAzure switches the client to Azure OpenAI.
+//
+// WHY THIS WAY:
+// - Requests go to {endpoint}/openai/deployments/{deployment}/...?api-version=...
+// - The API key is sent in the "api-key" header instead of Authorization: Bearer
+// - Errors are reported under the provider name "azure-openai"
+//
+// WHEN TO USE:
+// - Enterprise tenants that only allow Azure OpenAI
+// - For Microsoft Entra ID tokens, add WithAuthHeader("Authorization", "Bearer") after this option
+//
+// Example:
+//
+//	client := openai.New(
+//		openai.WithAPIKey(os.Getenv("AZURE_OPENAI_API_KEY")),
+//		openai.WithAzure(openai.AzureConfig{
+//			Endpoint:            "https://my-resource.openai.azure.com",
+//			Deployment:          "gpt-4o-prod",
+//			EmbeddingDeployment: "embeddings",
+//		}),
+//	)
+func WithAzure(cfg AzureConfig) Option {
+	return func(c *Client) {
+		if cfg.APIVersion == "" {
+			cfg.APIVersion = DefaultAzureAPIVersion
+		}
+		cfg.Endpoint = strings.TrimRight(cfg.Endpoint, "/")
+
+		c.azure = &cfg
+		c.authHeader = "api-key"
+		c.authScheme = ""
+		c.provider = "azure-openai"
+	}
+}
+
+// endpoint returns the URL for an API path.
+// On Azure, model is used as the deployment name unless one is configured.
+func (c *Client) endpoint(path, model string) string {
+	if c.azure == nil {
+		return c.baseURL + path
+	}
+
+	base := c.azure.Endpoint + "/openai"
+	if deployment := c.azure.deployment(path, model); deployment != "" {
+		base += "/deployments/" + url.PathEscape(deployment)
+	}
+	return base + path + "?api-version=" + url.QueryEscape(c.azure.APIVersion)
+}
+
+// deployment returns the deployment serving an API path.
+// Listing models is not deployment-scoped.
+func (a *AzureConfig) deployment(path, model string) string {
+	switch path {
+	case "/models":
+		return ""
+	case "/embeddings":
+		if a.EmbeddingDeployment != "" {
+			return a.EmbeddingDeployment
+		}
+	default:
+		if a.Deployment != "" {
+			return a.Deployment
+		}
+	}
+	return model
+}
+
+// IsContentFilterError checks if the error is an Azure content filter rejection.
+//
+// WHY: Azure rejects prompts that trip its content filters with HTTP 400; callers
+// usually want to show a policy message rather than retry or report an outage.
+func IsContentFilterError(err error) bool {
+	var oaiErr *OpenAIError
+	if !errors.As(err, &oaiErr) {
+		return false
+	}
+	return oaiErr.Code == "content_filter" || oaiErr.InnerCode == "ResponsibleAIPolicyViolation"
+}
+
+// Filtered returns the names of the categories that were filtered.
+func (r *ContentFilterResults) Filtered() []string {
+	if r == nil {
+		return nil
+	}
+
+	categories := []struct {
+		name   string
+		result *ContentFilterResult
+	}{
+		{"hate", r.Hate},
+		{"self_harm", r.SelfHarm},
+		{"sexual", r.Sexual},
+		{"violence", r.Violence},
+		{"jailbreak", r.Jailbreak},
+		{"protected_material_text", r.ProtectedMaterialText},
+		{"protected_material_code", r.ProtectedMaterialCode},
+	}
+
+	var filtered []string
+	for _, c := range categories {
+		if c.result != nil && c.result.Filtered {
+			filtered = append(filtered, c.name)
+		}
+	}
+	return filtered
+}
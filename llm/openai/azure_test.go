@@ -0,0 +1,245 @@
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/yashrahurikar23/goagents/core"
+)
+
+// newAzureServer starts a stand-in for an Azure OpenAI resource that checks
+// the deployment path, api-version and api-key header of every request.
+func newAzureServer(t *testing.T, wantPath string, handler http.HandlerFunc) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != wantPath {
+			t.Errorf("Path = %s, want %s", r.URL.Path, wantPath)
+		}
+		if v := r.URL.Query().Get("api-version"); v != "2024-06-01" {
+			t.Errorf("api-version = %q, want 2024-06-01", v)
+		}
+		if key := r.Header.Get("api-key"); key != "azure-key" {
+			t.Errorf("api-key = %q, want azure-key", key)
+		}
+		if auth := r.Header.Get("Authorization"); auth != "" {
+			t.Errorf("Expected no Authorization header, got %q", auth)
+		}
+		handler(w, r)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func newAzureClient(endpoint string) *Client {
+	return New(
+		WithAPIKey("azure-key"),
+		WithMaxRetries(0),
+		WithAzure(AzureConfig{
+			Endpoint:            endpoint + "/",
+			APIVersion:          "2024-06-01",
+			Deployment:          "gpt-4o-prod",
+			EmbeddingDeployment: "embeddings-prod",
+		}),
+	)
+}
+
+func TestAzureChat(t *testing.T) {
+	server := newAzureServer(t, "/openai/deployments/gpt-4o-prod/chat/completions", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{
+			"model": "gpt-4o-2024-08-06",
+			"prompt_filter_results": [{"prompt_index": 0, "content_filter_results": {"hate": {"filtered": false, "severity": "safe"}}}],
+			"choices": [{"message": {"role": "assistant", "content": "Hello"}, "finish_reason": "stop",
+				"content_filter_results": {"violence": {"filtered": false, "severity": "safe"}}}],
+			"usage": {"prompt_tokens": 4, "completion_tokens": 1, "total_tokens": 5}
+		}`)
+	})
+
+	client := newAzureClient(server.URL)
+
+	resp, err := client.Chat(context.Background(), []core.Message{core.UserMessage("Hi")})
+	if err != nil {
+		t.Fatalf("Chat failed: %v", err)
+	}
+	if resp.Content != "Hello" || resp.Usage == nil || resp.Usage.TotalTokens != 5 {
+		t.Errorf("Chat() = %+v", resp)
+	}
+	if _, ok := resp.Meta["content_filter_results"].(*ContentFilterResults); !ok {
+		t.Errorf("Expected content_filter_results in metadata, got %v", resp.Meta)
+	}
+	if _, ok := resp.Meta["prompt_filter_results"]; !ok {
+		t.Errorf("Expected prompt_filter_results in metadata")
+	}
+}
+
+func TestAzureChatStream(t *testing.T) {
+	server := newAzureServer(t, "/openai/deployments/gpt-4o-prod/chat/completions", func(w http.ResponseWriter, r *http.Request) {
+		var req ChatCompletionRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		if !req.Stream {
+			t.Error("Expected stream request")
+		}
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		// Azure sends prompt filter results in a first, choice-less chunk
+		fmt.Fprint(w, "data: {\"choices\":[],\"prompt_filter_results\":[{\"prompt_index\":0,\"content_filter_results\":{}}]}\n\n")
+		fmt.Fprint(w, "data: {\"choices\":[{\"delta\":{\"content\":\"Hi\"}}]}\n\n")
+		fmt.Fprint(w, "data: {\"choices\":[{\"delta\":{},\"finish_reason\":\"content_filter\",\"content_filter_results\":{\"sexual\":{\"filtered\":true,\"severity\":\"medium\"}}}]}\n\n")
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	})
+
+	client := newAzureClient(server.URL)
+
+	stream, err := client.ChatStream(context.Background(), []core.Message{core.UserMessage("Hi")})
+	if err != nil {
+		t.Fatalf("ChatStream failed: %v", err)
+	}
+
+	var last core.StreamChunk
+	for chunk := range stream {
+		if chunk.Error != nil {
+			t.Fatalf("Chunk error: %v", chunk.Error)
+		}
+		last = chunk
+	}
+
+	if last.Content != "Hi" || last.FinishReason != "content_filter" {
+		t.Errorf("Last chunk = %+v", last)
+	}
+	results, ok := last.Metadata["content_filter_results"].(*ContentFilterResults)
+	if !ok || len(results.Filtered()) != 1 || results.Filtered()[0] != "sexual" {
+		t.Errorf("Expected filtered sexual category, got %v", last.Metadata)
+	}
+}
+
+func TestAzureEmbedding(t *testing.T) {
+	server := newAzureServer(t, "/openai/deployments/embeddings-prod/embeddings", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"object":"list","data":[{"object":"embedding","embedding":[0.1,0.2],"index":0}],"model":"text-embedding-3-small"}`)
+	})
+
+	client := newAzureClient(server.URL)
+
+	resp, err := client.CreateEmbedding(context.Background(), EmbeddingRequest{Input: "hello"})
+	if err != nil {
+		t.Fatalf("CreateEmbedding failed: %v", err)
+	}
+	if len(resp.Data) != 1 || len(resp.Data[0].Embedding) != 2 {
+		t.Errorf("CreateEmbedding() = %+v", resp)
+	}
+}
+
+func TestAzureModelAsDeployment(t *testing.T) {
+	var gotPath string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		if r.URL.Query().Get("api-version") != DefaultAzureAPIVersion {
+			t.Errorf("api-version = %q, want default", r.URL.Query().Get("api-version"))
+		}
+		fmt.Fprint(w, `{"choices":[{"message":{"role":"assistant","content":"ok"}}]}`)
+	}))
+	defer server.Close()
+
+	client := New(WithAPIKey("k"), WithModel("my-gpt4"), WithAzure(AzureConfig{Endpoint: server.URL}))
+
+	if _, err := client.Chat(context.Background(), []core.Message{core.UserMessage("Hi")}); err != nil {
+		t.Fatalf("Chat failed: %v", err)
+	}
+	if gotPath != "/openai/deployments/my-gpt4/chat/completions" {
+		t.Errorf("Path = %s, want the model as deployment", gotPath)
+	}
+}
+
+func TestAzureEntraIDAuth(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if auth := r.Header.Get("Authorization"); auth != "Bearer entra-token" {
+			t.Errorf("Authorization = %q, want Bearer entra-token", auth)
+		}
+		if key := r.Header.Get("api-key"); key != "" {
+			t.Errorf("Expected no api-key header, got %q", key)
+		}
+		fmt.Fprint(w, `{"choices":[{"message":{"role":"assistant","content":"ok"}}]}`)
+	}))
+	defer server.Close()
+
+	client := New(
+		WithAPIKey("entra-token"),
+		WithAzure(AzureConfig{Endpoint: server.URL, Deployment: "d"}),
+		WithAuthHeader("Authorization", "Bearer"),
+	)
+
+	if _, err := client.Chat(context.Background(), []core.Message{core.UserMessage("Hi")}); err != nil {
+		t.Fatalf("Chat failed: %v", err)
+	}
+}
+
+func TestAzureContentFilterError(t *testing.T) {
+	server := newAzureServer(t, "/openai/deployments/gpt-4o-prod/chat/completions", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprint(w, `{"error": {
+			"message": "The response was filtered due to the prompt triggering Azure OpenAI's content management policy.",
+			"type": null, "param": "prompt", "code": "content_filter", "status": 400,
+			"innererror": {
+				"code": "ResponsibleAIPolicyViolation",
+				"content_filter_result": {
+					"hate": {"filtered": false, "severity": "safe"},
+					"violence": {"filtered": true, "severity": "high"},
+					"jailbreak": {"filtered": false, "detected": false}
+				}
+			}
+		}}`)
+	})
+
+	client := newAzureClient(server.URL)
+
+	_, err := client.Chat(context.Background(), []core.Message{core.UserMessage("...")})
+	if err == nil {
+		t.Fatal("Expected error, got nil")
+	}
+
+	var llmErr *core.ErrLLMFailure
+	if !errors.As(err, &llmErr) || llmErr.Provider != "azure-openai" {
+		t.Errorf("Expected ErrLLMFailure from azure-openai, got %v", err)
+	}
+	if !IsContentFilterError(err) {
+		t.Fatalf("IsContentFilterError(%v) = false, want true", err)
+	}
+
+	var oaiErr *OpenAIError
+	errors.As(err, &oaiErr)
+	if oaiErr.InnerCode != "ResponsibleAIPolicyViolation" {
+		t.Errorf("InnerCode = %q", oaiErr.InnerCode)
+	}
+	if filtered := oaiErr.ContentFilter.Filtered(); len(filtered) != 1 || filtered[0] != "violence" {
+		t.Errorf("Filtered() = %v, want [violence]", filtered)
+	}
+}
+
+func TestAzureGatewayError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		fmt.Fprint(w, `{"statusCode": 401, "message": "Unauthorized. Access token is missing, invalid, audience is incorrect, or have expired."}`)
+	}))
+	defer server.Close()
+
+	client := New(WithAPIKey("bad"), WithMaxRetries(0), WithAzure(AzureConfig{Endpoint: server.URL, Deployment: "d"}))
+
+	_, err := client.CreateChatCompletion(context.Background(), ChatCompletionRequest{
+		Messages: []ChatMessage{UserMessage("Hi")},
+	})
+
+	var oaiErr *OpenAIError
+	if !errors.As(err, &oaiErr) {
+		t.Fatalf("Expected *OpenAIError, got %T: %v", err, err)
+	}
+	if oaiErr.StatusCode != http.StatusUnauthorized || oaiErr.Message == "" {
+		t.Errorf("OpenAIError = %+v", oaiErr)
+	}
+	if IsContentFilterError(err) {
+		t.Error("IsContentFilterError() = true for an auth error")
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"bufio"
 	"bytes"
 	"context"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -43,12 +44,20 @@ const (
 // - Custom HTTP client support: Enables middleware, custom transports, testing
 // - Per-client settings: Multiple clients can coexist with different configs
 type Client struct {
-	apiKey     string
-	baseURL    string
-	model      string
-	httpClient *http.Client
-	maxRetries int
-	timeout    time.Duration
+	apiKey      string
+	baseURL     string
+	model       string
+	httpClient  *http.Client
+	maxRetries  int
+	timeout     time.Duration
+	provider    string
+	authHeader  string
+	authScheme  string
+	headers     http.Header
+	streamUsage bool
+	azure       *AzureConfig
+
+	embeddingModel string
 }
 
 // Option is a functional option for configuring the Client.
@@ -102,13 +111,75 @@ func WithMaxRetries(maxRetries int) Option {
 	}
 }
 
+// WithHeader adds a header sent with every request.
+//
+// WHEN TO USE:
+// - Gateways and proxies that route or meter on custom headers
+// - OpenAI organization/project headers (OpenAI-Organization, OpenAI-Project)
+func WithHeader(key, value string) Option {
+	return func(c *Client) {
+		if c.headers == nil {
+			c.headers = make(http.Header)
+		}
+		c.headers.Set(key, value)
+	}
+}
+
+// WithAuthHeader sets the header that carries the API key and its scheme.
+// The default is "Authorization" with scheme "Bearer"; an empty scheme sends
+// the bare key (e.g. WithAuthHeader("api-key", "")).
+//
+// WHY: Many OpenAI-compatible servers and gateways authenticate with a
+// different header. Without an API key no auth header is sent at all.
+func WithAuthHeader(header, scheme string) Option {
+	return func(c *Client) {
+		c.authHeader = header
+		c.authScheme = scheme
+	}
+}
+
+// WithStreamUsage controls whether streaming requests ask for token usage
+// (stream_options.include_usage). Enabled by default.
+//
+// WHY: Some OpenAI-compatible servers reject unknown request fields
+func WithStreamUsage(enabled bool) Option {
+	return func(c *Client) {
+		c.streamUsage = enabled
+	}
+}
+
+// WithProviderName sets the provider name reported in errors (default "openai").
+//
+// WHY: Clients pointed at other OpenAI-compatible servers should not report
+// their failures as OpenAI failures
+func WithProviderName(name string) Option {
+	return func(c *Client) {
+		c.provider = name
+	}
+}
+
+// WithEmbeddingModel sets the model Embed uses (default "text-embedding-ada-002",
+// like CreateEmbedding).
+//
+// WHY: The chat model set with WithModel cannot produce embeddings, so the
+// client needs a second model to act as a core.Embedder
+func WithEmbeddingModel(model string) Option {
+	return func(c *Client) {
+		c.embeddingModel = model
+	}
+}
+
 // New creates a new OpenAI client with the given options.
 func New(opts ...Option) *Client {
 	c := &Client{
-		baseURL:    DefaultBaseURL,
-		model:      DefaultModel,
-		timeout:    DefaultTimeout,
-		maxRetries: DefaultMaxRetries,
+		baseURL:     DefaultBaseURL,
+		model:       DefaultModel,
+		timeout:     DefaultTimeout,
+		maxRetries:  DefaultMaxRetries,
+		provider:    "openai",
+		authHeader:  "Authorization",
+		authScheme:  "Bearer",
+		streamUsage: true,
 	}
 
 	for _, opt := range opts {
@@ -124,6 +195,13 @@ func New(opts ...Option) *Client {
 	return c
 }
 
+// Model returns the default model sent with requests.
+// WHY: Lets agents look up the model's context window and tokenizer
+// (core.DefaultContextWindows, core.EncodingForModel) without extra configuration.
+func (c *Client) Model() string {
+	return c.model
+}
+
 // Chat implements the core.LLM interface for chat completions.
 //
 // WHY THIS WAY:
@@ -132,43 +210,233 @@ func New(opts ...Option) *Client {
 // - Returns structured error types (ErrLLMFailure) for proper error handling
 // - Stores usage metadata for token tracking and cost monitoring
 //
-// BUSINESS LOGIC:
-// - Must have at least one choice in response (API contract)
-// - Tool calls parsed as JSON arguments for type safety
-// - Content may be empty when tool calls are present
-//
 // WHEN TO USE:
 // - Multi-turn conversations with chat history
 // - Function calling / tool use scenarios
 // - When you need structured responses with metadata
-func (c *Client) Chat(ctx context.Context, messages []core.Message) (*core.Response, error) {
+func (c *Client) Chat(ctx context.Context, messages []core.Message, opts ...core.CallOption) (*core.Response, error) {
 	// WHY: Convert between framework types and OpenAI API types
 	// Maintains clean separation between core interfaces and provider implementations
-	chatMessages := make([]ChatMessage, len(messages))
-	for i, msg := range messages {
-		chatMessages[i] = ChatMessage{
-			Role:    msg.Role,
-			Content: msg.Content,
-			Name:    msg.Name,
+	chatMessages, err := convertMessages(messages)
+	if err != nil {
+		return nil, err
+	}
+	req := ChatCompletionRequest{
+		Model:    c.model,
+		Messages: chatMessages,
+	}
+	applyCallOptions(&req, opts)
+
+	resp, err := c.CreateChatCompletion(ctx, req)
+	if err != nil {
+		return nil, &core.ErrLLMFailure{
+			Provider: c.provider,
+			Err:      err,
 		}
 	}
 
+	return c.convertResponse(resp)
+}
+
+// ChatWithTools implements the core.ToolCallingLLM interface.
+//
+// WHY THIS WAY:
+// - Advertises tools using OpenAI's native "tools" request field
+// - Lets the model decide when to call tools (tool_choice "auto") unless core.WithToolChoice overrides it
+// - Tool calls come back in core.Response.ToolCalls, so agents stay provider-agnostic
+//
+// WHEN TO USE:
+// - Function calling agents that need the model to pick and invoke tools
+// - Multi-turn tool loops (pass assistant tool calls and tool results back in messages)
+func (c *Client) ChatWithTools(ctx context.Context, messages []core.Message, tools []core.Tool, opts ...core.CallOption) (*core.Response, error) {
+	chatMessages, err := convertMessages(messages)
+	if err != nil {
+		return nil, err
+	}
 	req := ChatCompletionRequest{
 		Model:    c.model,
 		Messages: chatMessages,
 	}
 
+	// WHY: OpenAI rejects tool_choice when no tools are provided
+	if openaiTools := convertTools(tools); len(openaiTools) > 0 {
+		req.Tools = openaiTools
+		req.ToolChoice = "auto"
+	}
+	applyCallOptions(&req, opts)
+
 	resp, err := c.CreateChatCompletion(ctx, req)
 	if err != nil {
 		return nil, &core.ErrLLMFailure{
-			Provider: "openai",
+			Provider: c.provider,
 			Err:      err,
 		}
 	}
 
+	return c.convertResponse(resp)
+}
+
+// ChatWithSchema implements the core.StructuredOutputLLM interface.
+//
+// WHY THIS WAY:
+// - Uses response_format "json_schema" so the model is constrained to the schema
+// - Enables strict mode whenever the schema satisfies OpenAI's strict rules (closed objects, all properties required)
+// - Otherwise falls back to non-strict, best-effort adherence instead of failing the request
+//
+// WHEN TO USE:
+// - Prefer core.ChatStructured, which derives the schema and validates the reply
+func (c *Client) ChatWithSchema(ctx context.Context, messages []core.Message, schema *core.ResponseSchema, opts ...core.CallOption) (*core.Response, error) {
+	if schema == nil {
+		return nil, &core.ErrInvalidArgument{Argument: "schema", Reason: "cannot be nil"}
+	}
+
+	chatMessages, err := convertMessages(messages)
+	if err != nil {
+		return nil, err
+	}
+	req := ChatCompletionRequest{
+		Model:    c.model,
+		Messages: chatMessages,
+	}
+	applyCallOptions(&req, opts)
+
+	// WHY: The schema argument always wins over a per-call response format
+	req.ResponseFormat = schemaResponseFormat(schema)
+
+	resp, err := c.CreateChatCompletion(ctx, req)
+	if err != nil {
+		return nil, &core.ErrLLMFailure{
+			Provider: c.provider,
+			Err:      err,
+		}
+	}
+
+	return c.convertResponse(resp)
+}
+
+// schemaResponseFormat builds a json_schema response format for a core schema.
+func schemaResponseFormat(schema *core.ResponseSchema) *ResponseFormat {
+	return &ResponseFormat{
+		Type: "json_schema",
+		JSONSchema: &JSONSchemaFormat{
+			Name:        schema.Name,
+			Description: schema.Description,
+			Schema:      schema.Schema,
+			Strict:      isStrictSchema(schema.Schema),
+		},
+	}
+}
+
+// applyCallOptions merges per-call options into a request.
+//
+// WHY THIS WAY:
+// - Options override the client defaults for this request only
+// - One client can then serve both creative and deterministic calls
+// - tool_choice is only sent alongside tools (OpenAI rejects it otherwise)
+// - A tool name becomes the object form that forces that specific function
+func applyCallOptions(req *ChatCompletionRequest, opts []core.CallOption) {
+	if len(opts) == 0 {
+		return
+	}
+	o := core.NewCallOptions(opts...)
+
+	if o.Temperature != nil {
+		req.Temperature = o.Temperature
+	}
+	if o.MaxTokens != nil {
+		req.MaxTokens = o.MaxTokens
+	}
+	if o.Stop != nil {
+		req.Stop = o.Stop
+	}
+	if o.Seed != nil {
+		req.Seed = o.Seed
+	}
+	if len(o.Metadata) > 0 {
+		req.Metadata = o.Metadata
+	}
+
+	if o.ToolChoice != "" && len(req.Tools) > 0 {
+		switch o.ToolChoice {
+		case core.ToolChoiceAuto, core.ToolChoiceNone, core.ToolChoiceRequired:
+			req.ToolChoice = o.ToolChoice
+		default:
+			req.ToolChoice = map[string]interface{}{
+				"type":     "function",
+				"function": map[string]interface{}{"name": o.ToolChoice},
+			}
+		}
+	}
+
+	if f := o.ResponseFormat; f != nil {
+		switch f.Type {
+		case core.ResponseFormatJSON:
+			req.ResponseFormat = &ResponseFormat{Type: "json_object"}
+		case core.ResponseFormatJSONSchema:
+			if f.Schema != nil {
+				req.ResponseFormat = schemaResponseFormat(f.Schema)
+			}
+		case core.ResponseFormatText:
+			req.ResponseFormat = &ResponseFormat{Type: "text"}
+		}
+	}
+}
+
+// isStrictSchema reports whether a JSON Schema can be used with strict mode.
+// Strict mode requires every object to set additionalProperties to false and
+// to list all of its properties as required.
+func isStrictSchema(schema map[string]interface{}) bool {
+	if schema == nil {
+		return true
+	}
+
+	if schema["type"] == "object" {
+		if extra, ok := schema["additionalProperties"].(bool); !ok || extra {
+			return false
+		}
+
+		properties, _ := schema["properties"].(map[string]interface{})
+		required := make(map[string]bool)
+		switch list := schema["required"].(type) {
+		case []string:
+			for _, name := range list {
+				required[name] = true
+			}
+		case []interface{}:
+			for _, name := range list {
+				if s, ok := name.(string); ok {
+					required[s] = true
+				}
+			}
+		}
+
+		for name, prop := range properties {
+			propSchema, _ := prop.(map[string]interface{})
+			if !required[name] || !isStrictSchema(propSchema) {
+				return false
+			}
+		}
+	}
+
+	if schema["type"] == "array" {
+		// WHY: Strict mode rejects arrays whose element type is unknown
+		items, ok := schema["items"].(map[string]interface{})
+		return ok && isStrictSchema(items)
+	}
+
+	return true
+}
+
+// convertResponse converts an OpenAI chat completion into a core.Response.
+//
+// BUSINESS LOGIC:
+// - Must have at least one choice in response (API contract)
+// - Tool calls parsed as JSON arguments for type safety
+// - Content may be empty when tool calls are present
+func (c *Client) convertResponse(resp *ChatCompletionResponse) (*core.Response, error) {
 	if len(resp.Choices) == 0 {
 		return nil, &core.ErrLLMFailure{
-			Provider: "openai",
+			Provider: c.provider,
 			Err:      fmt.Errorf("no choices in response"),
 		}
 	}
@@ -187,9 +455,14 @@ func (c *Client) Chat(ctx context.Context, messages []core.Message) (*core.Respo
 				}
 			}
 
+			name := ""
+			if tc.Function != nil {
+				name = tc.Function.Name
+			}
+
 			toolCalls[i] = core.ToolCall{
 				ID:   tc.ID,
-				Name: tc.Function.Name,
+				Name: name,
 				Args: args,
 			}
 		}
@@ -202,24 +475,47 @@ func (c *Client) Chat(ctx context.Context, messages []core.Message) (*core.Respo
 		}
 	}
 
+	meta := map[string]interface{}{
+		"model":         resp.Model,
+		"finish_reason": choice.FinishReason,
+		"usage":         resp.Usage,
+	}
+
+	// WHY: Azure explains finish_reason "content_filter" through these verdicts
+	if choice.ContentFilterResults != nil {
+		meta["content_filter_results"] = choice.ContentFilterResults
+	}
+	if len(resp.PromptFilterResults) > 0 {
+		meta["prompt_filter_results"] = resp.PromptFilterResults
+	}
+
 	return &core.Response{
 		Content:   content,
 		ToolCalls: toolCalls,
-		Meta: map[string]interface{}{
-			"model":         resp.Model,
-			"finish_reason": choice.FinishReason,
-			"usage":         resp.Usage,
-		},
+		Usage:     convertUsage(resp.Usage),
+		Meta:      meta,
 	}, nil
 }
 
+// convertUsage converts OpenAI token usage to core.Usage.
+func convertUsage(usage *Usage) *core.Usage {
+	if usage == nil {
+		return nil
+	}
+	return &core.Usage{
+		PromptTokens:     usage.PromptTokens,
+		CompletionTokens: usage.CompletionTokens,
+		TotalTokens:      usage.TotalTokens,
+	}
+}
+
 // Complete implements the core.LLM interface for simple completions.
-func (c *Client) Complete(ctx context.Context, prompt string) (string, error) {
+func (c *Client) Complete(ctx context.Context, prompt string, opts ...core.CallOption) (string, error) {
 	messages := []core.Message{
 		{Role: "user", Content: prompt},
 	}
 
-	resp, err := c.Chat(ctx, messages)
+	resp, err := c.Chat(ctx, messages, opts...)
 	if err != nil {
 		return "", err
 	}
@@ -234,7 +530,7 @@ func (c *Client) CreateChatCompletion(ctx context.Context, req ChatCompletionReq
 	}
 
 	var resp ChatCompletionResponse
-	if err := c.doRequest(ctx, "POST", "/chat/completions", req, &resp); err != nil {
+	if err := c.doRequest(ctx, "POST", c.endpoint("/chat/completions", req.Model), req, &resp); err != nil {
 		return nil, err
 	}
 
@@ -248,39 +544,70 @@ func (c *Client) CreateChatCompletion(ctx context.Context, req ChatCompletionReq
 // - Returns a channel of core.StreamChunk for real-time token delivery
 // - Handles context cancellation gracefully
 // - Accumulates content across chunks for convenience
+// - Reports token usage on the final chunk
 //
 // IMPLEMENTATION:
 // - Uses CreateChatCompletionStream internally
 // - Converts OpenAI SSE chunks to core.StreamChunk format
 // - Runs stream processing in a goroutine
 // - Closes channel when stream completes or errors occur
-func (c *Client) ChatStream(ctx context.Context, messages []core.Message, opts ...interface{}) (<-chan core.StreamChunk, error) {
-	// Convert core.Message to ChatMessage
-	chatMessages := make([]ChatMessage, len(messages))
-	for i, msg := range messages {
-		chatMessages[i] = ChatMessage{
-			Role:    msg.Role,
-			Content: msg.Content,
-			Name:    msg.Name,
-		}
+// - Holds back the finish chunk until the stream ends to attach token usage
+// - OpenAI sends usage in a separate, choice-less chunk after the finish chunk
+func (c *Client) ChatStream(ctx context.Context, messages []core.Message, opts ...core.CallOption) (<-chan core.StreamChunk, error) {
+	chatMessages, err := convertMessages(messages)
+	if err != nil {
+		return nil, err
 	}
-
 	req := ChatCompletionRequest{
 		Model:    c.model,
 		Messages: chatMessages,
 	}
+	if c.streamUsage {
+		req.StreamOptions = &ChatStreamOptions{IncludeUsage: true}
+	}
+	applyCallOptions(&req, opts)
 
 	// Create buffered channel for chunks
 	chunkChan := make(chan core.StreamChunk, 10)
 
-	// Track accumulated content and index
+	// Track accumulated content, usage and index
 	var content string
+	var usage *core.Usage
+	var final *core.StreamChunk
+	var flushed bool
 	index := 0
 
 	// Start streaming in a goroutine
 	go func() {
 		defer close(chunkChan)
 
+		// flushFinal sends the held-back finish chunk with the usage attached
+		flushFinal := func() error {
+			if flushed {
+				return nil
+			}
+			// WHY: Some compatible servers report usage but never a finish_reason
+			if final == nil && usage != nil {
+				final = &core.StreamChunk{
+					Content:      content,
+					Index:        index,
+					FinishReason: "stop",
+					Timestamp:    time.Now(),
+				}
+			}
+			if final == nil {
+				return nil
+			}
+			final.Usage = usage
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case chunkChan <- *final:
+			}
+			flushed = true
+			return nil
+		}
+
 		streamOpts := StreamOptions{
 			OnChunk: func(chunk *ChatCompletionStreamResponse) error {
 				// Check for context cancellation
@@ -290,6 +617,10 @@ func (c *Client) ChatStream(ctx context.Context, messages []core.Message, opts .
 				default:
 				}
 
+				if chunk.Usage != nil {
+					usage = convertUsage(chunk.Usage)
+				}
+
 				// Extract delta from chunk
 				if len(chunk.Choices) == 0 {
 					return nil
@@ -318,8 +649,17 @@ func (c *Client) ChatStream(ctx context.Context, messages []core.Message, opts .
 					Timestamp: time.Now(),
 				}
 
+				if choice.ContentFilterResults != nil {
+					streamChunk.Metadata["content_filter_results"] = choice.ContentFilterResults
+				}
+
 				index++
 
+				if choice.FinishReason != "" {
+					final = &streamChunk
+					return nil
+				}
+
 				// Send chunk on channel
 				select {
 				case <-ctx.Done():
@@ -329,9 +669,7 @@ func (c *Client) ChatStream(ctx context.Context, messages []core.Message, opts .
 
 				return nil
 			},
-			OnComplete: func() error {
-				return nil
-			},
+			OnComplete: flushFinal,
 			OnError: func(err error) {
 				// Send error chunk
 				errorChunk := core.StreamChunk{
@@ -359,7 +697,11 @@ func (c *Client) ChatStream(ctx context.Context, messages []core.Message, opts .
 			case <-ctx.Done():
 			case chunkChan <- errorChunk:
 			}
+			return
 		}
+
+		// WHY: Some servers close the stream without sending [DONE]
+		flushFinal()
 	}()
 
 	return chunkChan, nil
@@ -371,7 +713,7 @@ func (c *Client) ChatStream(ctx context.Context, messages []core.Message, opts .
 // - Provides simple streaming interface for single-prompt use cases
 // - Wraps prompt as user message and delegates to ChatStream
 // - Maintains consistency with Complete() method signature
-func (c *Client) CompleteStream(ctx context.Context, prompt string, opts ...interface{}) (<-chan core.StreamChunk, error) {
+func (c *Client) CompleteStream(ctx context.Context, prompt string, opts ...core.CallOption) (<-chan core.StreamChunk, error) {
 	messages := []core.Message{
 		{Role: "user", Content: prompt},
 	}
@@ -396,13 +738,18 @@ func (c *Client) CompleteStream(ctx context.Context, prompt string, opts ...inte
 // - "[DONE]" message signals stream completion
 // - Errors during streaming call OnError handler but don't stop stream
 // - Context cancellation stops streaming immediately
+//
+// COMPATIBILITY:
+// - Accepts "data:" with or without the space and bare JSON lines (NDJSON)
+// - Skips event/id/retry fields, comments, keep-alives and other non-JSON frames
+// - An {"error": ...} frame ends the stream with an *OpenAIError
 func (c *Client) CreateChatCompletionStream(ctx context.Context, req ChatCompletionRequest, opts StreamOptions) error {
 	if req.Model == "" {
 		req.Model = c.model
 	}
 	req.Stream = true
 
-	httpReq, err := c.newRequest(ctx, "POST", "/chat/completions", req)
+	httpReq, err := c.newRequest(ctx, "POST", c.endpoint("/chat/completions", req.Model), req)
 	if err != nil {
 		return err
 	}
@@ -431,18 +778,11 @@ func (c *Client) CreateChatCompletionStream(ctx context.Context, req ChatComplet
 			return fmt.Errorf("stream read error: %w", err)
 		}
 
-		line = bytes.TrimSpace(line)
-		if len(line) == 0 {
+		data, ok := sseData(line)
+		if !ok {
 			continue
 		}
 
-		// SSE format: "data: {...}"
-		if !bytes.HasPrefix(line, []byte("data: ")) {
-			continue
-		}
-
-		data := bytes.TrimPrefix(line, []byte("data: "))
-
 		// Check for stream end
 		if bytes.Equal(data, []byte("[DONE]")) {
 			if opts.OnComplete != nil {
@@ -453,6 +793,16 @@ func (c *Client) CreateChatCompletionStream(ctx context.Context, req ChatComplet
 			break
 		}
 
+		// WHY: Compatible servers send keep-alives and other non-JSON frames
+		if data[0] != '{' {
+			continue
+		}
+
+		// WHY: Servers report mid-stream failures as an error frame
+		if err := streamError(httpResp.StatusCode, data); err != nil {
+			return err
+		}
+
 		// Parse chunk
 		var chunk ChatCompletionStreamResponse
 		if err := json.Unmarshal(data, &chunk); err != nil {
@@ -473,6 +823,45 @@ func (c *Client) CreateChatCompletionStream(ctx context.Context, req ChatComplet
 	return nil
 }
 
+// sseData extracts the payload of an SSE data line.
+// Bare JSON lines are accepted for servers that stream NDJSON.
+func sseData(line []byte) ([]byte, bool) {
+	line = bytes.TrimSpace(line)
+	switch {
+	case bytes.HasPrefix(line, []byte("data:")):
+		data := bytes.TrimSpace(bytes.TrimPrefix(line, []byte("data:")))
+		return data, len(data) > 0
+	case bytes.HasPrefix(line, []byte("{")):
+		return line, true
+	default:
+		return nil, false
+	}
+}
+
+// streamError returns the error carried by an {"error": ...} stream frame, if any.
+func streamError(statusCode int, data []byte) error {
+	var frame struct {
+		Error json.RawMessage `json:"error"`
+	}
+	if err := json.Unmarshal(data, &frame); err != nil || len(frame.Error) == 0 || string(frame.Error) == "null" {
+		return nil
+	}
+
+	var apiErr APIError
+	if err := json.Unmarshal(frame.Error, &apiErr); err != nil {
+		// WHY: Some servers send the error as a plain string
+		var msg string
+		json.Unmarshal(frame.Error, &msg)
+		apiErr.Message = msg
+	}
+	return &OpenAIError{
+		StatusCode: statusCode,
+		Type:       apiErr.Type,
+		Message:    apiErr.Message,
+		Code:       apiErr.Code,
+	}
+}
+
 // CreateEmbedding creates embeddings for the given input.
 func (c *Client) CreateEmbedding(ctx context.Context, req EmbeddingRequest) (*EmbeddingResponse, error) {
 	if req.Model == "" {
@@ -480,13 +869,41 @@ func (c *Client) CreateEmbedding(ctx context.Context, req EmbeddingRequest) (*Em
 	}
 
 	var resp EmbeddingResponse
-	if err := c.doRequest(ctx, "POST", "/embeddings", req, &resp); err != nil {
+	if err := c.doRequest(ctx, "POST", c.endpoint("/embeddings", req.Model), req, &resp); err != nil {
 		return nil, err
 	}
 
 	return &resp, nil
 }
 
+// Embed implements core.Embedder with a single embeddings request for all texts.
+//
+// WHY: The API accepts a batch of inputs, so embedding the facts of a turn
+// costs one round trip instead of one per fact
+func (c *Client) Embed(ctx context.Context, texts []string) ([][]float64, error) {
+	if len(texts) == 0 {
+		return nil, nil
+	}
+
+	resp, err := c.CreateEmbedding(ctx, EmbeddingRequest{Model: c.embeddingModel, Input: texts})
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.Data) != len(texts) {
+		return nil, fmt.Errorf("%s returned %d embeddings for %d inputs", c.provider, len(resp.Data), len(texts))
+	}
+
+	// WHY: Data carries the input index; order by it rather than trusting the array order
+	vectors := make([][]float64, len(texts))
+	for _, d := range resp.Data {
+		if d.Index < 0 || d.Index >= len(texts) {
+			return nil, fmt.Errorf("%s returned embedding index %d for %d inputs", c.provider, d.Index, len(texts))
+		}
+		vectors[d.Index] = d.Embedding
+	}
+	return vectors, nil
+}
+
 // CreateModeration checks content for policy violations.
 func (c *Client) CreateModeration(ctx context.Context, req ModerationRequest) (*ModerationResponse, error) {
 	if req.Model == "" {
@@ -494,7 +911,7 @@ func (c *Client) CreateModeration(ctx context.Context, req ModerationRequest) (*
 	}
 
 	var resp ModerationResponse
-	if err := c.doRequest(ctx, "POST", "/moderations", req, &resp); err != nil {
+	if err := c.doRequest(ctx, "POST", c.endpoint("/moderations", req.Model), req, &resp); err != nil {
 		return nil, err
 	}
 
@@ -504,7 +921,7 @@ func (c *Client) CreateModeration(ctx context.Context, req ModerationRequest) (*
 // ListModels lists available models.
 func (c *Client) ListModels(ctx context.Context) (*ModelListResponse, error) {
 	var resp ModelListResponse
-	if err := c.doRequest(ctx, "GET", "/models", nil, &resp); err != nil {
+	if err := c.doRequest(ctx, "GET", c.endpoint("/models", ""), nil, &resp); err != nil {
 		return nil, err
 	}
 
@@ -512,7 +929,7 @@ func (c *Client) ListModels(ctx context.Context) (*ModelListResponse, error) {
 }
 
 // doRequest performs an HTTP request with retry logic.
-func (c *Client) doRequest(ctx context.Context, method, path string, reqBody, respBody interface{}) error {
+func (c *Client) doRequest(ctx context.Context, method, url string, reqBody, respBody interface{}) error {
 	var lastErr error
 
 	for attempt := 0; attempt <= c.maxRetries; attempt++ {
@@ -526,7 +943,7 @@ func (c *Client) doRequest(ctx context.Context, method, path string, reqBody, re
 			}
 		}
 
-		req, err := c.newRequest(ctx, method, path, reqBody)
+		req, err := c.newRequest(ctx, method, url, reqBody)
 		if err != nil {
 			return fmt.Errorf("failed to create request: %w", err)
 		}
@@ -563,10 +980,8 @@ func (c *Client) doRequest(ctx context.Context, method, path string, reqBody, re
 	return fmt.Errorf("max retries exceeded: %w", lastErr)
 }
 
-// newRequest creates a new HTTP request.
-func (c *Client) newRequest(ctx context.Context, method, path string, body interface{}) (*http.Request, error) {
-	url := c.baseURL + path
-
+// newRequest creates a new HTTP request for a URL built by endpoint.
+func (c *Client) newRequest(ctx context.Context, method, url string, body interface{}) (*http.Request, error) {
 	var reqBody io.Reader
 	if body != nil {
 		jsonData, err := json.Marshal(body)
@@ -582,7 +997,18 @@ func (c *Client) newRequest(ctx context.Context, method, path string, body inter
 	}
 
 	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	for key, values := range c.headers {
+		req.Header[key] = values
+	}
+
+	// WHY: Local servers often run without auth; never send an empty credential
+	if c.apiKey != "" && c.authHeader != "" {
+		value := c.apiKey
+		if c.authScheme != "" {
+			value = c.authScheme + " " + c.apiKey
+		}
+		req.Header.Set(c.authHeader, value)
+	}
 
 	return req, nil
 }
@@ -600,12 +1026,27 @@ func (c *Client) handleErrorResponse(resp *http.Response) error {
 	}
 
 	if errorResp.Error != nil {
-		return &OpenAIError{
+		oaiErr := &OpenAIError{
 			StatusCode: resp.StatusCode,
 			Type:       errorResp.Error.Type,
 			Message:    errorResp.Error.Message,
 			Code:       errorResp.Error.Code,
 		}
+		// WHY: Azure puts the content filter verdict in innererror
+		if inner := errorResp.Error.InnerError; inner != nil {
+			oaiErr.InnerCode = inner.Code
+			oaiErr.ContentFilter = inner.ContentFilterResult
+		}
+		return oaiErr
+	}
+
+	// WHY: Azure API Management gateways answer with {"statusCode": ..., "message": ...}
+	var gwErr gatewayError
+	if err := json.Unmarshal(body, &gwErr); err == nil && gwErr.Message != "" {
+		return &OpenAIError{
+			StatusCode: resp.StatusCode,
+			Message:    gwErr.Message,
+		}
 	}
 
 	return fmt.Errorf("HTTP %d: unknown error", resp.StatusCode)
@@ -617,6 +1058,11 @@ type OpenAIError struct {
 	Type       string
 	Message    string
 	Code       interface{}
+
+	// InnerCode and ContentFilter are set from Azure's innererror
+	// (e.g. "ResponsibleAIPolicyViolation" with the filtered categories)
+	InnerCode     string
+	ContentFilter *ContentFilterResults
 }
 
 func (e *OpenAIError) Error() string {
@@ -637,6 +1083,130 @@ func IsTimeoutError(err error) bool {
 		strings.Contains(err.Error(), "deadline exceeded")
 }
 
+// convertMessages converts core messages to OpenAI chat messages.
+//
+// WHY THIS WAY:
+// - Preserves assistant tool calls so the model sees what it asked for
+// - Preserves tool_call_id on tool results, which OpenAI requires to match calls
+// - Tool call arguments are re-encoded as the JSON string OpenAI expects
+// - Content parts OpenAI cannot represent fail the conversion instead of being dropped
+func convertMessages(messages []core.Message) ([]ChatMessage, error) {
+	result := make([]ChatMessage, 0, len(messages))
+
+	for _, msg := range messages {
+		chatMsg := ChatMessage{
+			Role:    msg.Role,
+			Content: msg.Content,
+			Name:    msg.Name,
+		}
+
+		// WHY: Multimodal messages use the content array form; plain text stays a string
+		if len(msg.Parts) > 0 {
+			parts, err := convertContentParts(msg.ContentParts())
+			if err != nil {
+				return nil, err
+			}
+			chatMsg.Content = parts
+		}
+
+		if msg.Role == "tool" {
+			chatMsg.ToolCallID = msg.ToolCallID
+		}
+
+		if len(msg.ToolCalls) > 0 {
+			chatMsg.ToolCalls = make([]ToolCall, len(msg.ToolCalls))
+			for i, tc := range msg.ToolCalls {
+				args := tc.Args
+				if args == nil {
+					args = map[string]interface{}{}
+				}
+				argsJSON, _ := json.Marshal(args)
+				chatMsg.ToolCalls[i] = ToolCall{
+					ID:   tc.ID,
+					Type: "function",
+					Function: &FunctionCall{
+						Name:      tc.Name,
+						Arguments: string(argsJSON),
+					},
+				}
+			}
+		}
+
+		result = append(result, chatMsg)
+	}
+
+	return result, nil
+}
+
+// convertContentParts converts core content parts to OpenAI content parts.
+//
+// WHY THIS WAY:
+// - Image URLs are passed through; inline images become base64 data URLs
+// - Documents (e.g. PDFs) are sent as "file" parts with a data URL
+// - Unknown part types are an error, as core.ContentPart promises, so nothing is silently lost
+func convertContentParts(parts []core.ContentPart) ([]ContentPart, error) {
+	result := make([]ContentPart, 0, len(parts))
+	for _, part := range parts {
+		switch part.Type {
+		case core.ContentPartText:
+			result = append(result, ContentPart{Type: "text", Text: part.Text})
+		case core.ContentPartImageURL:
+			result = append(result, ContentPart{
+				Type:     "image_url",
+				ImageURL: &ImageURL{URL: part.URL, Detail: part.Detail},
+			})
+		case core.ContentPartImage:
+			result = append(result, ContentPart{
+				Type:     "image_url",
+				ImageURL: &ImageURL{URL: dataURL(part.MIMEType, part.Data), Detail: part.Detail},
+			})
+		case core.ContentPartDocument:
+			filename := "document"
+			if part.MIMEType == "application/pdf" {
+				filename = "document.pdf"
+			}
+			result = append(result, ContentPart{
+				Type: "file",
+				File: &File{Filename: filename, FileData: dataURL(part.MIMEType, part.Data)},
+			})
+		default:
+			return nil, fmt.Errorf("openai: unsupported content part type %q", part.Type)
+		}
+	}
+	return result, nil
+}
+
+// dataURL encodes bytes as a base64 data URL.
+func dataURL(mimeType string, data []byte) string {
+	return "data:" + mimeType + ";base64," + base64.StdEncoding.EncodeToString(data)
+}
+
+// convertTools converts core tools to OpenAI function tool definitions.
+// Tools without a schema are skipped because OpenAI cannot describe them to the model.
+//
+// WHY STRICT WHEN POSSIBLE:
+// - Strict mode guarantees the arguments match the schema (no missing or extra fields)
+// - It is only enabled when the schema meets strict mode's rules, see isStrictSchema
+func convertTools(tools []core.Tool) []Tool {
+	if len(tools) == 0 {
+		return nil
+	}
+
+	result := make([]Tool, 0, len(tools))
+	for _, tool := range tools {
+		schema := tool.Schema()
+		if schema == nil {
+			continue
+		}
+
+		fn := NewFunction(tool.Name(), tool.Description(), schema.JSONSchema())
+		fn.Strict = isStrictSchema(fn.Parameters)
+		result = append(result, NewTool(fn))
+	}
+
+	return result
+}
+
 // Helper functions for creating messages
 
 // SystemMessage creates a system message.
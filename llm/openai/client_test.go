@@ -11,6 +11,7 @@ import (
 	"time"
 
 	"github.com/yashrahurikar23/goagents/core"
+	"github.com/yashrahurikar23/goagents/tests/mocks"
 )
 
 func TestNew(t *testing.T) {
@@ -66,6 +67,15 @@ func TestNew(t *testing.T) {
 	}
 }
 
+func TestModel(t *testing.T) {
+	if got := New().Model(); got != DefaultModel {
+		t.Errorf("Model() = %v, want %v", got, DefaultModel)
+	}
+	if got := New(WithModel("gpt-4o-mini")).Model(); got != "gpt-4o-mini" {
+		t.Errorf("Model() = %v, want %v", got, "gpt-4o-mini")
+	}
+}
+
 func TestCreateChatCompletion(t *testing.T) {
 	mockResponse := ChatCompletionResponse{
 		ID:      "chatcmpl-123",
@@ -701,3 +711,536 @@ func TestStreamChunkAccumulation(t *testing.T) {
 		t.Errorf("Expected %d chunks, got %d", len(expectedAccumulations), i)
 	}
 }
+
+func TestChatWithTools(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req ChatCompletionRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatalf("Failed to decode request: %v", err)
+		}
+
+		if len(req.Tools) != 1 {
+			t.Fatalf("Expected 1 tool, got %d", len(req.Tools))
+		}
+		if req.Tools[0].Function.Name != "calculator" {
+			t.Errorf("Tool name = %v, want calculator", req.Tools[0].Function.Name)
+		}
+		if req.ToolChoice != "auto" {
+			t.Errorf("ToolChoice = %v, want auto", req.ToolChoice)
+		}
+
+		json.NewEncoder(w).Encode(ChatCompletionResponse{
+			Model: "gpt-4",
+			Choices: []Choice{
+				{
+					Message: &ChatMessage{
+						Role: "assistant",
+						ToolCalls: []ToolCall{
+							{
+								ID:   "call_1",
+								Type: "function",
+								Function: &FunctionCall{
+									Name:      "calculator",
+									Arguments: `{"a": 2, "b": 3}`,
+								},
+							},
+						},
+					},
+					FinishReason: "tool_calls",
+				},
+			},
+		})
+	}))
+	defer server.Close()
+
+	client := New(WithAPIKey("test-key"), WithBaseURL(server.URL))
+
+	tool := mocks.NewMockTool("calculator", "Performs arithmetic").WithSchema(&core.ToolSchema{
+		Name: "calculator",
+		Parameters: []core.Parameter{
+			{Name: "a", Type: "number", Required: true},
+			{Name: "b", Type: "number", Required: true},
+		},
+	})
+
+	resp, err := client.ChatWithTools(context.Background(), []core.Message{core.UserMessage("2+3?")}, []core.Tool{tool})
+	if err != nil {
+		t.Fatalf("ChatWithTools failed: %v", err)
+	}
+
+	if len(resp.ToolCalls) != 1 {
+		t.Fatalf("Expected 1 tool call, got %d", len(resp.ToolCalls))
+	}
+
+	tc := resp.ToolCalls[0]
+	if tc.ID != "call_1" || tc.Name != "calculator" {
+		t.Errorf("ToolCall = %+v, want call_1/calculator", tc)
+	}
+	if tc.Args["a"] != 2.0 || tc.Args["b"] != 3.0 {
+		t.Errorf("Args = %v, want a=2 b=3", tc.Args)
+	}
+}
+
+func TestConvertMessages(t *testing.T) {
+	messages := []core.Message{
+		core.SystemMessage("System prompt"),
+		core.UserMessage("Hello"),
+		{
+			Role: "assistant",
+			ToolCalls: []core.ToolCall{
+				{ID: "call_123", Name: "calculator", Args: map[string]interface{}{"a": 1.0}},
+			},
+		},
+		{
+			Role:       "tool",
+			Content:    "42",
+			Name:       "calculator",
+			ToolCallID: "call_123",
+		},
+	}
+
+	chatMsgs, err := convertMessages(messages)
+	if err != nil {
+		t.Fatalf("convertMessages() error = %v", err)
+	}
+
+	if len(chatMsgs) != 4 {
+		t.Fatalf("len(chatMsgs) = %d, want 4", len(chatMsgs))
+	}
+
+	if chatMsgs[0].Role != "system" {
+		t.Errorf("chatMsgs[0].Role = %q, want \"system\"", chatMsgs[0].Role)
+	}
+
+	if len(chatMsgs[2].ToolCalls) != 1 {
+		t.Fatalf("len(chatMsgs[2].ToolCalls) = %d, want 1", len(chatMsgs[2].ToolCalls))
+	}
+	if chatMsgs[2].ToolCalls[0].Function.Arguments != `{"a":1}` {
+		t.Errorf("Arguments = %q, want %q", chatMsgs[2].ToolCalls[0].Function.Arguments, `{"a":1}`)
+	}
+
+	if chatMsgs[3].ToolCallID != "call_123" {
+		t.Errorf("chatMsgs[3].ToolCallID = %q, want \"call_123\"", chatMsgs[3].ToolCallID)
+	}
+}
+
+func TestConvertMessagesWithParts(t *testing.T) {
+	messages := []core.Message{
+		core.UserMessageWithParts("Describe these",
+			core.ImageURLPart("https://example.com/cat.png"),
+			core.ImagePart([]byte("png"), "image/png"),
+			core.DocumentPart([]byte("pdf"), "application/pdf"),
+		),
+	}
+
+	chatMsgs, err := convertMessages(messages)
+	if err != nil {
+		t.Fatalf("convertMessages() error = %v", err)
+	}
+
+	parts, ok := chatMsgs[0].Content.([]ContentPart)
+	if !ok {
+		t.Fatalf("Content type = %T, want []ContentPart", chatMsgs[0].Content)
+	}
+	if len(parts) != 4 {
+		t.Fatalf("len(parts) = %d, want 4", len(parts))
+	}
+
+	if parts[0].Type != "text" || parts[0].Text != "Describe these" {
+		t.Errorf("parts[0] = %+v, want text part", parts[0])
+	}
+	if parts[1].ImageURL == nil || parts[1].ImageURL.URL != "https://example.com/cat.png" {
+		t.Errorf("parts[1] = %+v, want image URL", parts[1])
+	}
+	if parts[2].ImageURL == nil || parts[2].ImageURL.URL != "data:image/png;base64,cG5n" {
+		t.Errorf("parts[2] = %+v, want data URL", parts[2])
+	}
+	if parts[3].Type != "file" || parts[3].File == nil || parts[3].File.FileData != "data:application/pdf;base64,cGRm" {
+		t.Errorf("parts[3] = %+v, want PDF file part", parts[3])
+	}
+}
+
+func TestConvertMessagesUnsupportedPart(t *testing.T) {
+	messages := []core.Message{
+		core.UserMessageWithParts("Listen to this", core.ContentPart{Type: "audio", Data: []byte("wav")}),
+	}
+
+	if _, err := convertMessages(messages); err == nil || !strings.Contains(err.Error(), `"audio"`) {
+		t.Errorf("convertMessages() error = %v, want unsupported part type", err)
+	}
+}
+
+func TestChatWithSchema(t *testing.T) {
+	schema := &core.ResponseSchema{
+		Name: "sentiment",
+		Schema: map[string]interface{}{
+			"type":                 "object",
+			"properties":           map[string]interface{}{"label": map[string]interface{}{"type": "string"}},
+			"required":             []string{"label"},
+			"additionalProperties": false,
+		},
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req ChatCompletionRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatalf("Failed to decode request: %v", err)
+		}
+
+		rf := req.ResponseFormat
+		if rf == nil || rf.Type != "json_schema" || rf.JSONSchema == nil {
+			t.Fatalf("Expected json_schema response format, got %+v", rf)
+		}
+		if rf.JSONSchema.Name != "sentiment" || !rf.JSONSchema.Strict {
+			t.Errorf("Expected strict sentiment schema, got %+v", rf.JSONSchema)
+		}
+
+		json.NewEncoder(w).Encode(ChatCompletionResponse{
+			Choices: []Choice{{Message: &ChatMessage{Role: "assistant", Content: `{"label":"positive"}`}}},
+		})
+	}))
+	defer server.Close()
+
+	client := New(WithAPIKey("test-key"), WithBaseURL(server.URL))
+
+	resp, err := client.ChatWithSchema(context.Background(), []core.Message{core.UserMessage("I love it")}, schema)
+	if err != nil {
+		t.Fatalf("ChatWithSchema failed: %v", err)
+	}
+
+	if resp.Content != `{"label":"positive"}` {
+		t.Errorf("Content = %q", resp.Content)
+	}
+}
+
+func TestIsStrictSchema(t *testing.T) {
+	type optional struct {
+		Name  string `json:"name"`
+		Email string `json:"email,omitempty"`
+	}
+	type nested struct {
+		Items []struct {
+			ID int `json:"id"`
+		} `json:"items"`
+	}
+	type withMap struct {
+		Values map[string]int `json:"values"`
+	}
+
+	tests := []struct {
+		name string
+		v    interface{}
+		want bool
+	}{
+		{"all required", nested{}, true},
+		{"optional field", optional{}, false},
+		{"open map", withMap{}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schema, err := core.JSONSchemaFor(tt.v)
+			if err != nil {
+				t.Fatalf("JSONSchemaFor failed: %v", err)
+			}
+			if got := isStrictSchema(schema); got != tt.want {
+				t.Errorf("isStrictSchema() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestChatCallOptions(t *testing.T) {
+	var requests []ChatCompletionRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req ChatCompletionRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatalf("Failed to decode request: %v", err)
+		}
+		requests = append(requests, req)
+
+		json.NewEncoder(w).Encode(ChatCompletionResponse{
+			Choices: []Choice{{Message: &ChatMessage{Role: "assistant", Content: "ok"}}},
+		})
+	}))
+	defer server.Close()
+
+	client := New(WithAPIKey("test-key"), WithBaseURL(server.URL))
+	messages := []core.Message{core.UserMessage("Hi")}
+
+	_, err := client.Chat(context.Background(), messages,
+		core.WithTemperature(0),
+		core.WithMaxTokens(64),
+		core.WithStop("END"),
+		core.WithSeed(7),
+		core.WithResponseFormat(core.ResponseFormat{Type: core.ResponseFormatJSON}),
+		core.WithMetadata(map[string]string{"user": "u1"}),
+	)
+	if err != nil {
+		t.Fatalf("Chat failed: %v", err)
+	}
+
+	// Options must only apply to the call they were passed to
+	if _, err := client.Chat(context.Background(), messages); err != nil {
+		t.Fatalf("Chat failed: %v", err)
+	}
+
+	req := requests[0]
+	if req.Temperature == nil || *req.Temperature != 0 {
+		t.Errorf("Temperature = %v, want 0", req.Temperature)
+	}
+	if req.MaxTokens == nil || *req.MaxTokens != 64 {
+		t.Errorf("MaxTokens = %v, want 64", req.MaxTokens)
+	}
+	if len(req.Stop) != 1 || req.Stop[0] != "END" {
+		t.Errorf("Stop = %v, want [END]", req.Stop)
+	}
+	if req.Seed == nil || *req.Seed != 7 {
+		t.Errorf("Seed = %v, want 7", req.Seed)
+	}
+	if req.ResponseFormat == nil || req.ResponseFormat.Type != "json_object" {
+		t.Errorf("ResponseFormat = %+v, want json_object", req.ResponseFormat)
+	}
+	if req.Metadata["user"] != "u1" {
+		t.Errorf("Metadata = %v, want user=u1", req.Metadata)
+	}
+
+	req = requests[1]
+	if req.Temperature != nil || req.MaxTokens != nil || req.Seed != nil || req.ResponseFormat != nil {
+		t.Errorf("Expected no options on second call, got %+v", req)
+	}
+}
+
+func TestChatWithToolsToolChoice(t *testing.T) {
+	var toolChoice interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req ChatCompletionRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatalf("Failed to decode request: %v", err)
+		}
+		toolChoice = req.ToolChoice
+
+		json.NewEncoder(w).Encode(ChatCompletionResponse{
+			Choices: []Choice{{Message: &ChatMessage{Role: "assistant", Content: "ok"}}},
+		})
+	}))
+	defer server.Close()
+
+	client := New(WithAPIKey("test-key"), WithBaseURL(server.URL))
+	tools := []core.Tool{mocks.NewMockTool("calculator", "Performs arithmetic")}
+	messages := []core.Message{core.UserMessage("2+3?")}
+
+	tests := []struct {
+		choice string
+		want   string
+	}{
+		{core.ToolChoiceNone, `"none"`},
+		{core.ToolChoiceRequired, `"required"`},
+		{"calculator", `{"function":{"name":"calculator"},"type":"function"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.choice, func(t *testing.T) {
+			if _, err := client.ChatWithTools(context.Background(), messages, tools, core.WithToolChoice(tt.choice)); err != nil {
+				t.Fatalf("ChatWithTools failed: %v", err)
+			}
+
+			got, _ := json.Marshal(toolChoice)
+			if string(got) != tt.want {
+				t.Errorf("tool_choice = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestChatStreamCallOptions(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req ChatCompletionRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatalf("Failed to decode request: %v", err)
+		}
+
+		if req.Temperature == nil || *req.Temperature != 1.2 {
+			t.Errorf("Temperature = %v, want 1.2", req.Temperature)
+		}
+		if req.MaxTokens == nil || *req.MaxTokens != 10 {
+			t.Errorf("MaxTokens = %v, want 10", req.MaxTokens)
+		}
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "data: {\"choices\":[{\"delta\":{\"content\":\"Hi\"},\"finish_reason\":\"stop\"}]}\n\n")
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+	defer server.Close()
+
+	client := New(WithAPIKey("test-key"), WithBaseURL(server.URL))
+
+	stream, err := client.ChatStream(context.Background(), []core.Message{core.UserMessage("Hi")},
+		core.WithTemperature(1.2), core.WithMaxTokens(10))
+	if err != nil {
+		t.Fatalf("ChatStream failed: %v", err)
+	}
+
+	var content string
+	for chunk := range stream {
+		if chunk.Error != nil {
+			t.Fatalf("Stream error: %v", chunk.Error)
+		}
+		content = chunk.Content
+	}
+
+	if content != "Hi" {
+		t.Errorf("Content = %q, want Hi", content)
+	}
+}
+
+func TestChatUsage(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(ChatCompletionResponse{
+			Model:   "gpt-4o-mini",
+			Choices: []Choice{{Message: &ChatMessage{Role: "assistant", Content: "ok"}}},
+			Usage:   &Usage{PromptTokens: 12, CompletionTokens: 3, TotalTokens: 15},
+		})
+	}))
+	defer server.Close()
+
+	client := New(WithAPIKey("test-key"), WithBaseURL(server.URL))
+
+	resp, err := client.Chat(context.Background(), []core.Message{core.UserMessage("Hi")})
+	if err != nil {
+		t.Fatalf("Chat failed: %v", err)
+	}
+
+	if resp.Usage == nil || *resp.Usage != (core.Usage{PromptTokens: 12, CompletionTokens: 3, TotalTokens: 15}) {
+		t.Errorf("Usage = %+v, want 12/3/15", resp.Usage)
+	}
+}
+
+func TestChatStreamUsage(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req ChatCompletionRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatalf("Failed to decode request: %v", err)
+		}
+		if req.StreamOptions == nil || !req.StreamOptions.IncludeUsage {
+			t.Errorf("Expected stream_options.include_usage, got %+v", req.StreamOptions)
+		}
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "data: {\"choices\":[{\"delta\":{\"content\":\"Hi\"}}]}\n\n")
+		fmt.Fprint(w, "data: {\"choices\":[{\"delta\":{},\"finish_reason\":\"stop\"}]}\n\n")
+		fmt.Fprint(w, "data: {\"choices\":[],\"usage\":{\"prompt_tokens\":5,\"completion_tokens\":1,\"total_tokens\":6}}\n\n")
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+	defer server.Close()
+
+	client := New(WithAPIKey("test-key"), WithBaseURL(server.URL))
+
+	stream, err := client.ChatStream(context.Background(), []core.Message{core.UserMessage("Hi")})
+	if err != nil {
+		t.Fatalf("ChatStream failed: %v", err)
+	}
+
+	var chunks []core.StreamChunk
+	for chunk := range stream {
+		if chunk.Error != nil {
+			t.Fatalf("Stream error: %v", chunk.Error)
+		}
+		chunks = append(chunks, chunk)
+	}
+
+	if len(chunks) != 2 {
+		t.Fatalf("Expected 2 chunks, got %d", len(chunks))
+	}
+	if chunks[0].Usage != nil {
+		t.Errorf("Expected no usage before the final chunk, got %+v", chunks[0].Usage)
+	}
+
+	final := chunks[1]
+	if final.FinishReason != "stop" || final.Content != "Hi" {
+		t.Errorf("Final chunk = %+v, want stop with content Hi", final)
+	}
+	if final.Usage == nil || final.Usage.TotalTokens != 6 || final.Usage.PromptTokens != 5 {
+		t.Errorf("Final chunk usage = %+v, want 5/1/6", final.Usage)
+	}
+}
+
+func TestConvertToolsStrict(t *testing.T) {
+	orders := mocks.NewMockTool("create_orders", "Creates orders").WithSchema(&core.ToolSchema{
+		Name: "create_orders",
+		Parameters: []core.Parameter{
+			{Name: "orders", Type: "array", Required: true, Items: &core.Parameter{
+				Type: "object",
+				Properties: []core.Parameter{
+					{Name: "sku", Type: "string", Required: true},
+					{Name: "quantity", Type: "integer", Required: true, Minimum: core.Ptr(1.0)},
+				},
+			}},
+		},
+	})
+	optional := mocks.NewMockTool("search", "Searches").WithSchema(&core.ToolSchema{
+		Name: "search",
+		Parameters: []core.Parameter{
+			{Name: "query", Type: "string", Required: true},
+			{Name: "limit", Type: "integer"},
+		},
+	})
+	untypedArray := mocks.NewMockTool("tag", "Tags").WithSchema(&core.ToolSchema{
+		Name:       "tag",
+		Parameters: []core.Parameter{{Name: "tags", Type: "array", Required: true}},
+	})
+
+	tools := convertTools([]core.Tool{orders, optional, untypedArray})
+	if len(tools) != 3 {
+		t.Fatalf("Expected 3 tools, got %d", len(tools))
+	}
+
+	if !tools[0].Function.Strict {
+		t.Error("Expected strict mode for a fully described schema")
+	}
+	items := tools[0].Function.Parameters["properties"].(map[string]interface{})["orders"].(map[string]interface{})["items"].(map[string]interface{})
+	if items["additionalProperties"] != false || items["properties"] == nil {
+		t.Errorf("Expected closed nested object schema, got %v", items)
+	}
+
+	if tools[1].Function.Strict {
+		t.Error("Expected non-strict mode for a schema with optional parameters")
+	}
+	if tools[2].Function.Strict {
+		t.Error("Expected non-strict mode for an array without item type")
+	}
+}
+
+func TestEmbed(t *testing.T) {
+	var got EmbeddingRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&got)
+		// Out of order, to check the results are placed by index
+		fmt.Fprint(w, `{"object":"list","data":[`+
+			`{"object":"embedding","embedding":[0.3,0.4],"index":1},`+
+			`{"object":"embedding","embedding":[0.1,0.2],"index":0}]}`)
+	}))
+	defer server.Close()
+
+	client := New(WithAPIKey("test-key"), WithBaseURL(server.URL), WithEmbeddingModel("text-embedding-3-small"))
+
+	var embedder core.Embedder = client
+	vectors, err := embedder.Embed(context.Background(), []string{"a", "b"})
+	if err != nil {
+		t.Fatalf("Embed failed: %v", err)
+	}
+
+	if got.Model != "text-embedding-3-small" {
+		t.Errorf("Model = %q, want text-embedding-3-small", got.Model)
+	}
+	if inputs, ok := got.Input.([]interface{}); !ok || len(inputs) != 2 {
+		t.Errorf("Input = %v, want both texts", got.Input)
+	}
+	if len(vectors) != 2 || vectors[0][0] != 0.1 || vectors[1][0] != 0.3 {
+		t.Errorf("Embed() = %v, want vectors in input order", vectors)
+	}
+
+	if _, err := client.Embed(context.Background(), []string{"a", "b", "c"}); err == nil {
+		t.Error("Embed() with a missing embedding expected error, got nil")
+	}
+}
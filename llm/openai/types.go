@@ -25,6 +25,7 @@ MAIN COMPONENTS:
 - EmbeddingRequest/Response: Embeddings API types
 - ModerationRequest/Response: Content moderation types
 - StreamOptions: Streaming configuration with callbacks
+- AzureConfig: Azure OpenAI deployment routing (see WithAzure)
 
 USAGE PATTERNS:
 1. Basic chat: client.Complete(ctx, "prompt")
@@ -35,7 +36,10 @@ USAGE PATTERNS:
 */
 package openai
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+)
 
 // ChatCompletionRequest represents a request to the chat completions API.
 //
@@ -54,6 +58,7 @@ type ChatCompletionRequest struct {
 	TopP             *float64           `json:"top_p,omitempty"`
 	N                *int               `json:"n,omitempty"`
 	Stream           bool               `json:"stream,omitempty"`
+	StreamOptions    *ChatStreamOptions `json:"stream_options,omitempty"`
 	Stop             []string           `json:"stop,omitempty"`
 	PresencePenalty  *float64           `json:"presence_penalty,omitempty"`
 	FrequencyPenalty *float64           `json:"frequency_penalty,omitempty"`
@@ -65,10 +70,16 @@ type ChatCompletionRequest struct {
 	ToolChoice       interface{}        `json:"tool_choice,omitempty"` // string or object
 	Functions        []Function         `json:"functions,omitempty"`
 	FunctionCall     interface{}        `json:"function_call,omitempty"` // string or object
+	Metadata         map[string]string  `json:"metadata,omitempty"`
 	ReasoningEffort  string             `json:"reasoning_effort,omitempty"`
 	Verbosity        string             `json:"verbosity,omitempty"`
 }
 
+// ChatStreamOptions configures streamed chat completions.
+type ChatStreamOptions struct {
+	IncludeUsage bool `json:"include_usage"` // Send a final chunk with token usage
+}
+
 // ChatMessage represents a message in a conversation.
 type ChatMessage struct {
 	Role         string        `json:"role"`              // system, user, assistant, tool, function
@@ -79,11 +90,18 @@ type ChatMessage struct {
 	FunctionCall *FunctionCall `json:"function_call,omitempty"`
 }
 
-// ContentPart represents a part of message content (text or image).
+// ContentPart represents a part of message content (text, image or file).
 type ContentPart struct {
-	Type     string    `json:"type"` // text, image_url
+	Type     string    `json:"type"` // text, image_url, file
 	Text     string    `json:"text,omitempty"`
 	ImageURL *ImageURL `json:"image_url,omitempty"`
+	File     *File     `json:"file,omitempty"`
+}
+
+// File represents an inline file (e.g. a PDF) in a message.
+type File struct {
+	Filename string `json:"filename,omitempty"`
+	FileData string `json:"file_data"` // data URL: data:<mime>;base64,<data>
 }
 
 // ImageURL represents an image URL in a message.
@@ -94,7 +112,16 @@ type ImageURL struct {
 
 // ResponseFormat specifies the format of the response.
 type ResponseFormat struct {
-	Type string `json:"type"` // text, json_object, json_schema
+	Type       string            `json:"type"`                  // text, json_object, json_schema
+	JSONSchema *JSONSchemaFormat `json:"json_schema,omitempty"` // required when Type is json_schema
+}
+
+// JSONSchemaFormat describes a structured output schema for json_schema responses.
+type JSONSchemaFormat struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description,omitempty"`
+	Schema      map[string]interface{} `json:"schema"`
+	Strict      bool                   `json:"strict,omitempty"`
 }
 
 // Tool represents a tool that the model can call.
@@ -124,6 +151,29 @@ type FunctionCall struct {
 	Arguments string `json:"arguments"`
 }
 
+// UnmarshalJSON accepts arguments sent as a JSON object as well as the
+// JSON-encoded string OpenAI uses, since some compatible servers send objects.
+func (f *FunctionCall) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		Name      string          `json:"name"`
+		Arguments json.RawMessage `json:"arguments"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	f.Name = raw.Name
+	f.Arguments = ""
+	if len(raw.Arguments) == 0 || string(raw.Arguments) == "null" {
+		return nil
+	}
+	if raw.Arguments[0] == '"' {
+		return json.Unmarshal(raw.Arguments, &f.Arguments)
+	}
+	f.Arguments = string(raw.Arguments)
+	return nil
+}
+
 // ChatCompletionResponse represents a response from the chat completions API.
 type ChatCompletionResponse struct {
 	ID                string   `json:"id"`
@@ -133,6 +183,8 @@ type ChatCompletionResponse struct {
 	Choices           []Choice `json:"choices"`
 	Usage             *Usage   `json:"usage,omitempty"`
 	SystemFingerprint string   `json:"system_fingerprint,omitempty"`
+
+	PromptFilterResults []PromptFilterResult `json:"prompt_filter_results,omitempty"` // Azure only
 }
 
 // Choice represents a completion choice.
@@ -142,6 +194,32 @@ type Choice struct {
 	Delta        *ChatMessage `json:"delta,omitempty"` // For streaming
 	FinishReason string       `json:"finish_reason,omitempty"`
 	LogProbs     *LogProbs    `json:"logprobs,omitempty"`
+
+	ContentFilterResults *ContentFilterResults `json:"content_filter_results,omitempty"` // Azure only
+}
+
+// PromptFilterResult is Azure's content filter verdict for one prompt.
+type PromptFilterResult struct {
+	PromptIndex          int                   `json:"prompt_index"`
+	ContentFilterResults *ContentFilterResults `json:"content_filter_results,omitempty"`
+}
+
+// ContentFilterResults holds Azure's content filter verdicts per category.
+type ContentFilterResults struct {
+	Hate                  *ContentFilterResult `json:"hate,omitempty"`
+	SelfHarm              *ContentFilterResult `json:"self_harm,omitempty"`
+	Sexual                *ContentFilterResult `json:"sexual,omitempty"`
+	Violence              *ContentFilterResult `json:"violence,omitempty"`
+	Jailbreak             *ContentFilterResult `json:"jailbreak,omitempty"`
+	ProtectedMaterialText *ContentFilterResult `json:"protected_material_text,omitempty"`
+	ProtectedMaterialCode *ContentFilterResult `json:"protected_material_code,omitempty"`
+}
+
+// ContentFilterResult is the verdict for one content filter category.
+type ContentFilterResult struct {
+	Filtered bool   `json:"filtered"`
+	Severity string `json:"severity,omitempty"` // safe, low, medium, high
+	Detected *bool  `json:"detected,omitempty"` // jailbreak and protected material only
 }
 
 // LogProbs represents log probabilities.
@@ -169,7 +247,10 @@ type ChatCompletionStreamResponse struct {
 	Created           int64    `json:"created"`
 	Model             string   `json:"model"`
 	Choices           []Choice `json:"choices"`
+	Usage             *Usage   `json:"usage,omitempty"` // Only on the last chunk, with include_usage
 	SystemFingerprint string   `json:"system_fingerprint,omitempty"`
+
+	PromptFilterResults []PromptFilterResult `json:"prompt_filter_results,omitempty"` // Azure only
 }
 
 // EmbeddingRequest represents a request to the embeddings API.
@@ -245,10 +326,24 @@ type ErrorResponse struct {
 
 // APIError represents an API error.
 type APIError struct {
-	Message string      `json:"message"`
-	Type    string      `json:"type"`
-	Param   interface{} `json:"param"`
-	Code    interface{} `json:"code"`
+	Message    string      `json:"message"`
+	Type       string      `json:"type"`
+	Param      interface{} `json:"param"`
+	Code       interface{} `json:"code"`
+	InnerError *InnerError `json:"innererror,omitempty"` // Azure only
+}
+
+// InnerError carries Azure's error details, such as content filter results.
+type InnerError struct {
+	Code                string                `json:"code"`
+	ContentFilterResult *ContentFilterResults `json:"content_filter_result,omitempty"`
+}
+
+// gatewayError is the error body returned by Azure API Management gateways,
+// e.g. for invalid keys or exceeded quotas.
+type gatewayError struct {
+	StatusCode int    `json:"statusCode"`
+	Message    string `json:"message"`
 }
 
 // StreamOptions configures streaming behavior.
@@ -0,0 +1,211 @@
+/*
+Package openaicompat provides a client for self-hosted and third-party servers
+that speak the OpenAI /v1/chat/completions dialect (vLLM, LM Studio, llama.cpp
+server, LocalAI, TGI, LiteLLM and similar gateways).
+
+WHY THIS EXISTS:
+- These servers implement most of the OpenAI API but not all of its assumptions
+- Auth is often optional or uses another header; model names are whatever the server loaded
+- Usage reporting and SSE framing vary between implementations
+
+KEY DESIGN DECISIONS:
+- Wraps openai.Client: conversion, tool calling and streaming fixes apply to every server
+- No default model: Connect discovers it via /v1/models when WithModel is not given
+- Optional auth: no auth header is sent without an API key
+- Errors are reported under the provider name "openaicompat" (see WithProviderName)
+
+USAGE:
+
+	client, err := openaicompat.Connect(ctx, "http://localhost:8000/v1")
+	if err != nil {
+		log.Fatal(err)
+	}
+	agent := agent.NewFunctionAgent(client)
+*/
+package openaicompat
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/yashrahurikar23/goagents/core"
+	"github.com/yashrahurikar23/goagents/llm/openai"
+)
+
+// DefaultProviderName is the provider name reported in errors.
+const DefaultProviderName = "openaicompat"
+
+// Client is a chat client for an OpenAI-compatible server.
+//
+// It embeds *openai.Client, so it implements core.LLM, core.ToolCallingLLM,
+// core.StructuredOutputLLM and core.StreamingLLM, and exposes the lower-level
+// endpoints (CreateChatCompletion, CreateEmbedding, ListModels).
+type Client struct {
+	*openai.Client
+
+	model    string
+	provider string
+	opts     []openai.Option
+}
+
+// config collects the options of a client before it is built.
+type config struct {
+	model    string
+	provider string
+	opts     []openai.Option
+}
+
+// Option is a functional option for configuring the Client.
+type Option func(*config)
+
+// WithAPIKey sets the API key. Without one, no auth header is sent.
+func WithAPIKey(apiKey string) Option {
+	return func(c *config) {
+		c.opts = append(c.opts, openai.WithAPIKey(apiKey))
+	}
+}
+
+// WithModel sets the model name sent with every request.
+func WithModel(model string) Option {
+	return func(c *config) {
+		c.model = model
+	}
+}
+
+// WithHeader adds a header sent with every request.
+func WithHeader(key, value string) Option {
+	return func(c *config) {
+		c.opts = append(c.opts, openai.WithHeader(key, value))
+	}
+}
+
+// WithHeaders adds several headers sent with every request.
+func WithHeaders(headers map[string]string) Option {
+	return func(c *config) {
+		for key, value := range headers {
+			c.opts = append(c.opts, openai.WithHeader(key, value))
+		}
+	}
+}
+
+// WithAuthHeader sets the header that carries the API key and its scheme,
+// e.g. WithAuthHeader("X-API-Key", "") for gateways that do not use Bearer auth.
+func WithAuthHeader(header, scheme string) Option {
+	return func(c *config) {
+		c.opts = append(c.opts, openai.WithAuthHeader(header, scheme))
+	}
+}
+
+// WithHTTPClient sets a custom HTTP client.
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *config) {
+		c.opts = append(c.opts, openai.WithHTTPClient(httpClient))
+	}
+}
+
+// WithTimeout sets the HTTP timeout.
+func WithTimeout(timeout time.Duration) Option {
+	return func(c *config) {
+		c.opts = append(c.opts, openai.WithTimeout(timeout))
+	}
+}
+
+// WithMaxRetries sets the maximum number of retries.
+func WithMaxRetries(maxRetries int) Option {
+	return func(c *config) {
+		c.opts = append(c.opts, openai.WithMaxRetries(maxRetries))
+	}
+}
+
+// WithStreamUsage controls whether streaming requests send
+// stream_options.include_usage. Disable it for servers that reject the field.
+func WithStreamUsage(enabled bool) Option {
+	return func(c *config) {
+		c.opts = append(c.opts, openai.WithStreamUsage(enabled))
+	}
+}
+
+// WithProviderName sets the provider name reported in errors, e.g. "vllm".
+func WithProviderName(name string) Option {
+	return func(c *config) {
+		c.provider = name
+	}
+}
+
+// New creates a client for the server at baseURL (including the /v1 prefix,
+// e.g. "http://localhost:8000/v1").
+//
+// No model is set unless WithModel is given; servers that serve a single model
+// accept that. Use Connect to discover the model from /v1/models instead.
+func New(baseURL string, opts ...Option) *Client {
+	cfg := config{provider: DefaultProviderName}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	return build(baseURL, cfg.model, cfg.provider, cfg.opts)
+}
+
+// Connect creates a client and, if no model was configured, selects the first
+// model listed by the server's /v1/models endpoint.
+//
+// WHY: Self-hosted servers name models after whatever was loaded (file paths,
+// Hugging Face repos), so hardcoding a name is brittle.
+func Connect(ctx context.Context, baseURL string, opts ...Option) (*Client, error) {
+	c := New(baseURL, opts...)
+	if c.model != "" {
+		return c, nil
+	}
+
+	models, err := c.Models(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(models) == 0 {
+		return nil, &core.ErrLLMFailure{
+			Provider: c.provider,
+			Err:      fmt.Errorf("no models available at %s", baseURL),
+		}
+	}
+
+	return build(baseURL, models[0], c.provider, c.opts), nil
+}
+
+// build creates the underlying openai.Client.
+// User options are applied after the compatibility defaults so they win.
+func build(baseURL, model, provider string, opts []openai.Option) *Client {
+	all := append([]openai.Option{
+		openai.WithBaseURL(baseURL),
+		openai.WithModel(model),
+		openai.WithProviderName(provider),
+	}, opts...)
+
+	return &Client{
+		Client:   openai.New(all...),
+		model:    model,
+		provider: provider,
+		opts:     opts,
+	}
+}
+
+// Model returns the model name sent with requests ("" if none).
+func (c *Client) Model() string {
+	return c.model
+}
+
+// Models returns the IDs of the models served, as listed by /v1/models.
+func (c *Client) Models(ctx context.Context) ([]string, error) {
+	resp, err := c.ListModels(ctx)
+	if err != nil {
+		return nil, &core.ErrLLMFailure{Provider: c.provider, Err: err}
+	}
+
+	ids := make([]string, 0, len(resp.Data))
+	for _, m := range resp.Data {
+		if m.ID != "" {
+			ids = append(ids, m.ID)
+		}
+	}
+	return ids, nil
+}
@@ -0,0 +1,332 @@
+package openaicompat
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/yashrahurikar23/goagents/core"
+	"github.com/yashrahurikar23/goagents/llm/openai"
+	"github.com/yashrahurikar23/goagents/tests/mocks"
+)
+
+var (
+	_ core.LLM                 = (*Client)(nil)
+	_ core.ToolCallingLLM      = (*Client)(nil)
+	_ core.StructuredOutputLLM = (*Client)(nil)
+	_ core.StreamingLLM        = (*Client)(nil)
+)
+
+// newServer starts a stand-in for an OpenAI-compatible server.
+// It serves /v1/models and hands chat requests to the given handler.
+func newServer(t *testing.T, models []string, chat http.HandlerFunc) *httptest.Server {
+	t.Helper()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/models", func(w http.ResponseWriter, r *http.Request) {
+		resp := openai.ModelListResponse{Object: "list"}
+		for _, id := range models {
+			resp.Data = append(resp.Data, openai.Model{ID: id, Object: "model"})
+		}
+		json.NewEncoder(w).Encode(resp)
+	})
+	if chat != nil {
+		mux.HandleFunc("/v1/chat/completions", chat)
+	}
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestNewDefaults(t *testing.T) {
+	client := New("http://localhost:8000/v1")
+
+	if client.Model() != "" {
+		t.Errorf("Model() = %q, want no default model", client.Model())
+	}
+}
+
+func TestChatHeadersAndOptionalAuth(t *testing.T) {
+	tests := []struct {
+		name       string
+		opts       []Option
+		wantHeader string
+		wantValue  string
+	}{
+		{
+			name: "no api key sends no auth",
+			opts: nil,
+		},
+		{
+			name:       "bearer auth",
+			opts:       []Option{WithAPIKey("secret")},
+			wantHeader: "Authorization",
+			wantValue:  "Bearer secret",
+		},
+		{
+			name:       "custom auth header",
+			opts:       []Option{WithAPIKey("secret"), WithAuthHeader("X-API-Key", "")},
+			wantHeader: "X-API-Key",
+			wantValue:  "secret",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newServer(t, nil, func(w http.ResponseWriter, r *http.Request) {
+				if tt.wantHeader == "" {
+					if auth := r.Header.Get("Authorization"); auth != "" {
+						t.Errorf("Expected no Authorization header, got %q", auth)
+					}
+				} else if got := r.Header.Get(tt.wantHeader); got != tt.wantValue {
+					t.Errorf("%s = %q, want %q", tt.wantHeader, got, tt.wantValue)
+				}
+				if tt.wantHeader != "Authorization" && r.Header.Get("Authorization") != "" {
+					t.Errorf("Unexpected Authorization header %q", r.Header.Get("Authorization"))
+				}
+
+				if r.Header.Get("X-Tenant") != "acme" || r.Header.Get("X-Route") != "gpu-1" {
+					t.Errorf("Custom headers missing: %v", r.Header)
+				}
+
+				fmt.Fprint(w, `{"model":"local","choices":[{"message":{"role":"assistant","content":"ok"},"finish_reason":"stop"}]}`)
+			})
+
+			opts := append([]Option{
+				WithModel("local"),
+				WithHeader("X-Tenant", "acme"),
+				WithHeaders(map[string]string{"X-Route": "gpu-1"}),
+			}, tt.opts...)
+			client := New(server.URL+"/v1", opts...)
+
+			resp, err := client.Chat(context.Background(), []core.Message{core.UserMessage("Hi")})
+			if err != nil {
+				t.Fatalf("Chat failed: %v", err)
+			}
+			if resp.Content != "ok" {
+				t.Errorf("Content = %q, want ok", resp.Content)
+			}
+		})
+	}
+}
+
+func TestConnectDiscoversModel(t *testing.T) {
+	server := newServer(t, []string{"meta-llama/Llama-3.1-8B-Instruct", "other"}, func(w http.ResponseWriter, r *http.Request) {
+		var req openai.ChatCompletionRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		if req.Model != "meta-llama/Llama-3.1-8B-Instruct" {
+			t.Errorf("Model = %q, want the discovered model", req.Model)
+		}
+		fmt.Fprint(w, `{"choices":[{"message":{"role":"assistant","content":"hi"}}]}`)
+	})
+
+	client, err := Connect(context.Background(), server.URL+"/v1")
+	if err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	if client.Model() != "meta-llama/Llama-3.1-8B-Instruct" {
+		t.Errorf("Model() = %q", client.Model())
+	}
+
+	models, err := client.Models(context.Background())
+	if err != nil || len(models) != 2 {
+		t.Fatalf("Models() = %v, %v; want 2 models", models, err)
+	}
+
+	if _, err := client.Chat(context.Background(), []core.Message{core.UserMessage("Hi")}); err != nil {
+		t.Fatalf("Chat failed: %v", err)
+	}
+}
+
+func TestConnectKeepsConfiguredModel(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("Unexpected request to %s", r.URL.Path)
+	}))
+	defer server.Close()
+
+	client, err := Connect(context.Background(), server.URL+"/v1", WithModel("mine"))
+	if err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	if client.Model() != "mine" {
+		t.Errorf("Model() = %q, want mine", client.Model())
+	}
+}
+
+func TestConnectNoModels(t *testing.T) {
+	server := newServer(t, nil, nil)
+
+	_, err := Connect(context.Background(), server.URL+"/v1", WithProviderName("vllm"))
+
+	var llmErr *core.ErrLLMFailure
+	if !errors.As(err, &llmErr) {
+		t.Fatalf("Expected ErrLLMFailure, got %v", err)
+	}
+	if llmErr.Provider != "vllm" {
+		t.Errorf("Provider = %q, want vllm", llmErr.Provider)
+	}
+}
+
+func TestChatMissingUsage(t *testing.T) {
+	server := newServer(t, nil, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"choices":[{"message":{"role":"assistant","content":"no usage here"},"finish_reason":"stop"}]}`)
+	})
+
+	client := New(server.URL + "/v1")
+
+	resp, err := client.Chat(context.Background(), []core.Message{core.UserMessage("Hi")})
+	if err != nil {
+		t.Fatalf("Chat failed: %v", err)
+	}
+	if resp.Content != "no usage here" || resp.Usage != nil {
+		t.Errorf("Chat() = %+v, want content and nil usage", resp)
+	}
+}
+
+func TestChatWithToolsObjectArguments(t *testing.T) {
+	server := newServer(t, nil, func(w http.ResponseWriter, r *http.Request) {
+		// Some servers send arguments as an object instead of a JSON string
+		fmt.Fprint(w, `{"choices":[{"message":{"role":"assistant","tool_calls":[`+
+			`{"id":"call_1","type":"function","function":{"name":"calculator","arguments":{"a":2,"b":3}}}]},`+
+			`"finish_reason":"tool_calls"}]}`)
+	})
+
+	client := New(server.URL + "/v1")
+	tool := mocks.NewMockTool("calculator", "Adds numbers")
+
+	resp, err := client.ChatWithTools(context.Background(), []core.Message{core.UserMessage("2+3?")}, []core.Tool{tool})
+	if err != nil {
+		t.Fatalf("ChatWithTools failed: %v", err)
+	}
+	if len(resp.ToolCalls) != 1 || resp.ToolCalls[0].Args["b"] != 3.0 {
+		t.Errorf("ToolCalls = %+v", resp.ToolCalls)
+	}
+}
+
+func TestChatStreamNonStandardFrames(t *testing.T) {
+	server := newServer(t, nil, func(w http.ResponseWriter, r *http.Request) {
+		var req openai.ChatCompletionRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		if req.StreamOptions != nil {
+			t.Errorf("Expected no stream_options with WithStreamUsage(false), got %+v", req.StreamOptions)
+		}
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		frames := []string{
+			": keep-alive\n\n",
+			"event: message\n",
+			"data:{\"choices\":[{\"delta\":{\"role\":\"assistant\"}}]}\n\n",
+			"data: \n\n",
+			"data: {\"choices\":[{\"delta\":{\"content\":\"Hel\"}}]}\r\n\r\n",
+			"{\"choices\":[{\"delta\":{\"content\":\"lo\"}}]}\n",
+			"data: ping\n\n",
+			"data: {\"choices\":[{\"delta\":{},\"finish_reason\":\"stop\"}]}\n\n",
+			// No [DONE]: the server just closes the connection
+		}
+		for _, frame := range frames {
+			fmt.Fprint(w, frame)
+		}
+	})
+
+	client := New(server.URL+"/v1", WithStreamUsage(false))
+
+	stream, err := client.ChatStream(context.Background(), []core.Message{core.UserMessage("Hi")})
+	if err != nil {
+		t.Fatalf("ChatStream failed: %v", err)
+	}
+
+	var last core.StreamChunk
+	for chunk := range stream {
+		if chunk.Error != nil {
+			t.Fatalf("Chunk error: %v", chunk.Error)
+		}
+		last = chunk
+	}
+
+	if last.Content != "Hello" || last.FinishReason != "stop" {
+		t.Errorf("Last chunk = %+v, want content Hello with finish_reason stop", last)
+	}
+	if last.Usage != nil {
+		t.Errorf("Usage = %+v, want nil when the server reports none", last.Usage)
+	}
+}
+
+func TestChatStreamUsageWithoutFinishReason(t *testing.T) {
+	server := newServer(t, nil, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "data: {\"choices\":[{\"delta\":{\"content\":\"Hi\"}}]}\n\n")
+		fmt.Fprint(w, "data: {\"choices\":[],\"usage\":{\"prompt_tokens\":3,\"completion_tokens\":1,\"total_tokens\":4}}\n\n")
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	})
+
+	client := New(server.URL + "/v1")
+
+	stream, err := client.ChatStream(context.Background(), []core.Message{core.UserMessage("Hi")})
+	if err != nil {
+		t.Fatalf("ChatStream failed: %v", err)
+	}
+
+	var last core.StreamChunk
+	for chunk := range stream {
+		last = chunk
+	}
+
+	if last.FinishReason != "stop" || last.Content != "Hi" {
+		t.Errorf("Last chunk = %+v, want a synthesized stop chunk", last)
+	}
+	if last.Usage == nil || last.Usage.TotalTokens != 4 {
+		t.Errorf("Usage = %+v, want total 4", last.Usage)
+	}
+}
+
+func TestChatStreamErrorFrame(t *testing.T) {
+	server := newServer(t, nil, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "data: {\"choices\":[{\"delta\":{\"content\":\"partial\"}}]}\n\n")
+		fmt.Fprint(w, "data: {\"error\":{\"message\":\"context length exceeded\",\"type\":\"invalid_request_error\"}}\n\n")
+	})
+
+	client := New(server.URL + "/v1")
+
+	stream, err := client.ChatStream(context.Background(), []core.Message{core.UserMessage("Hi")})
+	if err != nil {
+		t.Fatalf("ChatStream failed: %v", err)
+	}
+
+	var errs []error
+	for chunk := range stream {
+		if chunk.Error != nil {
+			errs = append(errs, chunk.Error)
+		}
+	}
+
+	if len(errs) != 1 {
+		t.Fatalf("Expected exactly one error chunk, got %d: %v", len(errs), errs)
+	}
+	var apiErr *openai.OpenAIError
+	if !errors.As(errs[0], &apiErr) || !strings.Contains(apiErr.Message, "context length") {
+		t.Errorf("Expected OpenAIError with the server message, got %v", errs[0])
+	}
+}
+
+func TestChatErrorProviderName(t *testing.T) {
+	server := newServer(t, nil, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprint(w, `{"error":{"message":"bad model","type":"invalid_request_error"}}`)
+	})
+
+	client := New(server.URL+"/v1", WithProviderName("lmstudio"))
+
+	_, err := client.Chat(context.Background(), []core.Message{core.UserMessage("Hi")})
+
+	var llmErr *core.ErrLLMFailure
+	if !errors.As(err, &llmErr) || llmErr.Provider != "lmstudio" {
+		t.Fatalf("Expected ErrLLMFailure from lmstudio, got %v", err)
+	}
+}
@@ -0,0 +1,230 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/yashrahurikar23/goagents/core"
+)
+
+// CheckpointStore persists the checkpoints of agent runs, keyed by run ID, so
+// a run can be resumed after its process stopped, on the same machine or
+// another one sharing the store. Checkpoints are opaque to the store; agents
+// encode them (see agent.MarshalCheckpoint). Implementations are safe for
+// concurrent use.
+//
+// Bind a store to an agent with agent.WithCheckpointStore,
+// agent.ReActWithCheckpointStore or agent.ConvWithCheckpointStore.
+type CheckpointStore interface {
+	// Save writes the checkpoint of a run, replacing the previous one.
+	Save(ctx context.Context, runID string, data []byte) error
+
+	// Load returns the checkpoint of a run. A run without a checkpoint
+	// has no data (and no error).
+	Load(ctx context.Context, runID string) ([]byte, error)
+
+	// Delete removes the checkpoint of a run, if any.
+	Delete(ctx context.Context, runID string) error
+}
+
+// validateRunID rejects the empty run ID.
+func validateRunID(runID string) error {
+	if runID == "" {
+		return &core.ErrInvalidArgument{Argument: "runID", Reason: "cannot be empty"}
+	}
+	return nil
+}
+
+// InMemoryCheckpointStore keeps checkpoints in process memory. They are lost
+// when the process exits; use it for tests and for pausing runs within one
+// process.
+type InMemoryCheckpointStore struct {
+	mu   sync.RWMutex
+	runs map[string][]byte
+}
+
+// NewInMemoryCheckpointStore creates an empty in-memory checkpoint store.
+func NewInMemoryCheckpointStore() *InMemoryCheckpointStore {
+	return &InMemoryCheckpointStore{runs: make(map[string][]byte)}
+}
+
+// Save stores a copy of the checkpoint.
+func (s *InMemoryCheckpointStore) Save(ctx context.Context, runID string, data []byte) error {
+	if err := validateRunID(runID); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.runs[runID] = append([]byte(nil), data...)
+	return nil
+}
+
+// Load returns a copy of the checkpoint of a run.
+func (s *InMemoryCheckpointStore) Load(ctx context.Context, runID string) ([]byte, error) {
+	if err := validateRunID(runID); err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	data, ok := s.runs[runID]
+	if !ok {
+		return nil, nil
+	}
+	return append([]byte(nil), data...), nil
+}
+
+// Delete removes the checkpoint of a run.
+func (s *InMemoryCheckpointStore) Delete(ctx context.Context, runID string) error {
+	if err := validateRunID(runID); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.runs, runID)
+	return nil
+}
+
+// Runs returns the IDs of the runs with a checkpoint, in no particular order.
+func (s *InMemoryCheckpointStore) Runs() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	ids := make([]string, 0, len(s.runs))
+	for id := range s.runs {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// checkpointExt is the file extension of checkpoints in a FileCheckpointStore.
+const checkpointExt = ".checkpoint.json"
+
+// FileCheckpointStore keeps each checkpoint in a JSON file in a directory.
+// Saves rewrite the file atomically, so a crash never leaves a half-written
+// checkpoint.
+//
+// A FileCheckpointStore is safe for concurrent use within a process. Do not
+// let processes sharing a directory run the same run at the same time.
+type FileCheckpointStore struct {
+	dir string
+	mu  sync.RWMutex
+}
+
+// NewFileCheckpointStore creates a checkpoint store in dir, creating the
+// directory if needed.
+func NewFileCheckpointStore(dir string) (*FileCheckpointStore, error) {
+	if dir == "" {
+		return nil, &core.ErrInvalidArgument{Argument: "dir", Reason: "cannot be empty"}
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create checkpoint directory: %w", err)
+	}
+	return &FileCheckpointStore{dir: dir}, nil
+}
+
+// path returns the file of a run's checkpoint. The ID is escaped, so it
+// cannot name a file outside the directory.
+func (s *FileCheckpointStore) path(runID string) string {
+	return filepath.Join(s.dir, url.QueryEscape(runID)+checkpointExt)
+}
+
+// Save writes the checkpoint to a temporary file and renames it over the
+// previous one.
+func (s *FileCheckpointStore) Save(ctx context.Context, runID string, data []byte) error {
+	if err := validateRunID(runID); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tmp, err := os.CreateTemp(s.dir, ".checkpoint-*")
+	if err != nil {
+		return fmt.Errorf("failed to write checkpoint %s: %w", runID, err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := tmp.Chmod(0o644); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write checkpoint %s: %w", runID, err)
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write checkpoint %s: %w", runID, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write checkpoint %s: %w", runID, err)
+	}
+	if err := os.Rename(tmp.Name(), s.path(runID)); err != nil {
+		return fmt.Errorf("failed to write checkpoint %s: %w", runID, err)
+	}
+	return nil
+}
+
+// Load reads the checkpoint of a run.
+func (s *FileCheckpointStore) Load(ctx context.Context, runID string) ([]byte, error) {
+	if err := validateRunID(runID); err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	data, err := os.ReadFile(s.path(runID))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read checkpoint %s: %w", runID, err)
+	}
+	return data, nil
+}
+
+// Delete removes the checkpoint file of a run.
+func (s *FileCheckpointStore) Delete(ctx context.Context, runID string) error {
+	if err := validateRunID(runID); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := os.Remove(s.path(runID)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to delete checkpoint %s: %w", runID, err)
+	}
+	return nil
+}
+
+// Runs returns the IDs of the runs with a checkpoint, sorted by file name.
+func (s *FileCheckpointStore) Runs() ([]string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list checkpoints: %w", err)
+	}
+
+	var ids []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, checkpointExt) {
+			continue
+		}
+		id, err := url.QueryUnescape(strings.TrimSuffix(name, checkpointExt))
+		if err != nil {
+			continue
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
@@ -0,0 +1,124 @@
+package memory
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+// testCheckpointStore runs the behaviour every CheckpointStore must have.
+func testCheckpointStore(t *testing.T, newStore func(t *testing.T) CheckpointStore) {
+	ctx := context.Background()
+
+	t.Run("unknown run has no checkpoint", func(t *testing.T) {
+		store := newStore(t)
+		data, err := store.Load(ctx, "nobody")
+		if err != nil || data != nil {
+			t.Errorf("Load() = %q, %v, want no data", data, err)
+		}
+	})
+
+	t.Run("save replaces", func(t *testing.T) {
+		store := newStore(t)
+		for _, data := range []string{`{"step":1}`, `{"step":2}`} {
+			if err := store.Save(ctx, "run/1", []byte(data)); err != nil {
+				t.Fatalf("Save() error = %v", err)
+			}
+		}
+		if err := store.Save(ctx, "run-2", []byte(`{}`)); err != nil {
+			t.Fatalf("Save() error = %v", err)
+		}
+
+		data, err := store.Load(ctx, "run/1")
+		if err != nil || string(data) != `{"step":2}` {
+			t.Errorf("Load() = %q, %v, want the last checkpoint", data, err)
+		}
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		store := newStore(t)
+		store.Save(ctx, "r1", []byte(`{}`))
+		if err := store.Delete(ctx, "r1"); err != nil {
+			t.Fatalf("Delete() error = %v", err)
+		}
+		if err := store.Delete(ctx, "r1"); err != nil {
+			t.Errorf("Delete() of a missing checkpoint error = %v", err)
+		}
+		if data, _ := store.Load(ctx, "r1"); data != nil {
+			t.Errorf("Load() after Delete() = %q, want no data", data)
+		}
+	})
+
+	t.Run("empty run ID", func(t *testing.T) {
+		store := newStore(t)
+		if err := store.Save(ctx, "", []byte(`{}`)); err == nil {
+			t.Error("Save() with an empty run ID succeeded")
+		}
+		if _, err := store.Load(ctx, ""); err == nil {
+			t.Error("Load() with an empty run ID succeeded")
+		}
+	})
+}
+
+func TestInMemoryCheckpointStore(t *testing.T) {
+	testCheckpointStore(t, func(t *testing.T) CheckpointStore {
+		return NewInMemoryCheckpointStore()
+	})
+
+	store := NewInMemoryCheckpointStore()
+	data := []byte(`{}`)
+	store.Save(context.Background(), "r1", data)
+	data[0] = 'x'
+	if got, _ := store.Load(context.Background(), "r1"); string(got) != `{}` {
+		t.Errorf("Load() = %q, want the data as saved", got)
+	}
+	if got := store.Runs(); !reflect.DeepEqual(got, []string{"r1"}) {
+		t.Errorf("Runs() = %v, want [r1]", got)
+	}
+}
+
+func TestFileCheckpointStore(t *testing.T) {
+	testCheckpointStore(t, func(t *testing.T) CheckpointStore {
+		store, err := NewFileCheckpointStore(t.TempDir())
+		if err != nil {
+			t.Fatalf("NewFileCheckpointStore() error = %v", err)
+		}
+		return store
+	})
+}
+
+// TestFileCheckpointStore_Reopen tests that checkpoints survive a new store
+// on the same directory and that Runs lists them.
+func TestFileCheckpointStore_Reopen(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	store, _ := NewFileCheckpointStore(dir)
+	store.Save(ctx, "run/1", []byte(`{"step":1}`))
+	store.Save(ctx, "run-2", []byte(`{"step":2}`))
+	os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("ignored"), 0o644)
+
+	reopened, err := NewFileCheckpointStore(dir)
+	if err != nil {
+		t.Fatalf("NewFileCheckpointStore() error = %v", err)
+	}
+	if data, _ := reopened.Load(ctx, "run/1"); string(data) != `{"step":1}` {
+		t.Errorf("Load() = %q, want the saved checkpoint", data)
+	}
+
+	runs, err := reopened.Runs()
+	if err != nil {
+		t.Fatalf("Runs() error = %v", err)
+	}
+	sort.Strings(runs)
+	if !reflect.DeepEqual(runs, []string{"run-2", "run/1"}) {
+		t.Errorf("Runs() = %v, want both runs", runs)
+	}
+
+	if _, err := NewFileCheckpointStore(""); err == nil {
+		t.Error("NewFileCheckpointStore(\"\") succeeded")
+	}
+}
@@ -0,0 +1,186 @@
+package memory
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+// fakeSQLDriver is a database/sql driver that understands exactly the
+// statements SQLiteStore issues, so the store can be tested without a
+// SQLite driver dependency. Writes inside a transaction are buffered and
+// applied on commit. The memory/sqlitetest module runs the store against a
+// real SQLite database.
+type fakeSQLDriver struct {
+	mu  sync.Mutex
+	dbs map[string]*fakeDB
+}
+
+type fakeDB struct {
+	mu   sync.Mutex
+	rows []fakeRow
+}
+
+type fakeRow struct {
+	session  string
+	position int64
+	message  string
+}
+
+var (
+	fakeSQL     = &fakeSQLDriver{dbs: make(map[string]*fakeDB)}
+	fakeSQLSeq  atomic.Int64
+	registerSQL sync.Once
+)
+
+// openFakeSQL opens an empty fake database.
+func openFakeSQL(t *testing.T) *sql.DB {
+	t.Helper()
+	registerSQL.Do(func() { sql.Register("memoryfake", fakeSQL) })
+
+	db, err := sql.Open("memoryfake", fmt.Sprintf("db%d", fakeSQLSeq.Add(1)))
+	if err != nil {
+		t.Fatalf("sql.Open() error = %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func (d *fakeSQLDriver) Open(name string) (driver.Conn, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	db, ok := d.dbs[name]
+	if !ok {
+		db = &fakeDB{}
+		d.dbs[name] = db
+	}
+	return &fakeConn{db: db}, nil
+}
+
+type fakeConn struct {
+	db *fakeDB
+	tx *fakeTx
+}
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) {
+	return &fakeStmt{conn: c, query: strings.Join(strings.Fields(query), " ")}, nil
+}
+
+func (c *fakeConn) Close() error { return nil }
+
+func (c *fakeConn) Begin() (driver.Tx, error) {
+	// Like SQLite, hold the database for the whole write transaction
+	c.db.mu.Lock()
+	c.tx = &fakeTx{conn: c, rows: append([]fakeRow(nil), c.db.rows...)}
+	return c.tx, nil
+}
+
+type fakeTx struct {
+	conn *fakeConn
+	rows []fakeRow
+}
+
+func (tx *fakeTx) Commit() error {
+	tx.conn.db.rows = tx.rows
+	return tx.end()
+}
+
+func (tx *fakeTx) Rollback() error { return tx.end() }
+
+func (tx *fakeTx) end() error {
+	tx.conn.tx = nil
+	tx.conn.db.mu.Unlock()
+	return nil
+}
+
+type fakeStmt struct {
+	conn  *fakeConn
+	query string
+}
+
+func (s *fakeStmt) Close() error  { return nil }
+func (s *fakeStmt) NumInput() int { return -1 }
+
+// rows returns the table to work on and a function that releases it.
+func (s *fakeStmt) rows() (*[]fakeRow, func()) {
+	if s.conn.tx != nil {
+		return &s.conn.tx.rows, func() {}
+	}
+	s.conn.db.mu.Lock()
+	return &s.conn.db.rows, s.conn.db.mu.Unlock
+}
+
+func (s *fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	rows, release := s.rows()
+	defer release()
+
+	switch {
+	case strings.HasPrefix(s.query, "CREATE TABLE IF NOT EXISTS"):
+	case strings.HasPrefix(s.query, "INSERT INTO"):
+		*rows = append(*rows, fakeRow{session: args[0].(string), position: args[1].(int64), message: args[2].(string)})
+	case strings.HasPrefix(s.query, "DELETE FROM"):
+		kept := (*rows)[:0]
+		for _, r := range *rows {
+			if r.session != args[0].(string) {
+				kept = append(kept, r)
+			}
+		}
+		*rows = kept
+	default:
+		return nil, fmt.Errorf("fake driver: unsupported exec %q", s.query)
+	}
+	return driver.RowsAffected(1), nil
+}
+
+func (s *fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	rows, release := s.rows()
+	defer release()
+
+	var session []fakeRow
+	for _, r := range *rows {
+		if r.session == args[0].(string) {
+			session = append(session, r)
+		}
+	}
+	sort.Slice(session, func(i, j int) bool { return session[i].position < session[j].position })
+
+	switch {
+	case strings.HasPrefix(s.query, "SELECT message FROM"):
+		values := make([][]driver.Value, len(session))
+		for i, r := range session {
+			values[i] = []driver.Value{r.message}
+		}
+		return &fakeRows{columns: []string{"message"}, values: values}, nil
+	case strings.HasPrefix(s.query, "SELECT COALESCE(MAX(position), -1) + 1"):
+		next := int64(0)
+		if len(session) > 0 {
+			next = session[len(session)-1].position + 1
+		}
+		return &fakeRows{columns: []string{"next"}, values: [][]driver.Value{{next}}}, nil
+	default:
+		return nil, fmt.Errorf("fake driver: unsupported query %q", s.query)
+	}
+}
+
+type fakeRows struct {
+	columns []string
+	values  [][]driver.Value
+}
+
+func (r *fakeRows) Columns() []string { return r.columns }
+func (r *fakeRows) Close() error      { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.values) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.values[0])
+	r.values = r.values[1:]
+	return nil
+}
@@ -0,0 +1,171 @@
+package memory
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/yashrahurikar23/goagents/core"
+)
+
+// FileStore keeps each session in a JSON Lines file (one message per line)
+// in a directory. Appends only write the new lines; Replace rewrites the file
+// atomically.
+//
+// A FileStore is safe for concurrent use within a process. Do not share a
+// directory between processes that write the same sessions.
+type FileStore struct {
+	dir string
+	mu  sync.RWMutex
+}
+
+// NewFileStore creates a store in dir, creating the directory if needed.
+func NewFileStore(dir string) (*FileStore, error) {
+	if dir == "" {
+		return nil, &core.ErrInvalidArgument{Argument: "dir", Reason: "cannot be empty"}
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create store directory: %w", err)
+	}
+	return &FileStore{dir: dir}, nil
+}
+
+// path returns the file of a session. The ID is escaped, so it cannot
+// name a file outside the directory.
+func (s *FileStore) path(sessionID string) string {
+	return filepath.Join(s.dir, url.QueryEscape(sessionID)+".jsonl")
+}
+
+// Load reads the messages of a session.
+func (s *FileStore) Load(ctx context.Context, sessionID string) ([]core.Message, error) {
+	if err := validateSessionID(sessionID); err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	f, err := os.Open(s.path(sessionID))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open session %s: %w", sessionID, err)
+	}
+	defer f.Close()
+
+	var messages []core.Message
+	reader := bufio.NewReader(f)
+	for lineNo := 1; ; lineNo++ {
+		line, err := reader.ReadBytes('\n')
+		if line = bytes.TrimSpace(line); len(line) > 0 {
+			msg, decodeErr := UnmarshalMessage(line)
+			if decodeErr != nil {
+				return nil, fmt.Errorf("session %s line %d: %w", sessionID, lineNo, decodeErr)
+			}
+			messages = append(messages, msg)
+		}
+		if err == io.EOF {
+			return messages, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read session %s: %w", sessionID, err)
+		}
+	}
+}
+
+// Append writes messages to the end of a session's file.
+func (s *FileStore) Append(ctx context.Context, sessionID string, messages ...core.Message) error {
+	if err := validateSessionID(sessionID); err != nil {
+		return err
+	}
+	if len(messages) == 0 {
+		return nil
+	}
+
+	data, err := encodeLines(messages)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	f, err := os.OpenFile(s.path(sessionID), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open session %s: %w", sessionID, err)
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write session %s: %w", sessionID, err)
+	}
+	return f.Close()
+}
+
+// Replace rewrites a session's file, or deletes it when messages is empty.
+func (s *FileStore) Replace(ctx context.Context, sessionID string, messages []core.Message) error {
+	if err := validateSessionID(sessionID); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	path := s.path(sessionID)
+	if len(messages) == 0 {
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("failed to delete session %s: %w", sessionID, err)
+		}
+		return nil
+	}
+
+	data, err := encodeLines(messages)
+	if err != nil {
+		return err
+	}
+
+	// Write to a temporary file and rename it over the old one, so a crash
+	// never leaves a half-written session
+	tmp, err := os.CreateTemp(s.dir, ".session-*")
+	if err != nil {
+		return fmt.Errorf("failed to write session %s: %w", sessionID, err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := tmp.Chmod(0o644); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write session %s: %w", sessionID, err)
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write session %s: %w", sessionID, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write session %s: %w", sessionID, err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to write session %s: %w", sessionID, err)
+	}
+	return nil
+}
+
+// encodeLines encodes messages as JSON Lines.
+func encodeLines(messages []core.Message) ([]byte, error) {
+	var buf bytes.Buffer
+	for _, msg := range messages {
+		data, err := MarshalMessage(msg)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(data)
+		buf.WriteByte('\n')
+	}
+	return buf.Bytes(), nil
+}
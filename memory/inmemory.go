@@ -0,0 +1,74 @@
+package memory
+
+import (
+	"context"
+	"sync"
+
+	"github.com/yashrahurikar23/goagents/core"
+)
+
+// InMemoryStore keeps conversations in process memory. They are lost when
+// the process exits; use it for tests and single-instance services.
+type InMemoryStore struct {
+	mu       sync.RWMutex
+	sessions map[string][]core.Message
+}
+
+// NewInMemoryStore creates an empty in-memory store.
+func NewInMemoryStore() *InMemoryStore {
+	return &InMemoryStore{sessions: make(map[string][]core.Message)}
+}
+
+// Load returns a copy of the messages of a session.
+func (s *InMemoryStore) Load(ctx context.Context, sessionID string) ([]core.Message, error) {
+	if err := validateSessionID(sessionID); err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return append([]core.Message(nil), s.sessions[sessionID]...), nil
+}
+
+// Append adds messages to the end of a session.
+func (s *InMemoryStore) Append(ctx context.Context, sessionID string, messages ...core.Message) error {
+	if err := validateSessionID(sessionID); err != nil {
+		return err
+	}
+	if len(messages) == 0 {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sessions[sessionID] = append(s.sessions[sessionID], messages...)
+	return nil
+}
+
+// Replace overwrites the messages of a session.
+func (s *InMemoryStore) Replace(ctx context.Context, sessionID string, messages []core.Message) error {
+	if err := validateSessionID(sessionID); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(messages) == 0 {
+		delete(s.sessions, sessionID)
+		return nil
+	}
+	s.sessions[sessionID] = append([]core.Message(nil), messages...)
+	return nil
+}
+
+// Sessions returns the IDs of the stored sessions, in no particular order.
+func (s *InMemoryStore) Sessions() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	ids := make([]string, 0, len(s.sessions))
+	for id := range s.sessions {
+		ids = append(ids, id)
+	}
+	return ids
+}
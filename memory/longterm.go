@@ -0,0 +1,286 @@
+package memory
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/yashrahurikar23/goagents/core"
+)
+
+const (
+	// DefaultTopK is the number of memories recalled for a message.
+	DefaultTopK = 5
+
+	// DefaultDuplicateThreshold is the similarity above which a new fact is
+	// considered already remembered.
+	DefaultDuplicateThreshold = 0.95
+)
+
+// LongTermMemory remembers facts across conversations: it extracts salient
+// facts from each exchange with an LLM, embeds and stores them in a
+// VectorStore, and recalls the ones most relevant to a new message. Memories
+// are kept in the namespace WithScope returns for each request.
+//
+// Bind it to an agent with agent.ConvWithLongTermMemory:
+//
+//	embedder := ollama.New(ollama.WithModel("nomic-embed-text"))
+//	ltm, _ := memory.NewLongTermMemory(memory.NewInMemoryVectorStore(), embedder,
+//		memory.WithScope(func(ctx context.Context) string { return userIDFrom(ctx) }))
+//	chat := agent.NewConversationalAgent(llm, agent.ConvWithLongTermMemory(ltm))
+type LongTermMemory struct {
+	store    VectorStore
+	embedder core.Embedder
+
+	extractionLLM      core.LLM
+	topK               int
+	minScore           float64
+	duplicateThreshold float64
+	scope              func(ctx context.Context) string
+}
+
+// LongTermOption configures a LongTermMemory.
+type LongTermOption func(*LongTermMemory)
+
+// WithTopK sets how many memories are recalled for a message (default DefaultTopK).
+func WithTopK(k int) LongTermOption {
+	return func(m *LongTermMemory) {
+		m.topK = k
+	}
+}
+
+// WithMinScore sets the similarity a memory needs to be recalled (default 0).
+// The useful range depends on the embedding model.
+func WithMinScore(score float64) LongTermOption {
+	return func(m *LongTermMemory) {
+		m.minScore = score
+	}
+}
+
+// WithDuplicateThreshold sets the similarity above which a new fact is not
+// stored because a close one already is (default DefaultDuplicateThreshold).
+// A value above 1 disables the check.
+func WithDuplicateThreshold(score float64) LongTermOption {
+	return func(m *LongTermMemory) {
+		m.duplicateThreshold = score
+	}
+}
+
+// WithExtractionLLM sets the LLM that extracts facts from exchanges, for
+// example a smaller model than the agent's. By default the agent's LLM is used.
+func WithExtractionLLM(llm core.LLM) LongTermOption {
+	return func(m *LongTermMemory) {
+		m.extractionLLM = llm
+	}
+}
+
+// WithScope sets the namespace memories are stored in and recalled from for a
+// request, so that users do not see each other's memories. It is required.
+// Scope memories by user to recall them in all of a user's sessions:
+//
+//	memory.WithScope(func(ctx context.Context) string { return userIDFrom(ctx) })
+//
+// memory.WithScope(core.SessionIDFromContext) keeps them within a session
+// instead, and a constant shares them between all requests, as in a
+// single-user application. Requests for which scope returns "" fail with
+// core.ErrInvalidArgument rather than sharing an unnamed namespace.
+func WithScope(scope func(ctx context.Context) string) LongTermOption {
+	return func(m *LongTermMemory) {
+		m.scope = scope
+	}
+}
+
+// NewLongTermMemory creates a long-term memory on a vector store and embedder.
+func NewLongTermMemory(store VectorStore, embedder core.Embedder, opts ...LongTermOption) (*LongTermMemory, error) {
+	if store == nil {
+		return nil, &core.ErrInvalidArgument{Argument: "store", Reason: "cannot be nil"}
+	}
+	if embedder == nil {
+		return nil, &core.ErrInvalidArgument{Argument: "embedder", Reason: "cannot be nil"}
+	}
+
+	m := &LongTermMemory{
+		store:              store,
+		embedder:           embedder,
+		topK:               DefaultTopK,
+		duplicateThreshold: DefaultDuplicateThreshold,
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	if m.scope == nil {
+		return nil, &core.ErrInvalidArgument{Argument: "scope", Reason: "must be set with WithScope, e.g. to the user ID"}
+	}
+	return m, nil
+}
+
+// namespace returns the namespace of a request.
+func (m *LongTermMemory) namespace(ctx context.Context) (string, error) {
+	namespace := m.scope(ctx)
+	if namespace == "" {
+		return "", &core.ErrInvalidArgument{Argument: "scope", Reason: "returned no namespace for the request"}
+	}
+	return namespace, nil
+}
+
+// Remember embeds and stores facts, skipping ones that are already remembered.
+func (m *LongTermMemory) Remember(ctx context.Context, facts ...string) error {
+	if len(facts) == 0 {
+		return nil
+	}
+	namespace, err := m.namespace(ctx)
+	if err != nil {
+		return err
+	}
+
+	vectors, err := m.embedder.Embed(ctx, facts)
+	if err != nil {
+		return fmt.Errorf("failed to embed facts: %w", err)
+	}
+	if len(vectors) != len(facts) {
+		return fmt.Errorf("embedder returned %d vectors for %d facts", len(vectors), len(facts))
+	}
+
+	now := time.Now()
+	records := make([]Record, 0, len(facts))
+	for i, fact := range facts {
+		if m.duplicateThreshold <= 1 {
+			matches, err := m.store.Search(ctx, namespace, vectors[i], 1)
+			if err != nil {
+				return fmt.Errorf("failed to search memories: %w", err)
+			}
+			if len(matches) > 0 && matches[0].Score >= m.duplicateThreshold {
+				continue
+			}
+		}
+		records = append(records, Record{
+			ID:        newRecordID(),
+			Namespace: namespace,
+			Text:      fact,
+			Vector:    vectors[i],
+			CreatedAt: now,
+		})
+	}
+
+	if len(records) == 0 {
+		return nil
+	}
+	if err := m.store.Add(ctx, records...); err != nil {
+		return fmt.Errorf("failed to store memories: %w", err)
+	}
+	return nil
+}
+
+// Recall returns the memories most relevant to query, most similar first.
+func (m *LongTermMemory) Recall(ctx context.Context, query string) ([]ScoredRecord, error) {
+	if strings.TrimSpace(query) == "" || m.topK <= 0 {
+		return nil, nil
+	}
+	namespace, err := m.namespace(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	vectors, err := m.embedder.Embed(ctx, []string{query})
+	if err != nil {
+		return nil, fmt.Errorf("failed to embed query: %w", err)
+	}
+	if len(vectors) != 1 {
+		return nil, fmt.Errorf("embedder returned %d vectors for 1 query", len(vectors))
+	}
+
+	matches, err := m.store.Search(ctx, namespace, vectors[0], m.topK)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search memories: %w", err)
+	}
+
+	relevant := matches[:0]
+	for _, match := range matches {
+		if match.Score >= m.minScore {
+			relevant = append(relevant, match)
+		}
+	}
+	return relevant, nil
+}
+
+// RememberExchange extracts the facts worth remembering from a user message
+// and the reply to it, and stores them. Facts are extracted with the
+// extraction LLM, or llm when none was set. The extraction response is
+// returned, even when storing fails, so its usage can be accounted for.
+func (m *LongTermMemory) RememberExchange(ctx context.Context, llm core.LLM, input, reply string) (*core.Response, error) {
+	facts, resp, err := m.ExtractFacts(ctx, llm, input, reply)
+	if err != nil {
+		return nil, err
+	}
+	return resp, m.Remember(ctx, facts...)
+}
+
+// ExtractFacts asks an LLM for the facts worth remembering from an exchange.
+// It uses the extraction LLM, or llm when none was set, and returns its
+// response with the facts so that callers can account for its usage.
+func (m *LongTermMemory) ExtractFacts(ctx context.Context, llm core.LLM, input, reply string) ([]string, *core.Response, error) {
+	if m.extractionLLM != nil {
+		llm = m.extractionLLM
+	}
+	if llm == nil {
+		return nil, nil, &core.ErrInvalidArgument{Argument: "llm", Reason: "no extraction LLM set"}
+	}
+
+	prompt := fmt.Sprintf(
+		"Extract the facts from this exchange that are worth remembering in future conversations: "+
+			"lasting facts about the user (name, preferences, circumstances, plans) and decisions that were made. "+
+			"Write each fact as one short, self-contained sentence on its own line. "+
+			"If there is nothing worth remembering, reply NONE.\n\nUser: %s\nAssistant: %s\n\nFacts:",
+		input, reply,
+	)
+
+	resp, err := llm.Chat(ctx, []core.Message{core.UserMessage(prompt)})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to extract facts: %w", err)
+	}
+	return parseFacts(resp.Content), resp, nil
+}
+
+// parseFacts splits an extraction reply into facts, dropping list markers.
+func parseFacts(reply string) []string {
+	var facts []string
+	for _, line := range strings.Split(reply, "\n") {
+		line = strings.TrimSpace(line)
+		line = strings.TrimLeft(line, "-*• ")
+		if i := strings.IndexAny(line, ".)"); i > 0 && i <= 3 && strings.Trim(line[:i], "0123456789") == "" {
+			line = line[i+1:] // "1. fact" or "2) fact"
+		}
+		line = strings.TrimSpace(line)
+
+		// Skip blank lines, headings such as "Facts:" and the NONE reply
+		if line == "" || strings.HasSuffix(line, ":") || strings.EqualFold(strings.TrimRight(line, "."), "none") {
+			continue
+		}
+		facts = append(facts, line)
+	}
+	return facts
+}
+
+// FormatMemories renders recalled memories as the system message an agent
+// adds to its request.
+func FormatMemories(memories []ScoredRecord) string {
+	var sb strings.Builder
+	sb.WriteString("Relevant memories from earlier conversations:")
+	for _, mem := range memories {
+		sb.WriteString("\n- ")
+		sb.WriteString(mem.Text)
+	}
+	return sb.String()
+}
+
+// newRecordID returns a random record ID.
+func newRecordID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "mem_" + time.Now().Format("20060102150405.000000000")
+	}
+	return "mem_" + hex.EncodeToString(b)
+}
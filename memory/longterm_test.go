@@ -0,0 +1,280 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yashrahurikar23/goagents/core"
+	"github.com/yashrahurikar23/goagents/tests/mocks"
+)
+
+// keywordEmbedder embeds a text as the counts of a few keywords, so that
+// texts about the same topic are similar.
+type keywordEmbedder struct {
+	keywords []string
+	calls    int
+}
+
+func newKeywordEmbedder() *keywordEmbedder {
+	return &keywordEmbedder{keywords: []string{"name", "alice", "coffee", "tea", "paris", "trip"}}
+}
+
+func (e *keywordEmbedder) Embed(ctx context.Context, texts []string) ([][]float64, error) {
+	e.calls++
+	vectors := make([][]float64, len(texts))
+	for i, text := range texts {
+		vector := make([]float64, len(e.keywords))
+		for j, keyword := range e.keywords {
+			vector[j] = float64(strings.Count(strings.ToLower(text), keyword))
+		}
+		vectors[i] = vector
+	}
+	return vectors, nil
+}
+
+// singleUser scopes all memories to one namespace.
+var singleUser = WithScope(func(ctx context.Context) string { return "user" })
+
+func TestCosineSimilarity(t *testing.T) {
+	tests := []struct {
+		a, b []float64
+		want float64
+	}{
+		{[]float64{1, 0}, []float64{2, 0}, 1},
+		{[]float64{1, 0}, []float64{0, 1}, 0},
+		{[]float64{1, 0}, []float64{-1, 0}, -1},
+		{[]float64{1, 0}, []float64{1, 0, 0}, 0},
+		{[]float64{0, 0}, []float64{1, 0}, 0},
+	}
+
+	for _, tt := range tests {
+		if got := CosineSimilarity(tt.a, tt.b); got != tt.want {
+			t.Errorf("CosineSimilarity(%v, %v) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+// testVectorStore runs the behaviour every VectorStore must have.
+func testVectorStore(t *testing.T, store VectorStore) {
+	ctx := context.Background()
+	now := time.Now()
+
+	err := store.Add(ctx,
+		Record{ID: "a", Namespace: "u1", Text: "x", Vector: []float64{1, 0}, CreatedAt: now},
+		Record{ID: "b", Namespace: "u1", Text: "y", Vector: []float64{1, 1}, CreatedAt: now},
+		Record{ID: "c", Namespace: "u1", Text: "old", Vector: []float64{1, 0, 0}, CreatedAt: now},
+		Record{ID: "d", Namespace: "u2", Text: "other user", Vector: []float64{1, 0}, CreatedAt: now},
+	)
+	if err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+
+	results, err := store.Search(ctx, "u1", []float64{1, 0}, 5)
+	if err != nil {
+		t.Fatalf("Search() error = %v", err)
+	}
+	// c has another dimension and d another namespace
+	if len(results) != 2 || results[0].ID != "a" || results[1].ID != "b" {
+		t.Fatalf("Search() = %+v, want a then b", results)
+	}
+	if results[0].Score != 1 {
+		t.Errorf("Score = %v, want 1", results[0].Score)
+	}
+
+	if results, _ := store.Search(ctx, "u1", []float64{1, 0}, 1); len(results) != 1 {
+		t.Errorf("Search(k=1) = %d results, want 1", len(results))
+	}
+
+	// Adding an existing ID replaces the record
+	store.Add(ctx, Record{ID: "a", Namespace: "u1", Text: "x2", Vector: []float64{0, 1}, CreatedAt: now})
+	results, _ = store.Search(ctx, "u1", []float64{0, 1}, 5)
+	if len(results) != 2 || results[0].Text != "x2" {
+		t.Errorf("Search() after replace = %+v, want x2 first", results)
+	}
+
+	if err := store.Delete(ctx, "u1", "a", "b"); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if results, _ := store.Search(ctx, "u1", []float64{1, 0}, 5); len(results) != 0 {
+		t.Errorf("Search() after Delete = %+v, want none", results)
+	}
+	if results, _ := store.Search(ctx, "u2", []float64{1, 0}, 5); len(results) != 1 {
+		t.Errorf("Search(u2) = %+v, want d", results)
+	}
+
+	var invalid *core.ErrInvalidArgument
+	if err := store.Add(ctx, Record{Text: "no id"}); !errors.As(err, &invalid) {
+		t.Errorf("Add() without ID error = %v, want ErrInvalidArgument", err)
+	}
+}
+
+func TestInMemoryVectorStore(t *testing.T) {
+	testVectorStore(t, NewInMemoryVectorStore())
+}
+
+func TestFileVectorStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "memories", "vectors.jsonl")
+	store, err := NewFileVectorStore(path)
+	if err != nil {
+		t.Fatalf("NewFileVectorStore() error = %v", err)
+	}
+	testVectorStore(t, store)
+
+	// The file holds what survived: c and d
+	reopened, err := NewFileVectorStore(path)
+	if err != nil {
+		t.Fatalf("NewFileVectorStore() error = %v", err)
+	}
+	if reopened.Len() != 2 {
+		t.Errorf("Len() after reopen = %d, want 2", reopened.Len())
+	}
+
+	reopened.Add(context.Background(), Record{ID: "e", Namespace: "u1", Text: "new", Vector: []float64{1, 0}})
+	again, _ := NewFileVectorStore(path)
+	if results, _ := again.Search(context.Background(), "u1", []float64{1, 0}, 5); len(results) != 1 || results[0].Text != "new" {
+		t.Errorf("Search() after reopen = %+v, want the appended record", results)
+	}
+}
+
+func TestLongTermMemory_RememberRecall(t *testing.T) {
+	ctx := context.Background()
+	store := NewInMemoryVectorStore()
+	ltm, err := NewLongTermMemory(store, newKeywordEmbedder(), singleUser, WithTopK(2), WithMinScore(0.5))
+	if err != nil {
+		t.Fatalf("NewLongTermMemory() error = %v", err)
+	}
+
+	err = ltm.Remember(ctx, "The user's name is Alice", "The user prefers tea over coffee", "The user plans a trip to Paris")
+	if err != nil {
+		t.Fatalf("Remember() error = %v", err)
+	}
+
+	memories, err := ltm.Recall(ctx, "Any tips for my Paris trip?")
+	if err != nil {
+		t.Fatalf("Recall() error = %v", err)
+	}
+	if len(memories) != 1 || memories[0].Text != "The user plans a trip to Paris" {
+		t.Errorf("Recall() = %+v, want the trip", memories)
+	}
+
+	// Facts close to a stored one are not stored again
+	ltm.Remember(ctx, "The user's name is Alice.")
+	if store.Len() != 3 {
+		t.Errorf("Len() = %d, want 3 after remembering a duplicate", store.Len())
+	}
+
+	if memories, _ := ltm.Recall(ctx, "   "); len(memories) != 0 {
+		t.Errorf("Recall(blank) = %+v, want none", memories)
+	}
+}
+
+func TestLongTermMemory_Scope(t *testing.T) {
+	type userKey struct{}
+	scope := func(ctx context.Context) string {
+		user, _ := ctx.Value(userKey{}).(string)
+		return user
+	}
+	ltm, _ := NewLongTermMemory(NewInMemoryVectorStore(), newKeywordEmbedder(), WithScope(scope))
+
+	alice := context.WithValue(context.Background(), userKey{}, "alice")
+	bob := context.WithValue(context.Background(), userKey{}, "bob")
+
+	ltm.Remember(alice, "The user's name is Alice")
+	if memories, _ := ltm.Recall(bob, "What is my name?"); len(memories) != 0 {
+		t.Errorf("Recall(bob) = %+v, want none of alice's memories", memories)
+	}
+	if memories, _ := ltm.Recall(alice, "What is my name?"); len(memories) != 1 {
+		t.Errorf("Recall(alice) = %+v, want her name", memories)
+	}
+
+	// Requests without a namespace are rejected rather than pooled
+	var invalid *core.ErrInvalidArgument
+	if err := ltm.Remember(context.Background(), "The user's name is Carol"); !errors.As(err, &invalid) {
+		t.Errorf("Remember() without a user error = %v, want ErrInvalidArgument", err)
+	}
+	if _, err := ltm.Recall(context.Background(), "What is my name?"); !errors.As(err, &invalid) {
+		t.Errorf("Recall() without a user error = %v, want ErrInvalidArgument", err)
+	}
+}
+
+// TestLongTermMemory_SessionScope tests that memories can be kept per session.
+func TestLongTermMemory_SessionScope(t *testing.T) {
+	ltm, _ := NewLongTermMemory(NewInMemoryVectorStore(), newKeywordEmbedder(), WithScope(core.SessionIDFromContext))
+
+	alice := core.ContextWithSessionID(context.Background(), "alice-1")
+	bob := core.ContextWithSessionID(context.Background(), "bob-1")
+
+	ltm.Remember(alice, "The user's name is Alice")
+	if memories, _ := ltm.Recall(bob, "What is my name?"); len(memories) != 0 {
+		t.Errorf("Recall(bob) = %+v, want none of alice's memories", memories)
+	}
+	if memories, _ := ltm.Recall(alice, "What is my name?"); len(memories) != 1 {
+		t.Errorf("Recall(alice) = %+v, want her name", memories)
+	}
+}
+
+func TestLongTermMemory_RememberExchange(t *testing.T) {
+	ctx := context.Background()
+	agentLLM := mocks.NewMockLLM().WithSequentialChatResponses([]*core.Response{
+		{Content: "- The user's name is Alice\n- The user prefers tea", Usage: core.NewUsage(40, 12)},
+	}, nil)
+	extractor := mocks.NewMockLLM().WithChatResponse("NONE", nil)
+
+	ltm, _ := NewLongTermMemory(NewInMemoryVectorStore(), newKeywordEmbedder(), singleUser)
+	resp, err := ltm.RememberExchange(ctx, agentLLM, "Hi, I'm Alice. I like tea.", "Hello Alice!")
+	if err != nil {
+		t.Fatalf("RememberExchange() error = %v", err)
+	}
+	if resp == nil || resp.Usage == nil || resp.Usage.TotalTokens != 52 {
+		t.Errorf("RememberExchange() response = %+v, want the extraction response with its usage", resp)
+	}
+
+	prompt := agentLLM.GetChatCalls()[0].Messages[0].Content
+	if !strings.Contains(prompt, "User: Hi, I'm Alice. I like tea.") || !strings.Contains(prompt, "Assistant: Hello Alice!") {
+		t.Errorf("extraction prompt = %q, want the exchange", prompt)
+	}
+	if memories, _ := ltm.Recall(ctx, "my name"); len(memories) != 2 || memories[0].Text != "The user's name is Alice" {
+		t.Errorf("Recall() = %+v, want the extracted facts", memories)
+	}
+
+	// An extraction LLM takes precedence over the agent's
+	withExtractor, _ := NewLongTermMemory(NewInMemoryVectorStore(), newKeywordEmbedder(), singleUser, WithExtractionLLM(extractor))
+	withExtractor.RememberExchange(ctx, agentLLM, "Hi", "Hello")
+	if extractor.ChatCallCount() != 1 || agentLLM.ChatCallCount() != 1 {
+		t.Errorf("extraction calls = %d, agent calls = %d, want 1 and 1", extractor.ChatCallCount(), agentLLM.ChatCallCount())
+	}
+
+	if _, err := ltm.RememberExchange(ctx, nil, "Hi", "Hello"); err == nil {
+		t.Error("RememberExchange() without an LLM expected error, got nil")
+	}
+}
+
+func TestParseFacts(t *testing.T) {
+	reply := "Facts:\n1. The user is Alice.\n2) She likes tea\n- Lives in Paris\n* Works nights\n\nNONE"
+	want := []string{"The user is Alice.", "She likes tea", "Lives in Paris", "Works nights"}
+	if got := parseFacts(reply); !reflect.DeepEqual(got, want) {
+		t.Errorf("parseFacts() = %q, want %q", got, want)
+	}
+
+	if got := parseFacts("None."); len(got) != 0 {
+		t.Errorf("parseFacts(None.) = %q, want none", got)
+	}
+}
+
+func TestNewLongTermMemory_Errors(t *testing.T) {
+	if _, err := NewLongTermMemory(nil, newKeywordEmbedder(), singleUser); err == nil {
+		t.Error("NewLongTermMemory(nil store) expected error, got nil")
+	}
+	if _, err := NewLongTermMemory(NewInMemoryVectorStore(), nil, singleUser); err == nil {
+		t.Error("NewLongTermMemory(nil embedder) expected error, got nil")
+	}
+	var invalid *core.ErrInvalidArgument
+	if _, err := NewLongTermMemory(NewInMemoryVectorStore(), newKeywordEmbedder()); !errors.As(err, &invalid) || invalid.Argument != "scope" {
+		t.Errorf("NewLongTermMemory() without a scope error = %v, want ErrInvalidArgument for scope", err)
+	}
+}
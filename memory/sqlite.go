@@ -0,0 +1,177 @@
+package memory
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/yashrahurikar23/goagents/core"
+)
+
+// DefaultSQLiteTable is the table SQLiteStore uses unless WithTable is set.
+const DefaultSQLiteTable = "goagents_messages"
+
+var sqlIdentifierRe = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// SQLiteStore keeps conversations in a SQLite table, one row per message.
+//
+// The store works with any SQLite driver for database/sql; the module does
+// not depend on one. Open the database with the driver of your choice:
+//
+//	import _ "modernc.org/sqlite" // or "github.com/mattn/go-sqlite3" (driver "sqlite3")
+//
+//	db, err := sql.Open("sqlite", "conversations.db")
+//	store, err := memory.NewSQLiteStore(ctx, db)
+type SQLiteStore struct {
+	db    *sql.DB
+	table string
+
+	// mu serializes writes: appends read the last position of a session
+	// before inserting after it
+	mu sync.Mutex
+}
+
+// SQLiteOption configures a SQLiteStore.
+type SQLiteOption func(*SQLiteStore)
+
+// WithTable sets the name of the messages table (default DefaultSQLiteTable).
+func WithTable(name string) SQLiteOption {
+	return func(s *SQLiteStore) {
+		s.table = name
+	}
+}
+
+// NewSQLiteStore creates a store in db, creating its table if it does not exist.
+func NewSQLiteStore(ctx context.Context, db *sql.DB, opts ...SQLiteOption) (*SQLiteStore, error) {
+	if db == nil {
+		return nil, &core.ErrInvalidArgument{Argument: "db", Reason: "cannot be nil"}
+	}
+
+	store := &SQLiteStore{db: db, table: DefaultSQLiteTable}
+	for _, opt := range opts {
+		opt(store)
+	}
+
+	// The table name is interpolated into statements, so only plain identifiers are allowed
+	if !sqlIdentifierRe.MatchString(store.table) {
+		return nil, &core.ErrInvalidArgument{Argument: "table", Reason: fmt.Sprintf("%q is not a valid identifier", store.table)}
+	}
+
+	_, err := db.ExecContext(ctx, fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+		session_id TEXT NOT NULL,
+		position INTEGER NOT NULL,
+		message TEXT NOT NULL,
+		created_at TIMESTAMP NOT NULL,
+		PRIMARY KEY (session_id, position)
+	)`, store.table))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create table %s: %w", store.table, err)
+	}
+	return store, nil
+}
+
+// Load returns the messages of a session in order.
+func (s *SQLiteStore) Load(ctx context.Context, sessionID string) ([]core.Message, error) {
+	if err := validateSessionID(sessionID); err != nil {
+		return nil, err
+	}
+
+	rows, err := s.db.QueryContext(ctx,
+		fmt.Sprintf(`SELECT message FROM %s WHERE session_id = ? ORDER BY position`, s.table),
+		sessionID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load session %s: %w", sessionID, err)
+	}
+	defer rows.Close()
+
+	var messages []core.Message
+	for rows.Next() {
+		var data string
+		if err := rows.Scan(&data); err != nil {
+			return nil, fmt.Errorf("failed to load session %s: %w", sessionID, err)
+		}
+		msg, err := UnmarshalMessage([]byte(data))
+		if err != nil {
+			return nil, fmt.Errorf("session %s: %w", sessionID, err)
+		}
+		messages = append(messages, msg)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to load session %s: %w", sessionID, err)
+	}
+	return messages, nil
+}
+
+// Append inserts messages after the last message of a session.
+func (s *SQLiteStore) Append(ctx context.Context, sessionID string, messages ...core.Message) error {
+	if err := validateSessionID(sessionID); err != nil {
+		return err
+	}
+	if len(messages) == 0 {
+		return nil
+	}
+
+	return s.write(ctx, sessionID, func(tx *sql.Tx) (int, error) {
+		var next int
+		err := tx.QueryRowContext(ctx,
+			fmt.Sprintf(`SELECT COALESCE(MAX(position), -1) + 1 FROM %s WHERE session_id = ?`, s.table),
+			sessionID,
+		).Scan(&next)
+		return next, err
+	}, messages)
+}
+
+// Replace deletes the messages of a session and inserts the given ones.
+func (s *SQLiteStore) Replace(ctx context.Context, sessionID string, messages []core.Message) error {
+	if err := validateSessionID(sessionID); err != nil {
+		return err
+	}
+
+	return s.write(ctx, sessionID, func(tx *sql.Tx) (int, error) {
+		_, err := tx.ExecContext(ctx, fmt.Sprintf(`DELETE FROM %s WHERE session_id = ?`, s.table), sessionID)
+		return 0, err
+	}, messages)
+}
+
+// write runs prepare in a transaction and inserts messages from the position it returns.
+func (s *SQLiteStore) write(ctx context.Context, sessionID string, prepare func(*sql.Tx) (int, error), messages []core.Message) error {
+	encoded := make([]string, len(messages))
+	for i, msg := range messages {
+		data, err := MarshalMessage(msg)
+		if err != nil {
+			return err
+		}
+		encoded[i] = string(data)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to write session %s: %w", sessionID, err)
+	}
+	defer tx.Rollback()
+
+	position, err := prepare(tx)
+	if err != nil {
+		return fmt.Errorf("failed to write session %s: %w", sessionID, err)
+	}
+
+	now := time.Now().UTC()
+	insert := fmt.Sprintf(`INSERT INTO %s (session_id, position, message, created_at) VALUES (?, ?, ?, ?)`, s.table)
+	for i, data := range encoded {
+		if _, err := tx.ExecContext(ctx, insert, sessionID, position+i, data, now); err != nil {
+			return fmt.Errorf("failed to write session %s: %w", sessionID, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to write session %s: %w", sessionID, err)
+	}
+	return nil
+}
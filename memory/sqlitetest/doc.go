@@ -0,0 +1,7 @@
+// Package sqlitetest tests memory.SQLiteStore against a real SQLite database.
+//
+// It is a separate module so that the goagents module does not depend on a
+// SQLite driver. The tests use modernc.org/sqlite, which needs no cgo:
+//
+//	cd memory/sqlitetest && go test ./...
+package sqlitetest
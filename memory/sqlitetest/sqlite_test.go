@@ -0,0 +1,183 @@
+package sqlitetest
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/yashrahurikar23/goagents/agent"
+	"github.com/yashrahurikar23/goagents/core"
+	"github.com/yashrahurikar23/goagents/memory"
+	"github.com/yashrahurikar23/goagents/tests/mocks"
+
+	_ "modernc.org/sqlite"
+)
+
+// openDB opens a SQLite database file in a temporary directory.
+func openDB(t *testing.T, path string) *sql.DB {
+	t.Helper()
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatalf("sql.Open() error = %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func newStore(t *testing.T) *memory.SQLiteStore {
+	t.Helper()
+	store, err := memory.NewSQLiteStore(context.Background(), openDB(t, filepath.Join(t.TempDir(), "conversations.db")))
+	if err != nil {
+		t.Fatalf("NewSQLiteStore() error = %v", err)
+	}
+	return store
+}
+
+func TestSQLiteStore(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("unknown session is empty", func(t *testing.T) {
+		msgs, err := newStore(t).Load(ctx, "nobody")
+		if err != nil || len(msgs) != 0 {
+			t.Errorf("Load() = %d messages, %v, want none", len(msgs), err)
+		}
+	})
+
+	t.Run("append and load", func(t *testing.T) {
+		store := newStore(t)
+		store.Append(ctx, "s1", core.UserMessage("Hi"), core.AssistantMessage("Hello"))
+		store.Append(ctx, "s2", core.UserMessage("Other"))
+		if err := store.Append(ctx, "s1", core.UserMessage("Bye")); err != nil {
+			t.Fatalf("Append() error = %v", err)
+		}
+
+		msgs, err := store.Load(ctx, "s1")
+		if err != nil {
+			t.Fatalf("Load() error = %v", err)
+		}
+		want := []core.Message{core.UserMessage("Hi"), core.AssistantMessage("Hello"), core.UserMessage("Bye")}
+		if !reflect.DeepEqual(msgs, want) {
+			t.Errorf("Load(s1) = %+v, want %+v", msgs, want)
+		}
+	})
+
+	t.Run("replace", func(t *testing.T) {
+		store := newStore(t)
+		store.Append(ctx, "s1", core.UserMessage("a"), core.AssistantMessage("b"))
+
+		summary := []core.Message{core.SystemMessage("Summary: a, b")}
+		if err := store.Replace(ctx, "s1", summary); err != nil {
+			t.Fatalf("Replace() error = %v", err)
+		}
+		store.Append(ctx, "s1", core.UserMessage("c"))
+
+		msgs, _ := store.Load(ctx, "s1")
+		if len(msgs) != 2 || msgs[0].Content != "Summary: a, b" || msgs[1].Content != "c" {
+			t.Errorf("Load() = %+v, want the summary followed by c", msgs)
+		}
+
+		if err := store.Replace(ctx, "s1", nil); err != nil {
+			t.Fatalf("Replace(nil) error = %v", err)
+		}
+		if msgs, _ = store.Load(ctx, "s1"); len(msgs) != 0 {
+			t.Errorf("Load() after Replace(nil) = %d messages, want 0", len(msgs))
+		}
+	})
+
+	t.Run("tool calls and parts round trip", func(t *testing.T) {
+		store := newStore(t)
+		want := []core.Message{
+			core.UserMessageWithParts("What is this?", core.ImagePart([]byte{0x89, 'P', 'N', 'G'}, "image/png")),
+			{
+				Role:      "assistant",
+				ToolCalls: []core.ToolCall{{ID: "call_1", Name: "calculator", Args: map[string]interface{}{"a": 2.0}}},
+				Meta:      map[string]interface{}{},
+			},
+			{Role: "tool", Content: "4", ToolCallID: "call_1", Name: "calculator", Meta: map[string]interface{}{}},
+		}
+		if err := store.Append(ctx, "s1", want...); err != nil {
+			t.Fatalf("Append() error = %v", err)
+		}
+		msgs, _ := store.Load(ctx, "s1")
+		if !reflect.DeepEqual(msgs, want) {
+			t.Errorf("Load() = %+v, want %+v", msgs, want)
+		}
+	})
+
+	t.Run("concurrent appends", func(t *testing.T) {
+		store := newStore(t)
+		var wg sync.WaitGroup
+		for i := 0; i < 20; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if err := store.Append(ctx, "s1", core.UserMessage("q"), core.AssistantMessage("a")); err != nil {
+					t.Errorf("Append() error = %v", err)
+				}
+			}()
+		}
+		wg.Wait()
+
+		msgs, _ := store.Load(ctx, "s1")
+		if len(msgs) != 40 {
+			t.Fatalf("Load() = %d messages, want 40", len(msgs))
+		}
+		for i := 0; i < len(msgs); i += 2 {
+			if msgs[i].Role != "user" || msgs[i+1].Role != "assistant" {
+				t.Fatalf("messages %d-%d = %s, %s, want user, assistant", i, i+1, msgs[i].Role, msgs[i+1].Role)
+			}
+		}
+	})
+}
+
+// TestSQLiteStore_Reopen tests that a session survives reopening the database.
+func TestSQLiteStore_Reopen(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "conversations.db")
+
+	store, err := memory.NewSQLiteStore(ctx, openDB(t, path), memory.WithTable("chat_messages"))
+	if err != nil {
+		t.Fatalf("NewSQLiteStore() error = %v", err)
+	}
+	store.Append(ctx, "user-42", core.UserMessage("Hi"))
+
+	reopened, err := memory.NewSQLiteStore(ctx, openDB(t, path), memory.WithTable("chat_messages"))
+	if err != nil {
+		t.Fatalf("NewSQLiteStore() on an existing table error = %v", err)
+	}
+	msgs, err := reopened.Load(ctx, "user-42")
+	if err != nil || len(msgs) != 1 || msgs[0].Content != "Hi" {
+		t.Errorf("Load() = %+v, %v, want the stored message", msgs, err)
+	}
+}
+
+// TestConversationalAgent_SQLiteStore tests that a memory strategy trims only
+// what the LLM is sent, while the store keeps the full transcript.
+func TestConversationalAgent_SQLiteStore(t *testing.T) {
+	store := newStore(t)
+	ctx := core.ContextWithSessionID(context.Background(), "s1")
+
+	llm := mocks.NewMockLLM().WithChatResponse("ok", nil)
+	chat := agent.NewConversationalAgent(llm,
+		agent.ConvWithStore(store),
+		agent.ConvWithMemoryStrategy(agent.MemoryStrategyWindow),
+		agent.ConvWithMaxMessages(3),
+	)
+	for i := 0; i < 4; i++ {
+		if _, err := chat.Run(ctx, fmt.Sprintf("Q%d", i)); err != nil {
+			t.Fatalf("Run() error = %v", err)
+		}
+	}
+
+	stored, _ := store.Load(ctx, "s1")
+	if len(stored) != 8 || stored[0].Content != "Q0" {
+		t.Errorf("stored %d messages starting with %q, want all 8 from Q0", len(stored), stored[0].Content)
+	}
+	if sent := llm.GetChatCalls()[3].Messages; len(sent) != 3 {
+		t.Errorf("last run sent %d messages, want the window of 3", len(sent))
+	}
+}
@@ -0,0 +1,181 @@
+// Package memory persists agent conversations by session ID, so a service can
+// restart without losing them and serve many sessions from one agent.
+//
+// Stores:
+//   - InMemoryStore: process-local, for tests and single-instance services
+//   - FileStore: one JSON Lines file per session in a directory
+//   - SQLiteStore: a table in a SQLite database opened with database/sql
+//
+// Bind a store to an agent with agent.ConvWithStore or agent.WithStore, and
+// select the session per request with core.ContextWithSessionID:
+//
+//	store, _ := memory.NewFileStore("./conversations")
+//	chat := agent.NewConversationalAgent(llm, agent.ConvWithStore(store))
+//
+//	ctx = core.ContextWithSessionID(ctx, "user-42")
+//	resp, err := chat.Run(ctx, "Hi, I'm Alice")
+//
+// The package also provides long-term memory across conversations: LongTermMemory
+// stores facts from conversations in a VectorStore (InMemoryVectorStore,
+// FileVectorStore) using a core.Embedder, and recalls the relevant ones.
+//
+// A CheckpointStore (InMemoryCheckpointStore, FileCheckpointStore) keeps the
+// state of agent runs in progress, so they can be resumed after a crash or a
+// long wait for approval.
+package memory
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/yashrahurikar23/goagents/core"
+)
+
+// Store persists the message history of conversations, keyed by session ID.
+// Implementations are safe for concurrent use.
+type Store interface {
+	// Load returns the messages of a session in order.
+	// A session that was never written has no messages (and no error).
+	Load(ctx context.Context, sessionID string) ([]core.Message, error)
+
+	// Append adds messages to the end of a session.
+	Append(ctx context.Context, sessionID string, messages ...core.Message) error
+
+	// Replace overwrites the messages of a session, for example to redact or
+	// compact it. Agents only append: memory strategies trim the copy sent to
+	// the LLM, not the stored transcript. Replacing with no messages deletes
+	// the session.
+	Replace(ctx context.Context, sessionID string, messages []core.Message) error
+}
+
+// validateSessionID rejects the empty session ID.
+func validateSessionID(sessionID string) error {
+	if sessionID == "" {
+		return &core.ErrInvalidArgument{Argument: "sessionID", Reason: "cannot be empty"}
+	}
+	return nil
+}
+
+// messageRecord is the stored form of a core.Message.
+type messageRecord struct {
+	Role       string                 `json:"role"`
+	Content    string                 `json:"content,omitempty"`
+	Name       string                 `json:"name,omitempty"`
+	ToolCallID string                 `json:"tool_call_id,omitempty"`
+	ToolCalls  []toolCallRecord       `json:"tool_calls,omitempty"`
+	Parts      []partRecord           `json:"parts,omitempty"`
+	Meta       map[string]interface{} `json:"meta,omitempty"`
+}
+
+type toolCallRecord struct {
+	ID       string                 `json:"id,omitempty"`
+	Name     string                 `json:"name"`
+	Args     map[string]interface{} `json:"args,omitempty"`
+	Result   interface{}            `json:"result,omitempty"`
+	Error    string                 `json:"error,omitempty"`
+	Duration time.Duration          `json:"duration,omitempty"`
+}
+
+type partRecord struct {
+	Type     string `json:"type"`
+	Text     string `json:"text,omitempty"`
+	URL      string `json:"url,omitempty"`
+	Data     []byte `json:"data,omitempty"`
+	MIMEType string `json:"mime_type,omitempty"`
+	Detail   string `json:"detail,omitempty"`
+}
+
+// MarshalMessage encodes a message as JSON, including its tool calls and
+// parts. Tool call errors are kept as their text.
+func MarshalMessage(msg core.Message) ([]byte, error) {
+	rec := messageRecord{
+		Role:       msg.Role,
+		Content:    msg.Content,
+		Name:       msg.Name,
+		ToolCallID: msg.ToolCallID,
+		Meta:       msg.Meta,
+	}
+	for _, tc := range msg.ToolCalls {
+		call := toolCallRecord{ID: tc.ID, Name: tc.Name, Args: tc.Args, Result: tc.Result, Duration: tc.Duration}
+		if tc.Error != nil {
+			call.Error = tc.Error.Error()
+		}
+		rec.ToolCalls = append(rec.ToolCalls, call)
+	}
+	for _, p := range msg.Parts {
+		rec.Parts = append(rec.Parts, partRecord(p))
+	}
+
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode %s message: %w", msg.Role, err)
+	}
+	return data, nil
+}
+
+// UnmarshalMessage decodes a message encoded with MarshalMessage.
+func UnmarshalMessage(data []byte) (core.Message, error) {
+	var rec messageRecord
+	if err := json.Unmarshal(data, &rec); err != nil {
+		return core.Message{}, fmt.Errorf("failed to decode message: %w", err)
+	}
+
+	msg := core.Message{
+		Role:       rec.Role,
+		Content:    rec.Content,
+		Name:       rec.Name,
+		ToolCallID: rec.ToolCallID,
+		Meta:       rec.Meta,
+	}
+	if msg.Meta == nil {
+		// Match the message constructors, which never leave Meta nil
+		msg.Meta = make(map[string]interface{})
+	}
+	for _, call := range rec.ToolCalls {
+		tc := core.ToolCall{ID: call.ID, Name: call.Name, Args: call.Args, Result: call.Result, Duration: call.Duration}
+		if call.Error != "" {
+			tc.Error = errors.New(call.Error)
+		}
+		msg.ToolCalls = append(msg.ToolCalls, tc)
+	}
+	for _, p := range rec.Parts {
+		msg.Parts = append(msg.Parts, core.ContentPart(p))
+	}
+	return msg, nil
+}
+
+// MarshalMessages encodes a conversation as a JSON array. Unlike
+// ConversationalAgent.ExportConversation, the result can be decoded again
+// with UnmarshalMessages.
+func MarshalMessages(messages []core.Message) ([]byte, error) {
+	records := make([]json.RawMessage, len(messages))
+	for i, msg := range messages {
+		data, err := MarshalMessage(msg)
+		if err != nil {
+			return nil, err
+		}
+		records[i] = data
+	}
+	return json.Marshal(records)
+}
+
+// UnmarshalMessages decodes a conversation encoded with MarshalMessages.
+func UnmarshalMessages(data []byte) ([]core.Message, error) {
+	var records []json.RawMessage
+	if err := json.Unmarshal(data, &records); err != nil {
+		return nil, fmt.Errorf("failed to decode messages: %w", err)
+	}
+
+	messages := make([]core.Message, len(records))
+	for i, rec := range records {
+		msg, err := UnmarshalMessage(rec)
+		if err != nil {
+			return nil, err
+		}
+		messages[i] = msg
+	}
+	return messages, nil
+}
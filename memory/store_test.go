@@ -0,0 +1,262 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/yashrahurikar23/goagents/core"
+)
+
+// testStore runs the behaviour every Store must have.
+func testStore(t *testing.T, newStore func(t *testing.T) Store) {
+	ctx := context.Background()
+
+	t.Run("unknown session is empty", func(t *testing.T) {
+		store := newStore(t)
+		msgs, err := store.Load(ctx, "nobody")
+		if err != nil {
+			t.Fatalf("Load() error = %v", err)
+		}
+		if len(msgs) != 0 {
+			t.Errorf("Load() = %d messages, want 0", len(msgs))
+		}
+	})
+
+	t.Run("append and load", func(t *testing.T) {
+		store := newStore(t)
+		if err := store.Append(ctx, "s1", core.UserMessage("Hi"), core.AssistantMessage("Hello")); err != nil {
+			t.Fatalf("Append() error = %v", err)
+		}
+		if err := store.Append(ctx, "s1", core.UserMessage("Bye")); err != nil {
+			t.Fatalf("Append() error = %v", err)
+		}
+		if err := store.Append(ctx, "s2", core.UserMessage("Other")); err != nil {
+			t.Fatalf("Append() error = %v", err)
+		}
+
+		msgs, err := store.Load(ctx, "s1")
+		if err != nil {
+			t.Fatalf("Load() error = %v", err)
+		}
+		want := []core.Message{core.UserMessage("Hi"), core.AssistantMessage("Hello"), core.UserMessage("Bye")}
+		if !reflect.DeepEqual(msgs, want) {
+			t.Errorf("Load(s1) = %+v, want %+v", msgs, want)
+		}
+
+		msgs, _ = store.Load(ctx, "s2")
+		if len(msgs) != 1 || msgs[0].Content != "Other" {
+			t.Errorf("Load(s2) = %+v, want the one s2 message", msgs)
+		}
+	})
+
+	t.Run("replace", func(t *testing.T) {
+		store := newStore(t)
+		store.Append(ctx, "s1", core.UserMessage("a"), core.AssistantMessage("b"), core.UserMessage("c"))
+
+		summary := []core.Message{core.SystemMessage("Previous conversation summary: a, b"), core.UserMessage("c")}
+		if err := store.Replace(ctx, "s1", summary); err != nil {
+			t.Fatalf("Replace() error = %v", err)
+		}
+		msgs, _ := store.Load(ctx, "s1")
+		if !reflect.DeepEqual(msgs, summary) {
+			t.Errorf("Load() after Replace = %+v, want %+v", msgs, summary)
+		}
+
+		// Appends continue after the replaced history
+		store.Append(ctx, "s1", core.AssistantMessage("d"))
+		msgs, _ = store.Load(ctx, "s1")
+		if len(msgs) != 3 || msgs[2].Content != "d" {
+			t.Errorf("Load() after Append = %+v, want 3 messages ending in d", msgs)
+		}
+
+		if err := store.Replace(ctx, "s1", nil); err != nil {
+			t.Fatalf("Replace(nil) error = %v", err)
+		}
+		msgs, _ = store.Load(ctx, "s1")
+		if len(msgs) != 0 {
+			t.Errorf("Load() after Replace(nil) = %d messages, want 0", len(msgs))
+		}
+	})
+
+	t.Run("tool calls round trip", func(t *testing.T) {
+		store := newStore(t)
+		want := []core.Message{
+			{
+				Role:      "assistant",
+				ToolCalls: []core.ToolCall{{ID: "call_1", Name: "calculator", Args: map[string]interface{}{"a": 2.0}}},
+				Meta:      map[string]interface{}{},
+			},
+			{Role: "tool", Content: "4", ToolCallID: "call_1", Name: "calculator", Meta: map[string]interface{}{}},
+		}
+		if err := store.Append(ctx, "s1", want...); err != nil {
+			t.Fatalf("Append() error = %v", err)
+		}
+		msgs, _ := store.Load(ctx, "s1")
+		if !reflect.DeepEqual(msgs, want) {
+			t.Errorf("Load() = %+v, want %+v", msgs, want)
+		}
+	})
+
+	t.Run("empty session ID", func(t *testing.T) {
+		store := newStore(t)
+		var invalid *core.ErrInvalidArgument
+		if _, err := store.Load(ctx, ""); !errors.As(err, &invalid) {
+			t.Errorf("Load(\"\") error = %v, want ErrInvalidArgument", err)
+		}
+		if err := store.Append(ctx, "", core.UserMessage("x")); !errors.As(err, &invalid) {
+			t.Errorf("Append(\"\") error = %v, want ErrInvalidArgument", err)
+		}
+		if err := store.Replace(ctx, "", nil); !errors.As(err, &invalid) {
+			t.Errorf("Replace(\"\") error = %v, want ErrInvalidArgument", err)
+		}
+	})
+
+	t.Run("concurrent appends", func(t *testing.T) {
+		store := newStore(t)
+		var wg sync.WaitGroup
+		for i := 0; i < 20; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if err := store.Append(ctx, "s1", core.UserMessage("q"), core.AssistantMessage("a")); err != nil {
+					t.Errorf("Append() error = %v", err)
+				}
+			}()
+		}
+		wg.Wait()
+
+		msgs, _ := store.Load(ctx, "s1")
+		if len(msgs) != 40 {
+			t.Fatalf("Load() = %d messages, want 40", len(msgs))
+		}
+		// Each append is written as a unit
+		for i := 0; i < len(msgs); i += 2 {
+			if msgs[i].Role != "user" || msgs[i+1].Role != "assistant" {
+				t.Fatalf("messages %d-%d = %s, %s, want user, assistant", i, i+1, msgs[i].Role, msgs[i+1].Role)
+			}
+		}
+	})
+}
+
+func TestInMemoryStore(t *testing.T) {
+	testStore(t, func(t *testing.T) Store {
+		return NewInMemoryStore()
+	})
+}
+
+func TestInMemoryStore_Sessions(t *testing.T) {
+	store := NewInMemoryStore()
+	store.Append(context.Background(), "a", core.UserMessage("x"))
+	store.Append(context.Background(), "b", core.UserMessage("y"))
+
+	if got := store.Sessions(); len(got) != 2 {
+		t.Errorf("Sessions() = %v, want 2 sessions", got)
+	}
+}
+
+func TestFileStore(t *testing.T) {
+	testStore(t, func(t *testing.T) Store {
+		store, err := NewFileStore(t.TempDir())
+		if err != nil {
+			t.Fatalf("NewFileStore() error = %v", err)
+		}
+		return store
+	})
+}
+
+func TestFileStore_Reopen(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	store, _ := NewFileStore(dir)
+	store.Append(ctx, "../user/42", core.UserMessage("Hi"))
+
+	// A new store on the same directory sees the session
+	reopened, _ := NewFileStore(dir)
+	msgs, err := reopened.Load(ctx, "../user/42")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if len(msgs) != 1 || msgs[0].Content != "Hi" {
+		t.Errorf("Load() = %+v, want the stored message", msgs)
+	}
+
+	if _, err := NewFileStore(""); err == nil {
+		t.Error("NewFileStore(\"\") expected error, got nil")
+	}
+}
+
+func TestSQLiteStore(t *testing.T) {
+	testStore(t, func(t *testing.T) Store {
+		store, err := NewSQLiteStore(context.Background(), openFakeSQL(t))
+		if err != nil {
+			t.Fatalf("NewSQLiteStore() error = %v", err)
+		}
+		return store
+	})
+}
+
+func TestNewSQLiteStore_Errors(t *testing.T) {
+	ctx := context.Background()
+
+	if _, err := NewSQLiteStore(ctx, nil); err == nil {
+		t.Error("NewSQLiteStore(nil) expected error, got nil")
+	}
+	if _, err := NewSQLiteStore(ctx, openFakeSQL(t), WithTable("messages; DROP TABLE x")); err == nil {
+		t.Error("NewSQLiteStore() with invalid table expected error, got nil")
+	}
+	if _, err := NewSQLiteStore(ctx, openFakeSQL(t), WithTable("chat_messages")); err != nil {
+		t.Errorf("NewSQLiteStore() with valid table error = %v", err)
+	}
+}
+
+func TestMarshalMessages(t *testing.T) {
+	messages := []core.Message{
+		core.SystemMessage("Be brief"),
+		core.UserMessageWithParts("What is this?", core.ImagePart([]byte{1, 2, 3}, "image/png")),
+		{
+			Role: "assistant",
+			ToolCalls: []core.ToolCall{{
+				ID:       "call_1",
+				Name:     "lookup",
+				Args:     map[string]interface{}{"q": "x"},
+				Error:    errors.New("not found"),
+				Duration: 2 * time.Second,
+			}},
+			Meta: map[string]interface{}{"iterations": 1.0},
+		},
+	}
+
+	data, err := MarshalMessages(messages)
+	if err != nil {
+		t.Fatalf("MarshalMessages() error = %v", err)
+	}
+	got, err := UnmarshalMessages(data)
+	if err != nil {
+		t.Fatalf("UnmarshalMessages() error = %v", err)
+	}
+
+	if len(got) != len(messages) {
+		t.Fatalf("UnmarshalMessages() = %d messages, want %d", len(got), len(messages))
+	}
+	if !reflect.DeepEqual(got[:2], messages[:2]) {
+		t.Errorf("UnmarshalMessages() = %+v, want %+v", got[:2], messages[:2])
+	}
+
+	// Errors come back as their text
+	call := got[2].ToolCalls[0]
+	if call.Error == nil || call.Error.Error() != "not found" {
+		t.Errorf("tool call error = %v, want not found", call.Error)
+	}
+	if call.Duration != 2*time.Second || call.Args["q"] != "x" || got[2].Meta["iterations"] != 1.0 {
+		t.Errorf("tool call = %+v, meta = %v, want fields preserved", call, got[2].Meta)
+	}
+
+	if _, err := UnmarshalMessages([]byte("{")); err == nil {
+		t.Error("UnmarshalMessages() with bad JSON expected error, got nil")
+	}
+}
@@ -0,0 +1,313 @@
+package memory
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/yashrahurikar23/goagents/core"
+)
+
+// Record is one long-term memory: a fact and its embedding.
+type Record struct {
+	ID        string    `json:"id"`
+	Namespace string    `json:"namespace,omitempty"` // a session or user ID; see WithScope
+	Text      string    `json:"text"`
+	Vector    []float64 `json:"vector"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ScoredRecord is a search result with its cosine similarity to the query.
+type ScoredRecord struct {
+	Record
+	Score float64
+}
+
+// VectorStore stores long-term memories and finds the closest ones to a
+// vector. Implementations are safe for concurrent use.
+type VectorStore interface {
+	// Add stores records, replacing records with the same namespace and ID.
+	Add(ctx context.Context, records ...Record) error
+
+	// Search returns up to k records of a namespace, most similar first.
+	// Records whose vectors have a different dimension are skipped.
+	Search(ctx context.Context, namespace string, vector []float64, k int) ([]ScoredRecord, error)
+
+	// Delete removes records of a namespace by ID.
+	Delete(ctx context.Context, namespace string, ids ...string) error
+}
+
+// CosineSimilarity returns the cosine of the angle between a and b, from -1
+// to 1. It returns 0 when the dimensions differ or either vector is zero.
+func CosineSimilarity(a, b []float64) float64 {
+	if len(a) != len(b) || len(a) == 0 {
+		return 0
+	}
+
+	var dot, normA, normB float64
+	for i := range a {
+		dot += a[i] * b[i]
+		normA += a[i] * a[i]
+		normB += b[i] * b[i]
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
+
+// InMemoryVectorStore searches memories by brute force in process memory,
+// which is fast enough for tens of thousands of memories per namespace.
+type InMemoryVectorStore struct {
+	mu         sync.RWMutex
+	namespaces map[string][]Record
+}
+
+// NewInMemoryVectorStore creates an empty in-memory vector store.
+func NewInMemoryVectorStore() *InMemoryVectorStore {
+	return &InMemoryVectorStore{namespaces: make(map[string][]Record)}
+}
+
+// Add stores records, replacing records with the same namespace and ID.
+func (s *InMemoryVectorStore) Add(ctx context.Context, records ...Record) error {
+	for _, rec := range records {
+		if rec.ID == "" {
+			return &core.ErrInvalidArgument{Argument: "record.ID", Reason: "cannot be empty"}
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.add(records)
+	return nil
+}
+
+func (s *InMemoryVectorStore) add(records []Record) {
+	for _, rec := range records {
+		stored := s.namespaces[rec.Namespace]
+		replaced := false
+		for i := range stored {
+			if stored[i].ID == rec.ID {
+				stored[i] = rec
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			s.namespaces[rec.Namespace] = append(stored, rec)
+		}
+	}
+}
+
+// Search returns up to k records of a namespace, most similar first.
+func (s *InMemoryVectorStore) Search(ctx context.Context, namespace string, vector []float64, k int) ([]ScoredRecord, error) {
+	if k <= 0 {
+		return nil, nil
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	results := make([]ScoredRecord, 0, len(s.namespaces[namespace]))
+	for _, rec := range s.namespaces[namespace] {
+		if len(rec.Vector) != len(vector) {
+			continue
+		}
+		results = append(results, ScoredRecord{Record: rec, Score: CosineSimilarity(vector, rec.Vector)})
+	}
+
+	// Newer memories win ties, so updated facts come before the ones they replace
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].CreatedAt.After(results[j].CreatedAt)
+	})
+	if len(results) > k {
+		results = results[:k]
+	}
+	return results, nil
+}
+
+// Delete removes records of a namespace by ID.
+func (s *InMemoryVectorStore) Delete(ctx context.Context, namespace string, ids ...string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.delete(namespace, ids)
+	return nil
+}
+
+func (s *InMemoryVectorStore) delete(namespace string, ids []string) {
+	remove := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		remove[id] = true
+	}
+
+	var kept []Record
+	for _, rec := range s.namespaces[namespace] {
+		if !remove[rec.ID] {
+			kept = append(kept, rec)
+		}
+	}
+	if len(kept) == 0 {
+		delete(s.namespaces, namespace)
+		return
+	}
+	s.namespaces[namespace] = kept
+}
+
+// Len returns the number of stored records.
+func (s *InMemoryVectorStore) Len() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	n := 0
+	for _, records := range s.namespaces {
+		n += len(records)
+	}
+	return n
+}
+
+// FileVectorStore is an InMemoryVectorStore backed by a JSON Lines file, so
+// memories survive restarts. The file is read once when the store is opened;
+// additions are appended to it and deletions rewrite it.
+type FileVectorStore struct {
+	*InMemoryVectorStore
+	path string
+}
+
+// NewFileVectorStore opens the store in the file at path, creating it (and
+// its directory) if needed.
+func NewFileVectorStore(path string) (*FileVectorStore, error) {
+	if path == "" {
+		return nil, &core.ErrInvalidArgument{Argument: "path", Reason: "cannot be empty"}
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create store directory: %w", err)
+	}
+
+	s := &FileVectorStore{InMemoryVectorStore: NewInMemoryVectorStore(), path: path}
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// load reads the file into memory. Later lines replace earlier records with
+// the same ID.
+func (s *FileVectorStore) load() error {
+	f, err := os.Open(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to open vector store: %w", err)
+	}
+	defer f.Close()
+
+	reader := bufio.NewReader(f)
+	for lineNo := 1; ; lineNo++ {
+		line, err := reader.ReadBytes('\n')
+		if line = bytes.TrimSpace(line); len(line) > 0 {
+			var rec Record
+			if decodeErr := json.Unmarshal(line, &rec); decodeErr != nil {
+				return fmt.Errorf("vector store line %d: %w", lineNo, decodeErr)
+			}
+			s.add([]Record{rec})
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read vector store: %w", err)
+		}
+	}
+}
+
+// Add stores records and appends them to the file.
+func (s *FileVectorStore) Add(ctx context.Context, records ...Record) error {
+	var buf bytes.Buffer
+	for _, rec := range records {
+		if rec.ID == "" {
+			return &core.ErrInvalidArgument{Argument: "record.ID", Reason: "cannot be empty"}
+		}
+		data, err := json.Marshal(rec)
+		if err != nil {
+			return fmt.Errorf("failed to encode record %s: %w", rec.ID, err)
+		}
+		buf.Write(data)
+		buf.WriteByte('\n')
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	f, err := os.OpenFile(s.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open vector store: %w", err)
+	}
+	if _, err := f.Write(buf.Bytes()); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write vector store: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to write vector store: %w", err)
+	}
+
+	s.add(records)
+	return nil
+}
+
+// Delete removes records and rewrites the file without them.
+func (s *FileVectorStore) Delete(ctx context.Context, namespace string, ids ...string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.delete(namespace, ids)
+
+	var buf bytes.Buffer
+	for _, records := range s.namespaces {
+		for _, rec := range records {
+			data, err := json.Marshal(rec)
+			if err != nil {
+				return fmt.Errorf("failed to encode record %s: %w", rec.ID, err)
+			}
+			buf.Write(data)
+			buf.WriteByte('\n')
+		}
+	}
+
+	// Write to a temporary file and rename it over the old one, so a crash
+	// never leaves a half-written store
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), ".vectors-*")
+	if err != nil {
+		return fmt.Errorf("failed to write vector store: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := tmp.Chmod(0o644); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write vector store: %w", err)
+	}
+	if _, err := tmp.Write(buf.Bytes()); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write vector store: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write vector store: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return fmt.Errorf("failed to write vector store: %w", err)
+	}
+	return nil
+}
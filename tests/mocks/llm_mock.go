@@ -28,6 +28,10 @@ type MockLLM struct {
 	// If nil, returns a default successful response.
 	ChatFunc func(ctx context.Context, messages []core.Message) (*core.Response, error)
 
+	// ChatWithToolsFunc is called when ChatWithTools() is invoked.
+	// If nil, falls back to ChatFunc (or the default Chat() response).
+	ChatWithToolsFunc func(ctx context.Context, messages []core.Message, tools []core.Tool) (*core.Response, error)
+
 	// CompleteFunc is called when Complete() is invoked.
 	// If nil, returns the prompt as the response.
 	CompleteFunc func(ctx context.Context, prompt string) (string, error)
@@ -38,9 +42,11 @@ type MockLLM struct {
 	completeCalls []CompleteCall
 }
 
-// ChatCall records a single Chat() invocation
+// ChatCall records a single Chat() or ChatWithTools() invocation
 type ChatCall struct {
 	Messages []core.Message
+	Tools    []core.Tool       // Only set for ChatWithTools() calls
+	Options  *core.CallOptions // Resolved per-call options
 	Response *core.Response
 	Error    error
 }
@@ -48,6 +54,7 @@ type ChatCall struct {
 // CompleteCall records a single Complete() invocation
 type CompleteCall struct {
 	Prompt   string
+	Options  *core.CallOptions // Resolved per-call options
 	Response string
 	Error    error
 }
@@ -67,7 +74,7 @@ func NewMockLLM() *MockLLM {
 // - If ChatFunc is set, delegates to custom test behavior
 // - Otherwise returns sensible default for basic tests
 // - Records call for later assertion
-func (m *MockLLM) Chat(ctx context.Context, messages []core.Message) (*core.Response, error) {
+func (m *MockLLM) Chat(ctx context.Context, messages []core.Message, opts ...core.CallOption) (*core.Response, error) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
@@ -86,6 +93,43 @@ func (m *MockLLM) Chat(ctx context.Context, messages []core.Message) (*core.Resp
 	// Record the call
 	m.chatCalls = append(m.chatCalls, ChatCall{
 		Messages: messages,
+		Options:  core.NewCallOptions(opts...),
+		Response: resp,
+		Error:    err,
+	})
+
+	return resp, err
+}
+
+// ChatWithTools implements core.ToolCallingLLM.ChatWithTools().
+//
+// WHY THIS WAY:
+// - If ChatWithToolsFunc is set, delegates to custom test behavior
+// - Otherwise reuses ChatFunc so existing sequential responses work unchanged
+// - Records call (including tools) alongside Chat() calls
+func (m *MockLLM) ChatWithTools(ctx context.Context, messages []core.Message, tools []core.Tool, opts ...core.CallOption) (*core.Response, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var resp *core.Response
+	var err error
+
+	switch {
+	case m.ChatWithToolsFunc != nil:
+		resp, err = m.ChatWithToolsFunc(ctx, messages, tools)
+	case m.ChatFunc != nil:
+		resp, err = m.ChatFunc(ctx, messages)
+	default:
+		resp = &core.Response{
+			Content: "Mock response",
+		}
+	}
+
+	// Record the call
+	m.chatCalls = append(m.chatCalls, ChatCall{
+		Messages: messages,
+		Tools:    tools,
+		Options:  core.NewCallOptions(opts...),
 		Response: resp,
 		Error:    err,
 	})
@@ -99,7 +143,7 @@ func (m *MockLLM) Chat(ctx context.Context, messages []core.Message) (*core.Resp
 // - If CompleteFunc is set, delegates to custom test behavior
 // - Otherwise echoes prompt for predictable testing
 // - Records call for later assertion
-func (m *MockLLM) Complete(ctx context.Context, prompt string) (string, error) {
+func (m *MockLLM) Complete(ctx context.Context, prompt string, opts ...core.CallOption) (string, error) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
@@ -116,6 +160,7 @@ func (m *MockLLM) Complete(ctx context.Context, prompt string) (string, error) {
 	// Record the call
 	m.completeCalls = append(m.completeCalls, CompleteCall{
 		Prompt:   prompt,
+		Options:  core.NewCallOptions(opts...),
 		Response: resp,
 		Error:    err,
 	})
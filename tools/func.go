@@ -0,0 +1,112 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	"github.com/yashrahurikar23/goagents/core"
+)
+
+// FuncTool is a tool backed by a plain Go function, created with FromFunc.
+type FuncTool[In, Out any] struct {
+	name        string
+	description string
+	schema      *core.ToolSchema
+	fn          func(ctx context.Context, in In) (Out, error)
+}
+
+// FromFunc creates a tool from a Go function that takes its arguments as a
+// struct. The schema is derived from the struct's tags (json, desc, enum and
+// required, see core.JSONSchemaFor):
+//
+//	type WeatherInput struct {
+//	    City  string `json:"city" desc:"City name, e.g. Paris"`
+//	    Units string `json:"units,omitempty" enum:"celsius,fahrenheit"`
+//	}
+//
+//	weather, err := tools.FromFunc("get_weather", "Gets the current weather",
+//	    func(ctx context.Context, in WeatherInput) (WeatherOutput, error) { ... })
+//
+// Execute validates the arguments against the schema, decodes them into In,
+// calls fn and returns its output serialized as JSON (strings are returned as-is).
+func FromFunc[In, Out any](name, description string, fn func(ctx context.Context, in In) (Out, error)) (*FuncTool[In, Out], error) {
+	if name == "" {
+		return nil, &core.ErrInvalidArgument{Argument: "name", Reason: "cannot be empty"}
+	}
+	if fn == nil {
+		return nil, &core.ErrInvalidArgument{Argument: "fn", Reason: "cannot be nil"}
+	}
+
+	schema, err := core.ToolSchemaForType(name, description, reflect.TypeOf((*In)(nil)).Elem())
+	if err != nil {
+		return nil, fmt.Errorf("tool %s: %w", name, err)
+	}
+
+	return &FuncTool[In, Out]{
+		name:        name,
+		description: description,
+		schema:      schema,
+		fn:          fn,
+	}, nil
+}
+
+// MustFromFunc is like FromFunc but panics if the schema cannot be derived.
+// It is intended for tools declared as package-level variables.
+func MustFromFunc[In, Out any](name, description string, fn func(ctx context.Context, in In) (Out, error)) *FuncTool[In, Out] {
+	tool, err := FromFunc(name, description, fn)
+	if err != nil {
+		panic(err)
+	}
+	return tool
+}
+
+// Name returns the tool's name
+func (t *FuncTool[In, Out]) Name() string {
+	return t.name
+}
+
+// Description returns the tool's description
+func (t *FuncTool[In, Out]) Description() string {
+	return t.description
+}
+
+// Schema returns the schema derived from the input struct
+func (t *FuncTool[In, Out]) Schema() *core.ToolSchema {
+	return t.schema
+}
+
+// Execute decodes args into the input struct, calls the function and
+// serializes its output as JSON.
+func (t *FuncTool[In, Out]) Execute(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+	args, err := core.ValidateToolArgs(t.schema, args)
+	if err != nil {
+		return nil, err
+	}
+
+	// Round-trip through JSON so json tags, nested structs and
+	// numeric conversions behave exactly as they do for API payloads
+	data, err := json.Marshal(args)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode arguments: %w", err)
+	}
+	var in In
+	if err := json.Unmarshal(data, &in); err != nil {
+		return nil, fmt.Errorf("failed to decode arguments: %w", err)
+	}
+
+	out, err := t.fn(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+
+	if s, ok := any(out).(string); ok {
+		return s, nil
+	}
+	result, err := json.Marshal(out)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode result: %w", err)
+	}
+	return string(result), nil
+}
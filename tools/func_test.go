@@ -0,0 +1,132 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/yashrahurikar23/goagents/core"
+)
+
+type weatherInput struct {
+	City  string   `json:"city" desc:"City name"`
+	Units string   `json:"units,omitempty" enum:"celsius,fahrenheit"`
+	Days  int      `json:"days,omitempty" desc:"Forecast days"`
+	Tags  []string `json:"tags,omitempty"`
+}
+
+type weatherOutput struct {
+	City        string  `json:"city"`
+	Temperature float64 `json:"temperature"`
+	Units       string  `json:"units"`
+	Days        int     `json:"days"`
+}
+
+func newWeatherTool(t *testing.T) *FuncTool[weatherInput, weatherOutput] {
+	t.Helper()
+
+	tool, err := FromFunc("get_weather", "Gets the weather", func(ctx context.Context, in weatherInput) (weatherOutput, error) {
+		if in.City == "Atlantis" {
+			return weatherOutput{}, errors.New("city not found")
+		}
+		return weatherOutput{City: in.City, Temperature: 21.5, Units: in.Units, Days: in.Days}, nil
+	})
+	if err != nil {
+		t.Fatalf("FromFunc() error = %v", err)
+	}
+	return tool
+}
+
+func TestFromFunc_Schema(t *testing.T) {
+	tool := newWeatherTool(t)
+
+	if tool.Name() != "get_weather" || tool.Description() != "Gets the weather" {
+		t.Errorf("unexpected name/description: %s, %s", tool.Name(), tool.Description())
+	}
+
+	params := tool.Schema().Parameters
+	if len(params) != 4 {
+		t.Fatalf("expected 4 parameters, got %d", len(params))
+	}
+
+	city := params[0]
+	if city.Name != "city" || city.Type != "string" || !city.Required || city.Description != "City name" {
+		t.Errorf("unexpected city parameter: %+v", city)
+	}
+	units := params[1]
+	if units.Required || len(units.Enum) != 2 {
+		t.Errorf("unexpected units parameter: %+v", units)
+	}
+	if params[2].Type != "integer" {
+		t.Errorf("expected integer days, got %s", params[2].Type)
+	}
+
+	tags := tool.Schema().JSONSchema()["properties"].(map[string]interface{})["tags"].(map[string]interface{})
+	if items, ok := tags["items"].(map[string]interface{}); !ok || items["type"] != "string" {
+		t.Errorf("expected string items for tags, got %v", tags)
+	}
+}
+
+func TestFromFunc_Execute(t *testing.T) {
+	tool := newWeatherTool(t)
+
+	result, err := tool.Execute(context.Background(), map[string]interface{}{
+		"city":  "Paris",
+		"units": "Celsius",
+		"days":  "3",
+	})
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+
+	var out weatherOutput
+	if err := json.Unmarshal([]byte(result.(string)), &out); err != nil {
+		t.Fatalf("expected JSON output, got %v: %v", result, err)
+	}
+	want := weatherOutput{City: "Paris", Temperature: 21.5, Units: "celsius", Days: 3}
+	if out != want {
+		t.Errorf("output = %+v, want %+v", out, want)
+	}
+}
+
+func TestFromFunc_Execute_Errors(t *testing.T) {
+	tool := newWeatherTool(t)
+
+	_, err := tool.Execute(context.Background(), map[string]interface{}{"units": "kelvin"})
+	var invalid *core.ErrInvalidToolArgs
+	if !errors.As(err, &invalid) || len(invalid.Violations) != 2 {
+		t.Errorf("expected 2 argument violations, got %v", err)
+	}
+
+	if _, err := tool.Execute(context.Background(), map[string]interface{}{"city": "Atlantis"}); err == nil || err.Error() != "city not found" {
+		t.Errorf("expected function error, got %v", err)
+	}
+}
+
+func TestFromFunc_StringOutput(t *testing.T) {
+	tool := MustFromFunc("echo", "Echoes text", func(ctx context.Context, in struct {
+		Text string `json:"text"`
+	}) (string, error) {
+		return in.Text, nil
+	})
+
+	result, err := tool.Execute(context.Background(), map[string]interface{}{"text": "hello"})
+	if err != nil || result != "hello" {
+		t.Errorf("Execute() = %v, %v; want hello", result, err)
+	}
+}
+
+func TestFromFunc_InvalidInput(t *testing.T) {
+	if _, err := FromFunc("bad", "Not a struct", func(ctx context.Context, in string) (string, error) {
+		return in, nil
+	}); err == nil {
+		t.Error("expected an error for a non-struct input")
+	}
+
+	if _, err := FromFunc[weatherInput, string]("", "No name", func(ctx context.Context, in weatherInput) (string, error) {
+		return "", nil
+	}); err == nil {
+		t.Error("expected an error for an empty name")
+	}
+}
@@ -107,25 +107,30 @@ func (h *HTTPTool) Schema() *core.ToolSchema {
 				Name:        "method",
 				Type:        "string",
 				Required:    true,
-				Description: "HTTP method: GET, POST, PUT, DELETE, or PATCH",
+				Description: "HTTP method",
+				Enum:        []interface{}{"GET", "POST", "PUT", "DELETE", "PATCH"},
 			},
 			{
 				Name:        "url",
 				Type:        "string",
 				Required:    true,
 				Description: "The URL to request (must include protocol: http:// or https://)",
+				Format:      "uri",
+				Pattern:     "^https?://",
 			},
 			{
-				Name:        "headers",
-				Type:        "object",
-				Required:    false,
-				Description: "Optional HTTP headers as key-value pairs (e.g., {\"Authorization\": \"Bearer token\"})",
+				Name:                 "headers",
+				Type:                 "object",
+				Required:             false,
+				Description:          "Optional HTTP headers as key-value pairs (e.g., {\"Authorization\": \"Bearer token\"})",
+				AdditionalProperties: &core.Parameter{Type: "string"},
 			},
 			{
-				Name:        "query_params",
-				Type:        "object",
-				Required:    false,
-				Description: "Optional query parameters as key-value pairs (e.g., {\"q\": \"search term\", \"limit\": \"10\"})",
+				Name:                 "query_params",
+				Type:                 "object",
+				Required:             false,
+				Description:          "Optional query parameters as key-value pairs (e.g., {\"q\": \"search term\", \"limit\": \"10\"})",
+				AdditionalProperties: &core.Parameter{Schema: map[string]interface{}{"type": []string{"string", "number", "boolean"}}},
 			},
 			{
 				Name:        "body",
@@ -9,6 +9,8 @@ import (
 	"strings"
 	"testing"
 	"time"
+
+	"github.com/yashrahurikar23/goagents/core"
 )
 
 func TestHTTPTool_Name(t *testing.T) {
@@ -68,6 +70,41 @@ func TestHTTPTool_Schema(t *testing.T) {
 	}
 }
 
+func TestHTTPTool_Schema_JSONSchema(t *testing.T) {
+	schema := NewHTTPTool().Schema().JSONSchema()
+	props := schema["properties"].(map[string]interface{})
+
+	method := props["method"].(map[string]interface{})
+	if enum, ok := method["enum"].([]interface{}); !ok || len(enum) != 5 {
+		t.Errorf("expected method enum, got %v", method)
+	}
+
+	headers := props["headers"].(map[string]interface{})
+	values, ok := headers["additionalProperties"].(map[string]interface{})
+	if !ok || values["type"] != "string" {
+		t.Errorf("expected string header values, got %v", headers)
+	}
+
+	args, err := core.ValidateToolArgs(NewHTTPTool().Schema(), map[string]interface{}{
+		"method":       "get",
+		"url":          "https://example.com",
+		"query_params": map[string]interface{}{"limit": 10.0},
+	})
+	if err != nil {
+		t.Fatalf("ValidateToolArgs() error = %v", err)
+	}
+	if args["method"] != "GET" {
+		t.Errorf("expected method to be normalized to GET, got %v", args["method"])
+	}
+
+	if _, err := core.ValidateToolArgs(NewHTTPTool().Schema(), map[string]interface{}{
+		"method": "GET",
+		"url":    "example.com",
+	}); err == nil {
+		t.Error("expected an error for a URL without protocol")
+	}
+}
+
 func TestHTTPTool_Execute_GET(t *testing.T) {
 	// Create test server
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {